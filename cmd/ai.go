@@ -1,10 +1,16 @@
 package main
 
 import (
+	"github.com/abhinavxd/libredesk/internal/ai/models"
+	amodels "github.com/abhinavxd/libredesk/internal/auth/models"
 	"github.com/abhinavxd/libredesk/internal/envelope"
+	"github.com/abhinavxd/libredesk/internal/knowledgebase"
 	"github.com/zerodha/fastglue"
 )
 
+// Maximum number of knowledge base articles included in the context of suggested replies.
+const maxAIContextArticles = 3
+
 type aiCompletionReq struct {
 	PromptKey string `json:"prompt_key"`
 	Content   string `json:"content"`
@@ -13,6 +19,21 @@ type aiCompletionReq struct {
 type providerUpdateReq struct {
 	Provider string `json:"provider"`
 	APIKey   string `json:"api_key"`
+	Model    string `json:"model"`
+	BaseURL  string `json:"base_url"`
+	Timeout  string `json:"timeout"`
+	// IsDefault makes the provider the default provider.
+	IsDefault bool `json:"is_default"`
+}
+
+// config returns the provider config from the request.
+func (p providerUpdateReq) config() models.ProviderConfig {
+	return models.ProviderConfig{
+		APIKey:  p.APIKey,
+		Model:   p.Model,
+		BaseURL: p.BaseURL,
+		Timeout: p.Timeout,
+	}
 }
 
 // handleAICompletion handles AI completion requests
@@ -54,8 +75,124 @@ func handleUpdateAIProvider(r *fastglue.Request) error {
 	if err := r.Decode(&req, "json"); err != nil {
 		return sendErrorEnvelope(r, envelope.NewError(envelope.InputError, app.i18n.T("errors.parsingRequest"), nil))
 	}
-	if err := app.ai.UpdateProvider(req.Provider, req.APIKey); err != nil {
+	if err := app.ai.UpdateProvider(req.Provider, req.config(), req.IsDefault); err != nil {
 		return sendErrorEnvelope(r, err)
 	}
 	return r.SendEnvelope("Provider updated successfully")
 }
+
+// handleGetAIProviders returns all AI providers and their settings
+func handleGetAIProviders(r *fastglue.Request) error {
+	var (
+		app = r.Context.(*App)
+	)
+	resp, err := app.ai.GetProviders()
+	if err != nil {
+		return sendErrorEnvelope(r, err)
+	}
+	return r.SendEnvelope(resp)
+}
+
+// handleTestAIProvider sends a test prompt to the AI provider with the given settings
+func handleTestAIProvider(r *fastglue.Request) error {
+	var (
+		app = r.Context.(*App)
+		req providerUpdateReq
+	)
+	if err := r.Decode(&req, "json"); err != nil {
+		return sendErrorEnvelope(r, envelope.NewError(envelope.InputError, app.i18n.T("errors.parsingRequest"), nil))
+	}
+	if err := app.ai.TestProvider(req.Provider, req.config()); err != nil {
+		return sendErrorEnvelope(r, err)
+	}
+	return r.SendEnvelope(true)
+}
+
+// handleAISuggestReply drafts a reply for a conversation from its history, contact details and custom attributes.
+func handleAISuggestReply(r *fastglue.Request) error {
+	var (
+		app   = r.Context.(*App)
+		uuid  = r.RequestCtx.UserValue("uuid").(string)
+		auser = r.RequestCtx.UserValue("user").(amodels.User)
+	)
+
+	user, err := app.user.GetAgent(auser.ID, "")
+	if err != nil {
+		return sendErrorEnvelope(r, err)
+	}
+
+	conversation, err := enforceConversationAccess(app, uuid, user)
+	if err != nil {
+		return sendErrorEnvelope(r, err)
+	}
+
+	cc, err := app.conversation.GetAIContext(*conversation)
+	if err != nil {
+		return sendErrorEnvelope(r, err)
+	}
+
+	cc.Articles = getAIContextArticles(app, cc)
+
+	resp, err := app.ai.SuggestReply(cc)
+	if err != nil {
+		return sendErrorEnvelope(r, err)
+	}
+	return r.SendEnvelope(resp)
+}
+
+// handleAISummarizeConversation summarizes a conversation and adds the summary as a private note.
+func handleAISummarizeConversation(r *fastglue.Request) error {
+	var (
+		app   = r.Context.(*App)
+		uuid  = r.RequestCtx.UserValue("uuid").(string)
+		auser = r.RequestCtx.UserValue("user").(amodels.User)
+	)
+
+	user, err := app.user.GetAgent(auser.ID, "")
+	if err != nil {
+		return sendErrorEnvelope(r, err)
+	}
+
+	conversation, err := enforceConversationAccess(app, uuid, user)
+	if err != nil {
+		return sendErrorEnvelope(r, err)
+	}
+
+	message, err := app.conversation.SummarizeConversation(*conversation, user)
+	if err != nil {
+		return sendErrorEnvelope(r, err)
+	}
+	return r.SendEnvelope(message)
+}
+
+// getAIContextArticles returns published knowledge base articles relevant to the latest customer message
+// so that suggested replies can cite them. Errors are logged and ignored as articles are optional.
+func getAIContextArticles(app *App, cc models.ConversationContext) []models.ContextArticle {
+	query := cc.Subject
+	for i := len(cc.Messages) - 1; i >= 0; i-- {
+		if cc.Messages[i].FromContact {
+			query = cc.Messages[i].Content
+			break
+		}
+	}
+
+	results, err := app.kb.Search(query, "", true, true, maxAIContextArticles)
+	if err != nil || len(results) == 0 {
+		return nil
+	}
+	rootURL, err := app.setting.GetAppRootURL()
+	if err != nil {
+		app.lo.Error("error fetching app root URL", "error", err)
+		return nil
+	}
+
+	articles := make([]models.ContextArticle, 0, len(results))
+	for _, res := range results {
+		articles = append(articles, models.ContextArticle{
+			Title:   res.Title,
+			URL:     knowledgebase.ArticleURL(rootURL, res.UUID),
+			Content: res.TextContent,
+		})
+	}
+	return articles
+}
@@ -0,0 +1,125 @@
+package main
+
+import (
+	"encoding/json"
+	"strings"
+
+	cmodels "github.com/abhinavxd/libredesk/internal/conversation/models"
+	"github.com/abhinavxd/libredesk/internal/envelope"
+	"github.com/abhinavxd/libredesk/internal/inbox/channel/api"
+	"github.com/abhinavxd/libredesk/internal/stringutil"
+	"github.com/abhinavxd/libredesk/internal/webhook"
+	"github.com/google/uuid"
+	"github.com/valyala/fasthttp"
+	"github.com/volatiletech/null/v9"
+	"github.com/zerodha/fastglue"
+)
+
+// apiInboxMessageReq is the payload accepted by the API inbox incoming message endpoint.
+type apiInboxMessageReq struct {
+	Contact struct {
+		Email     string `json:"email"`
+		FirstName string `json:"first_name"`
+		LastName  string `json:"last_name"`
+	} `json:"contact"`
+	ConversationUUID string          `json:"conversation_uuid"`
+	SourceID         string          `json:"source_id"`
+	InReplyTo        string          `json:"in_reply_to"`
+	References       []string        `json:"references"`
+	Subject          string          `json:"subject"`
+	Content          string          `json:"content"`
+	ContentType      string          `json:"content_type"`
+	Meta             json.RawMessage `json:"meta"`
+}
+
+// handleAPIInboxIncomingMessage accepts a signed message for an API inbox and queues it for processing.
+func handleAPIInboxIncomingMessage(r *fastglue.Request) error {
+	var (
+		app       = r.Context.(*App)
+		inboxUUID = r.RequestCtx.UserValue("uuid").(string)
+		body      = r.RequestCtx.PostBody()
+		req       = apiInboxMessageReq{}
+	)
+
+	// Require a UUID here so callers cannot enumerate inboxes by numeric ID.
+	if _, err := uuid.Parse(inboxUUID); err != nil {
+		return r.SendErrorEnvelope(fasthttp.StatusNotFound, app.i18n.T("validation.notFoundInbox"), nil, envelope.NotFoundError)
+	}
+
+	record, err := app.inbox.GetDBRecord(inboxUUID)
+	if err != nil || !record.Enabled || record.Channel != api.ChannelAPI {
+		return r.SendErrorEnvelope(fasthttp.StatusNotFound, app.i18n.T("validation.notFoundInbox"), nil, envelope.NotFoundError)
+	}
+
+	inb, err := app.inbox.Get(record.ID)
+	if err != nil {
+		return r.SendErrorEnvelope(fasthttp.StatusNotFound, app.i18n.T("validation.notFoundInbox"), nil, envelope.NotFoundError)
+	}
+	apiInbox, ok := inb.(*api.API)
+	if !ok {
+		app.lo.Error("registered inbox is not an API inbox", "inbox_id", record.ID)
+		return r.SendErrorEnvelope(fasthttp.StatusInternalServerError, app.i18n.T("globals.messages.somethingWentWrong"), nil, envelope.GeneralError)
+	}
+
+	// Verify the HMAC signature of the raw body before parsing anything.
+	signature := string(r.RequestCtx.Request.Header.Peek(webhook.SignatureHeader))
+	if !apiInbox.VerifySignature(body, signature) {
+		return r.SendErrorEnvelope(fasthttp.StatusUnauthorized, app.i18n.T("validation.invalidSignature"), nil, envelope.PermissionError)
+	}
+
+	if err := json.Unmarshal(body, &req); err != nil {
+		return r.SendErrorEnvelope(fasthttp.StatusBadRequest, app.i18n.T("errors.parsingRequest"), err.Error(), envelope.InputError)
+	}
+
+	req.Contact.Email = strings.ToLower(strings.TrimSpace(req.Contact.Email))
+	if !stringutil.ValidEmail(req.Contact.Email) {
+		return r.SendErrorEnvelope(fasthttp.StatusBadRequest, app.i18n.T("validation.invalidEmail"), nil, envelope.InputError)
+	}
+	if strings.TrimSpace(req.Content) == "" {
+		return r.SendErrorEnvelope(fasthttp.StatusBadRequest, app.i18n.T("validation.messageCannotBeEmpty"), nil, envelope.InputError)
+	}
+	switch req.ContentType {
+	case "":
+		req.ContentType = cmodels.ContentTypeText
+	case cmodels.ContentTypeText, cmodels.ContentTypeHTML:
+	default:
+		return r.SendErrorEnvelope(fasthttp.StatusBadRequest, app.i18n.T("validation.invalidValue"), nil, envelope.InputError)
+	}
+	if len(req.Meta) == 0 {
+		req.Meta = json.RawMessage(`{}`)
+	}
+
+	// Only allow threading into conversations that belong to this inbox.
+	if req.ConversationUUID != "" {
+		conversation, err := app.conversation.GetConversation(0, req.ConversationUUID, "")
+		if err != nil || conversation.InboxID != record.ID {
+			return r.SendErrorEnvelope(fasthttp.StatusBadRequest, app.i18n.T("validation.notFoundConversation"), nil, envelope.InputError)
+		}
+	}
+
+	msg := cmodels.IncomingMessage{
+		Contact: cmodels.IncomingContact{
+			FirstName: req.Contact.FirstName,
+			LastName:  req.Contact.LastName,
+			Email:     null.StringFrom(req.Contact.Email),
+		},
+		Subject:                     req.Subject,
+		SourceID:                    null.NewString(req.SourceID, req.SourceID != ""),
+		Content:                     req.Content,
+		ContentType:                 req.ContentType,
+		Meta:                        req.Meta,
+		ConversationUUIDFromReplyTo: req.ConversationUUID,
+		InReplyTo:                   req.InReplyTo,
+		References:                  req.References,
+	}
+
+	if err := apiInbox.Ingest(msg); err != nil {
+		app.lo.Error("error ingesting API inbox message", "inbox_id", record.ID, "error", err)
+		if err == api.ErrQueueFull {
+			return r.SendErrorEnvelope(fasthttp.StatusTooManyRequests, app.i18n.T("globals.messages.tooManyRequests"), nil, envelope.RateLimitError)
+		}
+		return r.SendErrorEnvelope(fasthttp.StatusInternalServerError, app.i18n.T("globals.messages.somethingWentWrong"), nil, envelope.GeneralError)
+	}
+
+	return r.SendEnvelope(true)
+}
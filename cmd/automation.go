@@ -1,9 +1,16 @@
 package main
 
 import (
+	"encoding/json"
+	"fmt"
+	"regexp"
 	"strconv"
+	"time"
 
+	authmodels "github.com/abhinavxd/libredesk/internal/auth/models"
+	"github.com/abhinavxd/libredesk/internal/automation"
 	amodels "github.com/abhinavxd/libredesk/internal/automation/models"
+	"github.com/abhinavxd/libredesk/internal/conversation"
 	"github.com/abhinavxd/libredesk/internal/envelope"
 	"github.com/valyala/fasthttp"
 	"github.com/zerodha/fastglue"
@@ -13,6 +20,13 @@ type updateAutomationRuleExecutionModeReq struct {
 	Mode string `json:"mode"`
 }
 
+type testAutomationRuleReq struct {
+	ConversationUUID string `json:"conversation_uuid"`
+	ReferenceNumber  string `json:"reference_number"`
+	// Rules optionally overrides the saved rules to test unsaved changes.
+	Rules json.RawMessage `json:"rules"`
+}
+
 // handleGetAutomationRules gets all automation rules
 func handleGetAutomationRules(r *fastglue.Request) error {
 	var (
@@ -56,6 +70,7 @@ func handleToggleAutomationRule(r *fastglue.Request) error {
 func handleUpdateAutomationRule(r *fastglue.Request) error {
 	var (
 		app     = r.Context.(*App)
+		auser   = r.RequestCtx.UserValue("user").(authmodels.User)
 		rule    = amodels.RuleRecord{}
 		id, err = strconv.Atoi(r.RequestCtx.UserValue("id").(string))
 	)
@@ -67,7 +82,11 @@ func handleUpdateAutomationRule(r *fastglue.Request) error {
 		return r.SendErrorEnvelope(fasthttp.StatusBadRequest, app.i18n.T("errors.parsingRequest"), nil, envelope.InputError)
 	}
 
-	updatedRule, err := app.automation.UpdateRule(id, rule)
+	if err := validateAutomationRule(app, rule); err != nil {
+		return sendErrorEnvelope(r, err)
+	}
+
+	updatedRule, err := app.automation.UpdateRule(id, rule, auser.ID)
 	if err != nil {
 		return sendErrorEnvelope(r, err)
 	}
@@ -77,13 +96,17 @@ func handleUpdateAutomationRule(r *fastglue.Request) error {
 // handleCreateAutomationRule creates a new automation rule
 func handleCreateAutomationRule(r *fastglue.Request) error {
 	var (
-		app  = r.Context.(*App)
-		rule = amodels.RuleRecord{}
+		app   = r.Context.(*App)
+		auser = r.RequestCtx.UserValue("user").(authmodels.User)
+		rule  = amodels.RuleRecord{}
 	)
 	if err := r.Decode(&rule, "json"); err != nil {
 		return r.SendErrorEnvelope(fasthttp.StatusBadRequest, app.i18n.T("errors.parsingRequest"), nil, envelope.InputError)
 	}
-	createdRule, err := app.automation.CreateRule(rule)
+	if err := validateAutomationRule(app, rule); err != nil {
+		return sendErrorEnvelope(r, err)
+	}
+	createdRule, err := app.automation.CreateRule(rule, auser.ID)
 	if err != nil {
 		return sendErrorEnvelope(r, err)
 	}
@@ -143,3 +166,230 @@ func handleUpdateAutomationRuleExecutionMode(r *fastglue.Request) error {
 	}
 	return r.SendEnvelope(true)
 }
+
+// handleTestAutomationRule evaluates an automation rule against a conversation without applying any actions.
+func handleTestAutomationRule(r *fastglue.Request) error {
+	var (
+		app     = r.Context.(*App)
+		req     = testAutomationRuleReq{}
+		id, err = strconv.Atoi(r.RequestCtx.UserValue("id").(string))
+	)
+	if err != nil || id == 0 {
+		return r.SendErrorEnvelope(fasthttp.StatusBadRequest, app.i18n.T("globals.messages.somethingWentWrong"), nil, envelope.InputError)
+	}
+	if err := r.Decode(&req, "json"); err != nil {
+		return r.SendErrorEnvelope(fasthttp.StatusBadRequest, app.i18n.T("errors.parsingRequest"), nil, envelope.InputError)
+	}
+	if req.ConversationUUID == "" && req.ReferenceNumber == "" {
+		return r.SendErrorEnvelope(fasthttp.StatusBadRequest, app.i18n.Ts("globals.messages.empty", "name", "`conversation_uuid`"), nil, envelope.InputError)
+	}
+
+	rule, err := app.automation.GetRule(id)
+	if err != nil {
+		return sendErrorEnvelope(r, err)
+	}
+	if len(req.Rules) > 0 {
+		rule.Rules = req.Rules
+	}
+
+	out, err := app.automation.DryRunRule(rule, req.ConversationUUID, req.ReferenceNumber)
+	if err != nil {
+		return sendErrorEnvelope(r, err)
+	}
+	return r.SendEnvelope(out)
+}
+
+// handleGetAutomationRuleExecutions returns a page of automation rule execution logs.
+func handleGetAutomationRuleExecutions(r *fastglue.Request) error {
+	var (
+		app              = r.Context.(*App)
+		ruleID           = r.RequestCtx.QueryArgs().GetUintOrZero("rule_id")
+		conversationUUID = string(r.RequestCtx.QueryArgs().Peek("conversation_uuid"))
+		onlyErrors       = r.RequestCtx.QueryArgs().GetBool("only_errors")
+		total            = 0
+	)
+	page, pageSize := getPagination(r)
+	executions, err := app.automation.GetExecutions(ruleID, conversationUUID, onlyErrors, page, pageSize)
+	if err != nil {
+		return sendErrorEnvelope(r, err)
+	}
+	if len(executions) > 0 {
+		total = executions[0].Total
+	}
+	return r.SendEnvelope(envelope.PageResults{
+		Results:    executions,
+		Total:      total,
+		PerPage:    pageSize,
+		TotalPages: (total + pageSize - 1) / pageSize,
+		Page:       page,
+	})
+}
+
+// handleGetAutomationRuleVersions returns the versions of an automation rule.
+func handleGetAutomationRuleVersions(r *fastglue.Request) error {
+	var (
+		app     = r.Context.(*App)
+		id, err = strconv.Atoi(r.RequestCtx.UserValue("id").(string))
+	)
+	if err != nil || id == 0 {
+		return r.SendErrorEnvelope(fasthttp.StatusBadRequest, app.i18n.T("globals.messages.somethingWentWrong"), nil, envelope.InputError)
+	}
+	out, err := app.automation.GetRuleVersions(id)
+	if err != nil {
+		return sendErrorEnvelope(r, err)
+	}
+	return r.SendEnvelope(out)
+}
+
+// handleGetAutomationRuleVersion returns a version of an automation rule.
+func handleGetAutomationRuleVersion(r *fastglue.Request) error {
+	var (
+		app        = r.Context.(*App)
+		id, err    = strconv.Atoi(r.RequestCtx.UserValue("id").(string))
+		version, _ = strconv.Atoi(r.RequestCtx.UserValue("version").(string))
+	)
+	if err != nil || id == 0 || version == 0 {
+		return r.SendErrorEnvelope(fasthttp.StatusBadRequest, app.i18n.T("globals.messages.somethingWentWrong"), nil, envelope.InputError)
+	}
+	out, err := app.automation.GetRuleVersion(id, version)
+	if err != nil {
+		return sendErrorEnvelope(r, err)
+	}
+	return r.SendEnvelope(out)
+}
+
+// handleDiffAutomationRuleVersion returns the changes from a version of an automation rule to
+// the version in the `to` query param, or to the current rule if not set.
+func handleDiffAutomationRuleVersion(r *fastglue.Request) error {
+	var (
+		app        = r.Context.(*App)
+		id, err    = strconv.Atoi(r.RequestCtx.UserValue("id").(string))
+		version, _ = strconv.Atoi(r.RequestCtx.UserValue("version").(string))
+		to         = r.RequestCtx.QueryArgs().GetUintOrZero("to")
+	)
+	if err != nil || id == 0 || version == 0 {
+		return r.SendErrorEnvelope(fasthttp.StatusBadRequest, app.i18n.T("globals.messages.somethingWentWrong"), nil, envelope.InputError)
+	}
+	out, err := app.automation.DiffRuleVersions(id, version, to)
+	if err != nil {
+		return sendErrorEnvelope(r, err)
+	}
+	return r.SendEnvelope(out)
+}
+
+// handleRollbackAutomationRule restores an automation rule to a previous version.
+func handleRollbackAutomationRule(r *fastglue.Request) error {
+	var (
+		app        = r.Context.(*App)
+		auser      = r.RequestCtx.UserValue("user").(authmodels.User)
+		id, err    = strconv.Atoi(r.RequestCtx.UserValue("id").(string))
+		version, _ = strconv.Atoi(r.RequestCtx.UserValue("version").(string))
+	)
+	if err != nil || id == 0 || version == 0 {
+		return r.SendErrorEnvelope(fasthttp.StatusBadRequest, app.i18n.T("globals.messages.somethingWentWrong"), nil, envelope.InputError)
+	}
+	out, err := app.automation.RollbackRule(id, version, auser.ID)
+	if err != nil {
+		return sendErrorEnvelope(r, err)
+	}
+	return r.SendEnvelope(out)
+}
+
+// handleExportAutomationRules downloads the automation rules of the type in the `type` query param, or all rules, as a JSON file.
+func handleExportAutomationRules(r *fastglue.Request) error {
+	var (
+		app = r.Context.(*App)
+		typ = string(r.RequestCtx.QueryArgs().Peek("type"))
+	)
+	out, err := app.automation.ExportRules(typ)
+	if err != nil {
+		return sendErrorEnvelope(r, err)
+	}
+	b, err := json.MarshalIndent(out, "", "  ")
+	if err != nil {
+		app.lo.Error("error marshalling automation rules export", "error", err)
+		return sendErrorEnvelope(r, envelope.NewError(envelope.GeneralError, app.i18n.T("globals.messages.somethingWentWrong"), nil))
+	}
+	r.RequestCtx.Response.Header.Set("Content-Type", "application/json")
+	r.RequestCtx.Response.Header.Set("Content-Disposition", fmt.Sprintf(`attachment; filename="automation-rules-%s.json"`, time.Now().Format("2006-01-02")))
+	r.RequestCtx.SetBody(b)
+	return nil
+}
+
+// handleImportAutomationRules imports automation rules from an export file.
+func handleImportAutomationRules(r *fastglue.Request) error {
+	var (
+		app   = r.Context.(*App)
+		auser = r.RequestCtx.UserValue("user").(authmodels.User)
+		req   = amodels.RuleExport{}
+	)
+	if err := r.Decode(&req, "json"); err != nil {
+		return r.SendErrorEnvelope(fasthttp.StatusBadRequest, app.i18n.T("errors.parsingRequest"), nil, envelope.InputError)
+	}
+	if req.Version != automation.ExportFormatVersion {
+		return r.SendErrorEnvelope(fasthttp.StatusBadRequest, app.i18n.T("automation.unsupportedExportVersion"), nil, envelope.InputError)
+	}
+	if len(req.Rules) == 0 {
+		return r.SendErrorEnvelope(fasthttp.StatusBadRequest, app.i18n.Ts("globals.messages.empty", "name", "`rules`"), nil, envelope.InputError)
+	}
+	for _, rule := range req.Rules {
+		if rule.Name == "" {
+			return r.SendErrorEnvelope(fasthttp.StatusBadRequest, app.i18n.Ts("globals.messages.empty", "name", "`name`"), nil, envelope.InputError)
+		}
+		switch rule.Type {
+		case amodels.RuleTypeNewConversation, amodels.RuleTypeConversationUpdate, amodels.RuleTypeTimeTrigger:
+		default:
+			return r.SendErrorEnvelope(fasthttp.StatusBadRequest, app.i18n.T("validation.invalidValue"), nil, envelope.InputError)
+		}
+		if err := validateAutomationRule(app, amodels.RuleRecord{Rules: rule.Rules}); err != nil {
+			return sendErrorEnvelope(r, err)
+		}
+	}
+	out, err := app.automation.ImportRules(req.Rules, auser.ID)
+	if err != nil {
+		return sendErrorEnvelope(r, err)
+	}
+	return r.SendEnvelope(out)
+}
+
+// validateAutomationRule validates the regex conditions and action configs of an automation rule.
+func validateAutomationRule(app *App, rule amodels.RuleRecord) error {
+	var rules []amodels.Rule
+	if err := json.Unmarshal(rule.Rules, &rules); err != nil {
+		return envelope.NewError(envelope.InputError, app.i18n.T("errors.parsingRequest"), nil)
+	}
+	for _, rl := range rules {
+		for _, group := range rl.Groups {
+			for _, d := range group.Rules {
+				if d.Operator != amodels.RuleOperatorRegex {
+					continue
+				}
+				if _, err := regexp.Compile(d.Value); err != nil {
+					return envelope.NewError(envelope.InputError, app.i18n.Ts("automation.invalidRegex", "pattern", d.Value, "error", err.Error()), nil)
+				}
+			}
+		}
+		for _, action := range rl.Actions {
+			if action.DelayMinutes < 0 || action.DelayMinutes > amodels.MaxActionDelayMinutes {
+				return envelope.NewError(envelope.InputError, app.i18n.Ts("automation.invalidDelay", "max", strconv.Itoa(amodels.MaxActionDelayMinutes)), nil)
+			}
+			for _, c := range action.CancelOn {
+				switch c {
+				case amodels.CancelOnContactReplied, amodels.CancelOnStatusChanged, amodels.CancelOnAssigneeChanged, amodels.CancelOnConditionsUnmet:
+				default:
+					return envelope.NewError(envelope.InputError, app.i18n.T("validation.invalidValue"), nil)
+				}
+			}
+			if action.Type != amodels.ActionHTTPRequest {
+				continue
+			}
+			if len(action.Value) == 0 {
+				return envelope.NewError(envelope.InputError, app.i18n.Ts("globals.messages.empty", "name", "`value`"), nil)
+			}
+			if _, err := conversation.ParseHTTPRequestAction(action.Value[0]); err != nil {
+				return envelope.NewError(envelope.InputError, app.i18n.Ts("automation.invalidHTTPRequest", "error", err.Error()), nil)
+			}
+		}
+	}
+	return nil
+}
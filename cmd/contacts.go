@@ -1,6 +1,7 @@
 package main
 
 import (
+	"fmt"
 	"path/filepath"
 	"strconv"
 	"strings"
@@ -9,6 +10,7 @@ import (
 	"github.com/abhinavxd/libredesk/internal/envelope"
 	"github.com/abhinavxd/libredesk/internal/stringutil"
 	"github.com/abhinavxd/libredesk/internal/user/models"
+	realip "github.com/ferluci/fast-realip"
 	"github.com/valyala/fasthttp"
 	"github.com/volatiletech/null/v9"
 	"github.com/zerodha/fastglue"
@@ -22,6 +24,10 @@ type blockContactReq struct {
 	Enabled bool `json:"enabled"`
 }
 
+type mergeContactReq struct {
+	SourceID int `json:"source_id"`
+}
+
 // handleGetContacts returns a list of contacts from the database.
 func handleGetContacts(r *fastglue.Request) error {
 	var (
@@ -292,3 +298,133 @@ func handleBlockContact(r *fastglue.Request) error {
 	}
 	return r.SendEnvelope(contact)
 }
+
+// handleMergeContact merges the source contact into the contact in the URL.
+func handleMergeContact(r *fastglue.Request) error {
+	var (
+		app         = r.Context.(*App)
+		targetID, _ = strconv.Atoi(r.RequestCtx.UserValue("id").(string))
+		auser       = r.RequestCtx.UserValue("user").(amodels.User)
+		ip          = realip.FromRequest(r.RequestCtx)
+		req         = mergeContactReq{}
+	)
+
+	if targetID <= 0 {
+		return r.SendErrorEnvelope(fasthttp.StatusBadRequest, app.i18n.T("globals.messages.somethingWentWrong"), nil, envelope.InputError)
+	}
+
+	if err := r.Decode(&req, "json"); err != nil {
+		return sendErrorEnvelope(r, envelope.NewError(envelope.InputError, app.i18n.T("errors.parsingRequest"), nil))
+	}
+	if req.SourceID <= 0 {
+		return r.SendErrorEnvelope(fasthttp.StatusBadRequest, app.i18n.Ts("globals.messages.empty", "name", "`source_id`"), nil, envelope.InputError)
+	}
+
+	source, err := app.user.GetContactOrVisitor(req.SourceID, "")
+	if err != nil {
+		return sendErrorEnvelope(r, err)
+	}
+
+	app.lo.Info("merging contacts", "source_id", req.SourceID, "target_id", targetID, "actor_id", auser.ID)
+
+	result, err := app.user.MergeContacts(req.SourceID, targetID)
+	if err != nil {
+		return sendErrorEnvelope(r, err)
+	}
+
+	// Page visits are kept in redis.
+	mergePageVisits(app, req.SourceID, targetID)
+
+	target, err := app.user.GetContactOrVisitor(targetID, "")
+	if err != nil {
+		return sendErrorEnvelope(r, err)
+	}
+
+	if err := app.activityLog.ContactMerged(auser.ID, auser.Email, ip, source.ID, source.Email.String, target.ID, target.Email.String); err != nil {
+		app.lo.Error("error creating activity log", "error", err)
+	}
+
+	return r.SendEnvelope(result)
+}
+
+// handleGetContactIdentities returns the secondary email identities of a contact.
+func handleGetContactIdentities(r *fastglue.Request) error {
+	var (
+		app   = r.Context.(*App)
+		id, _ = strconv.Atoi(r.RequestCtx.UserValue("id").(string))
+	)
+	if id <= 0 {
+		return r.SendErrorEnvelope(fasthttp.StatusBadRequest, app.i18n.T("globals.messages.somethingWentWrong"), nil, envelope.InputError)
+	}
+	identities, err := app.user.GetContactIdentities(id)
+	if err != nil {
+		return sendErrorEnvelope(r, err)
+	}
+	return r.SendEnvelope(identities)
+}
+
+// handleExportContactData returns a zip archive with all data stored about a contact.
+func handleExportContactData(r *fastglue.Request) error {
+	var (
+		app   = r.Context.(*App)
+		id, _ = strconv.Atoi(r.RequestCtx.UserValue("id").(string))
+		auser = r.RequestCtx.UserValue("user").(amodels.User)
+		ip    = realip.FromRequest(r.RequestCtx)
+	)
+	if id <= 0 {
+		return r.SendErrorEnvelope(fasthttp.StatusBadRequest, app.i18n.T("globals.messages.somethingWentWrong"), nil, envelope.InputError)
+	}
+
+	contact, err := app.user.GetContactOrVisitor(id, "")
+	if err != nil {
+		return sendErrorEnvelope(r, err)
+	}
+
+	archive, err := app.gdpr.Export(contact.ID)
+	if err != nil {
+		return sendErrorEnvelope(r, err)
+	}
+
+	if err := app.activityLog.ContactDataExported(auser.ID, auser.Email, ip, contact.ID, contact.Email.String); err != nil {
+		app.lo.Error("error creating activity log", "error", err)
+	}
+
+	r.RequestCtx.Response.Header.Set("Content-Type", "application/zip")
+	r.RequestCtx.Response.Header.Set("Content-Disposition", fmt.Sprintf(`attachment; filename="contact-%d-export.zip"`, contact.ID))
+	r.RequestCtx.SetBody(archive)
+	return nil
+}
+
+// handleEraseContactData anonymises a contact, redacts their conversations and deletes their media.
+func handleEraseContactData(r *fastglue.Request) error {
+	var (
+		app   = r.Context.(*App)
+		id, _ = strconv.Atoi(r.RequestCtx.UserValue("id").(string))
+		auser = r.RequestCtx.UserValue("user").(amodels.User)
+		ip    = realip.FromRequest(r.RequestCtx)
+	)
+	if id <= 0 {
+		return r.SendErrorEnvelope(fasthttp.StatusBadRequest, app.i18n.T("globals.messages.somethingWentWrong"), nil, envelope.InputError)
+	}
+
+	contact, err := app.user.GetContactOrVisitor(id, "")
+	if err != nil {
+		return sendErrorEnvelope(r, err)
+	}
+
+	app.lo.Info("erasing contact data", "contact_id", contact.ID, "actor_id", auser.ID)
+
+	result, err := app.gdpr.Erase(contact.ID)
+	if err != nil {
+		return sendErrorEnvelope(r, err)
+	}
+
+	// Page visits are kept in redis.
+	deletePageVisits(app, contact.ID)
+
+	if err := app.activityLog.ContactDataErased(auser.ID, auser.Email, ip, contact.ID); err != nil {
+		app.lo.Error("error creating activity log", "error", err)
+	}
+
+	return r.SendEnvelope(result)
+}
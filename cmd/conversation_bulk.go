@@ -0,0 +1,322 @@
+package main
+
+import (
+	"encoding/json"
+	"strconv"
+	"time"
+
+	amodels "github.com/abhinavxd/libredesk/internal/auth/models"
+	autoModels "github.com/abhinavxd/libredesk/internal/automation/models"
+	cmodels "github.com/abhinavxd/libredesk/internal/conversation/models"
+	smodels "github.com/abhinavxd/libredesk/internal/conversation/status/models"
+	"github.com/abhinavxd/libredesk/internal/envelope"
+	imodels "github.com/abhinavxd/libredesk/internal/inbox/models"
+	mmodels "github.com/abhinavxd/libredesk/internal/macro/models"
+	tmodels "github.com/abhinavxd/libredesk/internal/team/models"
+	umodels "github.com/abhinavxd/libredesk/internal/user/models"
+	"github.com/valyala/fasthttp"
+	"github.com/zerodha/fastglue"
+)
+
+const (
+	// Maximum number of conversations that can be updated in a single bulk request.
+	maxBulkConversations = 100
+
+	bulkActionApplyMacro = "apply_macro"
+)
+
+// bulkConversationsReq is the request body for applying actions to multiple conversations.
+type bulkConversationsReq struct {
+	ConversationUUIDs []string                `json:"conversation_uuids"`
+	Actions           []autoModels.RuleAction `json:"actions"`
+	SnoozedUntil      string                  `json:"snoozed_until,omitempty"`
+}
+
+// bulkConversationResult is the outcome of a bulk request for a single conversation.
+type bulkConversationResult struct {
+	UUID    string `json:"uuid"`
+	Success bool   `json:"success"`
+	Error   string `json:"error,omitempty"`
+}
+
+// bulkStore holds the managers used by bulk updates, narrowed to the methods used.
+type bulkStore struct {
+	macro        bulkMacroStore
+	team         bulkTeamStore
+	status       bulkStatusStore
+	inbox        bulkInboxStore
+	conversation bulkConversationStore
+}
+
+type bulkMacroStore interface {
+	Get(id int) (mmodels.Macro, error)
+}
+
+type bulkTeamStore interface {
+	Get(id int) (tmodels.Team, error)
+}
+
+type bulkStatusStore interface {
+	Get(id int) (smodels.Status, error)
+}
+
+type bulkInboxStore interface {
+	GetDBRecord(identifier any) (imodels.Inbox, error)
+}
+
+type bulkConversationStore interface {
+	GetConversation(id int, uuid, refNum string) (cmodels.Conversation, error)
+	UpdateConversationUserAssignee(uuid string, assigneeID int, actor umodels.User) error
+	UpdateConversationTeamAssignee(uuid string, teamID int, actor umodels.User) error
+	UpdateConversationStatus(uuid string, statusID int, status, snoozeDur string, actor umodels.User) error
+	UpdateConversationPriority(uuid string, priorityID int, priority string, actor umodels.User) error
+	ApplyAction(action autoModels.RuleAction, conversation cmodels.Conversation, user umodels.User) error
+	SendCSATReply(actorUserID int, conversation cmodels.Conversation) error
+}
+
+// newBulkStore returns the bulk update store backed by the app's managers.
+func newBulkStore(app *App) bulkStore {
+	return bulkStore{
+		macro:        app.macro,
+		team:         app.team,
+		status:       app.status,
+		inbox:        app.inbox,
+		conversation: app.conversation,
+	}
+}
+
+// handleBulkUpdateConversations applies a list of actions to multiple conversations and
+// returns the per-conversation result instead of aborting on the first failure.
+//
+// Supported actions and their values:
+//   - assign_user: [agent ID]
+//   - assign_team: [team ID]
+//   - set_status: [status name], `snoozed_until` is required for `Snoozed`
+//   - set_priority: [priority name]
+//   - add_tags, remove_tags, set_tags: [tag names...]
+//   - apply_macro: [macro ID]
+func handleBulkUpdateConversations(r *fastglue.Request) error {
+	var (
+		app   = r.Context.(*App)
+		auser = r.RequestCtx.UserValue("user").(amodels.User)
+		req   = bulkConversationsReq{}
+	)
+
+	if err := r.Decode(&req, "json"); err != nil {
+		app.lo.Error("error decoding bulk conversations request", "error", err)
+		return r.SendErrorEnvelope(fasthttp.StatusBadRequest, app.i18n.T("errors.parsingRequest"), nil, envelope.InputError)
+	}
+
+	if len(req.ConversationUUIDs) == 0 {
+		return r.SendErrorEnvelope(fasthttp.StatusBadRequest, app.i18n.Ts("globals.messages.empty", "name", "`conversation_uuids`"), nil, envelope.InputError)
+	}
+	if len(req.ConversationUUIDs) > maxBulkConversations {
+		return r.SendErrorEnvelope(fasthttp.StatusBadRequest, app.i18n.Ts("validation.minmaxNumber", "min", "1", "max", strconv.Itoa(maxBulkConversations)), nil, envelope.InputError)
+	}
+	if len(req.Actions) == 0 {
+		return r.SendErrorEnvelope(fasthttp.StatusBadRequest, app.i18n.Ts("globals.messages.empty", "name", "`actions`"), nil, envelope.InputError)
+	}
+
+	user, err := app.user.GetAgent(auser.ID, "")
+	if err != nil {
+		return sendErrorEnvelope(r, err)
+	}
+
+	// Expand macros into their actions and validate every action upfront.
+	store := newBulkStore(app)
+	actions, macroIDs, err := expandBulkActions(app, store, req, user)
+	if err != nil {
+		return sendErrorEnvelope(r, err)
+	}
+
+	var (
+		results   = make([]bulkConversationResult, 0, len(req.ConversationUUIDs))
+		processed = make(map[string]struct{}, len(req.ConversationUUIDs))
+		anyOK     bool
+	)
+	for _, uuid := range req.ConversationUUIDs {
+		if _, ok := processed[uuid]; ok {
+			continue
+		}
+		processed[uuid] = struct{}{}
+
+		res := bulkConversationResult{UUID: uuid, Success: true}
+		conversation, err := enforceConversationAccess(app, uuid, user)
+		if err == nil {
+			err = applyBulkActions(app, store, *conversation, actions, req.SnoozedUntil, user)
+		}
+		if err != nil {
+			res.Success = false
+			if envErr, ok := err.(envelope.Error); ok {
+				res.Error = envErr.Error()
+			} else {
+				app.lo.Error("error applying bulk actions to conversation", "uuid", uuid, "error", err)
+				res.Error = app.i18n.T("globals.messages.somethingWentWrong")
+			}
+		} else {
+			anyOK = true
+		}
+		results = append(results, res)
+	}
+
+	// Increment macro usage count once per request.
+	if anyOK {
+		for _, id := range macroIDs {
+			app.macro.IncrementUsageCount(id)
+		}
+	}
+
+	return r.SendEnvelope(results)
+}
+
+// expandBulkActions validates the requested actions against the user's permissions and
+// replaces `apply_macro` actions with the actions of the macro.
+func expandBulkActions(app *App, store bulkStore, req bulkConversationsReq, user umodels.User) ([]autoModels.RuleAction, []int, error) {
+	var (
+		actions  = make([]autoModels.RuleAction, 0, len(req.Actions))
+		macroIDs []int
+	)
+	for _, act := range req.Actions {
+		if len(act.Value) == 0 {
+			return nil, nil, envelope.NewError(envelope.InputError, app.i18n.Ts("globals.messages.empty", "name", "`"+act.Type+"`"), nil)
+		}
+
+		if act.Type != bulkActionApplyMacro {
+			actions = append(actions, act)
+			continue
+		}
+
+		id, err := strconv.Atoi(act.Value[0])
+		if err != nil {
+			return nil, nil, envelope.NewError(envelope.InputError, app.i18n.T("validation.notFoundMacro"), nil)
+		}
+		macro, err := store.macro.Get(id)
+		if err != nil {
+			return nil, nil, err
+		}
+		var macroActions []autoModels.RuleAction
+		if err := json.Unmarshal(macro.Actions, &macroActions); err != nil {
+			app.lo.Error("error unmarshalling macro actions", "macro_id", macro.ID, "error", err)
+			return nil, nil, envelope.NewError(envelope.GeneralError, app.i18n.T("globals.messages.somethingWentWrong"), nil)
+		}
+		actions = append(actions, macroActions...)
+		macroIDs = append(macroIDs, macro.ID)
+	}
+
+	for _, act := range actions {
+		if !isMacroActionAllowed(act.Type) {
+			return nil, nil, envelope.NewError(envelope.PermissionError, app.i18n.Ts("macro.actionNotAllowed", "name", act.Type), nil)
+		}
+		if !hasActionPermission(act.Type, user.Permissions) {
+			app.lo.Warn("no permission to execute bulk action", "action", act.Type, "user_id", user.ID)
+			return nil, nil, envelope.NewError(envelope.PermissionError, app.i18n.T("status.deniedPermission"), nil)
+		}
+		if act.Type == autoModels.ActionAssignTeam {
+			teamID, _ := strconv.Atoi(act.Value[0])
+			if _, err := store.team.Get(teamID); err != nil {
+				return nil, nil, err
+			}
+		}
+	}
+
+	// Validate snooze duration if any action, including the actions of macros, snoozes the conversations.
+	for _, act := range actions {
+		if act.Type != autoModels.ActionSetStatus {
+			continue
+		}
+		// Macro actions carry the status ID, bulk actions the status name.
+		status := act.Value[0]
+		if id, err := strconv.Atoi(act.Value[0]); err == nil {
+			s, err := store.status.Get(id)
+			if err != nil {
+				return nil, nil, err
+			}
+			status = s.Name
+		}
+		if status == cmodels.StatusSnoozed {
+			if _, err := time.ParseDuration(req.SnoozedUntil); err != nil {
+				return nil, nil, envelope.NewError(envelope.InputError, app.i18n.T("validation.invalidSnoozeDuration"), nil)
+			}
+		}
+	}
+
+	return actions, macroIDs, nil
+}
+
+// applyBulkActions applies the actions to a single conversation, access to it must already be enforced.
+func applyBulkActions(app *App, store bulkStore, conversation cmodels.Conversation, actions []autoModels.RuleAction, snoozedUntil string, user umodels.User) error {
+	uuid := conversation.UUID
+	for _, act := range actions {
+		switch act.Type {
+		case autoModels.ActionAssignUser:
+			agentID, err := strconv.Atoi(act.Value[0])
+			if err != nil {
+				return envelope.NewError(envelope.InputError, app.i18n.T("validation.invalidUser"), nil)
+			}
+			if conversation.AssignedUserID.Int == agentID {
+				continue
+			}
+			if err := store.conversation.UpdateConversationUserAssignee(uuid, agentID, user); err != nil {
+				return err
+			}
+		case autoModels.ActionAssignTeam:
+			teamID, err := strconv.Atoi(act.Value[0])
+			if err != nil {
+				return envelope.NewError(envelope.InputError, app.i18n.T("validation.notFoundTeam"), nil)
+			}
+			if conversation.AssignedTeamID.Int == teamID {
+				continue
+			}
+			if err := store.conversation.UpdateConversationTeamAssignee(uuid, teamID, user); err != nil {
+				return err
+			}
+		case autoModels.ActionSetStatus:
+			// Macro actions carry the status ID, bulk actions the status name.
+			statusID, status := 0, act.Value[0]
+			if id, err := strconv.Atoi(act.Value[0]); err == nil {
+				statusID, status = id, ""
+			}
+			if err := store.conversation.UpdateConversationStatus(uuid, statusID, status, snoozedUntil, user); err != nil {
+				return err
+			}
+			if conversation.Status.String != cmodels.StatusResolved {
+				if err := sendBulkCSAT(store, uuid, user); err != nil {
+					return err
+				}
+			}
+		case autoModels.ActionSetPriority:
+			// Macro actions carry the priority ID, bulk actions the priority name.
+			priorityID, priority := 0, act.Value[0]
+			if id, err := strconv.Atoi(act.Value[0]); err == nil {
+				priorityID, priority = id, ""
+			}
+			if err := store.conversation.UpdateConversationPriority(uuid, priorityID, priority, user); err != nil {
+				return err
+			}
+		default:
+			if err := store.conversation.ApplyAction(act, conversation, user); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// sendBulkCSAT sends the CSAT survey if the conversation is now resolved and CSAT is enabled on the inbox.
+func sendBulkCSAT(store bulkStore, uuid string, user umodels.User) error {
+	conversation, err := store.conversation.GetConversation(0, uuid, "")
+	if err != nil {
+		return err
+	}
+	if conversation.Status.String != cmodels.StatusResolved {
+		return nil
+	}
+	inbox, err := store.inbox.GetDBRecord(conversation.InboxID)
+	if err != nil {
+		return err
+	}
+	if inbox.CSATEnabled {
+		return store.conversation.SendCSATReply(user.ID, conversation)
+	}
+	return nil
+}
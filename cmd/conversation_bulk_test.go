@@ -0,0 +1,307 @@
+package main
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+
+	authzModels "github.com/abhinavxd/libredesk/internal/authz/models"
+	autoModels "github.com/abhinavxd/libredesk/internal/automation/models"
+	cmodels "github.com/abhinavxd/libredesk/internal/conversation/models"
+	smodels "github.com/abhinavxd/libredesk/internal/conversation/status/models"
+	"github.com/abhinavxd/libredesk/internal/envelope"
+	imodels "github.com/abhinavxd/libredesk/internal/inbox/models"
+	mmodels "github.com/abhinavxd/libredesk/internal/macro/models"
+	tmodels "github.com/abhinavxd/libredesk/internal/team/models"
+	umodels "github.com/abhinavxd/libredesk/internal/user/models"
+	"github.com/volatiletech/null/v9"
+)
+
+var errBulkNotFound = errors.New("not found")
+
+type fakeMacroStore map[int]mmodels.Macro
+
+func (s fakeMacroStore) Get(id int) (mmodels.Macro, error) {
+	m, ok := s[id]
+	if !ok {
+		return m, errBulkNotFound
+	}
+	return m, nil
+}
+
+type fakeTeamStore map[int]tmodels.Team
+
+func (s fakeTeamStore) Get(id int) (tmodels.Team, error) {
+	t, ok := s[id]
+	if !ok {
+		return t, errBulkNotFound
+	}
+	return t, nil
+}
+
+type fakeStatusStore map[int]smodels.Status
+
+func (s fakeStatusStore) Get(id int) (smodels.Status, error) {
+	st, ok := s[id]
+	if !ok {
+		return st, errBulkNotFound
+	}
+	return st, nil
+}
+
+type fakeInboxStore map[int]imodels.Inbox
+
+func (s fakeInboxStore) GetDBRecord(identifier any) (imodels.Inbox, error) {
+	inb, ok := s[identifier.(int)]
+	if !ok {
+		return inb, errBulkNotFound
+	}
+	return inb, nil
+}
+
+// fakeBulkConversationStore applies status changes to conversations held in memory and records the updates made.
+type fakeBulkConversationStore struct {
+	conversations map[string]cmodels.Conversation
+	updates       []string
+	csatSent      []string
+}
+
+func (s *fakeBulkConversationStore) GetConversation(_ int, uuid, _ string) (cmodels.Conversation, error) {
+	c, ok := s.conversations[uuid]
+	if !ok {
+		return c, errBulkNotFound
+	}
+	return c, nil
+}
+
+func (s *fakeBulkConversationStore) UpdateConversationUserAssignee(uuid string, _ int, _ umodels.User) error {
+	s.updates = append(s.updates, autoModels.ActionAssignUser)
+	return nil
+}
+
+func (s *fakeBulkConversationStore) UpdateConversationTeamAssignee(uuid string, _ int, _ umodels.User) error {
+	s.updates = append(s.updates, autoModels.ActionAssignTeam)
+	return nil
+}
+
+func (s *fakeBulkConversationStore) UpdateConversationStatus(uuid string, _ int, status, _ string, _ umodels.User) error {
+	s.updates = append(s.updates, autoModels.ActionSetStatus)
+	c := s.conversations[uuid]
+	c.Status = null.StringFrom(status)
+	s.conversations[uuid] = c
+	return nil
+}
+
+func (s *fakeBulkConversationStore) UpdateConversationPriority(uuid string, _ int, _ string, _ umodels.User) error {
+	s.updates = append(s.updates, autoModels.ActionSetPriority)
+	return nil
+}
+
+func (s *fakeBulkConversationStore) ApplyAction(action autoModels.RuleAction, _ cmodels.Conversation, _ umodels.User) error {
+	s.updates = append(s.updates, action.Type)
+	return nil
+}
+
+func (s *fakeBulkConversationStore) SendCSATReply(_ int, conversation cmodels.Conversation) error {
+	s.csatSent = append(s.csatSent, conversation.UUID)
+	return nil
+}
+
+func newTestBulkStore(conversations map[string]cmodels.Conversation) (bulkStore, *fakeBulkConversationStore) {
+	convs := &fakeBulkConversationStore{conversations: conversations}
+	return bulkStore{
+		macro: fakeMacroStore{
+			1: {ID: 1, Actions: []byte(`[{"type": "set_priority", "value": ["2"]}, {"type": "add_tags", "value": ["vip"]}]`)},
+			2: {ID: 2, Actions: []byte(`[{"type": "set_priority", "value": ["2"]}, {"type": "send_reply", "value": ["Hi"]}]`)},
+			3: {ID: 3, Actions: []byte(`[{"type": "set_status", "value": ["4"]}]`)},
+		},
+		team:         fakeTeamStore{1: {ID: 1, Name: "Billing"}},
+		status:       fakeStatusStore{4: {ID: 4, Name: cmodels.StatusSnoozed}},
+		inbox:        fakeInboxStore{1: {ID: 1, CSATEnabled: true}, 2: {ID: 2}},
+		conversation: convs,
+	}, convs
+}
+
+func TestExpandBulkActions(t *testing.T) {
+	var (
+		app      = newTestApp(t)
+		store, _ = newTestBulkStore(nil)
+		agent    = umodels.User{ID: 1, Permissions: []string{
+			authzModels.PermConversationsUpdateStatus,
+			authzModels.PermConversationsUpdatePriority,
+			authzModels.PermConversationsUpdateTags,
+			authzModels.PermConversationsUpdateTeamAssignee,
+		}}
+		noPriority = umodels.User{ID: 2, Permissions: []string{authzModels.PermConversationsUpdateStatus, authzModels.PermConversationsUpdateTags}}
+	)
+
+	tests := []struct {
+		name         string
+		user         umodels.User
+		req          bulkConversationsReq
+		wantActions  []string
+		wantMacroIDs []int
+		wantErrType  string
+	}{
+		{
+			name:        "actions",
+			user:        agent,
+			req:         bulkConversationsReq{Actions: []autoModels.RuleAction{{Type: autoModels.ActionSetStatus, Value: []string{cmodels.StatusResolved}}, {Type: autoModels.ActionAssignTeam, Value: []string{"1"}}}},
+			wantActions: []string{autoModels.ActionSetStatus, autoModels.ActionAssignTeam},
+		},
+		{
+			name:         "macro expanded",
+			user:         agent,
+			req:          bulkConversationsReq{Actions: []autoModels.RuleAction{{Type: autoModels.ActionSetStatus, Value: []string{cmodels.StatusOpen}}, {Type: bulkActionApplyMacro, Value: []string{"1"}}}},
+			wantActions:  []string{autoModels.ActionSetStatus, autoModels.ActionSetPriority, autoModels.ActionAddTags},
+			wantMacroIDs: []int{1},
+		},
+		{
+			name:        "permission denied",
+			user:        noPriority,
+			req:         bulkConversationsReq{Actions: []autoModels.RuleAction{{Type: autoModels.ActionSetPriority, Value: []string{"High"}}}},
+			wantErrType: envelope.PermissionError,
+		},
+		{
+			name:        "permission denied for macro action",
+			user:        noPriority,
+			req:         bulkConversationsReq{Actions: []autoModels.RuleAction{{Type: bulkActionApplyMacro, Value: []string{"1"}}}},
+			wantErrType: envelope.PermissionError,
+		},
+		{
+			name:        "action not allowed",
+			user:        agent,
+			req:         bulkConversationsReq{Actions: []autoModels.RuleAction{{Type: autoModels.ActionSendPrivateNote, Value: []string{"note"}}}},
+			wantErrType: envelope.PermissionError,
+		},
+		{
+			name:        "macro with action not allowed",
+			user:        agent,
+			req:         bulkConversationsReq{Actions: []autoModels.RuleAction{{Type: bulkActionApplyMacro, Value: []string{"2"}}}},
+			wantErrType: envelope.PermissionError,
+		},
+		{
+			name:        "unknown macro",
+			user:        agent,
+			req:         bulkConversationsReq{Actions: []autoModels.RuleAction{{Type: bulkActionApplyMacro, Value: []string{"x"}}}},
+			wantErrType: envelope.InputError,
+		},
+		{
+			name:        "empty value",
+			user:        agent,
+			req:         bulkConversationsReq{Actions: []autoModels.RuleAction{{Type: autoModels.ActionSetStatus}}},
+			wantErrType: envelope.InputError,
+		},
+		{
+			name:        "snooze without duration",
+			user:        agent,
+			req:         bulkConversationsReq{Actions: []autoModels.RuleAction{{Type: autoModels.ActionSetStatus, Value: []string{cmodels.StatusSnoozed}}}},
+			wantErrType: envelope.InputError,
+		},
+		{
+			name:        "macro snooze without duration",
+			user:        agent,
+			req:         bulkConversationsReq{Actions: []autoModels.RuleAction{{Type: bulkActionApplyMacro, Value: []string{"3"}}}},
+			wantErrType: envelope.InputError,
+		},
+		{
+			name:         "macro snooze with duration",
+			user:         agent,
+			req:          bulkConversationsReq{Actions: []autoModels.RuleAction{{Type: bulkActionApplyMacro, Value: []string{"3"}}}, SnoozedUntil: "2h"},
+			wantActions:  []string{autoModels.ActionSetStatus},
+			wantMacroIDs: []int{3},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			actions, macroIDs, err := expandBulkActions(app, store, tt.req, tt.user)
+			if tt.wantErrType != "" {
+				var envErr envelope.Error
+				if !errors.As(err, &envErr) || envErr.ErrorType != tt.wantErrType {
+					t.Fatalf("expandBulkActions() error = %v, want %s", err, tt.wantErrType)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("expandBulkActions() error = %v", err)
+			}
+			var types []string
+			for _, a := range actions {
+				types = append(types, a.Type)
+			}
+			if !reflect.DeepEqual(types, tt.wantActions) {
+				t.Errorf("expandBulkActions() actions = %v, want %v", types, tt.wantActions)
+			}
+			if !reflect.DeepEqual(macroIDs, tt.wantMacroIDs) {
+				t.Errorf("expandBulkActions() macro IDs = %v, want %v", macroIDs, tt.wantMacroIDs)
+			}
+		})
+	}
+}
+
+func TestApplyBulkActions(t *testing.T) {
+	var (
+		app     = newTestApp(t)
+		user    = umodels.User{ID: 1}
+		resolve = []autoModels.RuleAction{{Type: autoModels.ActionSetStatus, Value: []string{cmodels.StatusResolved}}}
+	)
+
+	tests := []struct {
+		name         string
+		conversation cmodels.Conversation
+		actions      []autoModels.RuleAction
+		wantUpdates  []string
+		wantCSAT     bool
+	}{
+		{
+			name:         "resolved sends CSAT",
+			conversation: cmodels.Conversation{UUID: "c1", InboxID: 1, Status: null.StringFrom(cmodels.StatusOpen)},
+			actions:      resolve,
+			wantUpdates:  []string{autoModels.ActionSetStatus},
+			wantCSAT:     true,
+		},
+		{
+			name:         "already resolved does not send CSAT",
+			conversation: cmodels.Conversation{UUID: "c1", InboxID: 1, Status: null.StringFrom(cmodels.StatusResolved)},
+			actions:      resolve,
+			wantUpdates:  []string{autoModels.ActionSetStatus},
+		},
+		{
+			name:         "CSAT disabled on inbox",
+			conversation: cmodels.Conversation{UUID: "c1", InboxID: 2, Status: null.StringFrom(cmodels.StatusOpen)},
+			actions:      resolve,
+			wantUpdates:  []string{autoModels.ActionSetStatus},
+		},
+		{
+			name:         "not resolved does not send CSAT",
+			conversation: cmodels.Conversation{UUID: "c1", InboxID: 1, Status: null.StringFrom(cmodels.StatusResolved)},
+			actions:      []autoModels.RuleAction{{Type: autoModels.ActionSetStatus, Value: []string{cmodels.StatusOpen}}},
+			wantUpdates:  []string{autoModels.ActionSetStatus},
+		},
+		{
+			name:         "unchanged assignees are skipped",
+			conversation: cmodels.Conversation{UUID: "c1", InboxID: 1, AssignedUserID: null.IntFrom(3), AssignedTeamID: null.IntFrom(1)},
+			actions: []autoModels.RuleAction{
+				{Type: autoModels.ActionAssignUser, Value: []string{"3"}},
+				{Type: autoModels.ActionAssignTeam, Value: []string{"2"}},
+				{Type: autoModels.ActionSetPriority, Value: []string{"High"}},
+				{Type: autoModels.ActionAddTags, Value: []string{"vip"}},
+			},
+			wantUpdates: []string{autoModels.ActionAssignTeam, autoModels.ActionSetPriority, autoModels.ActionAddTags},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			store, convs := newTestBulkStore(map[string]cmodels.Conversation{tt.conversation.UUID: tt.conversation})
+			if err := applyBulkActions(app, store, tt.conversation, tt.actions, "", user); err != nil {
+				t.Fatalf("applyBulkActions() error = %v", err)
+			}
+			if !reflect.DeepEqual(convs.updates, tt.wantUpdates) {
+				t.Errorf("applyBulkActions() updates = %v, want %v", convs.updates, tt.wantUpdates)
+			}
+			if sent := len(convs.csatSent) > 0; sent != tt.wantCSAT {
+				t.Errorf("applyBulkActions() CSAT sent = %v, want %v", sent, tt.wantCSAT)
+			}
+		})
+	}
+}
@@ -57,6 +57,7 @@ func initHandlers(g *fastglue.Fastglue, hub *ws.Hub) {
 	g.GET("/api/v1/views/{id}/conversations", perm(handleGetViewConversations, "conversations:read"))
 	g.GET("/api/v1/conversations/{uuid}", perm(handleGetConversation, "conversations:read"))
 	g.GET("/api/v1/conversations/{uuid}/participants", perm(handleGetConversationParticipants, "conversations:read"))
+	g.GET("/api/v1/conversations/{uuid}/assignment-decisions", perm(handleGetConversationAssignmentDecisions, "conversations:read"))
 	g.PUT("/api/v1/conversations/{uuid}/assignee/user", perm(handleUpdateUserAssignee, "conversations:update_user_assignee"))
 	g.PUT("/api/v1/conversations/{uuid}/assignee/team", perm(handleUpdateTeamAssignee, "conversations:update_team_assignee"))
 	g.PUT("/api/v1/conversations/{uuid}/assignee/user/remove", perm(handleRemoveUserAssignee, "conversations:update_user_assignee"))
@@ -72,6 +73,7 @@ func initHandlers(g *fastglue.Fastglue, hub *ws.Hub) {
 	g.POST("/api/v1/conversations/{cuuid}/messages", perm(handleSendMessage, "messages:write"))
 	g.PUT("/api/v1/conversations/{cuuid}/messages/{uuid}/retry", perm(handleRetryMessage, "messages:write"))
 	g.POST("/api/v1/conversations", perm(handleCreateConversation, "conversations:write"))
+	g.POST("/api/v1/conversations/bulk", auth(handleBulkUpdateConversations))
 	g.PUT("/api/v1/conversations/{uuid}/custom-attributes", auth(handleUpdateConversationCustomAttributes))
 	g.PUT("/api/v1/conversations/{uuid}/contacts/custom-attributes", auth(handleUpdateContactCustomAttributes))
 	// Draft endpoints
@@ -126,10 +128,12 @@ func initHandlers(g *fastglue.Fastglue, hub *ws.Hub) {
 	g.PUT("/api/v1/agents/me", auth(handleUpdateCurrentAgent))
 	g.GET("/api/v1/agents/me/teams", auth(handleGetCurrentAgentTeams))
 	g.PUT("/api/v1/agents/me/availability", auth(handleUpdateAgentAvailability))
+	g.PUT("/api/v1/agents/me/email-signature", auth(handleUpdateCurrentAgentEmailSignature))
 	g.DELETE("/api/v1/agents/me/avatar", auth(handleDeleteCurrentAgentAvatar))
 
 	g.GET("/api/v1/agents/compact", auth(handleGetAgentsCompact))
 	g.GET("/api/v1/agents", perm(handleGetAgents, "users:manage"))
+	g.GET("/api/v1/agents/load", perm(handleGetAgentsLoad, "users:manage"))
 	g.GET("/api/v1/agents/{id}", perm(handleGetAgent, "users:manage"))
 	g.POST("/api/v1/agents", perm(handleCreateAgent, "users:manage"))
 	g.PUT("/api/v1/agents/{id}", perm(handleUpdateAgent, "users:manage"))
@@ -146,6 +150,10 @@ func initHandlers(g *fastglue.Fastglue, hub *ws.Hub) {
 	g.GET("/api/v1/contacts/{id}", perm(handleGetContact, "contacts:read"))
 	g.PUT("/api/v1/contacts/{id}", perm(handleUpdateContact, "contacts:write"))
 	g.PUT("/api/v1/contacts/{id}/block", perm(handleBlockContact, "contacts:block"))
+	g.POST("/api/v1/contacts/{id}/merge", perm(handleMergeContact, "contacts:merge"))
+	g.GET("/api/v1/contacts/{id}/identities", perm(handleGetContactIdentities, "contacts:read"))
+	g.GET("/api/v1/contacts/{id}/export", perm(handleExportContactData, "contacts:export"))
+	g.POST("/api/v1/contacts/{id}/erase", perm(handleEraseContactData, "contacts:erase"))
 
 	// Contact notes.
 	g.GET("/api/v1/contacts/{id}/notes", perm(handleGetContactNotes, "contact_notes:read"))
@@ -162,13 +170,21 @@ func initHandlers(g *fastglue.Fastglue, hub *ws.Hub) {
 
 	// Automations.
 	g.GET("/api/v1/automations/rules", perm(handleGetAutomationRules, "automations:manage"))
+	g.GET("/api/v1/automations/rules/export", perm(handleExportAutomationRules, "automations:manage"))
+	g.POST("/api/v1/automations/rules/import", perm(handleImportAutomationRules, "automations:manage"))
 	g.GET("/api/v1/automations/rules/{id}", perm(handleGetAutomationRule, "automations:manage"))
+	g.GET("/api/v1/automations/rules/{id}/versions", perm(handleGetAutomationRuleVersions, "automations:manage"))
+	g.GET("/api/v1/automations/rules/{id}/versions/{version}", perm(handleGetAutomationRuleVersion, "automations:manage"))
+	g.GET("/api/v1/automations/rules/{id}/versions/{version}/diff", perm(handleDiffAutomationRuleVersion, "automations:manage"))
+	g.POST("/api/v1/automations/rules/{id}/versions/{version}/rollback", perm(handleRollbackAutomationRule, "automations:manage"))
 	g.POST("/api/v1/automations/rules", perm(handleCreateAutomationRule, "automations:manage"))
 	g.PUT("/api/v1/automations/rules/{id}/toggle", perm(handleToggleAutomationRule, "automations:manage"))
 	g.PUT("/api/v1/automations/rules/{id}", perm(handleUpdateAutomationRule, "automations:manage"))
 	g.PUT("/api/v1/automations/rules/weights", perm(handleUpdateAutomationRuleWeights, "automations:manage"))
 	g.PUT("/api/v1/automations/rules/execution-mode", perm(handleUpdateAutomationRuleExecutionMode, "automations:manage"))
 	g.DELETE("/api/v1/automations/rules/{id}", perm(handleDeleteAutomationRule, "automations:manage"))
+	g.POST("/api/v1/automations/rules/{id}/test", perm(handleTestAutomationRule, "automations:manage"))
+	g.GET("/api/v1/automations/executions", perm(handleGetAutomationRuleExecutions, "automations:manage"))
 
 	// Inboxes.
 	g.GET("/api/v1/inboxes", auth(handleGetInboxes))
@@ -177,6 +193,9 @@ func initHandlers(g *fastglue.Fastglue, hub *ws.Hub) {
 	g.PUT("/api/v1/inboxes/{id}/toggle", perm(handleToggleInbox, "inboxes:manage"))
 	g.PUT("/api/v1/inboxes/{id}", perm(handleUpdateInbox, "inboxes:manage"))
 	g.DELETE("/api/v1/inboxes/{id}", perm(handleDeleteInbox, "inboxes:manage"))
+	g.GET("/api/v1/inboxes/{id}/retention-policy", perm(handleGetInboxRetentionPolicy, "inboxes:manage"))
+	g.PUT("/api/v1/inboxes/{id}/retention-policy", perm(handleUpdateInboxRetentionPolicy, "inboxes:manage"))
+	g.POST("/api/v1/inboxes/{id}/retention-policy/dry-run", perm(handleInboxRetentionDryRun, "inboxes:manage"))
 
 	// OAuth endpoints for email inboxes.
 	g.POST("/api/v1/inboxes/oauth/{provider}/authorize", perm(handleOAuthAuthorize, "inboxes:manage"))
@@ -240,7 +259,23 @@ func initHandlers(g *fastglue.Fastglue, hub *ws.Hub) {
 	// AI completions.
 	g.GET("/api/v1/ai/prompts", auth(handleGetAIPrompts))
 	g.POST("/api/v1/ai/completion", auth(handleAICompletion))
+	g.POST("/api/v1/conversations/{uuid}/ai/suggest-reply", perm(handleAISuggestReply, "messages:write"))
+	g.POST("/api/v1/conversations/{uuid}/ai/summarize", perm(handleAISummarizeConversation, "messages:write"))
+	g.GET("/api/v1/ai/providers", perm(handleGetAIProviders, "ai:manage"))
 	g.PUT("/api/v1/ai/provider", perm(handleUpdateAIProvider, "ai:manage"))
+	g.POST("/api/v1/ai/provider/test", perm(handleTestAIProvider, "ai:manage"))
+
+	// Knowledge base.
+	g.GET("/api/v1/kb/categories", auth(handleGetKBCategories))
+	g.POST("/api/v1/kb/categories", perm(handleCreateKBCategory, "kb:manage"))
+	g.PUT("/api/v1/kb/categories/{id}", perm(handleUpdateKBCategory, "kb:manage"))
+	g.DELETE("/api/v1/kb/categories/{id}", perm(handleDeleteKBCategory, "kb:manage"))
+	g.GET("/api/v1/kb/articles", perm(handleGetKBArticles, "kb:manage"))
+	g.GET("/api/v1/kb/articles/search", auth(handleSearchKBArticles))
+	g.GET("/api/v1/kb/articles/{id}", perm(handleGetKBArticle, "kb:manage"))
+	g.POST("/api/v1/kb/articles", perm(handleCreateKBArticle, "kb:manage"))
+	g.PUT("/api/v1/kb/articles/{id}", perm(handleUpdateKBArticle, "kb:manage"))
+	g.DELETE("/api/v1/kb/articles/{id}", perm(handleDeleteKBArticle, "kb:manage"))
 
 	// Custom attributes.
 	g.GET("/api/v1/custom-attributes", auth(handleGetCustomAttributes))
@@ -255,6 +290,12 @@ func initHandlers(g *fastglue.Fastglue, hub *ws.Hub) {
 	// CSAT.
 	g.POST("/api/v1/csat/{uuid}/response", rateLimit(handleSubmitCSATResponse, "public"))
 
+	// API inbox incoming messages.
+	g.POST("/api/v1/inbound/{uuid}/messages", rateLimit(handleAPIInboxIncomingMessage, "public"))
+
+	// Email inbox messages relayed by an MTA.
+	g.POST("/api/v1/inbound/{uuid}/email", rateLimit(handleInboundEmail, "public"))
+
 	// User notifications.
 	g.GET("/api/v1/notifications", auth(handleGetUserNotifications))
 	g.GET("/api/v1/notifications/stats", auth(handleGetUserNotificationStats))
@@ -282,6 +323,7 @@ func initHandlers(g *fastglue.Fastglue, hub *ws.Hub) {
 	g.GET("/api/v1/widget/chat/conversations/{uuid}", rateLimit(widgetAuth(handleChatGetConversation), "widget"))
 	g.POST("/api/v1/widget/chat/conversations/{uuid}/message", rateLimit(widgetAuth(handleChatSendMessage), "widget"))
 	g.POST("/api/v1/widget/media/upload", rateLimit(widgetAuth(handleWidgetMediaUpload), "widget"))
+	g.GET("/api/v1/widget/kb/search", rateLimit(validateWidgetInbox(handleWidgetSearchKBArticles), "widget"))
 
 	// Frontend pages.
 	g.GET("/", notAuthPage(serveIndexPage))
@@ -308,6 +350,7 @@ func initHandlers(g *fastglue.Fastglue, hub *ws.Hub) {
 	g.GET("/csat/{uuid}", rateLimit(handleShowCSAT, "public"))
 	g.GET("/csat/{uuid}/widget", rateLimit(handleShowCSATWidget, "public"))
 	g.POST("/csat/{uuid}", rateLimit(handleUpdateCSATResponse, "public"))
+	g.GET("/kb/{uuid}", rateLimit(handleShowKBArticle, "public"))
 
 	// Health check.
 	g.GET("/health", handleHealthCheck)
@@ -0,0 +1,68 @@
+package main
+
+import (
+	"github.com/abhinavxd/libredesk/internal/envelope"
+	"github.com/abhinavxd/libredesk/internal/inbox"
+	"github.com/abhinavxd/libredesk/internal/inbox/channel/email"
+	"github.com/abhinavxd/libredesk/internal/webhook"
+	"github.com/google/uuid"
+	"github.com/valyala/fasthttp"
+	"github.com/zerodha/fastglue"
+)
+
+// handleInboundEmail accepts a signed raw RFC 822 message relayed by an MTA for an email inbox and queues it for processing.
+func handleInboundEmail(r *fastglue.Request) error {
+	var (
+		app       = r.Context.(*App)
+		inboxUUID = r.RequestCtx.UserValue("uuid").(string)
+	)
+
+	// Require a UUID here so callers cannot enumerate inboxes by numeric ID.
+	if _, err := uuid.Parse(inboxUUID); err != nil {
+		return r.SendErrorEnvelope(fasthttp.StatusNotFound, app.i18n.T("validation.notFoundInbox"), nil, envelope.NotFoundError)
+	}
+
+	record, err := app.inbox.GetDBRecord(inboxUUID)
+	if err != nil || !record.Enabled || record.Channel != email.ChannelEmail {
+		return r.SendErrorEnvelope(fasthttp.StatusNotFound, app.i18n.T("validation.notFoundInbox"), nil, envelope.NotFoundError)
+	}
+
+	inb, err := app.inbox.Get(record.ID)
+	if err != nil {
+		return r.SendErrorEnvelope(fasthttp.StatusNotFound, app.i18n.T("validation.notFoundInbox"), nil, envelope.NotFoundError)
+	}
+	return receiveInboundEmail(r, inb)
+}
+
+// receiveInboundEmail verifies a relayed message for the given inbox and queues it for processing.
+func receiveInboundEmail(r *fastglue.Request, inb inbox.Inbox) error {
+	var (
+		app  = r.Context.(*App)
+		body = r.RequestCtx.PostBody()
+	)
+
+	emailInbox, ok := inb.(*email.Email)
+	if !ok || !emailInbox.InboundEnabled() {
+		return r.SendErrorEnvelope(fasthttp.StatusNotFound, app.i18n.T("validation.notFoundInbox"), nil, envelope.NotFoundError)
+	}
+
+	// Verify the HMAC signature and timestamp of the raw message before parsing anything.
+	var (
+		timestamp = string(r.RequestCtx.Request.Header.Peek(email.InboundTimestampHeader))
+		signature = string(r.RequestCtx.Request.Header.Peek(webhook.SignatureHeader))
+	)
+	if !emailInbox.VerifySignature(body, timestamp, signature) {
+		return r.SendErrorEnvelope(fasthttp.StatusUnauthorized, app.i18n.T("validation.invalidSignature"), nil, envelope.PermissionError)
+	}
+
+	if len(body) == 0 {
+		return r.SendErrorEnvelope(fasthttp.StatusBadRequest, app.i18n.T("validation.messageCannotBeEmpty"), nil, envelope.InputError)
+	}
+
+	if err := emailInbox.ProcessRawMessage(body); err != nil {
+		app.lo.Error("error processing relayed email", "inbox_id", inb.Identifier(), "error", err)
+		return r.SendErrorEnvelope(fasthttp.StatusInternalServerError, app.i18n.T("globals.messages.somethingWentWrong"), nil, envelope.GeneralError)
+	}
+
+	return r.SendEnvelope(true)
+}
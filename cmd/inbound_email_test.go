@@ -0,0 +1,156 @@
+package main
+
+import (
+	"context"
+	"os"
+	"strconv"
+	"testing"
+	"time"
+
+	cmodels "github.com/abhinavxd/libredesk/internal/conversation/models"
+	"github.com/abhinavxd/libredesk/internal/inbox"
+	"github.com/abhinavxd/libredesk/internal/inbox/channel/email"
+	imodels "github.com/abhinavxd/libredesk/internal/inbox/models"
+	umodels "github.com/abhinavxd/libredesk/internal/user/models"
+	"github.com/abhinavxd/libredesk/internal/webhook"
+	"github.com/knadh/go-i18n"
+	"github.com/valyala/fasthttp"
+	"github.com/zerodha/fastglue"
+	"github.com/zerodha/logf"
+)
+
+const testInboundSecret = "relay-secret"
+
+// apiInbox is an inbox of a channel other than email.
+type apiInbox struct{}
+
+func (apiInbox) Close() error                       { return nil }
+func (apiInbox) Identifier() int                    { return 2 }
+func (apiInbox) Receive(context.Context) error      { return nil }
+func (apiInbox) Send(cmodels.OutboundMessage) error { return nil }
+func (apiInbox) FromAddress() string                { return "" }
+func (apiInbox) Channel() string                    { return inbox.ChannelAPI }
+
+// relayStore records messages enqueued by an email inbox.
+type relayStore struct {
+	enqueued []cmodels.IncomingMessage
+}
+
+func (s *relayStore) MessageExists(string) (bool, error) { return false, nil }
+func (s *relayStore) EnqueueIncoming(m cmodels.IncomingMessage) error {
+	s.enqueued = append(s.enqueued, m)
+	return nil
+}
+func (s *relayStore) HandleEmailBounce(cmodels.EmailBounce) (bool, error) { return false, nil }
+func (s *relayStore) GetAgent(int, string) (umodels.User, error)          { return umodels.User{}, nil }
+func (s *relayStore) IsEmailBlocked(string) (bool, error)                 { return false, nil }
+
+func newTestApp(t *testing.T) *App {
+	t.Helper()
+	b, err := os.ReadFile("../i18n/en.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	i, err := i18n.New(b)
+	if err != nil {
+		t.Fatal(err)
+	}
+	lo := logf.New(logf.Opts{})
+	return &App{i18n: i, lo: &lo}
+}
+
+func newTestEmailInbox(t *testing.T, inboundEnabled bool) *email.Email {
+	t.Helper()
+	return newTestEmailInboxWithStore(t, inboundEnabled, &relayStore{})
+}
+
+func newTestEmailInboxWithStore(t *testing.T, inboundEnabled bool, store *relayStore) *email.Email {
+	t.Helper()
+	lo := logf.New(logf.Opts{})
+	e, err := email.New(store, store, email.Opts{
+		ID:     1,
+		Config: imodels.Config{From: "Support <support@example.com>", InboundEnabled: inboundEnabled},
+		Lo:     &lo,
+		Secret: testInboundSecret,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return e
+}
+
+func TestReceiveInboundEmail(t *testing.T) {
+	var (
+		now = strconv.FormatInt(time.Now().Unix(), 10)
+		old = strconv.FormatInt(time.Now().Add(-time.Hour).Unix(), 10)
+		msg = []byte("From: alice@example.com\r\nTo: support@example.com\r\nSubject: Hi\r\nMessage-ID: <1@example.com>\r\n\r\nHello")
+	)
+	sign := func(timestamp string, body []byte) string {
+		return webhook.GenerateSignature(email.InboundSignedPayload(timestamp, body), testInboundSecret)
+	}
+
+	tests := []struct {
+		name       string
+		inbox      inbox.Inbox
+		body       []byte
+		timestamp  string
+		signature  string
+		wantStatus int
+	}{
+		{name: "non email inbox", inbox: apiInbox{}, body: msg, timestamp: now, signature: sign(now, msg), wantStatus: fasthttp.StatusNotFound},
+		{name: "inbound disabled", inbox: newTestEmailInbox(t, false), body: msg, timestamp: now, signature: sign(now, msg), wantStatus: fasthttp.StatusNotFound},
+		{name: "missing signature", inbox: newTestEmailInbox(t, true), body: msg, timestamp: now, wantStatus: fasthttp.StatusUnauthorized},
+		{name: "bad signature", inbox: newTestEmailInbox(t, true), body: msg, timestamp: now, signature: sign(now, []byte("other")), wantStatus: fasthttp.StatusUnauthorized},
+		{name: "wrong secret", inbox: newTestEmailInbox(t, true), body: msg, timestamp: now, signature: webhook.GenerateSignature(email.InboundSignedPayload(now, msg), "other"), wantStatus: fasthttp.StatusUnauthorized},
+		{name: "body only signature", inbox: newTestEmailInbox(t, true), body: msg, timestamp: now, signature: webhook.GenerateSignature(msg, testInboundSecret), wantStatus: fasthttp.StatusUnauthorized},
+		{name: "missing timestamp", inbox: newTestEmailInbox(t, true), body: msg, signature: sign("", msg), wantStatus: fasthttp.StatusUnauthorized},
+		{name: "stale timestamp", inbox: newTestEmailInbox(t, true), body: msg, timestamp: old, signature: sign(old, msg), wantStatus: fasthttp.StatusUnauthorized},
+		{name: "signed timestamp changed", inbox: newTestEmailInbox(t, true), body: msg, timestamp: now, signature: sign(old, msg), wantStatus: fasthttp.StatusUnauthorized},
+		{name: "valid", inbox: newTestEmailInbox(t, true), body: msg, timestamp: now, signature: sign(now, msg), wantStatus: fasthttp.StatusOK},
+		{name: "empty body", inbox: newTestEmailInbox(t, true), timestamp: now, signature: sign(now, nil), wantStatus: fasthttp.StatusBadRequest},
+	}
+
+	app := newTestApp(t)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := &fasthttp.RequestCtx{}
+			ctx.Request.Header.SetMethod(fasthttp.MethodPost)
+			ctx.Request.Header.Set(email.InboundTimestampHeader, tt.timestamp)
+			ctx.Request.Header.Set(webhook.SignatureHeader, tt.signature)
+			ctx.Request.SetBody(tt.body)
+
+			if err := receiveInboundEmail(&fastglue.Request{RequestCtx: ctx, Context: app}, tt.inbox); err != nil {
+				t.Fatalf("receiveInboundEmail() error = %v", err)
+			}
+			if got := ctx.Response.StatusCode(); got != tt.wantStatus {
+				t.Errorf("receiveInboundEmail() status = %d, want %d, body %s", got, tt.wantStatus, ctx.Response.Body())
+			}
+		})
+	}
+}
+
+func TestReceiveInboundEmail_Enqueues(t *testing.T) {
+	var (
+		store = &relayStore{}
+		now   = strconv.FormatInt(time.Now().Unix(), 10)
+		msg   = []byte("From: alice@example.com\r\nTo: support@example.com\r\nSubject: Hi\r\nMessage-ID: <1@example.com>\r\n\r\nHello")
+		ctx   = &fasthttp.RequestCtx{}
+	)
+	ctx.Request.Header.SetMethod(fasthttp.MethodPost)
+	ctx.Request.Header.Set(email.InboundTimestampHeader, now)
+	ctx.Request.Header.Set(webhook.SignatureHeader, webhook.GenerateSignature(email.InboundSignedPayload(now, msg), testInboundSecret))
+	ctx.Request.SetBody(msg)
+
+	if err := receiveInboundEmail(&fastglue.Request{RequestCtx: ctx, Context: newTestApp(t)}, newTestEmailInboxWithStore(t, true, store)); err != nil {
+		t.Fatalf("receiveInboundEmail() error = %v", err)
+	}
+	if got := ctx.Response.StatusCode(); got != fasthttp.StatusOK {
+		t.Fatalf("receiveInboundEmail() status = %d, want %d, body %s", got, fasthttp.StatusOK, ctx.Response.Body())
+	}
+	if len(store.enqueued) != 1 {
+		t.Fatalf("enqueued %d messages, want 1", len(store.enqueued))
+	}
+	if got := store.enqueued[0].SourceID.String; got != "1@example.com" {
+		t.Errorf("enqueued message source ID = %q, want %q", got, "1@example.com")
+	}
+}
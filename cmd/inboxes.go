@@ -4,6 +4,7 @@ import (
 	"encoding/json"
 	"net/mail"
 	"regexp"
+	"slices"
 	"strconv"
 	"strings"
 	"time"
@@ -11,9 +12,11 @@ import (
 	"github.com/abhinavxd/libredesk/internal/envelope"
 	"github.com/abhinavxd/libredesk/internal/httputil"
 	"github.com/abhinavxd/libredesk/internal/inbox"
+	"github.com/abhinavxd/libredesk/internal/inbox/channel/api"
 	"github.com/abhinavxd/libredesk/internal/inbox/channel/email/oauth"
 	"github.com/abhinavxd/libredesk/internal/inbox/channel/livechat"
 	imodels "github.com/abhinavxd/libredesk/internal/inbox/models"
+	"github.com/abhinavxd/libredesk/internal/stringutil"
 	"github.com/valyala/fasthttp"
 	"github.com/zerodha/fastglue"
 )
@@ -326,9 +329,25 @@ func validateInbox(app *App, inbox imodels.Inbox) error {
 		}
 	}
 
+	// Validate API channel config.
+	if inbox.Channel == api.ChannelAPI {
+		var config api.Config
+		if err := json.Unmarshal(inbox.Config, &config); err != nil {
+			return envelope.NewError(envelope.InputError, app.i18n.T("validation.invalidValue"), nil)
+		}
+		if config.CallbackURL != "" && !httputil.IsValidHTTPURL(config.CallbackURL) {
+			return envelope.NewError(envelope.InputError, app.i18n.T("validation.invalidUrl"), nil)
+		}
+		if config.Timeout != "" {
+			if _, err := time.ParseDuration(config.Timeout); err != nil {
+				return envelope.NewError(envelope.InputError, app.i18n.Ts("validation.invalidDuration", "name", "timeout"), nil)
+			}
+		}
+	}
+
 	// Validate email channel config.
 	if inbox.Channel == "email" {
-		if err := validateEmailConfig(app, inbox.Config); err != nil {
+		if err := validateEmailConfig(app, inbox.Config, inbox.Secret.String); err != nil {
 			return err
 		}
 	}
@@ -336,12 +355,21 @@ func validateInbox(app *App, inbox imodels.Inbox) error {
 }
 
 // validateEmailConfig validates the email inbox configuration.
-func validateEmailConfig(app *App, configJSON json.RawMessage) error {
+func validateEmailConfig(app *App, configJSON json.RawMessage, secret string) error {
 	var cfg imodels.Config
 	if err := json.Unmarshal(configJSON, &cfg); err != nil {
 		return envelope.NewError(envelope.InputError, app.i18n.T("globals.messages.somethingWentWrong"), nil)
 	}
 
+	// Relayed messages are signed with the inbox secret.
+	if cfg.InboundEnabled && secret == "" {
+		return envelope.NewError(envelope.InputError, app.i18n.Ts("globals.messages.empty", "name", "secret"), nil)
+	}
+
+	if len(cfg.Signature) > maxEmailSignatureLen {
+		return envelope.NewError(envelope.InputError, app.i18n.Ts("globals.messages.maxLength", "max", strconv.Itoa(maxEmailSignatureLen)), nil)
+	}
+
 	// Validate auth_type.
 	if cfg.AuthType != "" && cfg.AuthType != imodels.AuthTypePassword && cfg.AuthType != imodels.AuthTypeOAuth2 {
 		return envelope.NewError(envelope.InputError, app.i18n.T("globals.messages.somethingWentWrong"), nil)
@@ -385,9 +413,22 @@ func validateEmailConfig(app *App, configJSON json.RawMessage) error {
 		if imap.Port <= 0 {
 			return envelope.NewError(envelope.InputError, app.i18n.T("globals.messages.somethingWentWrong"), nil)
 		}
-		if imap.Mailbox == "" {
+		if len(imap.AllMailboxes()) == 0 {
 			return envelope.NewError(envelope.InputError, app.i18n.Ts("globals.messages.empty", "name", "imap.mailbox"), nil)
 		}
+		// Validate post-processing.
+		switch imap.PostProcessAction() {
+		case imodels.PostProcessNone, imodels.PostProcessSeen, imodels.PostProcessDelete:
+		case imodels.PostProcessMove:
+			if imap.MoveToMailbox == "" {
+				return envelope.NewError(envelope.InputError, app.i18n.Ts("globals.messages.empty", "name", "imap.move_to_mailbox"), nil)
+			}
+			if slices.Contains(imap.AllMailboxes(), imap.MoveToMailbox) {
+				return envelope.NewError(envelope.InputError, app.i18n.T("admin.inbox.moveToMailbox.invalid"), nil)
+			}
+		default:
+			return envelope.NewError(envelope.InputError, app.i18n.T("globals.messages.somethingWentWrong"), nil)
+		}
 		// Validate tls_type.
 		validTLSTypes := map[string]bool{"none": true, "starttls": true, "tls": true}
 		if !validTLSTypes[imap.TLSType] {
@@ -427,6 +468,10 @@ func trimEmailConfig(cfg *imodels.Config) {
 		cfg.IMAP[i].Host = strings.TrimSpace(cfg.IMAP[i].Host)
 		cfg.IMAP[i].Username = strings.TrimSpace(cfg.IMAP[i].Username)
 		cfg.IMAP[i].Mailbox = strings.TrimSpace(cfg.IMAP[i].Mailbox)
+		for j := range cfg.IMAP[i].Mailboxes {
+			cfg.IMAP[i].Mailboxes[j] = strings.TrimSpace(cfg.IMAP[i].Mailboxes[j])
+		}
+		cfg.IMAP[i].MoveToMailbox = strings.TrimSpace(cfg.IMAP[i].MoveToMailbox)
 	}
 
 	// Trim SMTP configs.
@@ -442,4 +487,6 @@ func trimEmailConfig(cfg *imodels.Config) {
 		cfg.OAuth.ClientID = strings.TrimSpace(cfg.OAuth.ClientID)
 		cfg.OAuth.TenantID = strings.TrimSpace(cfg.OAuth.TenantID)
 	}
+
+	cfg.Signature = stringutil.SanitizeHTML(strings.TrimSpace(cfg.Signature))
 }
@@ -27,11 +27,14 @@ import (
 	"github.com/abhinavxd/libredesk/internal/conversation/status"
 	"github.com/abhinavxd/libredesk/internal/csat"
 	customAttribute "github.com/abhinavxd/libredesk/internal/custom_attribute"
+	"github.com/abhinavxd/libredesk/internal/gdpr"
 	"github.com/abhinavxd/libredesk/internal/importer"
 	"github.com/abhinavxd/libredesk/internal/inbox"
+	"github.com/abhinavxd/libredesk/internal/inbox/channel/api"
 	"github.com/abhinavxd/libredesk/internal/inbox/channel/email"
 	"github.com/abhinavxd/libredesk/internal/inbox/channel/livechat"
 	imodels "github.com/abhinavxd/libredesk/internal/inbox/models"
+	"github.com/abhinavxd/libredesk/internal/knowledgebase"
 	"github.com/abhinavxd/libredesk/internal/macro"
 	"github.com/abhinavxd/libredesk/internal/media"
 	fs "github.com/abhinavxd/libredesk/internal/media/stores/localfs"
@@ -41,6 +44,7 @@ import (
 	"github.com/abhinavxd/libredesk/internal/oidc"
 	"github.com/abhinavxd/libredesk/internal/ratelimit"
 	"github.com/abhinavxd/libredesk/internal/report"
+	"github.com/abhinavxd/libredesk/internal/retention"
 	"github.com/abhinavxd/libredesk/internal/role"
 	"github.com/abhinavxd/libredesk/internal/search"
 	"github.com/abhinavxd/libredesk/internal/setting"
@@ -294,7 +298,7 @@ func initConversations(
 	csat *csat.Manager,
 	automationEngine *automation.Engine,
 	template *tmpl.Manager,
-	webhook *webhook.Manager,
+	webhookStore *webhook.Manager,
 	dispatcher *notifier.Dispatcher,
 ) *conversation.Manager {
 	continuityConfig := &conversation.ContinuityConfig{}
@@ -302,12 +306,14 @@ func initConversations(
 		continuityConfig.BatchCheckInterval = ko.MustDuration("conversation.continuity_scan_interval")
 	}
 
-	c, err := conversation.New(hub, i18n, sla, status, priority, inboxStore, userStore, teamStore, mediaStore, settings, csat, automationEngine, template, webhook, dispatcher, conversation.Opts{
+	lo := initLogger("conversation_manager")
+	c, err := conversation.New(hub, i18n, sla, status, priority, inboxStore, userStore, teamStore, mediaStore, settings, csat, automationEngine, template, webhookStore, dispatcher, conversation.Opts{
 		DB:                       db,
-		Lo:                       initLogger("conversation_manager"),
+		Lo:                       lo,
 		OutgoingMessageQueueSize: ko.MustInt("message.outgoing_queue_size"),
 		IncomingMessageQueueSize: ko.MustInt("message.incoming_queue_size"),
 		ContinuityConfig:         continuityConfig,
+		HTTPClient:               webhook.NewHTTPClient(ko.MustDuration("webhook.timeout"), ko.Strings("webhook.allowed_hosts"), lo),
 	})
 	if err != nil {
 		log.Fatalf("error initializing conversation manager: %v", err)
@@ -329,6 +335,20 @@ func initTag(db *sqlx.DB, i18n *i18n.I18n) *tag.Manager {
 	return mgr
 }
 
+// initKnowledgeBase inits knowledge base manager.
+func initKnowledgeBase(db *sqlx.DB, i18n *i18n.I18n) *knowledgebase.Manager {
+	var lo = initLogger("knowledgebase_manager")
+	mgr, err := knowledgebase.New(knowledgebase.Opts{
+		DB:   db,
+		Lo:   lo,
+		I18n: i18n,
+	})
+	if err != nil {
+		log.Fatalf("error initializing knowledge base manager: %v", err)
+	}
+	return mgr
+}
+
 // initViews inits view manager.
 func initView(db *sqlx.DB, i18n *i18n.I18n) *view.Manager {
 	var lo = initLogger("view_manager")
@@ -603,9 +623,11 @@ func initInbox(db *sqlx.DB, i18n *i18n.I18n) *inbox.Manager {
 func initAutomationEngine(db *sqlx.DB, i18n *i18n.I18n) *automation.Engine {
 	var lo = initLogger("automation_engine")
 	engine, err := automation.New(automation.Opts{
-		DB:   db,
-		Lo:   lo,
-		I18n: i18n,
+		DB:                   db,
+		Lo:                   lo,
+		I18n:                 i18n,
+		TimeTriggerLookback:  ko.Duration("automation.time_trigger_lookback"),
+		TimeTriggerBatchSize: ko.Int("automation.time_trigger_batch_size"),
 	})
 	if err != nil {
 		log.Fatalf("error initializing automation engine: %v", err)
@@ -614,12 +636,16 @@ func initAutomationEngine(db *sqlx.DB, i18n *i18n.I18n) *automation.Engine {
 }
 
 // initAutoAssigner initializes the auto assigner.
-func initAutoAssigner(teamManager *team.Manager, userManager *user.Manager, conversationManager *conversation.Manager) *autoassigner.Engine {
+func initAutoAssigner(db *sqlx.DB, i18n *i18n.I18n, teamManager *team.Manager, userManager *user.Manager, conversationManager *conversation.Manager) *autoassigner.Engine {
 	systemUser, err := userManager.GetSystemUser()
 	if err != nil {
 		log.Fatalf("error fetching system user: %v", err)
 	}
-	e, err := autoassigner.New(teamManager, conversationManager, systemUser, initLogger("autoassigner"))
+	e, err := autoassigner.New(teamManager, conversationManager, systemUser, autoassigner.Opts{
+		DB:   db,
+		Lo:   initLogger("autoassigner"),
+		I18n: i18n,
+	})
 	if err != nil {
 		log.Fatalf("error initializing auto assigner: %v", err)
 	}
@@ -665,7 +691,7 @@ func initEmailInbox(inboxRecord imodels.Inbox, msgStore inbox.MessageStore, usrS
 		log.Printf("WARNING: Zero SMTP servers configured for `%s` inbox: Name: `%s`", inboxRecord.Channel, inboxRecord.Name)
 	}
 
-	if len(config.IMAP) == 0 {
+	if len(config.IMAP) == 0 && !config.InboundEnabled {
 		log.Printf("WARNING: Zero IMAP clients configured for `%s` inbox: Name: `%s`", inboxRecord.Channel, inboxRecord.Name)
 	}
 
@@ -699,6 +725,8 @@ func initEmailInbox(inboxRecord imodels.Inbox, msgStore inbox.MessageStore, usrS
 		Config:               config,
 		Lo:                   initLogger("email_inbox"),
 		TokenRefreshCallback: tokenRefreshCallback,
+		CheckpointStore:      mgr,
+		Secret:               inboxRecord.Secret.String,
 	})
 
 	if err != nil {
@@ -739,6 +767,51 @@ func initLiveChatInbox(inboxRecord imodels.Inbox, msgStore inbox.MessageStore, u
 	return inbox, nil
 }
 
+// initAPIInbox initializes the generic API inbox.
+func initAPIInbox(inboxRecord imodels.Inbox, msgStore inbox.MessageStore, usrStore inbox.UserStore) (inbox.Inbox, error) {
+	var (
+		config api.Config
+		k      = koanf.New(".")
+	)
+
+	// Load JSON data into a separate Koanf instance so optional keys don't leak between inboxes.
+	if err := k.Load(rawbytes.Provider([]byte(inboxRecord.Config)), kjson.Parser()); err != nil {
+		return nil, fmt.Errorf("loading config: %w", err)
+	}
+
+	if err := k.UnmarshalWithConf("", &config, koanf.UnmarshalConf{Tag: "json"}); err != nil {
+		return nil, fmt.Errorf("unmarshalling `%s` %s config: %w", inboxRecord.Channel, inboxRecord.Name, err)
+	}
+
+	// Fall back to the webhook timeout if the inbox doesn't set one.
+	timeout := ko.MustDuration("webhook.timeout")
+	if config.Timeout != "" {
+		d, err := time.ParseDuration(config.Timeout)
+		if err != nil {
+			return nil, fmt.Errorf("parsing `%s` inbox timeout: %w", inboxRecord.Name, err)
+		}
+		timeout = d
+	}
+
+	lo := initLogger("api_inbox")
+	inbox, err := api.New(msgStore, usrStore, api.Opts{
+		ID:         inboxRecord.ID,
+		Config:     config,
+		From:       inboxRecord.From,
+		Secret:     inboxRecord.Secret.String,
+		Lo:         lo,
+		HTTPClient: webhook.NewHTTPClient(timeout, ko.Strings("webhook.allowed_hosts"), lo),
+	})
+
+	if err != nil {
+		return nil, fmt.Errorf("initializing `%s` inbox: `%s` error : %w", inboxRecord.Channel, inboxRecord.Name, err)
+	}
+
+	log.Printf("`%s` inbox successfully initialized", inboxRecord.Name)
+
+	return inbox, nil
+}
+
 // makeInboxInitializer creates an inbox initializer function.
 func makeInboxInitializer(mgr *inbox.Manager, signAvatarURL func(*null.String)) func(imodels.Inbox, inbox.MessageStore, inbox.UserStore) (inbox.Inbox, error) {
 	return func(inboxR imodels.Inbox, msgStore inbox.MessageStore, usrStore inbox.UserStore) (inbox.Inbox, error) {
@@ -747,6 +820,8 @@ func makeInboxInitializer(mgr *inbox.Manager, signAvatarURL func(*null.String))
 			return initEmailInbox(inboxR, msgStore, usrStore, mgr)
 		case inbox.ChannelLiveChat:
 			return initLiveChatInbox(inboxR, msgStore, usrStore, signAvatarURL)
+		case inbox.ChannelAPI:
+			return initAPIInbox(inboxR, msgStore, usrStore)
 		default:
 			return nil, fmt.Errorf("unknown inbox channel: %s", inboxR.Channel)
 		}
@@ -982,6 +1057,38 @@ func initSearch(db *sqlx.DB, i18n *i18n.I18n) *search.Manager {
 	return m
 }
 
+// initGDPR inits the GDPR data export and erasure manager.
+func initGDPR(db *sqlx.DB, media *media.Manager, i18n *i18n.I18n) *gdpr.Manager {
+	lo := initLogger("gdpr")
+	m, err := gdpr.New(gdpr.Opts{
+		DB:    db,
+		Media: media,
+		Lo:    lo,
+		I18n:  i18n,
+	})
+	if err != nil {
+		log.Fatalf("error initializing gdpr manager: %v", err)
+	}
+	return m
+}
+
+// initRetention inits the data retention manager.
+func initRetention(db *sqlx.DB, media *media.Manager, user *user.Manager, activityLog *activitylog.Manager, i18n *i18n.I18n) *retention.Manager {
+	lo := initLogger("retention")
+	m, err := retention.New(retention.Opts{
+		DB:          db,
+		Media:       media,
+		User:        user,
+		ActivityLog: activityLog,
+		Lo:          lo,
+		I18n:        i18n,
+	})
+	if err != nil {
+		log.Fatalf("error initializing retention manager: %v", err)
+	}
+	return m
+}
+
 // initCustomAttribute inits custom attribute manager.
 func initCustomAttribute(db *sqlx.DB, i18n *i18n.I18n) *customAttribute.Manager {
 	lo := initLogger("custom-attribute")
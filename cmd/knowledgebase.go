@@ -0,0 +1,263 @@
+package main
+
+import (
+	"html/template"
+	"strconv"
+
+	amodels "github.com/abhinavxd/libredesk/internal/auth/models"
+	"github.com/abhinavxd/libredesk/internal/envelope"
+	"github.com/abhinavxd/libredesk/internal/inbox/channel/livechat"
+	"github.com/abhinavxd/libredesk/internal/knowledgebase"
+	kbmodels "github.com/abhinavxd/libredesk/internal/knowledgebase/models"
+	"github.com/valyala/fasthttp"
+	"github.com/volatiletech/null/v9"
+	"github.com/zerodha/fastglue"
+)
+
+const (
+	// Number of results returned by agent and widget article searches.
+	kbSearchLimit = 10
+)
+
+// handleGetKBCategories returns all knowledge base categories.
+func handleGetKBCategories(r *fastglue.Request) error {
+	var app = r.Context.(*App)
+	out, err := app.kb.GetCategories()
+	if err != nil {
+		return sendErrorEnvelope(r, err)
+	}
+	return r.SendEnvelope(out)
+}
+
+// handleCreateKBCategory creates a knowledge base category.
+func handleCreateKBCategory(r *fastglue.Request) error {
+	var (
+		app = r.Context.(*App)
+		req = kbmodels.Category{}
+	)
+	if err := r.Decode(&req, "json"); err != nil {
+		return r.SendErrorEnvelope(fasthttp.StatusBadRequest, app.i18n.T("errors.parsingRequest"), err.Error(), envelope.InputError)
+	}
+	out, err := app.kb.CreateCategory(req)
+	if err != nil {
+		return sendErrorEnvelope(r, err)
+	}
+	return r.SendEnvelope(out)
+}
+
+// handleUpdateKBCategory updates a knowledge base category.
+func handleUpdateKBCategory(r *fastglue.Request) error {
+	var (
+		app = r.Context.(*App)
+		req = kbmodels.Category{}
+	)
+	id, err := strconv.Atoi(r.RequestCtx.UserValue("id").(string))
+	if err != nil || id <= 0 {
+		return r.SendErrorEnvelope(fasthttp.StatusBadRequest, app.i18n.T("validation.invalidValue"), nil, envelope.InputError)
+	}
+	if err := r.Decode(&req, "json"); err != nil {
+		return r.SendErrorEnvelope(fasthttp.StatusBadRequest, app.i18n.T("errors.parsingRequest"), err.Error(), envelope.InputError)
+	}
+	out, err := app.kb.UpdateCategory(id, req)
+	if err != nil {
+		return sendErrorEnvelope(r, err)
+	}
+	return r.SendEnvelope(out)
+}
+
+// handleDeleteKBCategory deletes a knowledge base category.
+func handleDeleteKBCategory(r *fastglue.Request) error {
+	var app = r.Context.(*App)
+	id, err := strconv.Atoi(r.RequestCtx.UserValue("id").(string))
+	if err != nil || id <= 0 {
+		return r.SendErrorEnvelope(fasthttp.StatusBadRequest, app.i18n.T("validation.invalidValue"), nil, envelope.InputError)
+	}
+	if err := app.kb.DeleteCategory(id); err != nil {
+		return sendErrorEnvelope(r, err)
+	}
+	return r.SendEnvelope(true)
+}
+
+// handleGetKBArticles returns a page of knowledge base articles filtered by category, status and locale.
+func handleGetKBArticles(r *fastglue.Request) error {
+	var (
+		app        = r.Context.(*App)
+		categoryID = r.RequestCtx.QueryArgs().GetUintOrZero("category_id")
+		status     = string(r.RequestCtx.QueryArgs().Peek("status"))
+		locale     = string(r.RequestCtx.QueryArgs().Peek("locale"))
+		total      = 0
+	)
+	page, pageSize := getPagination(r)
+	articles, err := app.kb.GetArticles(categoryID, status, locale, page, pageSize)
+	if err != nil {
+		return sendErrorEnvelope(r, err)
+	}
+	if len(articles) > 0 {
+		total = articles[0].Total
+	}
+	return r.SendEnvelope(envelope.PageResults{
+		Results:    articles,
+		Total:      total,
+		PerPage:    pageSize,
+		TotalPages: (total + pageSize - 1) / pageSize,
+		Page:       page,
+	})
+}
+
+// handleGetKBArticle returns a knowledge base article.
+func handleGetKBArticle(r *fastglue.Request) error {
+	var app = r.Context.(*App)
+	id, err := strconv.Atoi(r.RequestCtx.UserValue("id").(string))
+	if err != nil || id <= 0 {
+		return r.SendErrorEnvelope(fasthttp.StatusBadRequest, app.i18n.T("validation.invalidValue"), nil, envelope.InputError)
+	}
+	out, err := app.kb.GetArticle(id)
+	if err != nil {
+		return sendErrorEnvelope(r, err)
+	}
+	return r.SendEnvelope(out)
+}
+
+// handleCreateKBArticle creates a knowledge base article.
+func handleCreateKBArticle(r *fastglue.Request) error {
+	var (
+		app   = r.Context.(*App)
+		auser = r.RequestCtx.UserValue("user").(amodels.User)
+		req   = kbmodels.Article{}
+	)
+	if err := r.Decode(&req, "json"); err != nil {
+		return r.SendErrorEnvelope(fasthttp.StatusBadRequest, app.i18n.T("errors.parsingRequest"), err.Error(), envelope.InputError)
+	}
+	req.AuthorID = null.IntFrom(auser.ID)
+	out, err := app.kb.CreateArticle(req)
+	if err != nil {
+		return sendErrorEnvelope(r, err)
+	}
+	return r.SendEnvelope(out)
+}
+
+// handleUpdateKBArticle updates a knowledge base article.
+func handleUpdateKBArticle(r *fastglue.Request) error {
+	var (
+		app = r.Context.(*App)
+		req = kbmodels.Article{}
+	)
+	id, err := strconv.Atoi(r.RequestCtx.UserValue("id").(string))
+	if err != nil || id <= 0 {
+		return r.SendErrorEnvelope(fasthttp.StatusBadRequest, app.i18n.T("validation.invalidValue"), nil, envelope.InputError)
+	}
+	if err := r.Decode(&req, "json"); err != nil {
+		return r.SendErrorEnvelope(fasthttp.StatusBadRequest, app.i18n.T("errors.parsingRequest"), err.Error(), envelope.InputError)
+	}
+	out, err := app.kb.UpdateArticle(id, req)
+	if err != nil {
+		return sendErrorEnvelope(r, err)
+	}
+	return r.SendEnvelope(out)
+}
+
+// handleDeleteKBArticle deletes a knowledge base article.
+func handleDeleteKBArticle(r *fastglue.Request) error {
+	var app = r.Context.(*App)
+	id, err := strconv.Atoi(r.RequestCtx.UserValue("id").(string))
+	if err != nil || id <= 0 {
+		return r.SendErrorEnvelope(fasthttp.StatusBadRequest, app.i18n.T("validation.invalidValue"), nil, envelope.InputError)
+	}
+	if err := app.kb.DeleteArticle(id); err != nil {
+		return sendErrorEnvelope(r, err)
+	}
+	return r.SendEnvelope(true)
+}
+
+// handleSearchKBArticles searches published articles for agents to insert links into replies.
+func handleSearchKBArticles(r *fastglue.Request) error {
+	var (
+		app    = r.Context.(*App)
+		query  = string(r.RequestCtx.QueryArgs().Peek("query"))
+		locale = string(r.RequestCtx.QueryArgs().Peek("locale"))
+	)
+	results, err := app.kb.Search(query, locale, true, false, kbSearchLimit)
+	if err != nil {
+		return sendErrorEnvelope(r, err)
+	}
+	setKBArticleURLs(app, results)
+	return r.SendEnvelope(results)
+}
+
+// handleWidgetSearchKBArticles searches published articles from the live chat widget.
+// The search is only available if the inbox has the knowledge base search home app enabled.
+func handleWidgetSearchKBArticles(r *fastglue.Request) error {
+	var (
+		app   = r.Context.(*App)
+		query = string(r.RequestCtx.QueryArgs().Peek("query"))
+	)
+	config, err := getWidgetConfig(r)
+	if err != nil {
+		app.lo.Error("error getting widget config", "error", err)
+		return r.SendErrorEnvelope(fasthttp.StatusInternalServerError, app.i18n.T("globals.messages.somethingWentWrong"), nil, envelope.GeneralError)
+	}
+
+	var (
+		enabled bool
+		locale  string
+	)
+	for _, ha := range config.HomeApps {
+		if ha.Type == livechat.HomeAppKBSearch {
+			enabled, locale = true, ha.Locale
+			break
+		}
+	}
+	if !enabled {
+		return r.SendErrorEnvelope(fasthttp.StatusNotFound, app.i18n.T("globals.messages.notFound"), nil, envelope.NotFoundError)
+	}
+
+	results, err := app.kb.Search(query, locale, true, false, kbSearchLimit)
+	if err != nil {
+		return sendErrorEnvelope(r, err)
+	}
+	setKBArticleURLs(app, results)
+	return r.SendEnvelope(results)
+}
+
+// handleShowKBArticle renders the public page of a published article.
+func handleShowKBArticle(r *fastglue.Request) error {
+	var (
+		app  = r.Context.(*App)
+		uuid = r.RequestCtx.UserValue("uuid").(string)
+	)
+	article, err := app.kb.GetPublishedArticle(uuid)
+	if err != nil {
+		return app.tmpl.RenderWebPage(r.RequestCtx, "error", map[string]interface{}{
+			"Data": map[string]interface{}{
+				"ErrorMessage": app.i18n.T("globals.messages.pageNotFound"),
+			},
+		})
+	}
+
+	// Translations are optional, errors are logged by the manager.
+	translations, _ := app.kb.GetTranslations(article.Slug)
+
+	return app.tmpl.RenderWebPage(r.RequestCtx, "kb-article", map[string]interface{}{
+		"Data": map[string]interface{}{
+			"Title":        article.Title,
+			"Category":     article.CategoryName.String,
+			"Locale":       article.Locale,
+			"UpdatedAt":    article.UpdatedAt,
+			"Translations": translations,
+			// Article content is sanitized when the article is saved.
+			"Content": template.HTML(article.Content),
+		},
+	})
+}
+
+// setKBArticleURLs sets the public URL on article search results.
+func setKBArticleURLs(app *App, results []kbmodels.SearchResult) {
+	rootURL, err := app.setting.GetAppRootURL()
+	if err != nil {
+		app.lo.Error("error fetching app root URL", "error", err)
+		return
+	}
+	for i := range results {
+		results[i].URL = knowledgebase.ArticleURL(rootURL, results[i].UUID)
+	}
+}
@@ -18,6 +18,7 @@ import (
 	"github.com/abhinavxd/libredesk/internal/ai"
 	auth_ "github.com/abhinavxd/libredesk/internal/auth"
 	"github.com/abhinavxd/libredesk/internal/authz"
+	"github.com/abhinavxd/libredesk/internal/autoassigner"
 	businesshours "github.com/abhinavxd/libredesk/internal/business_hours"
 	"github.com/abhinavxd/libredesk/internal/colorlog"
 	"github.com/abhinavxd/libredesk/internal/csat"
@@ -25,6 +26,7 @@ import (
 	"github.com/abhinavxd/libredesk/internal/macro"
 	notifier "github.com/abhinavxd/libredesk/internal/notification"
 	"github.com/abhinavxd/libredesk/internal/report"
+	"github.com/abhinavxd/libredesk/internal/retention"
 	"github.com/abhinavxd/libredesk/internal/search"
 	"github.com/abhinavxd/libredesk/internal/sla"
 	umodels "github.com/abhinavxd/libredesk/internal/user/models"
@@ -36,8 +38,10 @@ import (
 	"github.com/abhinavxd/libredesk/internal/conversation"
 	"github.com/abhinavxd/libredesk/internal/conversation/priority"
 	"github.com/abhinavxd/libredesk/internal/conversation/status"
+	"github.com/abhinavxd/libredesk/internal/gdpr"
 	"github.com/abhinavxd/libredesk/internal/importer"
 	"github.com/abhinavxd/libredesk/internal/inbox"
+	"github.com/abhinavxd/libredesk/internal/knowledgebase"
 	"github.com/abhinavxd/libredesk/internal/media"
 	"github.com/abhinavxd/libredesk/internal/oidc"
 	"github.com/abhinavxd/libredesk/internal/ratelimit"
@@ -95,11 +99,13 @@ type App struct {
 	macro            *macro.Manager
 	conversation     *conversation.Manager
 	automation       *automation.Engine
+	autoAssigner     *autoassigner.Engine
 	businessHours    *businesshours.Manager
 	sla              *sla.Manager
 	csat             *csat.Manager
 	view             *view.Manager
 	ai               *ai.Manager
+	kb               *knowledgebase.Manager
 	search           *search.Manager
 	activityLog      *activitylog.Manager
 	notifier         *notifier.Service
@@ -111,6 +117,8 @@ type App struct {
 	rateLimit        *ratelimit.Limiter
 	redis            *redis.Client
 	importer         *importer.Importer
+	gdpr             *gdpr.Manager
+	retention        *retention.Manager
 
 	// Global state that stores data on an available app update.
 	update *AppUpdate
@@ -198,7 +206,9 @@ func main() {
 		autoAssignInterval          = ko.MustDuration("autoassigner.autoassign_interval")
 		unsnoozeInterval            = ko.MustDuration("conversation.unsnooze_interval")
 		draftRetentionDuration      = cmp.Or(ko.Duration("conversation.draft_retention_duration"), 360*time.Hour)
+		retentionSweepInterval      = cmp.Or(ko.Duration("conversation.retention_sweep_interval"), 24*time.Hour)
 		automationWorkers           = ko.MustInt("automation.worker_count")
+		scheduledActionInterval     = cmp.Or(ko.Duration("automation.scheduled_action_interval"), time.Minute)
 		messageOutgoingQWorkers     = ko.MustDuration("message.outgoing_queue_workers")
 		messageIncomingQWorkers     = ko.MustDuration("message.incoming_queue_workers")
 		messageOutgoingScanInterval = ko.MustDuration(msgOutgoingScanIntervalKey)
@@ -226,17 +236,25 @@ func main() {
 		automation                  = initAutomationEngine(db, i18n)
 		sla                         = initSLA(db, team, settings, businessHours, template, user, i18n, notifDispatcher)
 		conversation                = initConversations(i18n, sla, status, priority, wsHub, db, inbox, user, team, media, settings, csat, automation, template, webhook, notifDispatcher)
-		autoassigner                = initAutoAssigner(team, user, conversation)
+		autoassigner                = initAutoAssigner(db, i18n, team, user, conversation)
 		rateLimiter                 = initRateLimit(rdb)
+		activityLog                 = initActivityLog(db, i18n)
+		retention                   = initRetention(db, media, user, activityLog, i18n)
+		ai                          = initAI(db, i18n)
+		tag                         = initTag(db, i18n)
 	)
 
 	wsHub.SetConversationStore(conversation)
 	automation.SetConversationStore(conversation)
+	automation.SetBusinessHoursStore(sla)
+	conversation.SetAIStore(ai, tag)
+	conversation.SetAutoAssigner(autoassigner)
 
 	// Start inboxes.
 	startInboxes(ctx, inbox, conversation, user, conversation.SignAvatarURL)
 
 	go automation.Run(ctx, automationWorkers)
+	go automation.RunScheduledActions(ctx, scheduledActionInterval)
 	go autoassigner.Run(ctx, autoAssignInterval)
 	go conversation.Run(ctx, messageIncomingQWorkers, messageOutgoingQWorkers, messageOutgoingScanInterval)
 	go conversation.RunUnsnoozer(ctx, unsnoozeInterval)
@@ -247,8 +265,10 @@ func main() {
 	go sla.SendNotifications(ctx)
 	go media.DeleteUnlinkedMedia(ctx)
 	go user.MonitorUserAvailability(ctx, onUsersOffline(conversation))
+	go user.MonitorAgentShifts(ctx, onAgentShiftChange(conversation, autoassigner))
 	go conversation.RunDraftCleaner(ctx, draftRetentionDuration)
 	go userNotification.RunNotificationCleaner(ctx)
+	go retention.Run(ctx, retentionSweepInterval)
 
 	var app = &App{
 		ctx:              ctx,
@@ -271,18 +291,22 @@ func main() {
 		consts:           atomic.Value{},
 		conversation:     conversation,
 		automation:       automation,
+		autoAssigner:     autoassigner,
 		businessHours:    businessHours,
-		activityLog:      initActivityLog(db, i18n),
+		activityLog:      activityLog,
 		customAttribute:  initCustomAttribute(db, i18n),
 		authz:            initAuthz(i18n),
 		view:             initView(db, i18n),
 		report:           initReport(db, i18n),
 		search:           initSearch(db, i18n),
 		role:             initRole(db, i18n),
-		tag:              initTag(db, i18n),
+		tag:              tag,
 		macro:            initMacro(db, i18n),
-		ai:               initAI(db, i18n),
+		ai:               ai,
+		kb:               initKnowledgeBase(db, i18n),
 		importer:         initImporter(i18n),
+		gdpr:             initGDPR(db, media, i18n),
+		retention:        retention,
 		webhook:          webhook,
 		contextLink:      initContextLink(db, i18n),
 		rateLimit:        rateLimiter,
@@ -360,3 +384,16 @@ func onUsersOffline(conv *conversation.Manager) func([]umodels.OfflineUser) {
 		}
 	}
 }
+
+// onAgentShiftChange returns a callback for MonitorAgentShifts that unassigns open conversations at the end of a shift
+// if the agent has reassign enabled, broadcasts the new status and refreshes auto assignment.
+func onAgentShiftChange(conv *conversation.Manager, assigner *autoassigner.Engine) func(umodels.ShiftChange) {
+	return func(c umodels.ShiftChange) {
+		if c.Reassign {
+			// Errors are logged by the conversation manager.
+			conv.UnassignOpen(c.UserID)
+		}
+		conv.BroadcastAgentStatusToWidget(c.UserID, c.Status)
+		assigner.AgentShiftChanged(c.UserID, c.OnDuty)
+	}
+}
@@ -0,0 +1,77 @@
+package main
+
+import (
+	"strconv"
+
+	"github.com/abhinavxd/libredesk/internal/envelope"
+	rmodels "github.com/abhinavxd/libredesk/internal/retention/models"
+	"github.com/valyala/fasthttp"
+	"github.com/zerodha/fastglue"
+)
+
+// handleGetInboxRetentionPolicy returns the retention policy of an inbox.
+func handleGetInboxRetentionPolicy(r *fastglue.Request) error {
+	var (
+		app   = r.Context.(*App)
+		id, _ = strconv.Atoi(r.RequestCtx.UserValue("id").(string))
+	)
+	if _, err := app.inbox.GetDBRecord(id); err != nil {
+		return sendErrorEnvelope(r, err)
+	}
+	policy, err := app.retention.GetPolicy(id)
+	if err != nil {
+		return sendErrorEnvelope(r, err)
+	}
+	return r.SendEnvelope(policy)
+}
+
+// handleUpdateInboxRetentionPolicy creates or updates the retention policy of an inbox.
+func handleUpdateInboxRetentionPolicy(r *fastglue.Request) error {
+	var (
+		app    = r.Context.(*App)
+		id, _  = strconv.Atoi(r.RequestCtx.UserValue("id").(string))
+		policy = rmodels.Policy{}
+	)
+	if err := r.Decode(&policy, "json"); err != nil {
+		return r.SendErrorEnvelope(fasthttp.StatusBadRequest, app.i18n.T("errors.parsingRequest"), nil, envelope.InputError)
+	}
+	if _, err := app.inbox.GetDBRecord(id); err != nil {
+		return sendErrorEnvelope(r, err)
+	}
+	updated, err := app.retention.UpsertPolicy(id, policy)
+	if err != nil {
+		return sendErrorEnvelope(r, err)
+	}
+	return r.SendEnvelope(updated)
+}
+
+// handleInboxRetentionDryRun returns how many records a retention policy would delete or anonymise.
+// The policy in the request body is used if present, the saved policy otherwise.
+func handleInboxRetentionDryRun(r *fastglue.Request) error {
+	var (
+		app   = r.Context.(*App)
+		id, _ = strconv.Atoi(r.RequestCtx.UserValue("id").(string))
+	)
+	if _, err := app.inbox.GetDBRecord(id); err != nil {
+		return sendErrorEnvelope(r, err)
+	}
+
+	var policy rmodels.Policy
+	if len(r.RequestCtx.PostBody()) > 0 {
+		if err := r.Decode(&policy, "json"); err != nil {
+			return r.SendErrorEnvelope(fasthttp.StatusBadRequest, app.i18n.T("errors.parsingRequest"), nil, envelope.InputError)
+		}
+	} else {
+		saved, err := app.retention.GetPolicy(id)
+		if err != nil {
+			return sendErrorEnvelope(r, err)
+		}
+		policy = saved
+	}
+
+	result, err := app.retention.DryRun(id, policy)
+	if err != nil {
+		return sendErrorEnvelope(r, err)
+	}
+	return r.SendEnvelope(result)
+}
@@ -2,8 +2,15 @@ package main
 
 import (
 	"fmt"
+	"strconv"
+	"strings"
+	"time"
 
+	amodels "github.com/abhinavxd/libredesk/internal/auth/models"
 	"github.com/abhinavxd/libredesk/internal/envelope"
+	smodels "github.com/abhinavxd/libredesk/internal/search/models"
+	umodels "github.com/abhinavxd/libredesk/internal/user/models"
+	"github.com/volatiletech/null/v9"
 	"github.com/zerodha/fastglue"
 )
 
@@ -14,19 +21,25 @@ const (
 // handleSearchConversations searches conversations based on the query.
 func handleSearchConversations(r *fastglue.Request) error {
 	app := r.Context.(*App)
-	wrapper := func(query string) (interface{}, error) {
-		return app.search.Conversations(query)
-	}
-	return handleSearch(r, wrapper)
+	return handlePaginatedSearch(r, func(query string, filters smodels.Filters, access smodels.Access, page, pageSize int) (any, int, error) {
+		results, err := app.search.Conversations(query, filters, access, page, pageSize)
+		if err != nil || len(results) == 0 {
+			return results, 0, err
+		}
+		return results, results[0].Total, nil
+	})
 }
 
 // handleSearchMessages searches messages based on the query.
 func handleSearchMessages(r *fastglue.Request) error {
 	app := r.Context.(*App)
-	wrapper := func(query string) (interface{}, error) {
-		return app.search.Messages(query)
-	}
-	return handleSearch(r, wrapper)
+	return handlePaginatedSearch(r, func(query string, filters smodels.Filters, access smodels.Access, page, pageSize int) (any, int, error) {
+		results, err := app.search.Messages(query, filters, access, page, pageSize)
+		if err != nil || len(results) == 0 {
+			return results, 0, err
+		}
+		return results, results[0].Total, nil
+	})
 }
 
 // handleSearchContacts searches contacts based on the query.
@@ -55,3 +68,122 @@ func handleSearch(r *fastglue.Request, searchFunc func(string) (interface{}, err
 	}
 	return r.SendEnvelope(results)
 }
+
+// handlePaginatedSearch parses the query, filters and pagination, restricts results to the
+// conversations the user can read and returns a page of results from the search function.
+func handlePaginatedSearch(r *fastglue.Request, searchFunc func(string, smodels.Filters, smodels.Access, int, int) (any, int, error)) error {
+	var (
+		app   = r.Context.(*App)
+		auser = r.RequestCtx.UserValue("user").(amodels.User)
+		q     = strings.TrimSpace(string(r.RequestCtx.QueryArgs().Peek("query")))
+	)
+
+	if len(q) < minSearchQueryLength {
+		return sendErrorEnvelope(r, envelope.NewError(envelope.InputError, app.i18n.Ts("search.minQueryLength", "length", fmt.Sprintf("%d", minSearchQueryLength)), nil))
+	}
+
+	filters, err := parseSearchFilters(r)
+	if err != nil {
+		return sendErrorEnvelope(r, err)
+	}
+
+	user, err := app.user.GetAgent(auser.ID, "")
+	if err != nil {
+		return sendErrorEnvelope(r, err)
+	}
+	access, err := getSearchAccess(app, user)
+	if err != nil {
+		return sendErrorEnvelope(r, err)
+	}
+
+	page, pageSize := getPagination(r)
+	results, total, err := searchFunc(q, filters, access, page, pageSize)
+	if err != nil {
+		return sendErrorEnvelope(r, err)
+	}
+	return r.SendEnvelope(envelope.PageResults{
+		Results:    results,
+		Total:      total,
+		PerPage:    pageSize,
+		TotalPages: (total + pageSize - 1) / pageSize,
+		Page:       page,
+	})
+}
+
+// parseSearchFilters parses the optional search filters from the query string.
+// `from` and `to` accept a date (2006-01-02) or an RFC3339 timestamp, `to` dates are inclusive.
+func parseSearchFilters(r *fastglue.Request) (smodels.Filters, error) {
+	var (
+		app     = r.Context.(*App)
+		args    = r.RequestCtx.QueryArgs()
+		filters = smodels.Filters{}
+	)
+
+	ints := map[string]*int{
+		"inbox_id":         &filters.InboxID,
+		"status_id":        &filters.StatusID,
+		"assigned_user_id": &filters.AssignedUserID,
+		"assigned_team_id": &filters.AssignedTeamID,
+	}
+	for key, dst := range ints {
+		v := string(args.Peek(key))
+		if v == "" {
+			continue
+		}
+		id, err := strconv.Atoi(v)
+		if err != nil || id < 0 {
+			return filters, envelope.NewError(envelope.InputError, app.i18n.T("validation.invalidValue"), nil)
+		}
+		*dst = id
+	}
+
+	for _, v := range args.PeekMulti("tag_id") {
+		id, err := strconv.Atoi(string(v))
+		if err != nil || id <= 0 {
+			return filters, envelope.NewError(envelope.InputError, app.i18n.T("validation.invalidValue"), nil)
+		}
+		filters.TagIDs = append(filters.TagIDs, id)
+	}
+
+	for key, dst := range map[string]*null.Time{"from": &filters.From, "to": &filters.To} {
+		v := string(args.Peek(key))
+		if v == "" {
+			continue
+		}
+		if t, err := time.Parse(time.RFC3339, v); err == nil {
+			*dst = null.TimeFrom(t)
+			continue
+		}
+		t, err := time.Parse(time.DateOnly, v)
+		if err != nil {
+			return filters, envelope.NewError(envelope.InputError, app.i18n.T("validation.invalidValue"), nil)
+		}
+		if key == "to" {
+			t = t.AddDate(0, 0, 1)
+		}
+		*dst = null.TimeFrom(t)
+	}
+	return filters, nil
+}
+
+// getSearchAccess returns the conversation read permissions of the user for filtering search results.
+func getSearchAccess(app *App, user umodels.User) (smodels.Access, error) {
+	access := smodels.Access{
+		UserID:  user.ID,
+		TeamIDs: user.Teams.IDs(),
+	}
+	for action, dst := range map[string]*bool{
+		"read_all":        &access.ReadAll,
+		"read_assigned":   &access.ReadAssigned,
+		"read_team_all":   &access.ReadTeamAll,
+		"read_team_inbox": &access.ReadTeamInbox,
+		"read_unassigned": &access.ReadUnassigned,
+	} {
+		allowed, err := app.authz.Enforce(user, "conversations", action)
+		if err != nil {
+			return access, envelope.NewError(envelope.GeneralError, app.i18n.T("globals.messages.somethingWentWrong"), nil)
+		}
+		*dst = allowed
+	}
+	return access, nil
+}
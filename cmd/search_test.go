@@ -0,0 +1,129 @@
+package main
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/abhinavxd/libredesk/internal/authz"
+	authzModels "github.com/abhinavxd/libredesk/internal/authz/models"
+	"github.com/abhinavxd/libredesk/internal/envelope"
+	smodels "github.com/abhinavxd/libredesk/internal/search/models"
+	tmodels "github.com/abhinavxd/libredesk/internal/team/models"
+	umodels "github.com/abhinavxd/libredesk/internal/user/models"
+	"github.com/valyala/fasthttp"
+	"github.com/volatiletech/null/v9"
+	"github.com/zerodha/fastglue"
+)
+
+func TestParseSearchFilters(t *testing.T) {
+	tests := []struct {
+		name    string
+		query   string
+		want    smodels.Filters
+		wantErr bool
+	}{
+		{name: "none", query: "query=refund", want: smodels.Filters{}},
+		{
+			name:  "ids and tags",
+			query: "inbox_id=1&status_id=2&assigned_user_id=3&assigned_team_id=4&tag_id=5&tag_id=6",
+			want:  smodels.Filters{InboxID: 1, StatusID: 2, AssignedUserID: 3, AssignedTeamID: 4, TagIDs: []int{5, 6}},
+		},
+		{
+			// A `to` date includes the whole day.
+			name:  "dates",
+			query: "from=2026-01-01&to=2026-01-31",
+			want: smodels.Filters{
+				From: null.TimeFrom(time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)),
+				To:   null.TimeFrom(time.Date(2026, 2, 1, 0, 0, 0, 0, time.UTC)),
+			},
+		},
+		{
+			name:  "timestamps",
+			query: "from=2026-01-01T10:00:00Z&to=2026-01-31T18:00:00Z",
+			want: smodels.Filters{
+				From: null.TimeFrom(time.Date(2026, 1, 1, 10, 0, 0, 0, time.UTC)),
+				To:   null.TimeFrom(time.Date(2026, 1, 31, 18, 0, 0, 0, time.UTC)),
+			},
+		},
+		{name: "invalid id", query: "inbox_id=abc", wantErr: true},
+		{name: "negative id", query: "status_id=-1", wantErr: true},
+		{name: "invalid tag", query: "tag_id=0", wantErr: true},
+		{name: "invalid date", query: "from=01/01/2026", wantErr: true},
+	}
+
+	app := newTestApp(t)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := &fasthttp.RequestCtx{}
+			ctx.Request.SetRequestURI("/api/v1/search/conversations?" + tt.query)
+
+			got, err := parseSearchFilters(&fastglue.Request{RequestCtx: ctx, Context: app})
+			if tt.wantErr {
+				var envErr envelope.Error
+				if !errors.As(err, &envErr) || envErr.ErrorType != envelope.InputError {
+					t.Fatalf("parseSearchFilters() error = %v, want an input error", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("parseSearchFilters() error = %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("parseSearchFilters() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestGetSearchAccess(t *testing.T) {
+	app := newTestApp(t)
+	enforcer, err := authz.NewEnforcer(app.lo, app.i18n)
+	if err != nil {
+		t.Fatal(err)
+	}
+	app.authz = enforcer
+
+	tests := []struct {
+		name string
+		user umodels.User
+		want smodels.Access
+	}{
+		{
+			name: "no read permissions",
+			user: umodels.User{ID: 1},
+			want: smodels.Access{UserID: 1, TeamIDs: []int{}},
+		},
+		{
+			name: "all",
+			user: umodels.User{ID: 2, Permissions: []string{authzModels.PermConversationsReadAll}},
+			want: smodels.Access{UserID: 2, TeamIDs: []int{}, ReadAll: true},
+		},
+		{
+			name: "assigned and team inbox",
+			user: umodels.User{
+				ID:          3,
+				Teams:       tmodels.TeamsCompact{{ID: 4}, {ID: 5}},
+				Permissions: []string{authzModels.PermConversationsReadAssigned, authzModels.PermConversationsReadTeamInbox},
+			},
+			want: smodels.Access{UserID: 3, TeamIDs: []int{4, 5}, ReadAssigned: true, ReadTeamInbox: true},
+		},
+		{
+			name: "team and unassigned",
+			user: umodels.User{ID: 4, Permissions: []string{authzModels.PermConversationsReadTeamAll, authzModels.PermConversationsReadUnassigned}},
+			want: smodels.Access{UserID: 4, TeamIDs: []int{}, ReadTeamAll: true, ReadUnassigned: true},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := getSearchAccess(app, tt.user)
+			if err != nil {
+				t.Fatalf("getSearchAccess() error = %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("getSearchAccess() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
@@ -41,6 +41,7 @@ var migList = []migFunc{
 	{"v0.10.0", migrations.V0_10_0},
 	{"v1.0.1", migrations.V1_0_1},
 	{"v2.0.0", migrations.V2_0_0},
+	{"v2.1.0", migrations.V2_1_0},
 }
 
 // upgrade upgrades the database to the current version by running SQL migration files
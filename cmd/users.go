@@ -1,16 +1,20 @@
 package main
 
 import (
+	"encoding/json"
 	"fmt"
 	"mime/multipart"
 	"path/filepath"
 	"slices"
 	"strconv"
 	"strings"
+	"time"
 
 	amodels "github.com/abhinavxd/libredesk/internal/auth/models"
+	bhmodels "github.com/abhinavxd/libredesk/internal/business_hours/models"
 	"github.com/abhinavxd/libredesk/internal/envelope"
 	"github.com/abhinavxd/libredesk/internal/image"
+	"github.com/abhinavxd/libredesk/internal/inbox"
 	mmodels "github.com/abhinavxd/libredesk/internal/media/models"
 	notifier "github.com/abhinavxd/libredesk/internal/notification"
 	"github.com/abhinavxd/libredesk/internal/stringutil"
@@ -24,6 +28,10 @@ import (
 
 const (
 	maxAvatarSizeMB = 2
+	// maxChannelWeight is the highest load a conversation of a channel can add to an agent.
+	maxChannelWeight = 100
+	// maxEmailSignatureLen is the longest email signature, in bytes of HTML.
+	maxEmailSignatureLen = 10000
 )
 
 type resetPasswordRequest struct {
@@ -39,16 +47,26 @@ type availabilityRequest struct {
 	Status string `json:"status"`
 }
 
+type emailSignatureRequest struct {
+	Signature string `json:"signature"`
+}
+
 type agentReq struct {
-	FirstName          string   `json:"first_name"`
-	LastName           string   `json:"last_name"`
-	Email              string   `json:"email"`
-	SendWelcomeEmail   bool     `json:"send_welcome_email"`
-	Teams              []string `json:"teams"`
-	Roles              []string `json:"roles"`
-	Enabled            bool     `json:"enabled"`
-	AvailabilityStatus string   `json:"availability_status"`
-	NewPassword        string   `json:"new_password,omitempty"`
+	FirstName          string                `json:"first_name"`
+	LastName           string                `json:"last_name"`
+	Email              string                `json:"email"`
+	SendWelcomeEmail   bool                  `json:"send_welcome_email"`
+	Teams              []string              `json:"teams"`
+	Skills             *[]string             `json:"skills"`
+	Capacity           null.Int              `json:"capacity"`
+	ChannelWeights     models.ChannelWeights `json:"channel_weights"`
+	ShiftHours         json.RawMessage       `json:"shift_hours"`
+	ShiftTimezone      null.String           `json:"shift_timezone"`
+	ShiftReassign      *bool                 `json:"shift_reassign"`
+	Roles              []string              `json:"roles"`
+	Enabled            bool                  `json:"enabled"`
+	AvailabilityStatus string                `json:"availability_status"`
+	NewPassword        string                `json:"new_password,omitempty"`
 }
 
 // handleGetAgents returns all agents.
@@ -71,6 +89,16 @@ func handleGetAgentsCompact(r *fastglue.Request) error {
 	return r.SendEnvelope(agents)
 }
 
+// handleGetAgentsLoad returns the current load and capacity of agents.
+func handleGetAgentsLoad(r *fastglue.Request) error {
+	var app = r.Context.(*App)
+	loads, err := app.user.GetAgentsLoad()
+	if err != nil {
+		return sendErrorEnvelope(r, err)
+	}
+	return r.SendEnvelope(loads)
+}
+
 // handleGetAgent returns an agent.
 func handleGetAgent(r *fastglue.Request) error {
 	var app = r.Context.(*App)
@@ -118,6 +146,9 @@ func handleUpdateAgentAvailability(r *fastglue.Request) error {
 	// Notify widget clients about the agent's availability change.
 	go app.conversation.BroadcastAgentStatusToWidget(auser.ID, availReq.Status)
 
+	// Refresh auto assignment with the agent's availability.
+	app.autoAssigner.AgentAvailabilityChanged(auser.ID, availReq.Status)
+
 	// Skip activity log if agent returns online from away (to avoid spam).
 	if !(agent.AvailabilityStatus == models.Away && availReq.Status == models.Online) {
 		if err := app.activityLog.UserAvailability(auser.ID, auser.Email, availReq.Status, ip, "", 0); err != nil {
@@ -181,6 +212,32 @@ func handleUpdateCurrentAgent(r *fastglue.Request) error {
 	return r.SendEnvelope(agent)
 }
 
+// handleUpdateCurrentAgentEmailSignature updates the email signature of the current agent.
+func handleUpdateCurrentAgentEmailSignature(r *fastglue.Request) error {
+	var (
+		app   = r.Context.(*App)
+		auser = r.RequestCtx.UserValue("user").(amodels.User)
+		req   emailSignatureRequest
+	)
+	if err := r.Decode(&req, "json"); err != nil {
+		return r.SendErrorEnvelope(fasthttp.StatusBadRequest, app.i18n.T("errors.parsingRequest"), nil, envelope.InputError)
+	}
+	if len(req.Signature) > maxEmailSignatureLen {
+		return r.SendErrorEnvelope(fasthttp.StatusBadRequest, app.i18n.Ts("globals.messages.maxLength", "max", strconv.Itoa(maxEmailSignatureLen)), nil, envelope.InputError)
+	}
+
+	// The signature is HTML included in outgoing emails, keep only safe markup.
+	if err := app.user.UpdateAgentEmailSignature(auser.ID, stringutil.SanitizeHTML(req.Signature)); err != nil {
+		return sendErrorEnvelope(r, err)
+	}
+
+	agent, err := app.user.GetAgent(auser.ID, "")
+	if err != nil {
+		return sendErrorEnvelope(r, err)
+	}
+	return r.SendEnvelope(agent)
+}
+
 // handleCreateAgent creates a new agent.
 func handleCreateAgent(r *fastglue.Request) error {
 	var (
@@ -207,6 +264,27 @@ func handleCreateAgent(r *fastglue.Request) error {
 		app.team.UpsertUserTeams(agent.ID, req.Teams)
 	}
 
+	// Set agent skills.
+	if req.Skills != nil && len(*req.Skills) > 0 {
+		if err := app.user.UpdateAgentSkills(agent.ID, *req.Skills); err != nil {
+			return sendErrorEnvelope(r, err)
+		}
+	}
+
+	// Set agent capacity.
+	if req.Capacity.Valid || len(req.ChannelWeights) > 0 {
+		if err := app.user.UpdateAgentCapacity(agent.ID, req.Capacity, req.ChannelWeights); err != nil {
+			return sendErrorEnvelope(r, err)
+		}
+	}
+
+	// Set agent shift.
+	if hasShift(req.ShiftHours) {
+		if err := app.user.UpdateAgentShift(agent.ID, req.ShiftHours, req.ShiftTimezone, req.ShiftReassign != nil && *req.ShiftReassign); err != nil {
+			return sendErrorEnvelope(r, err)
+		}
+	}
+
 	if req.SendWelcomeEmail {
 		// Generate reset token.
 		resetToken, err := app.user.SetResetPasswordToken(agent.ID)
@@ -278,8 +356,9 @@ func handleUpdateAgent(r *fastglue.Request) error {
 	// Invalidate authz cache.
 	defer app.authz.InvalidateUserCache(id)
 
-	// Create activity log if user availability status changed.
+	// Create activity log and refresh auto assignment if user availability status changed.
 	if oldAvailabilityStatus != req.AvailabilityStatus {
+		app.autoAssigner.AgentAvailabilityChanged(id, req.AvailabilityStatus)
 		if err := app.activityLog.UserAvailability(auser.ID, auser.Email, req.AvailabilityStatus, ip, req.Email, id); err != nil {
 			app.lo.Error("error creating activity log", "error", err)
 		}
@@ -297,6 +376,38 @@ func handleUpdateAgent(r *fastglue.Request) error {
 		return sendErrorEnvelope(r, err)
 	}
 
+	// Update agent skills if sent, so that callers unaware of skills don't clear them.
+	if req.Skills != nil {
+		if err := app.user.UpdateAgentSkills(id, *req.Skills); err != nil {
+			return sendErrorEnvelope(r, err)
+		}
+	}
+
+	// Update agent capacity if sent, fields that are left out keep their current value.
+	if req.Capacity.IsSet() || req.ChannelWeights != nil {
+		capacity, weights := agent.Capacity, agent.ChannelWeights
+		if req.Capacity.IsSet() {
+			capacity = req.Capacity
+		}
+		if req.ChannelWeights != nil {
+			weights = req.ChannelWeights
+		}
+		if err := app.user.UpdateAgentCapacity(id, capacity, weights); err != nil {
+			return sendErrorEnvelope(r, err)
+		}
+	}
+
+	// Update agent shift if the shift hours are sent, null hours remove the shift.
+	if req.ShiftHours != nil {
+		reassign := agent.ShiftReassign
+		if req.ShiftReassign != nil {
+			reassign = *req.ShiftReassign
+		}
+		if err := app.user.UpdateAgentShift(id, req.ShiftHours, req.ShiftTimezone, reassign); err != nil {
+			return sendErrorEnvelope(r, err)
+		}
+	}
+
 	// Refetch agent and return.
 	agent, err = app.user.GetAgent(id, "")
 	if err != nil {
@@ -605,5 +716,59 @@ func validateAgentRequest(r *fastglue.Request, req *agentReq) error {
 		return r.SendErrorEnvelope(fasthttp.StatusBadRequest, app.i18n.Ts("globals.messages.empty", "name", "`first_name`"), nil, envelope.InputError)
 	}
 
+	if req.Capacity.Valid && req.Capacity.Int < 1 {
+		return r.SendErrorEnvelope(fasthttp.StatusBadRequest, app.i18n.T("user.invalidCapacity"), nil, envelope.InputError)
+	}
+
+	for channel, weight := range req.ChannelWeights {
+		if !slices.Contains([]string{inbox.ChannelEmail, inbox.ChannelLiveChat, inbox.ChannelAPI}, channel) || weight <= 0 || weight > maxChannelWeight {
+			return r.SendErrorEnvelope(fasthttp.StatusBadRequest, app.i18n.Ts("user.invalidChannelWeight", "channel", channel, "max", strconv.Itoa(maxChannelWeight)), nil, envelope.InputError)
+		}
+	}
+
+	if hasShift(req.ShiftHours) {
+		if _, err := time.LoadLocation(req.ShiftTimezone.String); err != nil || req.ShiftTimezone.String == "" {
+			return r.SendErrorEnvelope(fasthttp.StatusBadRequest, app.i18n.T("user.invalidShiftTimezone"), nil, envelope.InputError)
+		}
+		if day, err := validateShiftHours(req.ShiftHours); err != nil {
+			return r.SendErrorEnvelope(fasthttp.StatusBadRequest, app.i18n.Ts("user.invalidShiftHours", "day", day), nil, envelope.InputError)
+		}
+	}
+
 	return nil
 }
+
+// hasShift reports whether the shift hours of an agent request are set.
+func hasShift(hours json.RawMessage) bool {
+	return len(hours) > 0 && string(hours) != "null"
+}
+
+// validateShiftHours checks that shift hours are in the business hours format with different open and close times,
+// returning the offending day on error. A shift that closes before it opens ends the next day.
+func validateShiftHours(hours json.RawMessage) (string, error) {
+	var days map[string]bhmodels.WorkingHours
+	if err := json.Unmarshal(hours, &days); err != nil {
+		return "", err
+	}
+	var weekdays []string
+	for d := time.Sunday; d <= time.Saturday; d++ {
+		weekdays = append(weekdays, d.String())
+	}
+	for day, h := range days {
+		if !slices.Contains(weekdays, day) {
+			return day, fmt.Errorf("invalid day %s", day)
+		}
+		open, err := time.Parse("15:04", h.Open)
+		if err != nil {
+			return day, err
+		}
+		closeAt, err := time.Parse("15:04", h.Close)
+		if err != nil {
+			return day, err
+		}
+		if open.Equal(closeAt) {
+			return day, fmt.Errorf("open time %s is the same as close time %s", h.Open, h.Close)
+		}
+	}
+	return "", nil
+}
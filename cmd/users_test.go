@@ -0,0 +1,34 @@
+package main
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestValidateShiftHours(t *testing.T) {
+	tests := []struct {
+		name    string
+		hours   string
+		wantDay string
+		wantErr bool
+	}{
+		{name: "valid", hours: `{"Monday": {"open": "09:00", "close": "17:00"}, "Sunday": {"open": "00:00", "close": "23:59"}}`},
+		{name: "empty", hours: `{}`},
+		{name: "unknown day", hours: `{"Mon": {"open": "09:00", "close": "17:00"}}`, wantDay: "Mon", wantErr: true},
+		{name: "invalid time", hours: `{"Friday": {"open": "9am", "close": "17:00"}}`, wantDay: "Friday", wantErr: true},
+		{name: "overnight", hours: `{"Tuesday": {"open": "22:00", "close": "06:00"}}`},
+		{name: "open same as close", hours: `{"Tuesday": {"open": "09:00", "close": "09:00"}}`, wantDay: "Tuesday", wantErr: true},
+		{name: "not an object", hours: `[]`, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			day, err := validateShiftHours(json.RawMessage(tt.hours))
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("validateShiftHours() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if day != tt.wantDay {
+				t.Errorf("validateShiftHours() day = %q, want %q", day, tt.wantDay)
+			}
+		})
+	}
+}
@@ -361,3 +361,37 @@ func getPageVisitsFromRedis(app *App, contactID int) []map[string]string {
 	}
 	return pages
 }
+
+// mergePageVisits moves the recent page visits of the source contact to the target contact.
+func mergePageVisits(app *App, sourceID, targetID int) {
+	redisCtx := context.Background()
+	sourceKey := fmt.Sprintf("%s%d", pageVisitRedisKeyPrefix, sourceID)
+	targetKey := fmt.Sprintf("%s%d", pageVisitRedisKeyPrefix, targetID)
+
+	entries, err := app.redis.LRange(redisCtx, sourceKey, 0, maxPageVisits-1).Result()
+	if err != nil || len(entries) == 0 {
+		return
+	}
+
+	// Source entries are newest first, append them after the target's own visits.
+	values := make([]any, len(entries))
+	for i, e := range entries {
+		values[i] = e
+	}
+	pipe := app.redis.Pipeline()
+	pipe.RPush(redisCtx, targetKey, values...)
+	pipe.LTrim(redisCtx, targetKey, 0, maxPageVisits-1)
+	pipe.Expire(redisCtx, targetKey, pageVisitTTL)
+	pipe.Del(redisCtx, sourceKey)
+	if _, err := pipe.Exec(redisCtx); err != nil {
+		app.lo.Error("error merging contact page visits", "source_id", sourceID, "target_id", targetID, "error", err)
+	}
+}
+
+// deletePageVisits deletes the recent page visits of a contact.
+func deletePageVisits(app *App, contactID int) {
+	key := fmt.Sprintf("%s%d", pageVisitRedisKeyPrefix, contactID)
+	if err := app.redis.Del(context.Background(), key).Err(); err != nil {
+		app.lo.Error("error deleting contact page visits", "contact_id", contactID, "error", err)
+	}
+}
@@ -175,6 +175,25 @@ const updateAutomationRulesExecutionMode = (data) =>
       'Content-Type': 'application/json'
     }
   })
+const testAutomationRule = (id, data) =>
+  http.post(`/api/v1/automations/rules/${id}/test`, data, {
+    headers: {
+      'Content-Type': 'application/json'
+    }
+  })
+const getAutomationRuleExecutions = (params) =>
+  http.get('/api/v1/automations/executions', { params })
+const getAutomationRuleVersions = (id) => http.get(`/api/v1/automations/rules/${id}/versions`)
+const getAutomationRuleVersionDiff = (id, version, params) =>
+  http.get(`/api/v1/automations/rules/${id}/versions/${version}/diff`, { params })
+const rollbackAutomationRule = (id, version) =>
+  http.post(`/api/v1/automations/rules/${id}/versions/${version}/rollback`)
+const importAutomationRules = (data) =>
+  http.post('/api/v1/automations/rules/import', data, {
+    headers: {
+      'Content-Type': 'application/json'
+    }
+  })
 const getRoles = () => http.get('/api/v1/roles')
 const getRole = (id) => http.get(`/api/v1/roles/${id}`)
 const createRole = (data) =>
@@ -224,6 +243,7 @@ const updateUser = (id, data) =>
     }
   })
 const getUsers = () => http.get('/api/v1/agents')
+const getAgentsLoad = () => http.get('/api/v1/agents/load')
 const getUsersCompact = () => http.get('/api/v1/agents/compact')
 const updateCurrentUser = (data) =>
   http.put('/api/v1/agents/me', data, {
@@ -240,6 +260,11 @@ const updateCurrentUserAvailability = (data) => http.put('/api/v1/agents/me/avai
     'Content-Type': 'application/json'
   }
 })
+const updateCurrentUserEmailSignature = (data) => http.put('/api/v1/agents/me/email-signature', data, {
+  headers: {
+    'Content-Type': 'application/json'
+  }
+})
 const resetPassword = (data) => http.post('/api/v1/agents/reset-password', data, {
   headers: {
     'Content-Type': 'application/json'
@@ -331,6 +356,8 @@ const sendMessage = (uuid, data) =>
   })
 const getConversation = (uuid) => http.get(`/api/v1/conversations/${uuid}`)
 const getConversationParticipants = (uuid) => http.get(`/api/v1/conversations/${uuid}/participants`)
+const getConversationAssignmentDecisions = (uuid) =>
+  http.get(`/api/v1/conversations/${uuid}/assignment-decisions`)
 const getContactPageVisits = (uuid) => http.get(`/api/v1/conversations/${uuid}/page-visits`)
 const getAllMacros = () => http.get('/api/v1/macros')
 const getMacro = (id) => http.get(`/api/v1/macros/${id}`)
@@ -446,6 +473,14 @@ const updateAIProvider = (data) => http.put('/api/v1/ai/provider', data, {
     'Content-Type': 'application/json'
   }
 })
+const aiSuggestReply = (uuid) => http.post(`/api/v1/conversations/${uuid}/ai/suggest-reply`)
+const aiSummarizeConversation = (uuid) => http.post(`/api/v1/conversations/${uuid}/ai/summarize`)
+const getAIProviders = () => http.get('/api/v1/ai/providers')
+const testAIProvider = (data) => http.post('/api/v1/ai/provider/test', data, {
+  headers: {
+    'Content-Type': 'application/json'
+  }
+})
 const getContactNotes = (id) => http.get(`/api/v1/contacts/${id}/notes`)
 const createContactNote = (id, data) => http.post(`/api/v1/contacts/${id}/notes`, data, {
   headers: {
@@ -488,6 +523,29 @@ const getActiveContextLinks = () => http.get('/api/v1/context-links/active')
 const getContextLinkURL = (id, conversationUUID) =>
   http.get(`/api/v1/context-links/${id}/url`, { params: { conversation_uuid: conversationUUID } })
 
+const getKBCategories = () => http.get('/api/v1/kb/categories')
+const createKBCategory = (data) =>
+  http.post('/api/v1/kb/categories', data, {
+    headers: { 'Content-Type': 'application/json' }
+  })
+const updateKBCategory = (id, data) =>
+  http.put(`/api/v1/kb/categories/${id}`, data, {
+    headers: { 'Content-Type': 'application/json' }
+  })
+const deleteKBCategory = (id) => http.delete(`/api/v1/kb/categories/${id}`)
+const getKBArticles = (params) => http.get('/api/v1/kb/articles', { params })
+const getKBArticle = (id) => http.get(`/api/v1/kb/articles/${id}`)
+const createKBArticle = (data) =>
+  http.post('/api/v1/kb/articles', data, {
+    headers: { 'Content-Type': 'application/json' }
+  })
+const updateKBArticle = (id, data) =>
+  http.put(`/api/v1/kb/articles/${id}`, data, {
+    headers: { 'Content-Type': 'application/json' }
+  })
+const deleteKBArticle = (id) => http.delete(`/api/v1/kb/articles/${id}`)
+const searchKBArticles = (params) => http.get('/api/v1/kb/articles/search', { params })
+
 const generateAPIKey = (id) => 
   http.post(`/api/v1/agents/${id}/api-key`, {}, {
     headers: {
@@ -562,6 +620,8 @@ export default {
   getOverviewMessageVolume,
   getOverviewTagDistribution,
   getConversationParticipants,
+  getConversationAssignmentDecisions,
+  getAgentsLoad,
   getConversationMessage,
   getConversationMessages,
   getCurrentUser,
@@ -584,10 +644,21 @@ export default {
   markConversationAsUnread,
   updateUser,
   updateCurrentUserAvailability,
+  updateCurrentUserEmailSignature,
   updateAutomationRule,
   updateAutomationRuleWeights,
   updateAutomationRulesExecutionMode,
+  testAutomationRule,
+  getAutomationRuleExecutions,
+  getAutomationRuleVersions,
+  getAutomationRuleVersionDiff,
+  rollbackAutomationRule,
+  importAutomationRules,
   updateAIProvider,
+  aiSuggestReply,
+  aiSummarizeConversation,
+  getAIProviders,
+  testAIProvider,
   createAutomationRule,
   toggleAutomationRule,
   deleteAutomationRule,
@@ -667,6 +738,16 @@ export default {
   toggleWebhook,
   testWebhook,
   getContextLinks,
+  getKBCategories,
+  createKBCategory,
+  updateKBCategory,
+  deleteKBCategory,
+  getKBArticles,
+  getKBArticle,
+  createKBArticle,
+  updateKBArticle,
+  deleteKBArticle,
+  searchKBArticles,
   getContextLink,
   createContextLink,
   updateContextLink,
@@ -6,7 +6,7 @@ import { useTeamStore } from '@/stores/team'
 import { useSlaStore } from '@/stores/sla'
 import { useCustomAttributeStore } from '@/stores/customAttributes'
 import { useTagStore } from '@/stores/tag'
-import { FIELD_TYPE, FIELD_OPERATORS } from '@/constants/filterConfig'
+import { FIELD_TYPE, FIELD_OPERATORS, AUTOMATION_FIELD_OPERATORS } from '@/constants/filterConfig'
 import { useI18n } from 'vue-i18n'
 
 export function useConversationFilters () {
@@ -29,12 +29,12 @@ export function useConversationFilters () {
     }
 
     const customAttributeDataTypeToFieldOperators = {
-        'text': FIELD_OPERATORS.TEXT,
-        'number': FIELD_OPERATORS.NUMBER,
-        'checkbox': FIELD_OPERATORS.BOOLEAN,
-        'date': FIELD_OPERATORS.DATE,
-        'link': FIELD_OPERATORS.TEXT,
-        'list': FIELD_OPERATORS.SELECT,
+        'text': AUTOMATION_FIELD_OPERATORS.TEXT,
+        'number': AUTOMATION_FIELD_OPERATORS.NUMBER,
+        'checkbox': AUTOMATION_FIELD_OPERATORS.BOOLEAN,
+        'date': AUTOMATION_FIELD_OPERATORS.DATE,
+        'link': AUTOMATION_FIELD_OPERATORS.TEXT,
+        'list': AUTOMATION_FIELD_OPERATORS.SELECT,
     }
 
     const conversationsListFilters = computed(() => ({
@@ -76,122 +76,193 @@ export function useConversationFilters () {
         }
     }))
 
+    // Converts custom attributes to rule fields keyed by attribute key.
+    const customAttributesToFields = (attributes) => {
+        return attributes.reduce((acc, attribute) => {
+            acc[attribute.key] = {
+                label: attribute.label,
+                type: customAttributeDataTypeToFieldType[attribute.data_type] || FIELD_TYPE.TEXT,
+                operators: customAttributeDataTypeToFieldOperators[attribute.data_type] || AUTOMATION_FIELD_OPERATORS.TEXT,
+                options: attribute.values.map(value => ({
+                    label: value,
+                    value: value
+                })) || [],
+            }
+            return acc
+        }, {})
+    }
+
     const contactCustomAttributes = computed(() => {
-        return customAttributeStore.contactAttributeOptions
-            .filter(attribute => attribute.applies_to === 'contact')
-            .reduce((acc, attribute) => {
-                acc[attribute.key] = {
-                    label: attribute.label,
-                    type: customAttributeDataTypeToFieldType[attribute.data_type] || FIELD_TYPE.TEXT,
-                    operators: customAttributeDataTypeToFieldOperators[attribute.data_type] || FIELD_OPERATORS.TEXT,
-                    options: attribute.values.map(value => ({
-                        label: value,
-                        value: value
-                    })) || [],
-                }
-                return acc
-            }, {})
+        return customAttributesToFields(customAttributeStore.contactAttributeOptions
+            .filter(attribute => attribute.applies_to === 'contact'))
+    })
+
+    const conversationCustomAttributes = computed(() => {
+        return customAttributesToFields(customAttributeStore.conversationAttributeOptions
+            .filter(attribute => attribute.applies_to === 'conversation'))
     })
 
+    const channelOptions = computed(() => [
+        { label: t('globals.terms.email'), value: 'email' },
+        { label: t('globals.terms.liveChat'), value: 'livechat' },
+        { label: 'API', value: 'api' }
+    ])
+
+    const slaStatusOptions = computed(() => [
+        { label: t('automation.slaStatus.pending'), value: 'pending' },
+        { label: t('automation.slaStatus.breached'), value: 'breached' },
+        { label: t('automation.slaStatus.met'), value: 'met' },
+        { label: t('automation.slaStatus.partiallyMet'), value: 'partially_met' }
+    ])
+
+    // Rule fields available to all automation rule types.
+    const commonRuleFields = computed(() => ({
+        contact_name: {
+            label: t('automation.field.contactName'),
+            type: FIELD_TYPE.TEXT,
+            operators: AUTOMATION_FIELD_OPERATORS.TEXT
+        },
+        contact_email_domain: {
+            label: t('automation.field.contactEmailDomain'),
+            type: FIELD_TYPE.TEXT,
+            operators: AUTOMATION_FIELD_OPERATORS.TEXT
+        },
+        channel: {
+            label: t('globals.terms.channel'),
+            type: FIELD_TYPE.SELECT,
+            operators: AUTOMATION_FIELD_OPERATORS.SELECT,
+            options: channelOptions.value
+        },
+        tags: {
+            label: t('globals.terms.tag', 2),
+            type: FIELD_TYPE.MULTI_SELECT,
+            operators: AUTOMATION_FIELD_OPERATORS.MULTI_SELECT,
+            options: tagStore.tagOptions
+        },
+        within_business_hours: {
+            label: t('automation.field.withinBusinessHours'),
+            type: FIELD_TYPE.BOOLEAN,
+            operators: AUTOMATION_FIELD_OPERATORS.BOOLEAN
+        }
+    }))
+
     const newConversationFilters = computed(() => ({
         contact_email: {
             label: t('globals.terms.email'),
             type: FIELD_TYPE.TEXT,
-            operators: FIELD_OPERATORS.TEXT
+            operators: AUTOMATION_FIELD_OPERATORS.TEXT
         },
         content: {
             label: t('globals.terms.content'),
             type: FIELD_TYPE.TEXT,
-            operators: FIELD_OPERATORS.TEXT
+            operators: AUTOMATION_FIELD_OPERATORS.TEXT
         },
         subject: {
             label: t('globals.terms.subject'),
             type: FIELD_TYPE.TEXT,
-            operators: FIELD_OPERATORS.TEXT
+            operators: AUTOMATION_FIELD_OPERATORS.TEXT
         },
         status: {
             label: t('globals.terms.status'),
             type: FIELD_TYPE.SELECT,
-            operators: FIELD_OPERATORS.SELECT,
+            operators: AUTOMATION_FIELD_OPERATORS.SELECT,
             options: cStore.statusOptions
         },
         priority: {
             label: t('globals.terms.priority'),
             type: FIELD_TYPE.SELECT,
-            operators: FIELD_OPERATORS.SELECT,
+            operators: AUTOMATION_FIELD_OPERATORS.SELECT,
             options: cStore.priorityOptions
         },
         assigned_team: {
             label: t('actions.assignTeam'),
             type: FIELD_TYPE.SELECT,
-            operators: FIELD_OPERATORS.SELECT,
+            operators: AUTOMATION_FIELD_OPERATORS.SELECT,
             options: tStore.options
         },
         assigned_user: {
             label: t('actions.assignAgent'),
             type: FIELD_TYPE.SELECT,
-            operators: FIELD_OPERATORS.SELECT,
+            operators: AUTOMATION_FIELD_OPERATORS.SELECT,
             options: uStore.options
         },
         inbox: {
             label: t('globals.terms.inbox'),
             type: FIELD_TYPE.SELECT,
-            operators: FIELD_OPERATORS.SELECT,
+            operators: AUTOMATION_FIELD_OPERATORS.SELECT,
             options: iStore.options
-        }
+        },
+        ...commonRuleFields.value
     }))
 
     const conversationFilters = computed(() => ({
         status: {
             label: t('globals.terms.status'),
             type: FIELD_TYPE.SELECT,
-            operators: FIELD_OPERATORS.SELECT,
+            operators: AUTOMATION_FIELD_OPERATORS.SELECT,
             options: cStore.statusOptions
         },
         priority: {
             label: t('globals.terms.priority'),
             type: FIELD_TYPE.SELECT,
-            operators: FIELD_OPERATORS.SELECT,
+            operators: AUTOMATION_FIELD_OPERATORS.SELECT,
             options: cStore.priorityOptions
         },
         assigned_team: {
             label: t('actions.assignTeam'),
             type: FIELD_TYPE.SELECT,
-            operators: FIELD_OPERATORS.SELECT,
+            operators: AUTOMATION_FIELD_OPERATORS.SELECT,
             options: tStore.options
         },
         assigned_user: {
             label: t('actions.assignAgent'),
             type: FIELD_TYPE.SELECT,
-            operators: FIELD_OPERATORS.SELECT,
+            operators: AUTOMATION_FIELD_OPERATORS.SELECT,
             options: uStore.options
         },
         hours_since_created: {
             label: t('globals.messages.hoursSinceCreated'),
             type: FIELD_TYPE.NUMBER,
-            operators: FIELD_OPERATORS.NUMBER
+            operators: AUTOMATION_FIELD_OPERATORS.NUMBER
         },
         hours_since_first_reply: {
             label: t('globals.messages.hoursSinceFirstReply'),
             type: FIELD_TYPE.NUMBER,
-            operators: FIELD_OPERATORS.NUMBER
+            operators: AUTOMATION_FIELD_OPERATORS.NUMBER
         },
         hours_since_last_reply: {
             label: t('globals.messages.hoursSinceLastReply'),
             type: FIELD_TYPE.NUMBER,
-            operators: FIELD_OPERATORS.NUMBER
+            operators: AUTOMATION_FIELD_OPERATORS.NUMBER
         },
         hours_since_resolved: {
             label: t('globals.messages.hoursSinceResolved'),
             type: FIELD_TYPE.NUMBER,
-            operators: FIELD_OPERATORS.NUMBER
+            operators: AUTOMATION_FIELD_OPERATORS.NUMBER
         },
         inbox: {
             label: t('globals.terms.inbox'),
             type: FIELD_TYPE.SELECT,
-            operators: FIELD_OPERATORS.SELECT,
+            operators: AUTOMATION_FIELD_OPERATORS.SELECT,
             options: iStore.options
-        }
+        },
+        message_count: {
+            label: t('automation.field.messageCount'),
+            type: FIELD_TYPE.NUMBER,
+            operators: AUTOMATION_FIELD_OPERATORS.NUMBER
+        },
+        sla_status: {
+            label: t('automation.field.slaStatus'),
+            type: FIELD_TYPE.SELECT,
+            operators: AUTOMATION_FIELD_OPERATORS.SELECT,
+            options: slaStatusOptions.value
+        },
+        csat_score: {
+            label: t('automation.field.csatScore'),
+            type: FIELD_TYPE.NUMBER,
+            operators: AUTOMATION_FIELD_OPERATORS.NUMBER
+        },
+        ...commonRuleFields.value
     }))
 
     const conversationActions = computed(() => ({
@@ -226,6 +297,16 @@ export function useConversationFilters () {
         send_csat: {
             label: t('actions.sendCsat'),
         },
+        ai_summarize: {
+            label: t('actions.aiSummarize'),
+        },
+        ai_auto_tag: {
+            label: t('actions.aiAutoTag'),
+        },
+        http_request: {
+            label: t('actions.httpRequest'),
+            type: 'http_request'
+        },
         set_sla: {
             label: t('actions.setSla'),
             type: FIELD_TYPE.SELECT,
@@ -288,5 +369,6 @@ export function useConversationFilters () {
         conversationActions,
         macroActions,
         contactCustomAttributes,
+        conversationCustomAttributes,
     }
 }
\ No newline at end of file
@@ -17,9 +17,24 @@ export const OPERATOR = {
     CONTAINS: 'contains',
     NOT_CONTAINS: 'not contains',
     GREATER_THAN: 'greater than',
-    LESS_THAN: 'less than'
+    LESS_THAN: 'less than',
+    STARTS_WITH: 'starts with',
+    ENDS_WITH: 'ends with',
+    MATCHES_REGEX: 'matches regex',
+    IN_LIST: 'in list',
+    IS_ONE_OF: 'is one of'
 }
 
+// Operators whose value is a comma separated list of values.
+export const LIST_OPERATORS = [
+    OPERATOR.CONTAINS,
+    OPERATOR.NOT_CONTAINS,
+    OPERATOR.STARTS_WITH,
+    OPERATOR.ENDS_WITH,
+    OPERATOR.IN_LIST,
+    OPERATOR.IS_ONE_OF
+]
+
 export const FIELD_OPERATORS = {
     SELECT: [OPERATOR.EQUALS, OPERATOR.NOT_EQUALS, OPERATOR.SET, OPERATOR.NOT_SET],
     BOOLEAN: [OPERATOR.EQUALS, OPERATOR.NOT_EQUALS],
@@ -42,3 +57,17 @@ export const FIELD_OPERATORS = {
     NUMBER: [OPERATOR.EQUALS, OPERATOR.NOT_EQUALS, OPERATOR.GREATER_THAN, OPERATOR.LESS_THAN],
     MULTI_SELECT: [OPERATOR.CONTAINS, OPERATOR.NOT_CONTAINS, OPERATOR.SET, OPERATOR.NOT_SET]
 }
+
+// Automation rules are evaluated in the backend and support more operators than the conversation list filters.
+export const AUTOMATION_FIELD_OPERATORS = {
+    ...FIELD_OPERATORS,
+    SELECT: [...FIELD_OPERATORS.SELECT, OPERATOR.IS_ONE_OF],
+    TEXT: [
+        ...FIELD_OPERATORS.TEXT,
+        OPERATOR.STARTS_WITH,
+        OPERATOR.ENDS_WITH,
+        OPERATOR.MATCHES_REGEX,
+        OPERATOR.IN_LIST
+    ],
+    NUMBER: [...FIELD_OPERATORS.NUMBER, OPERATOR.SET, OPERATOR.NOT_SET]
+}
@@ -96,6 +96,12 @@ export const adminNavItems = [
         permission: 'automations:manage',
         isTitleKeyPlural: true,
         icon: 'Workflow'
+      },
+      {
+        titleKey: 'globals.terms.knowledgeBase',
+        href: '/admin/knowledge-base',
+        permission: 'kb:manage',
+        icon: 'BookOpen'
       }
     ]
   },
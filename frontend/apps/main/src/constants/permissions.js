@@ -37,10 +37,14 @@ export const permissions = {
   CONTACTS_READ: 'contacts:read',
   CONTACTS_WRITE: 'contacts:write',
   CONTACTS_BLOCK: 'contacts:block',
+  CONTACTS_MERGE: 'contacts:merge',
+  CONTACTS_EXPORT: 'contacts:export',
+  CONTACTS_ERASE: 'contacts:erase',
   CONTACT_NOTES_READ: 'contact_notes:read',
   CONTACT_NOTES_WRITE: 'contact_notes:write',
   CONTACT_NOTES_DELETE: 'contact_notes:delete',
   ACTIVITY_LOGS_MANAGE: 'activity_logs:manage',
   WEBHOOKS_MANAGE: 'webhooks:manage',
-  CONTEXT_LINKS_MANAGE: 'context_links:manage'
+  CONTEXT_LINKS_MANAGE: 'context_links:manage',
+  KB_MANAGE: 'kb:manage'
 }
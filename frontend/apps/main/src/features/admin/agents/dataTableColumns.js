@@ -48,6 +48,17 @@ export const createColumns = (t) => [
       return h('div', { class: 'text-center' }, row.getValue('email'))
     }
   },
+  {
+    accessorKey: 'load',
+    header: function () {
+      return h('div', { class: 'text-center' }, t('admin.agent.load'))
+    },
+    cell: function ({ row }) {
+      const load = Math.round(row.getValue('load') * 100) / 100
+      const capacity = row.original.capacity
+      return h('div', { class: 'text-center' }, capacity ? `${load} / ${capacity}` : load)
+    }
+  },
   {
     accessorKey: 'created_at',
     header: function () {
@@ -1,5 +1,7 @@
 import * as z from 'zod'
 
+const timeRegex = /^([01]\d|2[0-3]):([0-5]\d)$/
+
 export const createFormSchema = (t) => z.object({
   first_name: z
     .string({
@@ -32,6 +34,36 @@ export const createFormSchema = (t) => z.object({
 
   teams: z.array(z.string()).default([]),
 
+  skills: z.array(z.string()).default([]),
+
+  capacity: z.preprocess(
+    (v) => (v === '' || v === undefined ? null : Number(v)),
+    z.number().int().min(1, t('user.invalidCapacity')).nullable()
+  ),
+
+  channel_weights: z
+    .record(
+      z.preprocess(
+        (v) => (v === '' || v === null ? undefined : Number(v)),
+        z.number().gt(0).max(100).optional()
+      )
+    )
+    .default({}),
+
+  shift_hours: z
+    .record(
+      z.object({
+        open: z.string().regex(timeRegex, t('validation.invalidTimeFormat')),
+        close: z.string().regex(timeRegex, t('validation.invalidTimeFormat'))
+      })
+    )
+    .nullable()
+    .optional(),
+
+  shift_timezone: z.string().nullable().optional(),
+
+  shift_reassign: z.boolean().optional().default(false),
+
   roles: z.array(z.string()).min(1, t('validation.selectAtLeastOneRole')),
 
   new_password: z
@@ -2,12 +2,33 @@ import * as z from 'zod'
 import { isGoDuration } from '@shared-ui/utils/string'
 import { AUTH_TYPE_PASSWORD, AUTH_TYPE_OAUTH2 } from '@main/constants/auth.js'
 
+const createIMAPSchema = (t) => z.object({
+  host: z.string().min(1, t('globals.messages.required')),
+  port: z.number().min(1).max(65535),
+  mailbox: z.string().min(1, t('globals.messages.required')),
+  mailboxes: z.array(z.string()).optional(),
+  post_process: z.enum(['none', 'seen', 'move', 'delete']).optional(),
+  move_to_mailbox: z.string().optional(),
+  username: z.string().min(1, t('globals.messages.required')),
+  password: z.string().min(1, t('globals.messages.required')),
+  tls_type: z.enum(['none', 'starttls', 'tls']),
+  tls_skip_verify: z.boolean().optional(),
+  scan_inbox_since: z.string().min(1, t('globals.messages.required')).refine(isGoDuration, {
+    message: t('validation.invalidDuration')
+  }),
+  read_interval: z.string().min(1, t('globals.messages.required')).refine(isGoDuration, {
+    message: t('validation.invalidDuration')
+  })
+})
+
 export const createFormSchema = (t) => z.object({
   name: z.string().min(1, t('globals.messages.required')),
   from: z.string().min(1, t('globals.messages.required')),
   enabled: z.boolean().optional(),
   csat_enabled: z.boolean().optional(),
   enable_plus_addressing: z.boolean().optional(),
+  flag_bounced_contacts: z.boolean().optional(),
+  signature: z.string().optional(),
   auth_type: z.enum([AUTH_TYPE_PASSWORD, AUTH_TYPE_OAUTH2]),
   oauth: z.object({
     access_token: z.string().optional(),
@@ -17,21 +38,10 @@ export const createFormSchema = (t) => z.object({
     provider: z.string().optional(),
     refresh_token: z.string().optional()
   }).optional(),
-  imap: z.object({
-    host: z.string().min(1, t('globals.messages.required')),
-    port: z.number().min(1).max(65535),
-    mailbox: z.string().min(1, t('globals.messages.required')),
-    username: z.string().min(1, t('globals.messages.required')),
-    password: z.string().min(1, t('globals.messages.required')),
-    tls_type: z.enum(['none', 'starttls', 'tls']),
-    tls_skip_verify: z.boolean().optional(),
-    scan_inbox_since: z.string().min(1, t('globals.messages.required')).refine(isGoDuration, {
-      message: t('validation.invalidDuration')
-    }),
-    read_interval: z.string().min(1, t('globals.messages.required')).refine(isGoDuration, {
-      message: t('validation.invalidDuration')
-    })
-  }),
+  inbound_enabled: z.boolean().optional(),
+  secret: z.string().optional(),
+  // IMAP is validated below as relayed inboxes go without it.
+  imap: z.any(),
   smtp: z.object({
     host: z.string().min(1, t('globals.messages.required')),
     port: z.number().min(1).max(65535),
@@ -50,4 +60,15 @@ export const createFormSchema = (t) => z.object({
     hello_hostname: z.string().optional(),
     auth_protocol: z.enum(['login', 'cram', 'plain', 'none'])
   })
+}).superRefine((values, ctx) => {
+  if (values.inbound_enabled) {
+    if (!values.secret) {
+      ctx.addIssue({ code: z.ZodIssueCode.custom, path: ['secret'], message: t('globals.messages.required') })
+    }
+    return
+  }
+  const result = createIMAPSchema(t).safeParse(values.imap)
+  if (!result.success) {
+    result.error.issues.forEach((issue) => ctx.addIssue({ ...issue, path: ['imap', ...issue.path] }))
+  }
 })
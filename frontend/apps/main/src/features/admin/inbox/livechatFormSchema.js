@@ -71,12 +71,13 @@ export const createFormSchema = (t) => z.object({
     trusted_domains: z.string().optional(),
     blocked_ips: z.string().optional(),
     home_apps: z.array(z.object({
-      type: z.enum(['announcement', 'external_link']),
+      type: z.enum(['announcement', 'external_link', 'kb_search']),
       title: z.string().optional().or(z.literal('')),
       description: z.string().optional().or(z.literal('')),
       image_url: optionalUrl(t),
       url: optionalUrl(t),
       text: z.string().optional().or(z.literal('')),
+      locale: z.string().optional().or(z.literal('')),
     })),
     visitors: z.object({
       start_conversation_button_text: z.string(),
@@ -0,0 +1,85 @@
+import { h } from 'vue'
+import { RouterLink } from 'vue-router'
+import dropdown from './dataTableDropdown.vue'
+import { format } from 'date-fns'
+import { Badge } from '@shared-ui/components/ui/badge'
+
+export const createColumns = (t) => [
+  {
+    accessorKey: 'title',
+    header: function () {
+      return h('div', { class: 'text-center' }, t('globals.terms.title'))
+    },
+    cell: function ({ row }) {
+      return h(
+        'div',
+        { class: 'text-center' },
+        h(
+          RouterLink,
+          {
+            to: { name: 'edit-kb-article', params: { id: row.original.id } },
+            class: 'text-primary hover:underline'
+          },
+          () => row.getValue('title')
+        )
+      )
+    }
+  },
+  {
+    accessorKey: 'category_name',
+    header: function () {
+      return h('div', { class: 'text-center' }, t('globals.terms.category'))
+    },
+    cell: function ({ row }) {
+      return h('div', { class: 'text-center' }, row.getValue('category_name') || '-')
+    }
+  },
+  {
+    accessorKey: 'locale',
+    header: function () {
+      return h('div', { class: 'text-center' }, t('globals.terms.language'))
+    },
+    cell: function ({ row }) {
+      return h('div', { class: 'text-center font-mono uppercase' }, row.getValue('locale'))
+    }
+  },
+  {
+    accessorKey: 'status',
+    header: () => h('div', { class: 'text-center' }, t('globals.terms.status')),
+    cell: ({ row }) => {
+      const published = row.getValue('status') === 'published'
+      return h('div', { class: 'text-center' }, [
+        h(
+          Badge,
+          {
+            variant: published ? 'default' : 'secondary',
+            class: 'text-xs'
+          },
+          () => (published ? t('kb.published') : t('globals.terms.draft'))
+        )
+      ])
+    }
+  },
+  {
+    accessorKey: 'updated_at',
+    header: function () {
+      return h('div', { class: 'text-center' }, t('globals.terms.updatedAt'))
+    },
+    cell: function ({ row }) {
+      return h(
+        'div',
+        { class: 'text-center text-sm' },
+        format(row.getValue('updated_at'), 'PPpp')
+      )
+    }
+  },
+  {
+    id: 'actions',
+    enableHiding: false,
+    enableSorting: false,
+    cell: ({ row }) => {
+      const article = row.original
+      return h('div', { class: 'relative' }, h(dropdown, { article }))
+    }
+  }
+]
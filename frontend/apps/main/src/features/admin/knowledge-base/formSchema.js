@@ -0,0 +1,18 @@
+import * as z from 'zod'
+
+export const createFormSchema = (t) =>
+  z.object({
+    title: z
+      .string({
+        required_error: t('globals.messages.required')
+      })
+      .min(1, {
+        message: t('globals.messages.required')
+      })
+      .max(300),
+    slug: z.string().max(200).optional(),
+    locale: z.string().min(2).max(10).default('en'),
+    category_id: z.coerce.number().int().optional().nullable(),
+    status: z.enum(['draft', 'published']).default('draft'),
+    content: z.string().optional()
+  })
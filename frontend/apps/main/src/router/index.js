@@ -385,6 +385,12 @@ const routes = [
                 name: 'edit-automation',
                 component: () => import('@main/views/admin/automations/CreateOrEditRule.vue'),
                 meta: { titleKey: 'automation.editRule' }
+              },
+              {
+                path: 'executions',
+                name: 'automation-executions',
+                component: () => import('@main/views/admin/automations/AutomationExecutions.vue'),
+                meta: { titleKey: 'admin.automation.executionLog' }
               }
             ]
           },
@@ -466,6 +472,38 @@ const routes = [
               }
             ]
           },
+          {
+            path: 'knowledge-base',
+            component: () => import('@main/views/admin/knowledge-base/KnowledgeBase.vue'),
+            name: 'knowledge-base',
+            meta: { titleKey: 'globals.terms.knowledgeBase' },
+            children: [
+              {
+                path: '',
+                name: 'kb-article-list',
+                component: () => import('@main/views/admin/knowledge-base/KBArticleList.vue')
+              },
+              {
+                path: 'articles/:id/edit',
+                props: true,
+                name: 'edit-kb-article',
+                component: () => import('@main/views/admin/knowledge-base/CreateEditKBArticle.vue'),
+                meta: { titleKey: 'kb.editArticle' }
+              },
+              {
+                path: 'articles/new',
+                name: 'new-kb-article',
+                component: () => import('@main/views/admin/knowledge-base/CreateEditKBArticle.vue'),
+                meta: { titleKey: 'kb.newArticle' }
+              },
+              {
+                path: 'categories',
+                name: 'kb-category-list',
+                component: () => import('@main/views/admin/knowledge-base/KBCategoryList.vue'),
+                meta: { titleKey: 'globals.terms.category', titleCount: 2 }
+              }
+            ]
+          },
           {
             path: 'context-links',
             component: () => import('@main/views/admin/context-links/ContextLinks.vue'),
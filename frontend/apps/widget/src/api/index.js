@@ -149,6 +149,9 @@ const uploadMedia = (conversationUUID, files) => {
     })
 }
 const updateConversationLastSeen = (uuid) => http.post(`/api/v1/widget/chat/conversations/${uuid}/update-last-seen`)
+const searchKBArticles = (query) => http.get('/api/v1/widget/kb/search', {
+    params: { query }
+})
 const submitCSATResponse = (csatUuid, rating, feedback) =>
     http.post(`/api/v1/csat/${csatUuid}/response`, {
         rating,
@@ -168,5 +171,6 @@ export default {
     closeChatConversation,
     uploadMedia,
     updateConversationLastSeen,
+    searchKBArticles,
     submitCSATResponse
 }
@@ -43,6 +43,7 @@ require (
 	github.com/zerodha/simplesessions/v3 v3.0.0
 	golang.org/x/crypto v0.45.0
 	golang.org/x/mod v0.33.0
+	golang.org/x/net v0.47.0
 	golang.org/x/oauth2 v0.27.0
 )
 
@@ -77,7 +78,6 @@ require (
 	github.com/stretchr/objx v0.5.2 // indirect
 	github.com/valyala/bytebufferpool v1.0.0 // indirect
 	golang.org/x/image v0.38.0 // indirect
-	golang.org/x/net v0.47.0 // indirect
 	golang.org/x/sys v0.38.0 // indirect
 	golang.org/x/text v0.35.0 // indirect
 	gopkg.in/yaml.v3 v3.0.1 // indirect
@@ -6,6 +6,7 @@ import (
 	"database/sql"
 	"embed"
 	"fmt"
+	"strconv"
 	"strings"
 
 	"github.com/abhinavxd/libredesk/internal/activity_log/models"
@@ -27,6 +28,8 @@ type Manager struct {
 	lo   *logf.Logger
 	i18n *i18n.I18n
 	db   *sqlx.DB
+	// insert stores an activity log entry.
+	insert func(activityType, activityDescription string, actorID int, targetModelType string, targetModelID int, ip string) error
 }
 
 // Opts contains options for initializing the Manager.
@@ -48,12 +51,14 @@ func New(opts Opts) (*Manager, error) {
 	if err := dbutil.ScanSQLFile("queries.sql", &q, opts.DB, efs); err != nil {
 		return nil, err
 	}
-	return &Manager{
+	m := &Manager{
 		q:    q,
 		lo:   opts.Lo,
 		i18n: opts.I18n,
 		db:   opts.DB,
-	}, nil
+	}
+	m.insert = m.insertActivity
+	return m, nil
 }
 
 // GetAll retrieves all activity logs.
@@ -261,15 +266,94 @@ func (al *Manager) RolePermissionsChanged(actorID int, actorEmail, ip string, ro
 	)
 }
 
+// ContactMerged records a contact merge event.
+func (al *Manager) ContactMerged(actorID int, actorEmail, ip string, sourceID int, sourceEmail string, targetID int, targetEmail string) error {
+	description := al.i18n.Ts("activityLog.contactMerged",
+		"actorEmail", actorEmail,
+		"actorId", fmt.Sprintf("#%d", actorID),
+		"sourceEmail", sourceEmail,
+		"sourceId", fmt.Sprintf("#%d", sourceID),
+		"targetEmail", targetEmail,
+		"targetId", fmt.Sprintf("#%d", targetID))
+	return al.create(
+		models.ContactMerged,
+		description,
+		actorID,
+		umodels.UserModel,
+		targetID,
+		ip,
+	)
+}
+
+// ContactDataExported records a contact data export request.
+func (al *Manager) ContactDataExported(actorID int, actorEmail, ip string, contactID int, contactEmail string) error {
+	description := al.i18n.Ts("activityLog.contactDataExported",
+		"actorEmail", actorEmail,
+		"actorId", fmt.Sprintf("#%d", actorID),
+		"targetEmail", contactEmail,
+		"targetId", fmt.Sprintf("#%d", contactID))
+	return al.create(
+		models.ContactDataExported,
+		description,
+		actorID,
+		umodels.UserModel,
+		contactID,
+		ip,
+	)
+}
+
+// ContactDataErased records a contact data erasure request, only the contact ID is recorded as the contact's data is erased.
+func (al *Manager) ContactDataErased(actorID int, actorEmail, ip string, contactID int) error {
+	description := al.i18n.Ts("activityLog.contactDataErased",
+		"actorEmail", actorEmail,
+		"actorId", fmt.Sprintf("#%d", actorID),
+		"targetId", fmt.Sprintf("#%d", contactID))
+	return al.create(
+		models.ContactDataErased,
+		description,
+		actorID,
+		umodels.UserModel,
+		contactID,
+		ip,
+	)
+}
+
+// RetentionSweep records a retention policy sweep of an inbox.
+func (al *Manager) RetentionSweep(actorID int, actorEmail string, inboxID int, inboxName, action string, conversations, messages, attachments int) error {
+	description := al.i18n.Ts("activityLog.retentionSweep",
+		"actorEmail", actorEmail,
+		"actorId", fmt.Sprintf("#%d", actorID),
+		"inboxName", inboxName,
+		"inboxId", fmt.Sprintf("#%d", inboxID),
+		"action", action,
+		"conversations", strconv.Itoa(conversations),
+		"messages", strconv.Itoa(messages),
+		"attachments", strconv.Itoa(attachments))
+	return al.create(
+		models.RetentionSweep,
+		description,
+		actorID,
+		models.InboxModel,
+		inboxID,
+		"",
+	)
+}
+
 // create creates a new activity log in DB.
 func (m *Manager) create(activityType, activityDescription string, actorID int, targetModelType string, targetModelID int, ip string) error {
-	if _, err := m.q.InsertActivity.Exec(activityType, activityDescription, actorID, targetModelType, targetModelID, ip); err != nil {
+	if err := m.insert(activityType, activityDescription, actorID, targetModelType, targetModelID, ip); err != nil {
 		m.lo.Error("error inserting activity log", "error", err)
 		return envelope.NewError(envelope.GeneralError, m.i18n.T("globals.messages.somethingWentWrong"), nil)
 	}
 	return nil
 }
 
+// insertActivity inserts an activity log entry into the database.
+func (m *Manager) insertActivity(activityType, activityDescription string, actorID int, targetModelType string, targetModelID int, ip string) error {
+	_, err := m.q.InsertActivity.Exec(activityType, activityDescription, actorID, targetModelType, targetModelID, ip)
+	return err
+}
+
 // makeQuery constructs the SQL query for fetching activity logs with filters and pagination.
 func (m *Manager) makeQuery(page, pageSize int, order, orderBy, filtersJSON string) (string, []any, error) {
 	var (
@@ -0,0 +1,73 @@
+package activitylog
+
+import (
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/abhinavxd/libredesk/internal/activity_log/models"
+	"github.com/knadh/go-i18n"
+	"github.com/zerodha/logf"
+)
+
+// entry is an activity log entry recorded by the test manager.
+type entry struct {
+	activityType string
+	description  string
+	targetID     int
+}
+
+func newTestManager(t *testing.T) (*Manager, *[]entry) {
+	t.Helper()
+	b, err := os.ReadFile("../../i18n/en.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	i, err := i18n.New(b)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var (
+		lo      = logf.New(logf.Opts{})
+		entries []entry
+		m       = &Manager{i18n: i, lo: &lo}
+	)
+	m.insert = func(activityType, activityDescription string, actorID int, targetModelType string, targetModelID int, ip string) error {
+		entries = append(entries, entry{activityType: activityType, description: activityDescription, targetID: targetModelID})
+		return nil
+	}
+	return m, &entries
+}
+
+func TestContactDataErased(t *testing.T) {
+	m, entries := newTestManager(t)
+
+	if err := m.ContactDataErased(1, "agent@example.com", "127.0.0.1", 42); err != nil {
+		t.Fatalf("ContactDataErased() error = %v", err)
+	}
+	if len(*entries) != 1 {
+		t.Fatalf("ContactDataErased() recorded %d entries, want 1", len(*entries))
+	}
+	e := (*entries)[0]
+	if e.activityType != models.ContactDataErased || e.targetID != 42 {
+		t.Errorf("ContactDataErased() recorded %+v", e)
+	}
+	if !strings.Contains(e.description, "#42") {
+		t.Errorf("description %q does not reference the contact ID", e.description)
+	}
+	// The erased contact's email must not be recorded, the description only has the agent's email.
+	if strings.Count(e.description, "@") != 1 || strings.Contains(e.description, "{") {
+		t.Errorf("description %q records more than the agent's email", e.description)
+	}
+}
+
+func TestContactDataExported(t *testing.T) {
+	m, entries := newTestManager(t)
+
+	if err := m.ContactDataExported(1, "agent@example.com", "127.0.0.1", 42, "contact@example.com"); err != nil {
+		t.Fatalf("ContactDataExported() error = %v", err)
+	}
+	if len(*entries) != 1 || !strings.Contains((*entries)[0].description, "contact@example.com") {
+		t.Errorf("ContactDataExported() recorded %+v, want the contact's email", *entries)
+	}
+}
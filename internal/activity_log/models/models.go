@@ -12,6 +12,12 @@ const (
 	AgentOnline                 = "agent_online"
 	AgentPasswordSet            = "agent_password_set"
 	AgentRolePermissionsChanged = "agent_role_permissions_changed"
+	ContactMerged               = "contact_merged"
+	ContactDataExported         = "contact_data_exported"
+	ContactDataErased           = "contact_data_erased"
+	RetentionSweep              = "retention_sweep"
+
+	InboxModel = "inbox"
 )
 
 type ActivityLog struct {
@@ -9,7 +9,7 @@ SELECT
     actor_id, 
     target_model_type, 
     target_model_id, 
-    ip
+    COALESCE(host(ip), '') AS ip
 FROM 
     activity_logs WHERE 1=1 
 
@@ -22,5 +22,5 @@ INSERT INTO activity_logs (
     target_model_id, 
     ip
 ) VALUES (
-    $1, $2, $3, $4, $5, $6
+    $1, $2, $3, $4, $5, NULLIF($6, '')::INET
 );
\ No newline at end of file
@@ -6,11 +6,15 @@ import (
 	"embed"
 	"encoding/json"
 	"errors"
+	"strings"
+	"time"
 
 	"github.com/abhinavxd/libredesk/internal/ai/models"
 	"github.com/abhinavxd/libredesk/internal/crypto"
 	"github.com/abhinavxd/libredesk/internal/dbutil"
 	"github.com/abhinavxd/libredesk/internal/envelope"
+	"github.com/abhinavxd/libredesk/internal/httputil"
+	"github.com/abhinavxd/libredesk/internal/stringutil"
 	"github.com/jmoiron/sqlx"
 	"github.com/knadh/go-i18n"
 	"github.com/zerodha/logf"
@@ -26,6 +30,7 @@ var (
 
 type Manager struct {
 	q             queries
+	db            *sqlx.DB
 	lo            *logf.Logger
 	i18n          *i18n.I18n
 	encryptionKey string
@@ -41,10 +46,14 @@ type Opts struct {
 
 // queries contains prepared SQL queries.
 type queries struct {
-	GetDefaultProvider *sqlx.Stmt `query:"get-default-provider"`
-	GetPrompt          *sqlx.Stmt `query:"get-prompt"`
-	GetPrompts         *sqlx.Stmt `query:"get-prompts"`
-	SetOpenAIKey       *sqlx.Stmt `query:"set-openai-key"`
+	GetDefaultProvider   *sqlx.Stmt `query:"get-default-provider"`
+	GetProvider          *sqlx.Stmt `query:"get-provider"`
+	GetProviders         *sqlx.Stmt `query:"get-providers"`
+	GetPrompt            *sqlx.Stmt `query:"get-prompt"`
+	GetPrompts           *sqlx.Stmt `query:"get-prompts"`
+	UpdateProviderConfig *sqlx.Stmt `query:"update-provider-config"`
+	UnsetDefaultProvider *sqlx.Stmt `query:"unset-default-provider"`
+	SetDefaultProvider   *sqlx.Stmt `query:"set-default-provider"`
 }
 
 // New creates and returns a new instance of the Manager.
@@ -55,6 +64,7 @@ func New(opts Opts) (*Manager, error) {
 	}
 	return &Manager{
 		q:             q,
+		db:            opts.DB,
 		lo:            opts.Lo,
 		i18n:          opts.I18n,
 		encryptionKey: opts.EncryptionKey,
@@ -68,29 +78,23 @@ func (m *Manager) Completion(k string, prompt string) (string, error) {
 		return "", err
 	}
 
-	client, err := m.getDefaultProviderClient()
+	return m.send(PromptPayload{
+		SystemPrompt: systemPrompt,
+		UserPrompt:   prompt,
+	})
+}
+
+// send sends the payload to the default provider and returns the response.
+func (m *Manager) send(payload PromptPayload) (string, error) {
+	provider, client, err := m.getDefaultProviderClient()
 	if err != nil {
 		m.lo.Error("error getting provider client", "error", err)
 		return "", envelope.NewError(envelope.GeneralError, m.i18n.T("globals.messages.somethingWentWrong"), nil)
 	}
 
-	payload := PromptPayload{
-		SystemPrompt: systemPrompt,
-		UserPrompt:   prompt,
-	}
-
 	response, err := client.SendPrompt(payload)
 	if err != nil {
-		if errors.Is(err, ErrInvalidAPIKey) {
-			m.lo.Error("error invalid API key", "error", err)
-			return "", envelope.NewError(envelope.InputError, m.i18n.T("globals.messages.somethingWentWrong"), nil)
-		}
-		if errors.Is(err, ErrApiKeyNotSet) {
-			m.lo.Error("error API key not set", "error", err)
-			return "", envelope.NewError(envelope.InputError, m.i18n.Ts("ai.apiKeyNotSet", "provider", "OpenAI"), nil)
-		}
-		m.lo.Error("error sending prompt to provider", "error", err)
-		return "", envelope.NewError(envelope.GeneralError, err.Error(), nil)
+		return "", m.providerError(provider, err)
 	}
 
 	return response, nil
@@ -106,33 +110,235 @@ func (m *Manager) GetPrompts() ([]models.Prompt, error) {
 	return prompts, nil
 }
 
-// UpdateProvider updates a provider.
-func (m *Manager) UpdateProvider(provider, apiKey string) error {
-	switch ProviderType(provider) {
-	case ProviderOpenAI:
-		return m.setOpenAIAPIKey(apiKey)
-	default:
-		m.lo.Error("unsupported provider type", "provider", provider)
-		return envelope.NewError(envelope.GeneralError, m.i18n.T("validation.invalidProvider"), nil)
+// GetProviders returns all providers along with their settings, API keys are masked.
+func (m *Manager) GetProviders() ([]models.ProviderSettings, error) {
+	var providers []models.Provider
+	if err := m.q.GetProviders.Select(&providers); err != nil {
+		m.lo.Error("error fetching providers", "error", err)
+		return nil, envelope.NewError(envelope.GeneralError, m.i18n.T("globals.messages.somethingWentWrong"), nil)
 	}
+
+	var out = make([]models.ProviderSettings, 0, len(providers))
+	for _, p := range providers {
+		var cfg models.ProviderConfig
+		if err := json.Unmarshal([]byte(p.Config), &cfg); err != nil {
+			m.lo.Error("error parsing provider config", "provider", p.Provider, "error", err)
+			return nil, envelope.NewError(envelope.GeneralError, m.i18n.T("globals.messages.somethingWentWrong"), nil)
+		}
+		settings := models.ProviderSettings{
+			ID:        p.ID,
+			Name:      p.Name,
+			Provider:  p.Provider,
+			IsDefault: p.IsDefault,
+			Model:     cfg.Model,
+			BaseURL:   cfg.BaseURL,
+			Timeout:   cfg.Timeout,
+			HasAPIKey: cfg.APIKey != "",
+		}
+		// Hide the API key, the masked key is sent back to keep it.
+		if settings.HasAPIKey {
+			settings.APIKey = strings.Repeat(stringutil.PasswordDummy, 10)
+		}
+		out = append(out, settings)
+	}
+	return out, nil
 }
 
-// setOpenAIAPIKey sets the OpenAI API key in the database.
-func (m *Manager) setOpenAIAPIKey(apiKey string) error {
-	// Encrypt API key before storing.
-	encryptedKey, err := crypto.Encrypt(apiKey, m.encryptionKey)
+// UpdateProvider updates the configuration of a provider, a masked API key keeps the stored key.
+// The provider is made the default provider only if makeDefault is set.
+func (m *Manager) UpdateProvider(provider string, cfg models.ProviderConfig, makeDefault bool) error {
+	cfg, err := m.mergeConfig(provider, cfg)
+	if err != nil {
+		return err
+	}
+	if err := m.validateConfig(ProviderType(provider), cfg); err != nil {
+		return err
+	}
+
+	if cfg.APIKey != "" {
+		// Encrypt API key before storing.
+		encryptedKey, err := crypto.Encrypt(cfg.APIKey, m.encryptionKey)
+		if err != nil {
+			m.lo.Error("error encrypting API key", "error", err)
+			return envelope.NewError(envelope.GeneralError, m.i18n.T("globals.messages.somethingWentWrong"), nil)
+		}
+		cfg.APIKey = encryptedKey
+	}
+
+	config, err := json.Marshal(cfg)
 	if err != nil {
-		m.lo.Error("error encrypting API key", "error", err)
+		m.lo.Error("error marshalling provider config", "error", err)
 		return envelope.NewError(envelope.GeneralError, m.i18n.T("globals.messages.somethingWentWrong"), nil)
 	}
 
-	if _, err := m.q.SetOpenAIKey.Exec(encryptedKey); err != nil {
-		m.lo.Error("error setting OpenAI API key", "error", err)
+	tx, err := m.db.Beginx()
+	if err != nil {
+		m.lo.Error("error starting transaction", "error", err)
+		return envelope.NewError(envelope.GeneralError, m.i18n.T("globals.messages.somethingWentWrong"), nil)
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Stmtx(m.q.UpdateProviderConfig).Exec(provider, config); err != nil {
+		m.lo.Error("error updating provider config", "provider", provider, "error", err)
+		return envelope.NewError(envelope.GeneralError, m.i18n.T("globals.messages.somethingWentWrong"), nil)
+	}
+	if makeDefault {
+		if _, err := tx.Stmtx(m.q.UnsetDefaultProvider).Exec(provider); err != nil {
+			m.lo.Error("error unsetting default provider", "error", err)
+			return envelope.NewError(envelope.GeneralError, m.i18n.T("globals.messages.somethingWentWrong"), nil)
+		}
+		if _, err := tx.Stmtx(m.q.SetDefaultProvider).Exec(provider); err != nil {
+			m.lo.Error("error setting default provider", "provider", provider, "error", err)
+			return envelope.NewError(envelope.GeneralError, m.i18n.T("globals.messages.somethingWentWrong"), nil)
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		m.lo.Error("error committing provider update", "error", err)
 		return envelope.NewError(envelope.GeneralError, m.i18n.T("globals.messages.somethingWentWrong"), nil)
 	}
 	return nil
 }
 
+// TestProvider sends a short prompt to the provider with the given configuration to check that it works.
+// A masked API key uses the stored key.
+func (m *Manager) TestProvider(provider string, cfg models.ProviderConfig) error {
+	cfg, err := m.mergeConfig(provider, cfg)
+	if err != nil {
+		return err
+	}
+	if err := m.validateConfig(ProviderType(provider), cfg); err != nil {
+		return err
+	}
+
+	client, err := m.newProviderClient(ProviderType(provider), cfg)
+	if err != nil {
+		return err
+	}
+
+	if _, err := client.SendPrompt(PromptPayload{
+		SystemPrompt: "You are a connection test. Reply with the single word OK.",
+		UserPrompt:   "ping",
+	}); err != nil {
+		return m.providerError(ProviderType(provider), err)
+	}
+	return nil
+}
+
+// mergeConfig returns the given configuration with a masked API key replaced by the stored, decrypted key of the provider.
+func (m *Manager) mergeConfig(provider string, cfg models.ProviderConfig) (models.ProviderConfig, error) {
+	existing, err := m.getProvider(provider)
+	if err != nil {
+		return cfg, err
+	}
+	stored, err := m.decryptConfig(existing)
+	if err != nil {
+		return cfg, err
+	}
+	return mergeProviderConfig(stored, cfg), nil
+}
+
+// mergeProviderConfig keeps the stored API key if the masked key is sent, all other fields are set as sent.
+func mergeProviderConfig(stored, cfg models.ProviderConfig) models.ProviderConfig {
+	if strings.Contains(cfg.APIKey, stringutil.PasswordDummy) {
+		cfg.APIKey = stored.APIKey
+	}
+	return cfg
+}
+
+// getProvider returns a provider from the database.
+func (m *Manager) getProvider(provider string) (models.Provider, error) {
+	var p models.Provider
+	if err := m.q.GetProvider.Get(&p, provider); err != nil {
+		if err == sql.ErrNoRows {
+			return p, envelope.NewError(envelope.InputError, m.i18n.T("validation.invalidProvider"), nil)
+		}
+		m.lo.Error("error fetching provider", "provider", provider, "error", err)
+		return p, envelope.NewError(envelope.GeneralError, m.i18n.T("globals.messages.somethingWentWrong"), nil)
+	}
+	return p, nil
+}
+
+// validateConfig validates the configuration of a provider.
+func (m *Manager) validateConfig(provider ProviderType, cfg models.ProviderConfig) error {
+	switch provider {
+	case ProviderOpenAI, ProviderAnthropic:
+	case ProviderOpenAICompatible:
+		if !httputil.IsValidHTTPURL(cfg.BaseURL) {
+			return envelope.NewError(envelope.InputError, m.i18n.T("validation.invalidUrl"), nil)
+		}
+		if cfg.Model == "" {
+			return envelope.NewError(envelope.InputError, m.i18n.Ts("globals.messages.empty", "name", "`model`"), nil)
+		}
+	default:
+		m.lo.Error("unsupported provider type", "provider", provider)
+		return envelope.NewError(envelope.InputError, m.i18n.T("validation.invalidProvider"), nil)
+	}
+	if cfg.Timeout != "" {
+		if d, err := time.ParseDuration(cfg.Timeout); err != nil || d <= 0 {
+			return envelope.NewError(envelope.InputError, m.i18n.Ts("validation.invalidDuration", "name", "`timeout`"), nil)
+		}
+	}
+	return nil
+}
+
+// decryptConfig parses the stored provider config and decrypts its API key.
+func (m *Manager) decryptConfig(p models.Provider) (models.ProviderConfig, error) {
+	var cfg models.ProviderConfig
+	if err := json.Unmarshal([]byte(p.Config), &cfg); err != nil {
+		m.lo.Error("error parsing provider config", "error", err)
+		return cfg, envelope.NewError(envelope.GeneralError, m.i18n.T("globals.messages.somethingWentWrong"), nil)
+	}
+	if cfg.APIKey == "" {
+		return cfg, nil
+	}
+	// Decrypt API key.
+	decryptedKey, err := crypto.Decrypt(cfg.APIKey, m.encryptionKey)
+	if err != nil {
+		m.lo.Error("error decrypting API key", "error", err)
+		return cfg, envelope.NewError(envelope.GeneralError, m.i18n.T("globals.messages.somethingWentWrong"), nil)
+	}
+	cfg.APIKey = decryptedKey
+	return cfg, nil
+}
+
+// newProviderClient returns a ProviderClient for the provider with the given decrypted configuration.
+func (m *Manager) newProviderClient(provider ProviderType, cfg models.ProviderConfig) (ProviderClient, error) {
+	opts := ClientOpts{
+		APIKey:  cfg.APIKey,
+		BaseURL: cfg.BaseURL,
+		Model:   cfg.Model,
+	}
+	if cfg.Timeout != "" {
+		opts.Timeout, _ = time.ParseDuration(cfg.Timeout)
+	}
+
+	switch provider {
+	case ProviderOpenAI:
+		return NewOpenAIClient(opts, m.lo), nil
+	case ProviderAnthropic:
+		return NewAnthropicClient(opts, m.lo), nil
+	case ProviderOpenAICompatible:
+		return NewOpenAICompatibleClient(opts, m.lo), nil
+	default:
+		m.lo.Error("unsupported provider type", "provider", provider)
+		return nil, envelope.NewError(envelope.GeneralError, m.i18n.T("validation.invalidProvider"), nil)
+	}
+}
+
+// providerError converts an error returned by a provider client to an envelope error.
+func (m *Manager) providerError(provider ProviderType, err error) error {
+	if errors.Is(err, ErrInvalidAPIKey) {
+		m.lo.Error("error invalid API key", "provider", provider, "error", err)
+		return envelope.NewError(envelope.InputError, m.i18n.Ts("ai.invalidAPIKey", "provider", providerDisplayName(provider)), nil)
+	}
+	if errors.Is(err, ErrApiKeyNotSet) {
+		m.lo.Error("error API key not set", "provider", provider, "error", err)
+		return envelope.NewError(envelope.InputError, m.i18n.Ts("ai.apiKeyNotSet", "provider", providerDisplayName(provider)), nil)
+	}
+	m.lo.Error("error sending prompt to provider", "provider", provider, "error", err)
+	return envelope.NewError(envelope.GeneralError, err.Error(), nil)
+}
+
 // getPrompt returns a prompt from the database.
 func (m *Manager) getPrompt(k string) (string, error) {
 	var p models.Prompt
@@ -148,32 +354,33 @@ func (m *Manager) getPrompt(k string) (string, error) {
 }
 
 // getDefaultProviderClient returns a ProviderClient for the default provider.
-func (m *Manager) getDefaultProviderClient() (ProviderClient, error) {
+func (m *Manager) getDefaultProviderClient() (ProviderType, ProviderClient, error) {
 	var p models.Provider
 
 	if err := m.q.GetDefaultProvider.Get(&p); err != nil {
 		m.lo.Error("error fetching provider details", "error", err)
-		return nil, envelope.NewError(envelope.GeneralError, m.i18n.T("globals.messages.somethingWentWrong"), nil)
+		return "", nil, envelope.NewError(envelope.GeneralError, m.i18n.T("globals.messages.somethingWentWrong"), nil)
 	}
 
-	switch ProviderType(p.Provider) {
-	case ProviderOpenAI:
-		config := struct {
-			APIKey string `json:"api_key"`
-		}{}
-		if err := json.Unmarshal([]byte(p.Config), &config); err != nil {
-			m.lo.Error("error parsing provider config", "error", err)
-			return nil, envelope.NewError(envelope.GeneralError, m.i18n.T("globals.messages.somethingWentWrong"), nil)
-		}
-		// Decrypt API key.
-		decryptedKey, err := crypto.Decrypt(config.APIKey, m.encryptionKey)
-		if err != nil {
-			m.lo.Error("error decrypting API key", "error", err)
-			return nil, envelope.NewError(envelope.GeneralError, m.i18n.T("globals.messages.somethingWentWrong"), nil)
-		}
-		return NewOpenAIClient(decryptedKey, m.lo), nil
+	cfg, err := m.decryptConfig(p)
+	if err != nil {
+		return "", nil, err
+	}
+	client, err := m.newProviderClient(ProviderType(p.Provider), cfg)
+	if err != nil {
+		return "", nil, err
+	}
+	return ProviderType(p.Provider), client, nil
+}
+
+// providerDisplayName returns the human readable name of a provider.
+func providerDisplayName(provider ProviderType) string {
+	switch provider {
+	case ProviderAnthropic:
+		return "Anthropic"
+	case ProviderOpenAICompatible:
+		return "OpenAI-compatible"
 	default:
-		m.lo.Error("unsupported provider type", "provider", p.Provider)
-		return nil, envelope.NewError(envelope.GeneralError, m.i18n.T("validation.invalidProvider"), nil)
+		return "OpenAI"
 	}
 }
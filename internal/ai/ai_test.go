@@ -0,0 +1,50 @@
+package ai
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/abhinavxd/libredesk/internal/ai/models"
+	"github.com/abhinavxd/libredesk/internal/stringutil"
+)
+
+func TestMergeProviderConfig(t *testing.T) {
+	var (
+		masked = strings.Repeat(stringutil.PasswordDummy, 10)
+		stored = models.ProviderConfig{APIKey: "sk-stored", Model: "gpt-4o", BaseURL: "http://localhost:11434/v1", Timeout: "30s"}
+	)
+
+	tests := []struct {
+		name string
+		cfg  models.ProviderConfig
+		want models.ProviderConfig
+	}{
+		{
+			name: "masked key keeps the stored key",
+			cfg:  models.ProviderConfig{APIKey: masked, Model: "gpt-4o-mini", BaseURL: stored.BaseURL, Timeout: "10s"},
+			want: models.ProviderConfig{APIKey: "sk-stored", Model: "gpt-4o-mini", BaseURL: stored.BaseURL, Timeout: "10s"},
+		},
+		{
+			name: "new key",
+			cfg:  models.ProviderConfig{APIKey: "sk-new", Model: "gpt-4o"},
+			want: models.ProviderConfig{APIKey: "sk-new", Model: "gpt-4o"},
+		},
+		{
+			name: "empty fields are cleared",
+			cfg:  models.ProviderConfig{APIKey: masked},
+			want: models.ProviderConfig{APIKey: "sk-stored"},
+		},
+		{
+			name: "empty key is cleared",
+			cfg:  models.ProviderConfig{Model: "llama3", BaseURL: stored.BaseURL},
+			want: models.ProviderConfig{Model: "llama3", BaseURL: stored.BaseURL},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := mergeProviderConfig(stored, tt.cfg); got != tt.want {
+				t.Errorf("mergeProviderConfig() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,107 @@
+package ai
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/valyala/fasthttp"
+	"github.com/zerodha/logf"
+)
+
+const (
+	anthropicBaseURL    = "https://api.anthropic.com/v1"
+	anthropicAPIVersion = "2023-06-01"
+)
+
+// AnthropicClient talks to the Anthropic messages API.
+type AnthropicClient struct {
+	apikey string
+	model  string
+	lo     *logf.Logger
+	client *http.Client
+}
+
+// NewAnthropicClient returns a client for the Anthropic API.
+func NewAnthropicClient(opts ClientOpts, lo *logf.Logger) *AnthropicClient {
+	return &AnthropicClient{
+		apikey: opts.APIKey,
+		model:  orDefault(opts.Model, defaultAnthropicModel),
+		lo:     lo,
+		client: &http.Client{Timeout: orDefaultTimeout(opts.Timeout)},
+	}
+}
+
+// SendPrompt sends a prompt to the Anthropic API and returns the response text.
+func (a *AnthropicClient) SendPrompt(payload PromptPayload) (string, error) {
+	if a.apikey == "" {
+		return "", ErrApiKeyNotSet
+	}
+
+	requestBody := map[string]interface{}{
+		"model":  a.model,
+		"system": payload.SystemPrompt,
+		"messages": []map[string]string{
+			{"role": "user", "content": payload.UserPrompt},
+		},
+		"max_tokens":  defaultMaxTokens,
+		"temperature": 0.7,
+	}
+
+	bodyBytes, err := json.Marshal(requestBody)
+	if err != nil {
+		a.lo.Error("error marshalling request body", "error", err)
+		return "", fmt.Errorf("marshalling request body: %w", err)
+	}
+
+	req, err := http.NewRequest(fasthttp.MethodPost, anthropicBaseURL+"/messages", bytes.NewBuffer(bodyBytes))
+	if err != nil {
+		a.lo.Error("error creating request", "error", err)
+		return "", fmt.Errorf("error creating request: %w", err)
+	}
+
+	req.Header.Set("x-api-key", a.apikey)
+	req.Header.Set("anthropic-version", anthropicAPIVersion)
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := a.client.Do(req)
+	if err != nil {
+		a.lo.Error("error making HTTP request", "error", err)
+		return "", fmt.Errorf("making HTTP request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusUnauthorized {
+		return "", ErrInvalidAPIKey
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		a.lo.Error("non-ok response received from anthropic API", "status", resp.Status, "code", resp.StatusCode, "response_text", body)
+		return "", fmt.Errorf("API error: %s, body: %s", resp.Status, body)
+	}
+
+	var responseBody struct {
+		Content []struct {
+			Type string `json:"type"`
+			Text string `json:"text"`
+		} `json:"content"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&responseBody); err != nil {
+		return "", fmt.Errorf("decoding response body: %w", err)
+	}
+
+	var text strings.Builder
+	for _, block := range responseBody.Content {
+		if block.Type == "text" {
+			text.WriteString(block.Text)
+		}
+	}
+	if text.Len() > 0 {
+		return text.String(), nil
+	}
+	return "", fmt.Errorf("no response found")
+}
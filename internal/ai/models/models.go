@@ -3,7 +3,7 @@ package models
 import "time"
 
 type Provider struct {
-	ID        string    `db:"id"`
+	ID        int       `db:"id"`
 	CreatedAt time.Time `db:"created_at"`
 	UpdatedAt time.Time `db:"updated_at"`
 	Name      string    `db:"name"`
@@ -12,6 +12,27 @@ type Provider struct {
 	IsDefault bool      `db:"is_default"`
 }
 
+// ProviderConfig is the configuration stored for a provider, the API key is stored encrypted.
+type ProviderConfig struct {
+	APIKey  string `json:"api_key"`
+	Model   string `json:"model"`
+	BaseURL string `json:"base_url"`
+	Timeout string `json:"timeout"`
+}
+
+// ProviderSettings is a provider as returned by the API, with its API key masked.
+type ProviderSettings struct {
+	ID        int    `json:"id"`
+	Name      string `json:"name"`
+	Provider  string `json:"provider"`
+	IsDefault bool   `json:"is_default"`
+	Model     string `json:"model"`
+	BaseURL   string `json:"base_url"`
+	Timeout   string `json:"timeout"`
+	APIKey    string `json:"api_key"`
+	HasAPIKey bool   `json:"has_api_key"`
+}
+
 type Prompt struct {
 	ID        int       `db:"id" json:"id"`
 	CreatedAt time.Time `db:"created_at" json:"created_at"`
@@ -20,3 +41,30 @@ type Prompt struct {
 	Key       string    `db:"key" json:"key"`
 	Content   string    `db:"content" json:"content,omitempty"`
 }
+
+// ConversationContext is the conversation context sent to the provider to draft replies, summarize and classify conversations.
+type ConversationContext struct {
+	Subject                string
+	ContactName            string
+	ContactEmail           string
+	ContactAttributes      map[string]any
+	ConversationAttributes map[string]any
+	// Messages are ordered oldest first.
+	Messages []ContextMessage
+	// Articles are knowledge base articles relevant to the conversation that replies can cite.
+	Articles []ContextArticle
+}
+
+// ContextMessage is a single conversation message in the conversation context.
+type ContextMessage struct {
+	FromContact bool
+	AuthorName  string
+	Content     string
+}
+
+// ContextArticle is a knowledge base article in the conversation context.
+type ContextArticle struct {
+	Title   string
+	URL     string
+	Content string
+}
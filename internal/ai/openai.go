@@ -6,40 +6,64 @@ import (
 	"fmt"
 	"io"
 	"net/http"
-	"time"
+	"strings"
 
 	"github.com/valyala/fasthttp"
 	"github.com/zerodha/logf"
 )
 
+const (
+	openAIBaseURL = "https://api.openai.com/v1"
+)
+
+// OpenAIClient talks to the OpenAI chat completions API or any server implementing it.
 type OpenAIClient struct {
-	apikey string
-	lo     *logf.Logger
-	client *http.Client
+	apikey  string
+	baseURL string
+	model   string
+	// requireKey is false for OpenAI-compatible servers, which often run without authentication.
+	requireKey bool
+	lo         *logf.Logger
+	client     *http.Client
 }
 
-func NewOpenAIClient(apiKey string, lo *logf.Logger) *OpenAIClient {
+// NewOpenAIClient returns a client for the OpenAI API.
+func NewOpenAIClient(opts ClientOpts, lo *logf.Logger) *OpenAIClient {
 	return &OpenAIClient{
-		apikey: apiKey,
-		lo:     lo,
-		client: &http.Client{Timeout: 10 * time.Second},
+		apikey:     opts.APIKey,
+		baseURL:    openAIBaseURL,
+		model:      orDefault(opts.Model, defaultOpenAIModel),
+		requireKey: true,
+		lo:         lo,
+		client:     &http.Client{Timeout: orDefaultTimeout(opts.Timeout)},
+	}
+}
+
+// NewOpenAICompatibleClient returns a client for a server exposing an OpenAI-compatible API at the base URL.
+func NewOpenAICompatibleClient(opts ClientOpts, lo *logf.Logger) *OpenAIClient {
+	return &OpenAIClient{
+		apikey:  opts.APIKey,
+		baseURL: strings.TrimRight(opts.BaseURL, "/"),
+		model:   opts.Model,
+		lo:      lo,
+		client:  &http.Client{Timeout: orDefaultTimeout(opts.Timeout)},
 	}
 }
 
 // SendPrompt sends a prompt to the OpenAI API and returns the response text.
 func (o *OpenAIClient) SendPrompt(payload PromptPayload) (string, error) {
-	if o.apikey == "" {
+	if o.requireKey && o.apikey == "" {
 		return "", ErrApiKeyNotSet
 	}
 
-	apiURL := "https://api.openai.com/v1/chat/completions"
+	apiURL := o.baseURL + "/chat/completions"
 	requestBody := map[string]interface{}{
-		"model": "gpt-4o-mini",
+		"model": o.model,
 		"messages": []map[string]string{
 			{"role": "system", "content": payload.SystemPrompt},
 			{"role": "user", "content": payload.UserPrompt},
 		},
-		"max_tokens":  1024,
+		"max_tokens":  defaultMaxTokens,
 		"temperature": 0.7,
 	}
 
@@ -55,7 +79,9 @@ func (o *OpenAIClient) SendPrompt(payload PromptPayload) (string, error) {
 		return "", fmt.Errorf("error creating request: %w", err)
 	}
 
-	req.Header.Set("Authorization", "Bearer "+o.apikey)
+	if o.apikey != "" {
+		req.Header.Set("Authorization", "Bearer "+o.apikey)
+	}
 	req.Header.Set("Content-Type", "application/json")
 
 	resp, err := o.client.Do(req)
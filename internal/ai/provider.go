@@ -1,5 +1,7 @@
 package ai
 
+import "time"
+
 // ProviderClient is the interface all providers should implement.
 type ProviderClient interface {
 	SendPrompt(payload PromptPayload) (string, error)
@@ -9,8 +11,16 @@ type ProviderClient interface {
 type ProviderType string
 
 const (
-	ProviderOpenAI ProviderType = "openai"
-	ProviderClaude ProviderType = "claude"
+	ProviderOpenAI           ProviderType = "openai"
+	ProviderAnthropic        ProviderType = "anthropic"
+	ProviderOpenAICompatible ProviderType = "openai_compatible"
+)
+
+const (
+	defaultOpenAIModel    = "gpt-4o-mini"
+	defaultAnthropicModel = "claude-3-5-haiku-latest"
+	defaultTimeout        = 30 * time.Second
+	defaultMaxTokens      = 1024
 )
 
 // PromptPayload represents the structured input for an LLM provider.
@@ -18,3 +28,27 @@ type PromptPayload struct {
 	SystemPrompt string `json:"system_prompt"`
 	UserPrompt   string `json:"user_prompt"`
 }
+
+// ClientOpts contains the options shared by all provider clients.
+type ClientOpts struct {
+	APIKey  string
+	BaseURL string
+	Model   string
+	Timeout time.Duration
+}
+
+// orDefault returns v if it is not empty, def otherwise.
+func orDefault(v, def string) string {
+	if v == "" {
+		return def
+	}
+	return v
+}
+
+// orDefaultTimeout returns d if it is positive, the default timeout otherwise.
+func orDefaultTimeout(d time.Duration) time.Duration {
+	if d <= 0 {
+		return defaultTimeout
+	}
+	return d
+}
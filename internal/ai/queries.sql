@@ -1,17 +1,26 @@
 -- name: get-default-provider
 SELECT id, name, provider, config, is_default FROM ai_providers where is_default is true;
 
+-- name: get-provider
+SELECT id, created_at, updated_at, name, provider, config, is_default FROM ai_providers WHERE provider = $1;
+
+-- name: get-providers
+SELECT id, created_at, updated_at, name, provider, config, is_default FROM ai_providers ORDER BY id;
+
 -- name: get-prompt
 SELECT id, created_at, updated_at, key, title, content FROM ai_prompts where key = $1;
 
 -- name: get-prompts
 SELECT id, created_at, updated_at, key, title FROM ai_prompts order by title;
 
--- name: set-openai-key
-UPDATE ai_providers 
-SET config = jsonb_set(
-    COALESCE(config, '{}'::jsonb),
-    '{api_key}', 
-    to_jsonb($1::text)
-) 
-WHERE provider = 'openai';
+-- name: update-provider-config
+UPDATE ai_providers
+SET config = $2,
+    updated_at = NOW()
+WHERE provider = $1;
+
+-- name: unset-default-provider
+UPDATE ai_providers SET is_default = FALSE, updated_at = NOW() WHERE is_default = TRUE AND provider != $1;
+
+-- name: set-default-provider
+UPDATE ai_providers SET is_default = TRUE, updated_at = NOW() WHERE provider = $1;
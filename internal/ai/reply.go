@@ -0,0 +1,144 @@
+package ai
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/abhinavxd/libredesk/internal/ai/models"
+	"github.com/abhinavxd/libredesk/internal/envelope"
+)
+
+const (
+	// Maximum number of tokens of conversation context sent to the provider.
+	contextTokenBudget = 3000
+
+	// Maximum number of tokens of a single knowledge base article included in the context.
+	articleTokenBudget = 400
+
+	// Rough number of characters per token, used to estimate token counts without a tokenizer.
+	charsPerToken = 4
+
+	replySystemPrompt = `You are a customer support agent drafting the next reply in a support conversation.
+Use the conversation history and the contact details to write a helpful, accurate and concise reply to the customer.
+Reply in the language used by the customer. Do not invent facts, order numbers, links or policies that are not in the conversation.
+If knowledge base articles are provided, prefer them as the source of truth and cite the URL of every article you rely on.
+Return only the body of the reply, without a subject line, greeting placeholders or signature.`
+)
+
+// SuggestReply drafts a reply for a conversation using the default provider.
+func (m *Manager) SuggestReply(rc models.ConversationContext) (string, error) {
+	if len(rc.Messages) == 0 {
+		return "", envelope.NewError(envelope.InputError, m.i18n.T("ai.noMessagesToReply"), nil)
+	}
+	return m.send(PromptPayload{
+		SystemPrompt: replySystemPrompt,
+		UserPrompt:   buildContextPrompt(rc, "Draft the next reply from the agent to the customer.", contextTokenBudget),
+	})
+}
+
+// buildContextPrompt builds a user prompt from the conversation context followed by the instruction, keeping
+// the most recent messages that fit in the token budget. The newest message is truncated if it alone exceeds the budget.
+func buildContextPrompt(rc models.ConversationContext, instruction string, budget int) string {
+	var header strings.Builder
+	if rc.Subject != "" {
+		fmt.Fprintf(&header, "Subject: %s\n", rc.Subject)
+	}
+	if rc.ContactName != "" || rc.ContactEmail != "" {
+		header.WriteString("Contact: " + strings.TrimSpace(rc.ContactName))
+		if rc.ContactEmail != "" {
+			fmt.Fprintf(&header, " <%s>", rc.ContactEmail)
+		}
+		header.WriteString("\n")
+	}
+	writeAttributes(&header, "Contact attributes", rc.ContactAttributes)
+	writeAttributes(&header, "Conversation attributes", rc.ConversationAttributes)
+	writeArticles(&header, rc.Articles)
+
+	header.WriteString("\nConversation (oldest first):\n")
+
+	footer := "\n" + instruction
+	remaining := budget - estimateTokens(header.String()) - estimateTokens(footer)
+
+	// Walk backwards from the newest message and keep as many as fit.
+	lines := make([]string, 0, len(rc.Messages))
+	for i := len(rc.Messages) - 1; i >= 0; i-- {
+		line := formatContextMessage(rc.Messages[i])
+		tokens := estimateTokens(line)
+		if tokens > remaining {
+			if len(lines) == 0 && remaining > 0 {
+				lines = append(lines, truncateToTokens(line, remaining))
+			}
+			break
+		}
+		remaining -= tokens
+		lines = append(lines, line)
+	}
+
+	var b strings.Builder
+	b.WriteString(header.String())
+	for i := len(lines) - 1; i >= 0; i-- {
+		b.WriteString(lines[i])
+	}
+	b.WriteString(footer)
+	return b.String()
+}
+
+// writeAttributes writes non-empty attributes as a sorted list under the given title.
+func writeAttributes(b *strings.Builder, title string, attrs map[string]any) {
+	keys := make([]string, 0, len(attrs))
+	for k, v := range attrs {
+		if v == nil || v == "" {
+			continue
+		}
+		keys = append(keys, k)
+	}
+	if len(keys) == 0 {
+		return
+	}
+	sort.Strings(keys)
+	b.WriteString(title + ":\n")
+	for _, k := range keys {
+		fmt.Fprintf(b, "- %s: %v\n", k, attrs[k])
+	}
+}
+
+// writeArticles writes knowledge base articles with their URLs, truncating long articles.
+func writeArticles(b *strings.Builder, articles []models.ContextArticle) {
+	if len(articles) == 0 {
+		return
+	}
+	b.WriteString("\nKnowledge base articles:\n")
+	for _, a := range articles {
+		fmt.Fprintf(b, "- %s (%s)\n", a.Title, a.URL)
+		if content := strings.TrimSpace(a.Content); content != "" {
+			b.WriteString(truncateToTokens(content+"\n", articleTokenBudget))
+		}
+	}
+}
+
+// formatContextMessage formats a message as a transcript line.
+func formatContextMessage(msg models.ContextMessage) string {
+	role := "Agent"
+	if msg.FromContact {
+		role = "Customer"
+	}
+	if msg.AuthorName != "" {
+		role += " (" + msg.AuthorName + ")"
+	}
+	return role + ": " + strings.TrimSpace(msg.Content) + "\n"
+}
+
+// estimateTokens returns an estimate of the number of tokens in s.
+func estimateTokens(s string) int {
+	return (len([]rune(s)) + charsPerToken - 1) / charsPerToken
+}
+
+// truncateToTokens truncates s to roughly the given number of tokens.
+func truncateToTokens(s string, tokens int) string {
+	r := []rune(s)
+	if n := tokens * charsPerToken; len(r) > n {
+		return string(r[:n]) + "\n"
+	}
+	return s
+}
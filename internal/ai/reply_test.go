@@ -0,0 +1,92 @@
+package ai
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/abhinavxd/libredesk/internal/ai/models"
+)
+
+func TestBuildContextPrompt(t *testing.T) {
+	rc := models.ConversationContext{
+		Subject:           "Refund request",
+		ContactName:       "Jane Doe",
+		ContactEmail:      "jane@example.com",
+		ContactAttributes: map[string]any{"plan": "pro", "empty": ""},
+		Messages: []models.ContextMessage{
+			{FromContact: true, AuthorName: "Jane Doe", Content: "first " + strings.Repeat("a", 400)},
+			{FromContact: false, AuthorName: "Agent Smith", Content: "middle"},
+			{FromContact: true, AuthorName: "Jane Doe", Content: "newest"},
+		},
+	}
+
+	t.Run("fits budget", func(t *testing.T) {
+		got := buildContextPrompt(rc, "Reply.", 1000)
+		for _, want := range []string{"Subject: Refund request", "Contact: Jane Doe <jane@example.com>", "- plan: pro", "first", "Agent (Agent Smith): middle", "Customer (Jane Doe): newest"} {
+			if !strings.Contains(got, want) {
+				t.Errorf("prompt missing %q:\n%s", want, got)
+			}
+		}
+		if strings.Contains(got, "empty") {
+			t.Errorf("prompt contains empty attribute:\n%s", got)
+		}
+		if strings.Index(got, "first ") > strings.Index(got, "newest") {
+			t.Errorf("messages not ordered oldest first:\n%s", got)
+		}
+	})
+
+	t.Run("drops oldest messages over budget", func(t *testing.T) {
+		got := buildContextPrompt(rc, "Reply.", 80)
+		if strings.Contains(got, "first ") {
+			t.Errorf("prompt should not contain oldest message:\n%s", got)
+		}
+		if !strings.Contains(got, "newest") || !strings.Contains(got, "middle") {
+			t.Errorf("prompt should contain recent messages:\n%s", got)
+		}
+	})
+
+	t.Run("truncates newest message", func(t *testing.T) {
+		long := models.ConversationContext{Messages: []models.ContextMessage{{FromContact: true, Content: strings.Repeat("b", 4000)}}}
+		got := buildContextPrompt(long, "Reply.", 100)
+		if estimateTokens(got) > 100 {
+			t.Errorf("prompt exceeds budget: %d tokens", estimateTokens(got))
+		}
+		if !strings.Contains(got, "Customer: bbb") {
+			t.Errorf("prompt should contain truncated message:\n%s", got)
+		}
+	})
+
+	t.Run("includes articles", func(t *testing.T) {
+		withArticles := rc
+		withArticles.Articles = []models.ContextArticle{{Title: "Refund policy", URL: "https://example.com/kb/1", Content: strings.Repeat("c", 4000)}}
+		got := buildContextPrompt(withArticles, "Reply.", 1000)
+		if !strings.Contains(got, "- Refund policy (https://example.com/kb/1)") {
+			t.Errorf("prompt missing article:\n%s", got)
+		}
+		if strings.Count(got, "c") > articleTokenBudget*charsPerToken+10 {
+			t.Errorf("article content not truncated:\n%s", got)
+		}
+		if !strings.Contains(got, "newest") {
+			t.Errorf("prompt should contain newest message:\n%s", got)
+		}
+	})
+}
+
+func TestMatchTag(t *testing.T) {
+	tags := []string{"Billing", "Bug report", "Feature request"}
+	tests := []struct {
+		resp string
+		want string
+	}{
+		{"Billing", "Billing"},
+		{" billing.\n", "Billing"},
+		{"\"Bug report\"", "Bug report"},
+		{"NONE", ""},
+		{"Billing and Bug report", ""},
+	}
+	for _, tt := range tests {
+		if got := matchTag(tt.resp, tags); got != tt.want {
+			t.Errorf("matchTag(%q) = %q, want %q", tt.resp, got, tt.want)
+		}
+	}
+}
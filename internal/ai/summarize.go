@@ -0,0 +1,57 @@
+package ai
+
+import (
+	"strings"
+
+	"github.com/abhinavxd/libredesk/internal/ai/models"
+	"github.com/abhinavxd/libredesk/internal/envelope"
+)
+
+const (
+	summarySystemPrompt = `You summarize customer support conversations for agents picking up a conversation.
+Write a short summary in plain text with three sections: "Issue", "What has been done" and "Next steps".
+Use short bullet points, mention relevant order numbers, dates and commitments made to the customer, and do not invent facts.`
+
+	classifySystemPrompt = `You classify customer support conversations into exactly one of the given tags.
+Reply with the tag name exactly as written in the list and nothing else.
+If none of the tags fit the conversation, reply with NONE.`
+)
+
+// Summarize summarizes a conversation using the default provider.
+func (m *Manager) Summarize(cc models.ConversationContext) (string, error) {
+	if len(cc.Messages) == 0 {
+		return "", envelope.NewError(envelope.InputError, m.i18n.T("ai.noMessagesToSummarize"), nil)
+	}
+	return m.send(PromptPayload{
+		SystemPrompt: summarySystemPrompt,
+		UserPrompt:   buildContextPrompt(cc, "Summarize this conversation.", contextTokenBudget),
+	})
+}
+
+// ClassifyTag asks the default provider to pick the tag that best fits the conversation.
+// An empty tag is returned if none of the tags fit.
+func (m *Manager) ClassifyTag(cc models.ConversationContext, tags []string) (string, error) {
+	if len(cc.Messages) == 0 || len(tags) == 0 {
+		return "", nil
+	}
+	resp, err := m.send(PromptPayload{
+		SystemPrompt: classifySystemPrompt,
+		UserPrompt:   buildContextPrompt(cc, "Tags:\n- "+strings.Join(tags, "\n- ")+"\n\nWhich tag fits this conversation best?", contextTokenBudget),
+	})
+	if err != nil {
+		return "", err
+	}
+	return matchTag(resp, tags), nil
+}
+
+// matchTag returns the tag from tags that the provider response names, ignoring case,
+// surrounding quotes and punctuation. An empty string is returned if there is no match.
+func matchTag(resp string, tags []string) string {
+	resp = strings.Trim(strings.TrimSpace(resp), "\"'`.-* ")
+	for _, tag := range tags {
+		if strings.EqualFold(resp, tag) {
+			return tag
+		}
+	}
+	return ""
+}
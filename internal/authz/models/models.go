@@ -52,6 +52,9 @@ const (
 	// Context Links
 	PermContextLinksManage = "context_links:manage"
 
+	// Knowledge base
+	PermKBManage = "kb:manage"
+
 	// Templates
 	PermTemplatesManage = "templates:manage"
 
@@ -81,6 +84,9 @@ const (
 	PermContactsRead    = "contacts:read"
 	PermContactsWrite   = "contacts:write"
 	PermContactsBlock   = "contacts:block"
+	PermContactsMerge   = "contacts:merge"
+	PermContactsExport  = "contacts:export"
+	PermContactsErase   = "contacts:erase"
 
 	// Contact Notes
 	PermContactNotesRead   = "contact_notes:read"
@@ -133,12 +139,16 @@ var validPermissions = map[string]struct{}{
 	PermContactsRead:                    {},
 	PermContactsWrite:                   {},
 	PermContactsBlock:                   {},
+	PermContactsMerge:                   {},
+	PermContactsExport:                  {},
+	PermContactsErase:                   {},
 	PermContactNotesRead:                {},
 	PermContactNotesWrite:               {},
 	PermContactNotesDelete:              {},
 	PermActivityLogsManage:              {},
 	PermWebhooksManage:                  {},
 	PermContextLinksManage:              {},
+	PermKBManage:                        {},
 }
 
 // PermissionExists returns true if the permission exists else false
@@ -1,34 +1,61 @@
-// Package autoassigner continuously assigns conversations at regular intervals to users.
+// Package autoassigner assigns conversations to team agents as soon as they are created or assigned to a team,
+// with a periodic sweep as a fallback for conversations that could not be assigned right away.
 package autoassigner
 
 import (
 	"context"
+	"embed"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"math/rand"
+	"slices"
 	"strconv"
+	"strings"
 	"sync"
 	"time"
 
-	"github.com/abhinavxd/libredesk/internal/conversation/models"
+	"github.com/abhinavxd/libredesk/internal/autoassigner/models"
+	cmodels "github.com/abhinavxd/libredesk/internal/conversation/models"
+	"github.com/abhinavxd/libredesk/internal/dbutil"
+	"github.com/abhinavxd/libredesk/internal/envelope"
 	tmodels "github.com/abhinavxd/libredesk/internal/team/models"
 	umodels "github.com/abhinavxd/libredesk/internal/user/models"
+	"github.com/jmoiron/sqlx"
+	"github.com/knadh/go-i18n"
 	"github.com/mr-karan/balance"
 	"github.com/zerodha/logf"
 )
 
 var (
+	//go:embed queries.sql
+	efs embed.FS
+
 	ErrTeamNotFound = errors.New("team not found")
 )
 
 const (
 	AssignmentTypeRoundRobin = "Round robin"
+	AssignmentTypeLeastBusy  = "Least busy"
+	AssignmentTypeSkillBased = "Skill based"
+	AssignmentTypeSticky     = "Sticky"
+)
+
+const (
+	// queueSize is the number of conversations that can wait for assignment, a sweep is requested once it is full.
+	queueSize = 1000
+	// teamReloadInterval is how long team members are reused when assigning queued conversations.
+	teamReloadInterval = 30 * time.Second
+	// decisionLogRetention is how long assignment decisions are kept.
+	decisionLogRetention = "30 days"
 )
 
 type conversationStore interface {
-	GetUnassignedConversations() ([]models.Conversation, error)
+	GetUnassignedConversations() ([]cmodels.Conversation, error)
+	GetUnassignedConversation(uuid string) (cmodels.Conversation, error)
 	UpdateConversationUserAssignee(conversationUUID string, userID int, user umodels.User) error
-	ActiveUserConversationsCount(userID int) (int, error)
+	ActiveUserConversationsByChannel(userID int) (map[string]int, error)
+	GetContactLastAssignee(contactID, excludeConversationID int) (int, error)
 }
 
 type teamStore interface {
@@ -36,65 +63,107 @@ type teamStore interface {
 	GetMembers(teamID int) ([]tmodels.TeamMember, error)
 }
 
+type queries struct {
+	InsertDecision     *sqlx.Stmt `query:"insert-decision"`
+	GetDecisions       *sqlx.Stmt `query:"get-decisions"`
+	DeleteOldDecisions *sqlx.Stmt `query:"delete-old-decisions"`
+}
+
+// Opts contains options for initializing the Engine.
+type Opts struct {
+	DB   *sqlx.DB
+	Lo   *logf.Logger
+	I18n *i18n.I18n
+}
+
+// queuedConversation is a conversation waiting to be assigned.
+type queuedConversation struct {
+	uuid    string
+	trigger string
+}
+
+// decision is the agent picked for a conversation and why every team member was or wasn't picked.
+type decision struct {
+	userID     int
+	fallback   string
+	candidates []models.Candidate
+}
+
 // Engine represents a manager for assigning unassigned conversations
-// to team agents in a round-robin pattern.
+// to team agents using the assignment strategy of the team.
 type Engine struct {
 	roundRobinBalancer map[int]*balance.Balance
-	// Mutex to protect the balancer map
+	// Mutex to protect the balancer and team maps
 	balanceMu              sync.Mutex
 	teamMaxAutoAssignments map[int]int
+	teamAssignmentTypes    map[int]string
+	teamMembers            map[int][]tmodels.TeamMember
+	lastReload             time.Time
 
+	queue   chan queuedConversation
+	sweepCh chan string
+
+	q                 queries
 	systemUser        umodels.User
 	conversationStore conversationStore
 	teamStore         teamStore
 	lo                *logf.Logger
+	i18n              *i18n.I18n
 	closed            bool
 	closedMu          sync.Mutex
 	wg                sync.WaitGroup
 }
 
 // New initializes a new Engine instance, set up with the provided team manager,
-// conversation manager, and logger.
-func New(teamStore teamStore, conversationStore conversationStore, systemUser umodels.User, lo *logf.Logger) (*Engine, error) {
+// conversation manager, and system user that assignments are made as.
+func New(teamStore teamStore, conversationStore conversationStore, systemUser umodels.User, opts Opts) (*Engine, error) {
+	var q queries
+	if err := dbutil.ScanSQLFile("queries.sql", &q, opts.DB, efs); err != nil {
+		return nil, err
+	}
 	var e = Engine{
+		q:                      q,
 		conversationStore:      conversationStore,
 		teamStore:              teamStore,
 		systemUser:             systemUser,
-		lo:                     lo,
+		lo:                     opts.Lo,
+		i18n:                   opts.I18n,
 		teamMaxAutoAssignments: make(map[int]int),
+		teamAssignmentTypes:    make(map[int]string),
+		teamMembers:            make(map[int][]tmodels.TeamMember),
 		roundRobinBalancer:     make(map[int]*balance.Balance),
+		queue:                  make(chan queuedConversation, queueSize),
+		sweepCh:                make(chan string, 1),
 	}
 	return &e, nil
 }
 
-// Run initiates the conversation assignment process and is to be invoked as a goroutine.
-// This function continuously assigns unassigned conversations to agents at regular intervals.
-func (e *Engine) Run(ctx context.Context, autoAssignInterval time.Duration) {
-	ticker := time.NewTicker(autoAssignInterval)
+// Run assigns queued conversations as they come in and is to be invoked as a goroutine.
+// All unassigned conversations are swept at start and then at every sweep interval as a fallback.
+func (e *Engine) Run(ctx context.Context, sweepInterval time.Duration) {
+	ticker := time.NewTicker(sweepInterval)
 	defer ticker.Stop()
 
 	e.wg.Add(1)
 	defer e.wg.Done()
 
+	// Assign conversations that came in while the app was not running.
+	e.sweep(models.TriggerSweep)
+
 	for {
+		if e.isClosed() {
+			return
+		}
 		select {
 		case <-ctx.Done():
 			return
 		case <-ticker.C:
-			e.closedMu.Lock()
-			closed := e.closed
-			e.closedMu.Unlock()
-			if closed {
-				return
-			}
-			// Reload the balancer with latest team and user data.
-			if err := e.reloadBalancer(); err != nil {
-				e.lo.Error("error reloading balancer", "error", err)
-			}
-			// Start assigning conversations.
-			if err := e.assignConversations(); err != nil {
-				e.lo.Error("error assigning conversations", "error", err)
-			}
+			e.sweep(models.TriggerSweep)
+			e.pruneDecisions()
+		case trigger := <-e.sweepCh:
+			e.sweep(trigger)
+		case c := <-e.queue:
+			e.assignQueued(c)
 		}
 	}
 }
@@ -111,7 +180,106 @@ func (e *Engine) Close() {
 	e.wg.Wait()
 }
 
-// reloadBalancer updates the round-robin balancer with the latest user and team data.
+// ConversationCreated queues a new conversation for assignment.
+func (e *Engine) ConversationCreated(uuid string) {
+	e.enqueue(uuid, models.TriggerConversationCreated)
+}
+
+// ConversationTeamAssigned queues a conversation that was assigned to a team for assignment.
+func (e *Engine) ConversationTeamAssigned(uuid string) {
+	e.enqueue(uuid, models.TriggerTeamAssigned)
+}
+
+// AgentAvailabilityChanged refreshes team members after an agent's availability changes,
+// and sweeps waiting conversations when the agent can take conversations again.
+func (e *Engine) AgentAvailabilityChanged(userID int, status string) {
+	if isAway(status) {
+		// Reload team members before the next assignment so the agent is no longer picked.
+		e.balanceMu.Lock()
+		e.lastReload = time.Time{}
+		e.balanceMu.Unlock()
+		return
+	}
+	e.lo.Debug("agent available, requesting auto assignment sweep", "user_id", userID, "availability_status", status)
+	e.requestSweep(models.TriggerAgentAvailable)
+}
+
+// AgentShiftChanged sweeps waiting conversations after an agent's shift starts or ends,
+// the sweep reloads team members so that agents off shift are no longer picked.
+func (e *Engine) AgentShiftChanged(userID int, onDuty bool) {
+	e.lo.Debug("agent shift changed, requesting auto assignment sweep", "user_id", userID, "on_duty", onDuty)
+	e.requestSweep(models.TriggerShiftChanged)
+}
+
+// GetDecisions returns the latest assignment decisions for a conversation, latest first.
+func (e *Engine) GetDecisions(conversationID int) ([]models.Decision, error) {
+	var decisions = make([]models.Decision, 0)
+	if err := e.q.GetDecisions.Select(&decisions, conversationID); err != nil {
+		e.lo.Error("error fetching assignment decisions", "conversation_id", conversationID, "error", err)
+		return decisions, envelope.NewError(envelope.GeneralError, e.i18n.T("globals.messages.somethingWentWrong"), nil)
+	}
+	return decisions, nil
+}
+
+// enqueue queues a conversation for assignment, a sweep is requested instead if the queue is full.
+func (e *Engine) enqueue(uuid, trigger string) {
+	select {
+	case e.queue <- queuedConversation{uuid: uuid, trigger: trigger}:
+	default:
+		e.lo.Warn("auto assignment queue is full, requesting sweep", "conversation_uuid", uuid)
+		e.requestSweep(trigger)
+	}
+}
+
+// requestSweep requests a sweep of all unassigned conversations, requests made while one is pending are dropped.
+func (e *Engine) requestSweep(trigger string) {
+	select {
+	case e.sweepCh <- trigger:
+	default:
+	}
+}
+
+func (e *Engine) isClosed() bool {
+	e.closedMu.Lock()
+	defer e.closedMu.Unlock()
+	return e.closed
+}
+
+// sweep reloads teams and assigns all unassigned conversations.
+func (e *Engine) sweep(trigger string) {
+	// Reload the balancer with latest team and user data.
+	if err := e.reloadBalancer(); err != nil {
+		e.lo.Error("error reloading balancer", "error", err)
+	}
+	// Start assigning conversations.
+	if err := e.assignConversations(trigger); err != nil {
+		e.lo.Error("error assigning conversations", "error", err)
+	}
+}
+
+// assignQueued assigns a queued conversation if it is still waiting for an agent.
+func (e *Engine) assignQueued(c queuedConversation) {
+	e.balanceMu.Lock()
+	stale := time.Since(e.lastReload) > teamReloadInterval
+	e.balanceMu.Unlock()
+	if stale {
+		if err := e.reloadBalancer(); err != nil {
+			e.lo.Error("error reloading balancer", "error", err)
+		}
+	}
+
+	conversation, err := e.conversationStore.GetUnassignedConversation(c.uuid)
+	if err != nil {
+		return
+	}
+	// Already assigned or not assigned to a team.
+	if conversation.ID == 0 {
+		return
+	}
+	e.assign(conversation, c.trigger, make(map[int]*agentLoad))
+}
+
+// reloadBalancer updates the balancers and team members with the latest user and team data.
 func (e *Engine) reloadBalancer() error {
 	e.balanceMu.Lock()
 	defer e.balanceMu.Unlock()
@@ -121,18 +289,26 @@ func (e *Engine) reloadBalancer() error {
 		e.lo.Error("error updating team balancer pool", "error", err)
 		return err
 	}
+	e.lastReload = time.Now()
 	return nil
 }
 
-// populateTeamBalancer populates the team balancer pool with the team members.
+// populateTeamBalancer populates the team balancer pool and members of teams with automatic assignment.
+// Every strategy keeps a round-robin balancer as sticky assignment falls back to it.
 func (e *Engine) populateTeamBalancer() error {
 	teams, err := e.teamStore.GetAll()
 	if err != nil {
 		return err
 	}
 
+	var (
+		assignmentTypes = make(map[int]string)
+		teamMembers     = make(map[int][]tmodels.TeamMember)
+	)
 	for _, team := range teams {
-		if team.ConversationAssignmentType != AssignmentTypeRoundRobin {
+		switch team.ConversationAssignmentType {
+		case AssignmentTypeRoundRobin, AssignmentTypeLeastBusy, AssignmentTypeSkillBased, AssignmentTypeSticky:
+		default:
 			continue
 		}
 
@@ -157,10 +333,14 @@ func (e *Engine) populateTeamBalancer() error {
 		existingUsers := make(map[string]struct{})
 		for _, user := range users {
 			// Skip user if availability status is `away_manual` or `away_and_reassigning`
-			if user.AvailabilityStatus == umodels.AwayManual || user.AvailabilityStatus == umodels.AwayAndReassigning {
+			if isAway(user.AvailabilityStatus) {
 				e.lo.Debug("user is away, skipping autoasssignment ", "team_id", team.ID, "user_id", user.ID, "availability_status", user.AvailabilityStatus)
 				continue
 			}
+			if user.OffShift {
+				e.lo.Debug("user is off shift, skipping autoassignment", "team_id", team.ID, "user_id", user.ID)
+				continue
+			}
 
 			// Add user to the balancer pool
 			uid := strconv.Itoa(user.ID)
@@ -187,13 +367,19 @@ func (e *Engine) populateTeamBalancer() error {
 
 		// Set max auto assigned conversations for the team
 		e.teamMaxAutoAssignments[team.ID] = team.MaxAutoAssignedConversations
+		assignmentTypes[team.ID] = team.ConversationAssignmentType
+		teamMembers[team.ID] = users
 	}
+
+	// Replace the team maps so teams switched to manual assignment are dropped.
+	e.teamAssignmentTypes = assignmentTypes
+	e.teamMembers = teamMembers
 	return nil
 }
 
 // assignConversations function fetches conversations that have been assigned to teams but not to any individual user,
-// and then proceeds to assign them to team members based on a round-robin strategy.
-func (e *Engine) assignConversations() error {
+// and then proceeds to assign them to team members based on the assignment strategy of the team.
+func (e *Engine) assignConversations(trigger string) error {
 	unassignedConversations, err := e.conversationStore.GetUnassignedConversations()
 	if err != nil {
 		return fmt.Errorf("fetching unassigned conversations: %w", err)
@@ -203,58 +389,393 @@ func (e *Engine) assignConversations() error {
 		e.lo.Debug("found unassigned conversations", "count", len(unassignedConversations))
 	}
 
+	// Agent loads, fetched once per run and updated as conversations are assigned.
+	loads := make(map[int]*agentLoad)
 	for _, conversation := range unassignedConversations {
-		// Get user from the pool.
-		userIDStr, err := e.getUserFromPool(conversation.AssignedTeamID.Int)
-		if err != nil {
-			if err != ErrTeamNotFound {
-				e.lo.Error("error fetching user from balancer pool", "conversation_uuid", conversation.UUID, "error", err)
-			}
-			continue
+		e.assign(conversation, trigger, loads)
+	}
+	return nil
+}
+
+// assign picks an agent for a conversation using the strategy of its team, assigns it and logs the decision.
+func (e *Engine) assign(conversation cmodels.Conversation, trigger string, loads map[int]*agentLoad) {
+	teamID := conversation.AssignedTeamID.Int
+
+	e.balanceMu.Lock()
+	strategy, ok := e.teamAssignmentTypes[teamID]
+	members := e.teamMembers[teamID]
+	teamMax := e.teamMaxAutoAssignments[teamID]
+	e.balanceMu.Unlock()
+	if !ok {
+		return
+	}
+
+	outcome := models.OutcomeNoAgentAvailable
+	d, err := e.decide(conversation, strategy, members, teamMax, loads)
+	switch {
+	case err != nil:
+		e.lo.Error("error picking user for auto assignment", "conversation_uuid", conversation.UUID, "team_id", teamID, "error", err)
+		outcome = models.OutcomeFailed
+	case d.userID == 0:
+		e.lo.Debug("no user available for auto assignment", "conversation_uuid", conversation.UUID, "team_id", teamID, "assignment_type", strategy)
+	default:
+		// Assign conversation to user.
+		if err := e.conversationStore.UpdateConversationUserAssignee(conversation.UUID, d.userID, e.systemUser); err != nil {
+			e.lo.Error("error assigning conversation", "conversation_uuid", conversation.UUID, "error", err)
+			outcome = models.OutcomeFailed
+			d.userID = 0
+			break
+		}
+		if l, ok := loads[d.userID]; ok {
+			l.conversations[conversation.InboxChannel]++
 		}
+		outcome = models.OutcomeAssigned
+	}
 
-		// Convert to int.
-		userID, err := strconv.Atoi(userIDStr)
-		if err != nil {
-			e.lo.Error("error converting user id from string to int", "user_id", userIDStr, "error", err)
+	e.logDecision(conversation, strategy, trigger, outcome, d)
+}
+
+// decide picks an agent for a conversation from the team members, zero if no agent is available.
+func (e *Engine) decide(conversation cmodels.Conversation, strategy string, members []tmodels.TeamMember, teamMax int, loads map[int]*agentLoad) (decision, error) {
+	var (
+		d         decision
+		available = make([]tmodels.TeamMember, 0, len(members))
+	)
+	for _, m := range members {
+		if isAway(m.AvailabilityStatus) {
+			d.candidates = append(d.candidates, models.Candidate{UserID: m.ID, Reason: models.ReasonAway})
 			continue
 		}
+		if m.OffShift {
+			d.candidates = append(d.candidates, models.Candidate{UserID: m.ID, Reason: models.ReasonOffShift})
+			continue
+		}
+		available = append(available, m)
+	}
 
-		// Get active conversations count for the user.
-		activeConversationsCount, err := e.conversationStore.ActiveUserConversationsCount(userID)
+	var err error
+	switch strategy {
+	case AssignmentTypeLeastBusy:
+		err = e.decideLeastBusy(&d, conversation, available, teamMax, loads)
+	case AssignmentTypeSkillBased:
+		err = e.decideSkillBased(&d, conversation, available, teamMax, loads)
+	case AssignmentTypeSticky:
+		err = e.decideSticky(&d, conversation, available, teamMax, loads)
+	default:
+		err = e.decideRoundRobin(&d, conversation, available, teamMax, loads)
+	}
+	return d, err
+}
+
+// decideRoundRobin picks the next agent from the team balancer pool if the conversation fits in their capacity.
+func (e *Engine) decideRoundRobin(d *decision, conversation cmodels.Conversation, members []tmodels.TeamMember, teamMax int, loads map[int]*agentLoad) error {
+	next, err := e.getUserFromPool(conversation.AssignedTeamID.Int)
+	if err != nil {
+		return err
+	}
+
+	for _, m := range members {
+		if m.ID != next {
+			d.candidates = append(d.candidates, candidate(m.ID, models.ReasonNotNextInRotation, loads, teamMax))
+			continue
+		}
+		load, err := e.loadAgent(m, loads)
 		if err != nil {
-			e.lo.Error("error fetching active conversations count for user", "user_id", userID, "error", err)
+			return err
+		}
+		if !load.fits(conversation.InboxChannel, teamMax) {
+			e.lo.Debug("user has reached capacity, skipping auto assignment", "user_id", m.ID, "load", load.load(), "capacity", load.limit(teamMax))
+			d.candidates = append(d.candidates, candidate(m.ID, models.ReasonAtCapacity, loads, teamMax))
 			continue
 		}
+		d.userID = m.ID
+		d.candidates = append(d.candidates, candidate(m.ID, models.ReasonPicked, loads, teamMax))
+	}
+	return nil
+}
+
+// decideLeastBusy picks the agent with the lowest load whose capacity fits the conversation.
+func (e *Engine) decideLeastBusy(d *decision, conversation cmodels.Conversation, members []tmodels.TeamMember, teamMax int, loads map[int]*agentLoad) error {
+	for _, m := range members {
+		if _, err := e.loadAgent(m, loads); err != nil {
+			return err
+		}
+	}
+	userID, candidates := rankLeastBusy(members, loads, conversation.InboxChannel, teamMax)
+	d.userID = userID
+	d.candidates = append(d.candidates, candidates...)
+	return nil
+}
 
-		teamMaxAutoAssignments := e.teamMaxAutoAssignments[conversation.AssignedTeamID.Int]
-		// Check if user has reached the max auto assigned conversations limit,
-		// 0 is unlimited.
-		if teamMaxAutoAssignments != 0 {
-			if activeConversationsCount >= teamMaxAutoAssignments {
-				e.lo.Debug("user has reached max auto assigned conversations limit, skipping auto assignment", "user_id", userID,
-					"user_active_conversations_count", activeConversationsCount, "max_auto_assigned_conversations", teamMaxAutoAssignments)
-				continue
-			}
+// decideSkillBased picks the least busy agent among those whose skills best match the conversation tags or inbox name,
+// falling back to all agents if none of them match.
+func (e *Engine) decideSkillBased(d *decision, conversation cmodels.Conversation, members []tmodels.TeamMember, teamMax int, loads map[int]*agentLoad) error {
+	tags := conversationTags(conversation)
+	matched := matchSkills(members, tags, conversation.InboxName)
+	if len(matched) == 0 {
+		matched = members
+		d.fallback = models.FallbackAllMembers
+	}
+
+	start := len(d.candidates)
+	for _, m := range members {
+		if !slices.ContainsFunc(matched, func(x tmodels.TeamMember) bool { return x.ID == m.ID }) {
+			d.candidates = append(d.candidates, candidate(m.ID, models.ReasonFewerSkillMatches, loads, teamMax))
 		}
+	}
+	if err := e.decideLeastBusy(d, conversation, matched, teamMax, loads); err != nil {
+		return err
+	}
 
-		// Assign conversation to user.
-		if err := e.conversationStore.UpdateConversationUserAssignee(conversation.UUID, userID, e.systemUser); err != nil {
-			e.lo.Error("error assigning conversation", "conversation_uuid", conversation.UUID, "error", err)
-			continue
+	// Record the number of skills each agent matched.
+	wanted := skillSet(tags, conversation.InboxName)
+	for i := start; i < len(d.candidates); i++ {
+		for _, m := range members {
+			if m.ID == d.candidates[i].UserID {
+				d.candidates[i].SkillMatches = countSkillMatches(m.Skills, wanted)
+			}
 		}
 	}
 	return nil
 }
 
-// getUserFromPool returns user ID from the team balancer pool.
-func (e *Engine) getUserFromPool(assignedTeamID int) (string, error) {
+// decideSticky picks the agent who last handled the contact if they are available and the conversation fits in their capacity,
+// otherwise it falls back to round robin.
+func (e *Engine) decideSticky(d *decision, conversation cmodels.Conversation, members []tmodels.TeamMember, teamMax int, loads map[int]*agentLoad) error {
+	lastUserID, err := e.conversationStore.GetContactLastAssignee(conversation.ContactID, conversation.ID)
+	if err != nil {
+		return err
+	}
+	if i := slices.IndexFunc(members, func(m tmodels.TeamMember) bool { return m.ID == lastUserID }); lastUserID > 0 && i >= 0 {
+		load, err := e.loadAgent(members[i], loads)
+		if err != nil {
+			return err
+		}
+		if load.fits(conversation.InboxChannel, teamMax) {
+			d.userID = lastUserID
+			for _, m := range members {
+				reason := models.ReasonNotLastAssignee
+				if m.ID == lastUserID {
+					reason = models.ReasonPicked
+				}
+				d.candidates = append(d.candidates, candidate(m.ID, reason, loads, teamMax))
+			}
+			return nil
+		}
+	}
+	d.fallback = models.FallbackRoundRobin
+	return e.decideRoundRobin(d, conversation, members, teamMax, loads)
+}
+
+// loadAgent fetches the active conversations of a team member if they are not already known.
+func (e *Engine) loadAgent(m tmodels.TeamMember, loads map[int]*agentLoad) (*agentLoad, error) {
+	if l, ok := loads[m.ID]; ok {
+		return l, nil
+	}
+	counts, err := e.conversationStore.ActiveUserConversationsByChannel(m.ID)
+	if err != nil {
+		return nil, fmt.Errorf("fetching active conversations for user %d: %w", m.ID, err)
+	}
+	l := &agentLoad{conversations: counts, capacity: m.Capacity.Int}
+	if len(m.ChannelWeights) > 0 {
+		if err := json.Unmarshal(m.ChannelWeights, &l.weights); err != nil {
+			e.lo.Warn("invalid channel weights, using defaults", "user_id", m.ID, "error", err)
+		}
+	}
+	loads[m.ID] = l
+	return l, nil
+}
+
+// getUserFromPool returns the next user ID from the team balancer pool, zero if the pool is empty.
+func (e *Engine) getUserFromPool(assignedTeamID int) (int, error) {
 	e.balanceMu.Lock()
 	defer e.balanceMu.Unlock()
 
 	pool, ok := e.roundRobinBalancer[assignedTeamID]
 	if !ok {
-		return "", ErrTeamNotFound
+		return 0, ErrTeamNotFound
+	}
+	id := pool.Get()
+	if id == "" {
+		return 0, nil
+	}
+	userID, err := strconv.Atoi(id)
+	if err != nil {
+		return 0, fmt.Errorf("converting user id %q to int: %w", id, err)
+	}
+	return userID, nil
+}
+
+// logDecision saves an assignment decision, failures are only logged.
+func (e *Engine) logDecision(conversation cmodels.Conversation, strategy, trigger, outcome string, d decision) {
+	candidates := d.candidates
+	if candidates == nil {
+		candidates = []models.Candidate{}
+	}
+	b, err := json.Marshal(candidates)
+	if err != nil {
+		e.lo.Error("error marshalling assignment candidates", "error", err)
+		return
+	}
+	if _, err := e.q.InsertDecision.Exec(conversation.ID, conversation.AssignedTeamID.Int, strategy, trigger, outcome, d.fallback, d.userID, b); err != nil {
+		e.lo.Error("error inserting assignment decision", "conversation_uuid", conversation.UUID, "error", err)
+	}
+}
+
+// pruneDecisions deletes assignment decisions older than the retention period.
+func (e *Engine) pruneDecisions() {
+	res, err := e.q.DeleteOldDecisions.Exec(decisionLogRetention)
+	if err != nil {
+		e.lo.Error("error deleting old assignment decisions", "error", err)
+		return
+	}
+	if n, _ := res.RowsAffected(); n > 0 {
+		e.lo.Info("deleted old assignment decisions", "count", n)
+	}
+}
+
+// isAway reports whether an availability status excludes the user from auto assignment.
+func isAway(status string) bool {
+	return status == umodels.AwayManual || status == umodels.AwayAndReassigning
+}
+
+// agentLoad is the active conversations of an agent by channel with their capacity override and channel weights.
+type agentLoad struct {
+	conversations map[string]int
+	weights       umodels.ChannelWeights
+	// Capacity override of the agent, zero uses the team limit.
+	capacity int
+}
+
+// load returns the weighted load of the agent's active conversations.
+func (l *agentLoad) load() float64 {
+	return l.weights.Load(l.conversations)
+}
+
+// limit returns the agent capacity override or else the team limit, 0 is unlimited.
+func (l *agentLoad) limit(teamMax int) int {
+	if l.capacity > 0 {
+		return l.capacity
+	}
+	return teamMax
+}
+
+// fits reports whether a conversation of the channel fits in the agent's capacity.
+func (l *agentLoad) fits(channel string, teamMax int) bool {
+	limit := l.limit(teamMax)
+	if limit == 0 {
+		return true
+	}
+	// Allow for rounding of fractional weights.
+	return l.load()+l.weights.Weight(channel) <= float64(limit)+1e-9
+}
+
+// loadOf returns the load of a user, an empty load if it is not known.
+func loadOf(loads map[int]*agentLoad, userID int) *agentLoad {
+	if l, ok := loads[userID]; ok {
+		return l
+	}
+	return &agentLoad{}
+}
+
+// candidate returns a candidate with their load and capacity if the load is known.
+func candidate(userID int, reason string, loads map[int]*agentLoad, teamMax int) models.Candidate {
+	c := models.Candidate{UserID: userID, Reason: reason}
+	if l, ok := loads[userID]; ok {
+		load := l.load()
+		c.Load = &load
+		c.Capacity = l.limit(teamMax)
+	}
+	return c
+}
+
+// rankLeastBusy picks the least busy member whose capacity fits the conversation and returns why every member was or wasn't picked.
+func rankLeastBusy(members []tmodels.TeamMember, loads map[int]*agentLoad, channel string, teamMax int) (int, []models.Candidate) {
+	picked := leastBusy(members, loads, channel, teamMax)
+
+	candidates := make([]models.Candidate, 0, len(members))
+	for _, m := range members {
+		reason := models.ReasonBusier
+		switch {
+		case m.ID == picked:
+			reason = models.ReasonPicked
+		case !loadOf(loads, m.ID).fits(channel, teamMax):
+			reason = models.ReasonAtCapacity
+		}
+		candidates = append(candidates, candidate(m.ID, reason, loads, teamMax))
+	}
+	return picked, candidates
+}
+
+// leastBusy returns the member with the lowest load whose capacity fits a conversation of the channel.
+// Ties go to the earlier member, zero is returned if the conversation fits no member.
+func leastBusy(members []tmodels.TeamMember, loads map[int]*agentLoad, channel string, teamMax int) int {
+	var (
+		picked int
+		lowest float64
+	)
+	for _, m := range members {
+		l := loadOf(loads, m.ID)
+		if !l.fits(channel, teamMax) {
+			continue
+		}
+		if load := l.load(); picked == 0 || load < lowest {
+			picked, lowest = m.ID, load
+		}
+	}
+	return picked
+}
+
+// matchSkills returns the members whose skills match the most conversation tags or the inbox name.
+// Matching is case-insensitive, no members are returned if none of them match.
+func matchSkills(members []tmodels.TeamMember, tags []string, inboxName string) []tmodels.TeamMember {
+	var (
+		wanted  = skillSet(tags, inboxName)
+		best    int
+		matched []tmodels.TeamMember
+	)
+	for _, m := range members {
+		score := countSkillMatches(m.Skills, wanted)
+		switch {
+		case score == 0 || score < best:
+		case score > best:
+			best = score
+			matched = []tmodels.TeamMember{m}
+		default:
+			matched = append(matched, m)
+		}
+	}
+	return matched
+}
+
+// skillSet returns the lowercased conversation tags and inbox name that agent skills are matched against.
+func skillSet(tags []string, inboxName string) map[string]struct{} {
+	wanted := make(map[string]struct{}, len(tags)+1)
+	for _, t := range tags {
+		wanted[strings.ToLower(strings.TrimSpace(t))] = struct{}{}
+	}
+	if inboxName != "" {
+		wanted[strings.ToLower(strings.TrimSpace(inboxName))] = struct{}{}
+	}
+	return wanted
+}
+
+// countSkillMatches returns the number of skills found in the wanted set.
+func countSkillMatches(skills []string, wanted map[string]struct{}) int {
+	var n int
+	for _, skill := range skills {
+		if _, ok := wanted[strings.ToLower(skill)]; ok {
+			n++
+		}
+	}
+	return n
+}
+
+// conversationTags returns the tag names of a conversation.
+func conversationTags(conversation cmodels.Conversation) []string {
+	var tags []string
+	if conversation.Tags.Valid {
+		json.Unmarshal(conversation.Tags.JSON, &tags)
 	}
-	return pool.Get(), nil
+	return tags
 }
@@ -0,0 +1,95 @@
+package autoassigner
+
+import (
+	"testing"
+
+	"github.com/abhinavxd/libredesk/internal/autoassigner/models"
+	tmodels "github.com/abhinavxd/libredesk/internal/team/models"
+	umodels "github.com/abhinavxd/libredesk/internal/user/models"
+	"github.com/lib/pq"
+	"github.com/stretchr/testify/assert"
+)
+
+func loadsOf(counts map[int]int) map[int]*agentLoad {
+	loads := make(map[int]*agentLoad)
+	for id, n := range counts {
+		loads[id] = &agentLoad{conversations: map[string]int{"email": n}}
+	}
+	return loads
+}
+
+func membersOf(ids ...int) []tmodels.TeamMember {
+	var members []tmodels.TeamMember
+	for _, id := range ids {
+		members = append(members, tmodels.TeamMember{ID: id})
+	}
+	return members
+}
+
+func TestLeastBusy(t *testing.T) {
+	loads := loadsOf(map[int]int{1: 4, 2: 2, 3: 2, 4: 0})
+
+	assert.Equal(t, 4, leastBusy(membersOf(1, 2, 3, 4), loads, "email", 0))
+	// Ties go to the earlier user.
+	assert.Equal(t, 2, leastBusy(membersOf(1, 2, 3), loads, "email", 0))
+	assert.Equal(t, 3, leastBusy(membersOf(3, 2), loads, "email", 5))
+	// Users at the limit are skipped.
+	assert.Equal(t, 0, leastBusy(membersOf(1, 2, 3), loads, "email", 2))
+	assert.Equal(t, 0, leastBusy(nil, loads, "email", 0))
+}
+
+func TestAgentLoadFits(t *testing.T) {
+	l := &agentLoad{
+		conversations: map[string]int{"livechat": 2, "email": 1},
+		weights:       umodels.ChannelWeights{"livechat": 1.5, "email": 0.5},
+	}
+	assert.Equal(t, 3.5, l.load())
+
+	// Team limit.
+	assert.True(t, l.fits("email", 4))
+	assert.False(t, l.fits("livechat", 4))
+	assert.True(t, l.fits("livechat", 0))
+
+	// Agent override takes precedence over the team limit.
+	l.capacity = 5
+	assert.True(t, l.fits("livechat", 4))
+	assert.Equal(t, 5, l.limit(2))
+	// Unlisted channels weigh 1.
+	assert.True(t, l.fits("api", 1))
+	assert.False(t, (&agentLoad{capacity: 1, conversations: map[string]int{"api": 1}}).fits("api", 0))
+}
+
+func TestMatchSkills(t *testing.T) {
+	members := []tmodels.TeamMember{
+		{ID: 1, Skills: pq.StringArray{"billing"}},
+		{ID: 2, Skills: pq.StringArray{"billing", "refunds"}},
+		{ID: 3, Skills: pq.StringArray{"support"}},
+		{ID: 4},
+	}
+	ids := func(members []tmodels.TeamMember) []int {
+		var out []int
+		for _, m := range members {
+			out = append(out, m.ID)
+		}
+		return out
+	}
+
+	assert.Equal(t, []int{2}, ids(matchSkills(members, []string{"Billing", "refunds"}, "")))
+	assert.Equal(t, []int{1, 2}, ids(matchSkills(members, []string{"billing"}, "Sales")))
+	assert.Equal(t, []int{3}, ids(matchSkills(members, nil, "Support")))
+	assert.Empty(t, matchSkills(members, []string{"shipping"}, "Sales"))
+}
+
+func TestRankLeastBusy(t *testing.T) {
+	counts := map[int]int{1: 3, 2: 1, 3: 5}
+
+	picked, candidates := rankLeastBusy(membersOf(1, 2, 3), loadsOf(counts), "email", 4)
+	assert.Equal(t, 2, picked)
+	reasons := make(map[int]string)
+	for _, c := range candidates {
+		reasons[c.UserID] = c.Reason
+		assert.Equal(t, float64(counts[c.UserID]), *c.Load)
+		assert.Equal(t, 4, c.Capacity)
+	}
+	assert.Equal(t, map[int]string{1: models.ReasonBusier, 2: models.ReasonPicked, 3: models.ReasonAtCapacity}, reasons)
+}
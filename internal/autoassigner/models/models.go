@@ -0,0 +1,65 @@
+package models
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/volatiletech/null/v9"
+)
+
+// Triggers of an auto assignment.
+const (
+	TriggerConversationCreated = "conversation_created"
+	TriggerTeamAssigned        = "team_assigned"
+	TriggerAgentAvailable      = "agent_available"
+	TriggerShiftChanged        = "shift_changed"
+	TriggerSweep               = "sweep"
+)
+
+// Outcomes of an auto assignment decision.
+const (
+	OutcomeAssigned         = "assigned"
+	OutcomeNoAgentAvailable = "no_agent_available"
+	OutcomeFailed           = "failed"
+)
+
+// Reasons an agent was or wasn't picked.
+const (
+	ReasonPicked            = "picked"
+	ReasonAway              = "away"
+	ReasonOffShift          = "off_shift"
+	ReasonAtCapacity        = "at_capacity"
+	ReasonBusier            = "busier"
+	ReasonFewerSkillMatches = "fewer_skill_matches"
+	ReasonNotNextInRotation = "not_next_in_rotation"
+	ReasonNotLastAssignee   = "not_last_assignee"
+)
+
+// Fallbacks used when the strategy of the team could not pick an agent on its own.
+const (
+	FallbackAllMembers = "all_members"
+	FallbackRoundRobin = "round_robin"
+)
+
+// Candidate is a team member considered for an auto assignment and why they were or weren't picked.
+type Candidate struct {
+	UserID       int      `json:"user_id"`
+	Reason       string   `json:"reason"`
+	Load         *float64 `json:"load,omitempty"`
+	Capacity     int      `json:"capacity,omitempty"`
+	SkillMatches int      `json:"skill_matches,omitempty"`
+}
+
+// Decision is a logged auto assignment decision for a conversation.
+type Decision struct {
+	ID             int             `db:"id" json:"id"`
+	CreatedAt      time.Time       `db:"created_at" json:"created_at"`
+	ConversationID int             `db:"conversation_id" json:"conversation_id"`
+	TeamID         null.Int        `db:"team_id" json:"team_id"`
+	Strategy       string          `db:"strategy" json:"strategy"`
+	Trigger        string          `db:"trigger" json:"trigger"`
+	Outcome        string          `db:"outcome" json:"outcome"`
+	Fallback       null.String     `db:"fallback" json:"fallback"`
+	AssignedUserID null.Int        `db:"assigned_user_id" json:"assigned_user_id"`
+	Candidates     json.RawMessage `db:"candidates" json:"candidates"`
+}
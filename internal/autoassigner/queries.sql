@@ -0,0 +1,24 @@
+-- name: insert-decision
+-- Decisions that did not assign the conversation are skipped if they repeat the previous decision.
+INSERT INTO auto_assignment_decisions (conversation_id, team_id, strategy, "trigger", outcome, fallback, assigned_user_id, candidates)
+SELECT $1, NULLIF($2, 0), $3, $4, $5, NULLIF($6, ''), NULLIF($7, 0), $8
+WHERE $5 = 'assigned' OR NOT EXISTS (
+    SELECT 1 FROM (
+        SELECT d.team_id, d.outcome, d.candidates
+        FROM auto_assignment_decisions d
+        WHERE d.conversation_id = $1
+        ORDER BY d.created_at DESC, d.id DESC
+        LIMIT 1
+    ) last
+    WHERE last.team_id IS NOT DISTINCT FROM NULLIF($2, 0) AND last.outcome = $5 AND last.candidates = $8::jsonb
+);
+
+-- name: get-decisions
+SELECT id, created_at, conversation_id, team_id, strategy, "trigger", outcome, fallback, assigned_user_id, candidates
+FROM auto_assignment_decisions
+WHERE conversation_id = $1
+ORDER BY created_at DESC, id DESC
+LIMIT 50;
+
+-- name: delete-old-decisions
+DELETE FROM auto_assignment_decisions WHERE created_at < NOW() - $1::INTERVAL;
@@ -7,11 +7,14 @@ import (
 	"database/sql"
 	"embed"
 	"encoding/json"
+	"fmt"
+	"regexp"
 	"slices"
 	"sync"
 	"time"
 
 	"github.com/abhinavxd/libredesk/internal/automation/models"
+	bmodels "github.com/abhinavxd/libredesk/internal/business_hours/models"
 	cmodels "github.com/abhinavxd/libredesk/internal/conversation/models"
 	"github.com/abhinavxd/libredesk/internal/dbutil"
 	"github.com/abhinavxd/libredesk/internal/envelope"
@@ -29,6 +32,9 @@ var (
 	MaxQueueSize = 10000
 )
 
+// executionLogRetention is how long rule execution logs are kept.
+const executionLogRetention = "30 days"
+
 // TaskType represents the type of conversation task.
 type TaskType string
 
@@ -48,26 +54,47 @@ type ConversationTask struct {
 type Engine struct {
 	rules             []models.Rule
 	rulesMu           sync.RWMutex
+	db                *sqlx.DB
 	q                 queries
 	lo                *logf.Logger
 	i18n              *i18n.I18n
 	conversationStore conversationStore
+	businessHours     businessHoursStore
 	taskQueue         chan ConversationTask
 	closed            bool
 	closedMu          sync.RWMutex
 	wg                sync.WaitGroup
+
+	// loadFacts loads the conversation facts used by conditions that are not part of the conversation record.
+	loadFacts func(conversationID int) (models.ConversationFacts, error)
+	// insertScheduledAction stores a delayed action, see scheduleAction.
+	insertScheduledAction func(ruleID, conversationID int, action []byte, delayMinutes int, cancelOn pq.StringArray) (models.ScheduledRun, error)
+	// regexes are the compiled regex patterns of the loaded rules, compiled once per rules load.
+	regexes map[string]*regexp.Regexp
+
+	timeTriggerLookback  time.Duration
+	timeTriggerBatchSize int
 }
 
 type Opts struct {
 	DB   *sqlx.DB
 	Lo   *logf.Logger
 	I18n *i18n.I18n
+
+	// TimeTriggerLookback is how far back resolved and closed conversations are evaluated by time trigger rules.
+	TimeTriggerLookback time.Duration
+	// TimeTriggerBatchSize is the number of conversations fetched at a time by time trigger rules.
+	TimeTriggerBatchSize int
 }
 
 type conversationStore interface {
 	ApplyAction(action models.RuleAction, conversation cmodels.Conversation, user umodels.User) error
 	GetConversation(teamID int, uuid, refNum string) (cmodels.Conversation, error)
-	GetConversationsCreatedAfter(time.Time) ([]cmodels.Conversation, error)
+}
+
+// businessHoursStore resolves the business hours of a team the same way SLAs do, falling back to the app defaults.
+type businessHoursStore interface {
+	GetBusinessHoursAndTimezone(assignedTeamID int) (bmodels.BusinessHours, string, error)
 }
 
 type queries struct {
@@ -80,6 +107,26 @@ type queries struct {
 	GetEnabledRules         *sqlx.Stmt `query:"get-enabled-rules"`
 	UpdateRuleWeight        *sqlx.Stmt `query:"update-rule-weight"`
 	UpdateRuleExecutionMode *sqlx.Stmt `query:"update-rule-execution-mode"`
+	InsertRuleExecution     *sqlx.Stmt `query:"insert-rule-execution"`
+	GetRuleExecutions       *sqlx.Stmt `query:"get-rule-executions"`
+	DeleteOldRuleExecutions *sqlx.Stmt `query:"delete-old-rule-executions"`
+	GetConversationFacts    *sqlx.Stmt `query:"get-conversation-facts"`
+	GetTimeTriggerConvs     *sqlx.Stmt `query:"get-time-trigger-conversations"`
+	InsertRuleFiring        *sqlx.Stmt `query:"insert-rule-firing"`
+	GetRuleFirings          *sqlx.Stmt `query:"get-rule-firings"`
+	ResetRuleFirings        *sqlx.Stmt `query:"reset-rule-firings"`
+
+	InsertRuleVersion *sqlx.Stmt `query:"insert-rule-version"`
+	GetRuleVersions   *sqlx.Stmt `query:"get-rule-versions"`
+	GetRuleVersion    *sqlx.Stmt `query:"get-rule-version"`
+	GetRulesForExport *sqlx.Stmt `query:"get-rules-for-export"`
+	GetRuleIDByName   *sqlx.Stmt `query:"get-rule-id-by-name"`
+	ImportRule        *sqlx.Stmt `query:"import-rule"`
+
+	InsertScheduledAction       *sqlx.Stmt `query:"insert-scheduled-action"`
+	ClaimDueScheduledActions    *sqlx.Stmt `query:"claim-due-scheduled-actions"`
+	UpdateScheduledActionStatus *sqlx.Stmt `query:"update-scheduled-action-status"`
+	DeleteOldScheduledActions   *sqlx.Stmt `query:"delete-old-scheduled-actions"`
 }
 
 // New initializes a new Engine.
@@ -87,16 +134,29 @@ func New(opt Opts) (*Engine, error) {
 	var (
 		q queries
 		e = &Engine{
+			db:        opt.DB,
 			lo:        opt.Lo,
 			i18n:      opt.I18n,
 			taskQueue: make(chan ConversationTask, MaxQueueSize),
+
+			timeTriggerLookback:  opt.TimeTriggerLookback,
+			timeTriggerBatchSize: opt.TimeTriggerBatchSize,
 		}
 	)
+	if e.timeTriggerLookback <= 0 {
+		e.timeTriggerLookback = defaultTimeTriggerLookback
+	}
+	if e.timeTriggerBatchSize <= 0 {
+		e.timeTriggerBatchSize = defaultTimeTriggerBatchSize
+	}
 	if err := dbutil.ScanSQLFile("queries.sql", &q, opt.DB, efs); err != nil {
 		return nil, err
 	}
 	e.q = q
+	e.loadFacts = e.getConversationFacts
+	e.insertScheduledAction = e.queryInsertScheduledAction
 	e.rules = e.queryRules()
+	e.regexes = e.compileRuleRegexes(e.rules)
 	return e, nil
 }
 
@@ -105,12 +165,18 @@ func (e *Engine) SetConversationStore(store conversationStore) {
 	e.conversationStore = store
 }
 
+// SetBusinessHoursStore sets the store used to resolve the business hours of conversations.
+func (e *Engine) SetBusinessHoursStore(store businessHoursStore) {
+	e.businessHours = store
+}
+
 // ReloadRules reloads automation rules from DB.
 func (e *Engine) ReloadRules() {
 	e.rulesMu.Lock()
 	defer e.rulesMu.Unlock()
 	e.lo.Debug("reloading automation engine rules")
 	e.rules = e.queryRules()
+	e.regexes = e.compileRuleRegexes(e.rules)
 }
 
 // Run starts the Engine with a worker pool to evaluate rules based on events.
@@ -134,6 +200,8 @@ func (e *Engine) Run(ctx context.Context, workerCount int) {
 		case <-ticker.C:
 			e.lo.Info("queuing time triggers")
 			e.taskQueue <- ConversationTask{taskType: TimeTrigger}
+			e.pruneExecutions()
+			e.pruneScheduledActions()
 		}
 	}
 }
@@ -209,31 +277,65 @@ func (e *Engine) ToggleRule(id int) (models.RuleRecord, error) {
 	return result, nil
 }
 
-// UpdateRule updates an existing rule.
-func (e *Engine) UpdateRule(id int, rule models.RuleRecord) (models.RuleRecord, error) {
+// UpdateRule updates an existing rule and saves the result as a new version of the rule by the given user.
+func (e *Engine) UpdateRule(id int, rule models.RuleRecord, userID int) (models.RuleRecord, error) {
 	if rule.Events == nil {
 		rule.Events = pq.StringArray{}
 	}
+	tx, err := e.db.Beginx()
+	if err != nil {
+		e.lo.Error("error starting transaction", "error", err)
+		return models.RuleRecord{}, envelope.NewError(envelope.GeneralError, e.i18n.T("globals.messages.somethingWentWrong"), nil)
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Stmtx(e.q.ResetRuleFirings).Exec(id, rule.Type, rule.Rules); err != nil {
+		e.lo.Error("error resetting rule firings", "rule_id", id, "error", err)
+		return models.RuleRecord{}, envelope.NewError(envelope.GeneralError, e.i18n.T("globals.messages.somethingWentWrong"), nil)
+	}
 	var result models.RuleRecord
-	if err := e.q.UpdateRule.Get(&result, id, rule.Name, rule.Description, rule.Type, rule.Events, rule.Rules, rule.Enabled); err != nil {
+	if err := tx.Stmtx(e.q.UpdateRule).Get(&result, id, rule.Name, rule.Description, rule.Type, rule.Events, rule.Rules, rule.Enabled); err != nil {
 		e.lo.Error("error updating rule", "error", err)
 		return models.RuleRecord{}, envelope.NewError(envelope.GeneralError, e.i18n.T("globals.messages.somethingWentWrong"), nil)
 	}
+	if _, err := tx.Stmtx(e.q.InsertRuleVersion).Exec(result.ID, userID); err != nil {
+		e.lo.Error("error inserting rule version", "rule_id", result.ID, "error", err)
+		return models.RuleRecord{}, envelope.NewError(envelope.GeneralError, e.i18n.T("globals.messages.somethingWentWrong"), nil)
+	}
+	if err := tx.Commit(); err != nil {
+		e.lo.Error("error committing rule update", "error", err)
+		return models.RuleRecord{}, envelope.NewError(envelope.GeneralError, e.i18n.T("globals.messages.somethingWentWrong"), nil)
+	}
 	// Reload rules.
 	e.ReloadRules()
 	return result, nil
 }
 
-// CreateRule creates a new rule.
-func (e *Engine) CreateRule(rule models.RuleRecord) (models.RuleRecord, error) {
+// CreateRule creates a new rule and saves it as the first version of the rule by the given user.
+func (e *Engine) CreateRule(rule models.RuleRecord, userID int) (models.RuleRecord, error) {
 	if rule.Events == nil {
 		rule.Events = pq.StringArray{}
 	}
+	tx, err := e.db.Beginx()
+	if err != nil {
+		e.lo.Error("error starting transaction", "error", err)
+		return models.RuleRecord{}, envelope.NewError(envelope.GeneralError, e.i18n.T("globals.messages.somethingWentWrong"), nil)
+	}
+	defer tx.Rollback()
+
 	var result models.RuleRecord
-	if err := e.q.InsertRule.Get(&result, rule.Name, rule.Description, rule.Type, rule.Events, rule.Rules); err != nil {
+	if err := tx.Stmtx(e.q.InsertRule).Get(&result, rule.Name, rule.Description, rule.Type, rule.Events, rule.Rules); err != nil {
 		e.lo.Error("error creating rule", "error", err)
 		return models.RuleRecord{}, envelope.NewError(envelope.GeneralError, e.i18n.T("globals.messages.somethingWentWrong"), nil)
 	}
+	if _, err := tx.Stmtx(e.q.InsertRuleVersion).Exec(result.ID, userID); err != nil {
+		e.lo.Error("error inserting rule version", "rule_id", result.ID, "error", err)
+		return models.RuleRecord{}, envelope.NewError(envelope.GeneralError, e.i18n.T("globals.messages.somethingWentWrong"), nil)
+	}
+	if err := tx.Commit(); err != nil {
+		e.lo.Error("error committing rule creation", "error", err)
+		return models.RuleRecord{}, envelope.NewError(envelope.GeneralError, e.i18n.T("globals.messages.somethingWentWrong"), nil)
+	}
 	// Reload rules.
 	e.ReloadRules()
 	return result, nil
@@ -274,6 +376,44 @@ func (e *Engine) UpdateRuleExecutionMode(ruleType, mode string) error {
 	return nil
 }
 
+// GetExecutions returns a page of rule executions, optionally filtered by rule, conversation and errors.
+func (e *Engine) GetExecutions(ruleID int, conversationUUID string, onlyErrors bool, page, pageSize int) ([]models.RuleExecution, error) {
+	var executions = make([]models.RuleExecution, 0)
+	if page < 1 {
+		page = 1
+	}
+	if err := e.q.GetRuleExecutions.Select(&executions, ruleID, conversationUUID, onlyErrors, pageSize, (page-1)*pageSize); err != nil {
+		e.lo.Error("error fetching rule executions", "error", err)
+		return nil, envelope.NewError(envelope.GeneralError, e.i18n.T("globals.messages.somethingWentWrong"), nil)
+	}
+	for i := range executions {
+		executions[i].Matched = true
+	}
+	return executions, nil
+}
+
+// DryRunRule evaluates a rule against a conversation, looked up by UUID or reference number,
+// without applying any actions and returns the result of each rule in it.
+func (e *Engine) DryRunRule(rule models.RuleRecord, conversationUUID, referenceNumber string) ([]models.RuleExecution, error) {
+	var rules []models.Rule
+	if err := json.Unmarshal(rule.Rules, &rules); err != nil {
+		return nil, envelope.NewError(envelope.InputError, e.i18n.T("validation.invalidValue"), nil)
+	}
+	for i := range rules {
+		rules[i].ID = rule.ID
+		rules[i].Name = rule.Name
+		rules[i].Type = rule.Type
+		rules[i].Events = rule.Events
+		rules[i].ExecutionMode = rule.ExecutionMode
+	}
+
+	conversation, err := e.conversationStore.GetConversation(0, conversationUUID, referenceNumber)
+	if err != nil {
+		return nil, err
+	}
+	return e.evalConversationRules(rules, conversation, true), nil
+}
+
 // EvaluateNewConversationRules enqueues a new conversation for rule evaluation.
 func (e *Engine) EvaluateNewConversationRules(conversation cmodels.Conversation) {
 	e.closedMu.RLock()
@@ -334,7 +474,7 @@ func (e *Engine) handleNewConversation(conversation cmodels.Conversation) {
 		e.lo.Info("no rules to evaluate for new conversation rule evaluation", "uuid", conversation.UUID)
 		return
 	}
-	e.evalConversationRules(rules, conversation)
+	e.recordExecutions(models.RuleTypeNewConversation, e.evalConversationRules(rules, conversation, false))
 }
 
 // handleUpdateConversation handles update conversation events with specific eventType.
@@ -345,32 +485,133 @@ func (e *Engine) handleUpdateConversation(conversation cmodels.Conversation, eve
 		e.lo.Info("no rules to evaluate for conversation update", "uuid", conversation.UUID, "event_type", eventType)
 		return
 	}
-	e.evalConversationRules(rules, conversation)
+	e.recordExecutions(eventType, e.evalConversationRules(rules, conversation, false))
 }
 
 // handleTimeTrigger handles time trigger events.
+// Conversations matching the SQL side filters of any rule are evaluated once against all time trigger rules,
+// in order, so that the execution mode applies as it does for the other rule types.
 func (e *Engine) handleTimeTrigger() {
 	e.lo.Info("running time trigger evaluation for automation rules")
-	thirtyDaysAgo := time.Now().Add(-30 * 24 * time.Hour)
-	conversations, err := e.conversationStore.GetConversationsCreatedAfter(thirtyDaysAgo)
-	if err != nil {
-		e.lo.Error("error fetching conversations for time trigger", "error", err)
-		return
-	}
 	rules := e.filterRulesByType(models.RuleTypeTimeTrigger, "")
 	if len(rules) == 0 {
 		e.lo.Info("no rules to evaluate for time trigger")
 		return
 	}
-	e.lo.Info("fetched conversations for evaluating time triggers", "conversations_count", len(conversations), "rules_count", len(rules))
-	for _, c := range conversations {
-		// Fetch entire conversation.
-		conversation, err := e.conversationStore.GetConversation(0, c.UUID, "")
-		if err != nil {
-			e.lo.Error("error fetching conversation for time trigger", "uuid", c.UUID, "error", err)
+
+	var (
+		evaluated = make(map[int]struct{})
+		lookback  = fmt.Sprintf("%d seconds", int(e.timeTriggerLookback.Seconds()))
+		fired     = 0
+	)
+	for _, rule := range rules {
+		filter := compileTimeTriggerFilter(rule)
+		cursor := 0
+		for {
+			var batch []timeTriggerConversation
+			if err := e.q.GetTimeTriggerConvs.Select(&batch, cursor, lookback,
+				filter.StatusIDs, filter.PriorityIDs, filter.InboxIDs, filter.TeamIDs, filter.UserIDs,
+				filter.HoursSinceCreated, filter.HoursSinceFirstReply, filter.HoursSinceLastReply, filter.HoursSinceResolved,
+				rule.ID, e.timeTriggerBatchSize); err != nil {
+				e.lo.Error("error fetching conversations for time trigger", "rule_id", rule.ID, "error", err)
+				break
+			}
+
+			for _, c := range batch {
+				if _, ok := evaluated[c.ID]; ok {
+					continue
+				}
+				evaluated[c.ID] = struct{}{}
+				fired += e.evalTimeTriggerRules(rules, c)
+			}
+
+			if len(batch) < e.timeTriggerBatchSize {
+				break
+			}
+			cursor = batch[len(batch)-1].ID
+		}
+	}
+	e.lo.Info("evaluated time trigger rules", "conversations_count", len(evaluated), "fired_count", fired)
+}
+
+// evalTimeTriggerRules evaluates the time trigger rules that have not fired for a conversation yet against it
+// and returns the number of rules that fired. A rule fires only once per conversation.
+func (e *Engine) evalTimeTriggerRules(rules []models.Rule, c timeTriggerConversation) int {
+	var firedIDs []int
+	if err := e.q.GetRuleFirings.Select(&firedIDs, c.ID); err != nil {
+		e.lo.Error("error fetching time trigger rule firings", "conversation_id", c.ID, "error", err)
+		return 0
+	}
+	pending := make([]models.Rule, 0, len(rules))
+	for _, rule := range rules {
+		if !slices.Contains(firedIDs, rule.ID) {
+			pending = append(pending, rule)
+		}
+	}
+	if len(pending) == 0 {
+		return 0
+	}
+
+	// Fetch entire conversation.
+	conversation, err := e.conversationStore.GetConversation(0, c.UUID, "")
+	if err != nil {
+		e.lo.Error("error fetching conversation for time trigger", "uuid", c.UUID, "error", err)
+		return 0
+	}
+
+	var (
+		executions = e.evalConversationRules(pending, conversation, false)
+		fired      = 0
+	)
+	for _, exec := range executions {
+		if !exec.Matched {
 			continue
 		}
-		e.evalConversationRules(rules, conversation)
+		if _, err := e.q.InsertRuleFiring.Exec(exec.RuleID, conversation.ID); err != nil {
+			e.lo.Error("error recording time trigger rule firing", "rule_id", exec.RuleID, "conversation_id", conversation.ID, "error", err)
+		}
+		fired++
+	}
+	e.recordExecutions(models.RuleTypeTimeTrigger, executions)
+	return fired
+}
+
+// getConversationFacts fetches the facts of a conversation used in rule conditions.
+func (e *Engine) getConversationFacts(conversationID int) (models.ConversationFacts, error) {
+	var facts models.ConversationFacts
+	if err := e.q.GetConversationFacts.Get(&facts, conversationID); err != nil {
+		return facts, err
+	}
+	return facts, nil
+}
+
+// recordExecutions persists the executions of rules that matched.
+func (e *Engine) recordExecutions(trigger string, executions []models.RuleExecution) {
+	for _, exec := range executions {
+		if !exec.Matched {
+			continue
+		}
+		if exec.GroupResults == nil {
+			exec.GroupResults = pq.BoolArray{}
+		}
+		if exec.Actions == nil {
+			exec.Actions = json.RawMessage("[]")
+		}
+		if _, err := e.q.InsertRuleExecution.Exec(exec.RuleID, exec.ConversationID, trigger, exec.GroupResults, exec.Actions, exec.HasErrors); err != nil {
+			e.lo.Error("error recording rule execution", "rule_id", exec.RuleID, "conversation_uuid", exec.ConversationUUID, "error", err)
+		}
+	}
+}
+
+// pruneExecutions deletes rule execution logs older than the retention period.
+func (e *Engine) pruneExecutions() {
+	res, err := e.q.DeleteOldRuleExecutions.Exec(executionLogRetention)
+	if err != nil {
+		e.lo.Error("error deleting old rule executions", "error", err)
+		return
+	}
+	if n, _ := res.RowsAffected(); n > 0 {
+		e.lo.Info("deleted old rule executions", "count", n)
 	}
 }
 
@@ -394,6 +635,8 @@ func (e *Engine) queryRules() []models.Rule {
 		}
 		// Set values from DB.
 		for i := range rulesBatch {
+			rulesBatch[i].ID = rule.ID
+			rulesBatch[i].Name = rule.Name
 			rulesBatch[i].Type = rule.Type
 			rulesBatch[i].Events = rule.Events
 			rulesBatch[i].ExecutionMode = rule.ExecutionMode
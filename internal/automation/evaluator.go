@@ -2,20 +2,33 @@ package automation
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
+	"regexp"
+	"slices"
 	"strconv"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/abhinavxd/libredesk/internal/automation/models"
+	businesshours "github.com/abhinavxd/libredesk/internal/business_hours"
 	cmodels "github.com/abhinavxd/libredesk/internal/conversation/models"
 	umodels "github.com/abhinavxd/libredesk/internal/user/models"
 )
 
 // evalConversationRules evaluates a list of rules against a given conversation.
 // If all the groups of a rule pass their evaluations based on the defined logical operations,
-// the corresponding actions are executed.
-func (e *Engine) evalConversationRules(rules []models.Rule, conversation cmodels.Conversation) {
+// the corresponding actions are executed. In dry run mode no actions are applied and the
+// returned results report what would have happened.
+func (e *Engine) evalConversationRules(rules []models.Rule, conversation cmodels.Conversation, dryRun bool) []models.RuleExecution {
+	var (
+		results = make([]models.RuleExecution, 0, len(rules))
+		facts   = &factsLoader{}
+	)
+	if e.loadFacts != nil {
+		facts.load = func() (models.ConversationFacts, error) { return e.loadFacts(conversation.ID) }
+	}
 	for _, rule := range rules {
 		e.lo.Debug("evaluating rules for conversation", "rule", rule, "conversation_id", conversation.ID)
 
@@ -31,26 +44,79 @@ func (e *Engine) evalConversationRules(rules []models.Rule, conversation cmodels
 				e.lo.Debug("no rules found in group, skipping rule group evaluation", "group_num", idx+1, "conversation_uuid", conversation.UUID)
 				continue
 			}
-			result := e.evaluateGroup(group.Rules, group.LogicalOp, conversation)
+			result := e.evaluateGroup(group.Rules, group.LogicalOp, conversation, facts)
 			e.lo.Debug("group rule evaluation complete", "logical_op", group.LogicalOp, "result", result, "conversation_uuid", conversation.UUID)
 			groupEvalResults = append(groupEvalResults, result)
 		}
 
-		if evaluateFinalResult(groupEvalResults, rule.GroupOperator) {
-			e.lo.Debug("all rules within groups evaluated successfully, executing actions", "conversation_uuid", conversation.UUID)
-			for _, action := range rule.Actions {
-				if err := e.conversationStore.ApplyAction(action, conversation, umodels.User{}); err != nil {
-					e.lo.Error("error applying action on conversation", "action", action, "conversation_uuid", conversation.UUID, "error", err)
-				}
+		exec := models.RuleExecution{
+			RuleID:           rule.ID,
+			RuleName:         rule.Name,
+			ConversationID:   conversation.ID,
+			ConversationUUID: conversation.UUID,
+			GroupResults:     groupEvalResults,
+			Matched:          evaluateFinalResult(groupEvalResults, rule.GroupOperator),
+		}
+
+		if !exec.Matched {
+			e.lo.Debug("rule evaluation failed, skipping actions", "group_eval_results", groupEvalResults, "conversation_uuid", conversation.UUID)
+			results = append(results, exec)
+			continue
+		}
+
+		e.lo.Debug("all rules within groups evaluated successfully, executing actions", "conversation_uuid", conversation.UUID, "dry_run", dryRun)
+		actions := make([]models.ActionResult, len(rule.Actions))
+		for i, action := range rule.Actions {
+			actions[i] = models.ActionResult{Type: action.Type, Value: action.Value, DelayMinutes: action.DelayMinutes}
+			if dryRun || action.DelayMinutes > 0 {
+				continue
 			}
-			if rule.ExecutionMode == models.ExecutionModeFirstMatch {
-				e.lo.Debug("automation is first match rule execution mode, breaking out of rule evaluation", "conversation_uuid", conversation.UUID)
-				break
+			if err := e.conversationStore.ApplyAction(action, conversation, umodels.User{}); err != nil {
+				e.lo.Error("error applying action on conversation", "action", action, "conversation_uuid", conversation.UUID, "error", err)
+				actions[i].Error = err.Error()
+				exec.HasErrors = true
 			}
-		} else {
-			e.lo.Debug("rule evaluation failed, skipping actions", "group_eval_results", groupEvalResults, "conversation_uuid", conversation.UUID)
 		}
+		// Delayed actions are scheduled after the other actions are applied so that the conversation
+		// state they are cancelled against includes the changes made by the rule.
+		for i, action := range rule.Actions {
+			if dryRun || action.DelayMinutes <= 0 {
+				continue
+			}
+			runAt, err := e.scheduleAction(rule, action, conversation)
+			if err != nil {
+				e.lo.Error("error scheduling delayed action", "action", action, "conversation_uuid", conversation.UUID, "error", err)
+				actions[i].Error = err.Error()
+				exec.HasErrors = true
+				continue
+			}
+			actions[i].RunAt = &runAt
+		}
+		exec.Actions, _ = json.Marshal(actions)
+		results = append(results, exec)
+
+		if rule.ExecutionMode == models.ExecutionModeFirstMatch {
+			e.lo.Debug("automation is first match rule execution mode, breaking out of rule evaluation", "conversation_uuid", conversation.UUID)
+			break
+		}
+	}
+	return results
+}
+
+// withinBusinessHours reports whether the conversation is within the business hours of its team, or of the app if the team has none.
+// Without any business hours configured the helpdesk is always open.
+func (e *Engine) withinBusinessHours(conversation cmodels.Conversation) (bool, error) {
+	if e.businessHours == nil {
+		return true, nil
+	}
+	bh, timezone, err := e.businessHours.GetBusinessHoursAndTimezone(conversation.AssignedTeamID.Int)
+	if err != nil {
+		if errors.Is(err, businesshours.ErrBusinessHoursNotConfigured) {
+			return true, nil
+		}
+		return false, err
 	}
+	return businesshours.IsOpen(bh, timezone, time.Now())
 }
 
 // evaluateFinalResult computes the final result of multiple group evaluations
@@ -77,12 +143,12 @@ func evaluateFinalResult(results []bool, operator string) bool {
 
 // evaluateGroup evaluates a set of rules within a group against a given conversation
 // based on the specified logical operator (AND/OR).
-func (e *Engine) evaluateGroup(rules []models.RuleDetail, operator string, conversation cmodels.Conversation) bool {
+func (e *Engine) evaluateGroup(rules []models.RuleDetail, operator string, conversation cmodels.Conversation, facts *factsLoader) bool {
 	switch operator {
 	case models.OperatorAnd:
 		// All conditions within the group must be true
 		for _, rule := range rules {
-			if !e.evaluateRule(rule, conversation) {
+			if !e.evaluateRule(rule, conversation, facts) {
 				return false
 			}
 		}
@@ -90,7 +156,7 @@ func (e *Engine) evaluateGroup(rules []models.RuleDetail, operator string, conve
 	case models.OperatorOR:
 		// At least one condition within the group must be true
 		for _, rule := range rules {
-			if e.evaluateRule(rule, conversation) {
+			if e.evaluateRule(rule, conversation, facts) {
 				return true
 			}
 		}
@@ -103,11 +169,11 @@ func (e *Engine) evaluateGroup(rules []models.RuleDetail, operator string, conve
 
 // evaluateRule evaluates a single rule against a given conversation by extracting the field value and comparing it with the rule's value.
 // Returns true if the rule condition is met, false otherwise.
-func (e *Engine) evaluateRule(rule models.RuleDetail, conversation cmodels.Conversation) bool {
+func (e *Engine) evaluateRule(rule models.RuleDetail, conversation cmodels.Conversation, facts *factsLoader) bool {
 	var (
 		valueToCompare   string
-		ruleValues       []string
-		conditionMet     bool
+		listToCompare    []string
+		isList           bool
 		customAttributes map[string]any
 	)
 
@@ -124,6 +190,12 @@ func (e *Engine) evaluateRule(rule models.RuleDetail, conversation cmodels.Conve
 		switch rule.Field {
 		case models.ContactEmail:
 			valueToCompare = conversation.Contact.Email.String
+		case models.ContactName:
+			valueToCompare = conversation.Contact.FullName()
+		case models.ContactEmailDomain:
+			if email := conversation.Contact.Email.String; strings.Contains(email, "@") {
+				valueToCompare = email[strings.LastIndex(email, "@")+1:]
+			}
 		case models.ConversationSubject:
 			valueToCompare = conversation.Subject.String
 		case models.ConversationContent:
@@ -156,13 +228,49 @@ func (e *Engine) evaluateRule(rule models.RuleDetail, conversation cmodels.Conve
 			}
 		case models.ConversationInbox:
 			valueToCompare = strconv.Itoa(conversation.InboxID)
+		case models.ConversationChannel:
+			valueToCompare = conversation.InboxChannel
+		case models.ConversationTags:
+			isList = true
+			if len(conversation.Tags.JSON) > 0 {
+				if err := json.Unmarshal(conversation.Tags.JSON, &listToCompare); err != nil {
+					e.lo.Error("error unmarshalling conversation tags", "conversation_uuid", conversation.UUID, "error", err)
+					return false
+				}
+			}
+		case models.ConversationWithinBusinessHours:
+			open, err := e.withinBusinessHours(conversation)
+			if err != nil {
+				e.lo.Error("error checking business hours", "conversation_uuid", conversation.UUID, "error", err)
+				return false
+			}
+			valueToCompare = strconv.FormatBool(open)
+		case models.ConversationMessageCount, models.ConversationSLAStatus, models.ConversationCSATScore:
+			f, err := facts.get()
+			if err != nil {
+				e.lo.Error("error fetching conversation facts", "field", rule.Field, "conversation_uuid", conversation.UUID, "error", err)
+				return false
+			}
+			switch rule.Field {
+			case models.ConversationMessageCount:
+				valueToCompare = strconv.Itoa(f.MessageCount)
+			case models.ConversationSLAStatus:
+				valueToCompare = f.SLAStatus
+			case models.ConversationCSATScore:
+				if f.CSATScore.Valid {
+					valueToCompare = strconv.Itoa(f.CSATScore.Int)
+				}
+			}
 		default:
 			e.lo.Error("error unrecognized conversation field", "field", rule.Field, "field_type", rule.FieldType, "conversation_uuid", conversation.UUID)
 			return false
 		}
-	} else if rule.FieldType == models.FieldTypeContactCustomAttribute {
+	} else if rule.FieldType == models.FieldTypeContactCustomAttribute || rule.FieldType == models.FieldTypeConversationCustomAttribute {
 		// If the field type is custom attribute, need to extract the value from the custom attributes
 		var attributes json.RawMessage = conversation.Contact.CustomAttributes
+		if rule.FieldType == models.FieldTypeConversationCustomAttribute {
+			attributes = conversation.CustomAttributes
+		}
 
 		// Unmarshal the custom attributes
 		if err := json.Unmarshal(attributes, &customAttributes); err != nil {
@@ -196,26 +304,40 @@ func (e *Engine) evaluateRule(rule models.RuleDetail, conversation cmodels.Conve
 		return false
 	}
 
+	var conditionMet bool
+	if isList {
+		conditionMet = e.compareList(listToCompare, rule)
+	} else {
+		conditionMet = e.compareValue(valueToCompare, rule)
+	}
+	e.lo.Debug("conversation automation rule status", "has_met", conditionMet, "conversation_uuid", conversation.UUID)
+	return conditionMet
+}
+
+// compareValue compares a single field value with the rule's value using the rule's operator.
+func (e *Engine) compareValue(valueToCompare string, rule models.RuleDetail) bool {
+	var (
+		ruleValues   []string
+		conditionMet bool
+		// Regex patterns are matched as entered, lowercasing would change their meaning.
+		pattern = rule.Value
+	)
+
 	// Case sensitive match?
 	if !rule.CaseSensitiveMatch {
 		valueToCompare = strings.ToLower(valueToCompare)
 		rule.Value = strings.ToLower(rule.Value)
 	}
 
-	// Split and trim values for Contains/NotContains operations
-	if rule.Operator == models.RuleOperatorContains || rule.Operator == models.RuleOperatorNotContains {
-		ruleValues = strings.Split(rule.Value, ",")
-		for i := range ruleValues {
-			ruleValues[i] = strings.TrimSpace(ruleValues[i])
-			if !rule.CaseSensitiveMatch {
-				ruleValues[i] = strings.ToLower(ruleValues[i])
-			}
-		}
+	// Split and trim values for operators that take a list of values.
+	switch rule.Operator {
+	case models.RuleOperatorContains, models.RuleOperatorNotContains, models.RuleOperatorStartsWith, models.RuleOperatorEndsWith,
+		models.RuleOperatorInList, models.RuleOperatorIsOneOf:
+		ruleValues = splitRuleValues(rule.Value)
 	}
 
 	e.lo.Debug("evaluating rule", "rule_field", rule.Field, "rule_operator", rule.Operator,
-		"rule_value", rule.Value, "rule_values", ruleValues, "value_to_compare",
-		valueToCompare, "conversation_uuid", conversation.UUID)
+		"rule_value", rule.Value, "rule_values", ruleValues, "value_to_compare", valueToCompare)
 
 	// Compare with set operator
 	switch rule.Operator {
@@ -232,21 +354,9 @@ func (e *Engine) evaluateRule(rule models.RuleDetail, conversation cmodels.Conve
 		for _, ruleValue := range ruleValues {
 			// Normalize rule value by collapsing multiple spaces
 			normalizedRuleValue := strings.Join(strings.Fields(ruleValue), " ")
-			
-			// Respect CaseSensitiveMatch flag
-			if rule.CaseSensitiveMatch {
-				if strings.Contains(normalizedInputText, normalizedRuleValue) {
-					conditionMet = true
-					break
-				}
-			} else {
-				if strings.Contains(
-					strings.ToLower(normalizedInputText),
-					strings.ToLower(normalizedRuleValue),
-				) {
-					conditionMet = true
-					break
-				}
+			if strings.Contains(normalizedInputText, normalizedRuleValue) {
+				conditionMet = true
+				break
 			}
 		}
 	case models.RuleOperatorNotContains:
@@ -258,23 +368,34 @@ func (e *Engine) evaluateRule(rule models.RuleDetail, conversation cmodels.Conve
 		for _, ruleValue := range ruleValues {
 			// Normalize rule value by collapsing multiple spaces
 			normalizedRuleValue := strings.Join(strings.Fields(ruleValue), " ")
-			
-			// Respect CaseSensitiveMatch flag
-			if rule.CaseSensitiveMatch {
-				if strings.Contains(normalizedInputText, normalizedRuleValue) {
-					conditionMet = false
-					break
-				}
-			} else {
-				if strings.Contains(
-					strings.ToLower(normalizedInputText),
-					strings.ToLower(normalizedRuleValue),
-				) {
-					conditionMet = false
-					break
-				}
+			if strings.Contains(normalizedInputText, normalizedRuleValue) {
+				conditionMet = false
+				break
 			}
 		}
+	case models.RuleOperatorStartsWith:
+		for _, ruleValue := range ruleValues {
+			if strings.HasPrefix(valueToCompare, ruleValue) {
+				conditionMet = true
+				break
+			}
+		}
+	case models.RuleOperatorEndsWith:
+		for _, ruleValue := range ruleValues {
+			if strings.HasSuffix(valueToCompare, ruleValue) {
+				conditionMet = true
+				break
+			}
+		}
+	case models.RuleOperatorInList, models.RuleOperatorIsOneOf:
+		conditionMet = slices.Contains(ruleValues, strings.TrimSpace(valueToCompare))
+	case models.RuleOperatorRegex:
+		re, err := e.compileRegex(pattern, rule.CaseSensitiveMatch)
+		if err != nil {
+			e.lo.Error("error compiling rule regex", "pattern", pattern, "error", err)
+			return false
+		}
+		conditionMet = re.MatchString(valueToCompare)
 	case models.RuleOperatorSet:
 		conditionMet = len(valueToCompare) > 0
 	case models.RuleOperatorNotSet:
@@ -291,6 +412,103 @@ func (e *Engine) evaluateRule(rule models.RuleDetail, conversation cmodels.Conve
 		e.lo.Error("error unrecognized rule logical operator", "operator", rule.Operator)
 		return false
 	}
-	e.lo.Debug("conversation automation rule status", "has_met", conditionMet, "conversation_uuid", conversation.UUID)
 	return conditionMet
 }
+
+// compareList compares a multi valued field, eg: tags, with the rule's value. Values are matched as a whole and
+// negated operators are met only if none of the values match.
+func (e *Engine) compareList(values []string, rule models.RuleDetail) bool {
+	ruleValues := splitRuleValues(rule.Value)
+	matches := func() bool {
+		for _, value := range values {
+			for _, ruleValue := range ruleValues {
+				if value == ruleValue || (!rule.CaseSensitiveMatch && strings.EqualFold(value, ruleValue)) {
+					return true
+				}
+			}
+		}
+		return false
+	}
+
+	switch rule.Operator {
+	case models.RuleOperatorSet:
+		return len(values) > 0
+	case models.RuleOperatorNotSet:
+		return len(values) == 0
+	case models.RuleOperatorContains, models.RuleOperatorEquals, models.RuleOperatorInList, models.RuleOperatorIsOneOf:
+		return matches()
+	case models.RuleOperatorNotContains, models.RuleOperatorNotEqual:
+		return !matches()
+	default:
+		e.lo.Error("error unsupported operator for list field", "field", rule.Field, "operator", rule.Operator)
+		return false
+	}
+}
+
+// compileRegex returns the compiled regex of a rule pattern, patterns of the loaded rules are compiled once when
+// the rules are loaded and other patterns, e.g. of dry runs, are compiled on each call.
+func (e *Engine) compileRegex(pattern string, caseSensitive bool) (*regexp.Regexp, error) {
+	if !caseSensitive {
+		pattern = "(?i)" + pattern
+	}
+	e.rulesMu.RLock()
+	re, ok := e.regexes[pattern]
+	e.rulesMu.RUnlock()
+	if ok {
+		return re, nil
+	}
+	return regexp.Compile(pattern)
+}
+
+// compileRuleRegexes compiles the regex patterns used in the conditions of the rules.
+func (e *Engine) compileRuleRegexes(rules []models.Rule) map[string]*regexp.Regexp {
+	regexes := make(map[string]*regexp.Regexp)
+	for _, rule := range rules {
+		for _, group := range rule.Groups {
+			for _, d := range group.Rules {
+				if d.Operator != models.RuleOperatorRegex {
+					continue
+				}
+				pattern := d.Value
+				if !d.CaseSensitiveMatch {
+					pattern = "(?i)" + pattern
+				}
+				re, err := regexp.Compile(pattern)
+				if err != nil {
+					e.lo.Error("error compiling rule regex", "rule_id", rule.ID, "pattern", d.Value, "error", err)
+					continue
+				}
+				regexes[pattern] = re
+			}
+		}
+	}
+	return regexes
+}
+
+// splitRuleValues splits a comma separated rule value into trimmed values.
+func splitRuleValues(value string) []string {
+	values := strings.Split(value, ",")
+	for i := range values {
+		values[i] = strings.TrimSpace(values[i])
+	}
+	return values
+}
+
+// factsLoader lazily loads the facts of a conversation, at most once per evaluation of a conversation.
+type factsLoader struct {
+	load  func() (models.ConversationFacts, error)
+	once  sync.Once
+	facts models.ConversationFacts
+	err   error
+}
+
+func (f *factsLoader) get() (models.ConversationFacts, error) {
+	f.once.Do(func() {
+		if f.load == nil {
+			f.err = fmt.Errorf("conversation facts loader not set")
+			return
+		}
+		f.facts, f.err = f.load()
+	})
+	return f.facts, f.err
+}
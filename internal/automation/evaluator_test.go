@@ -2,14 +2,21 @@ package automation
 
 import (
 	"encoding/json"
+	"errors"
+	"fmt"
 	"testing"
 	"time"
 
 	"github.com/abhinavxd/libredesk/internal/automation/models"
+	businesshours "github.com/abhinavxd/libredesk/internal/business_hours"
+	bmodels "github.com/abhinavxd/libredesk/internal/business_hours/models"
 	cmodels "github.com/abhinavxd/libredesk/internal/conversation/models"
 	umodels "github.com/abhinavxd/libredesk/internal/user/models"
+	"github.com/jmoiron/sqlx/types"
+	"github.com/lib/pq"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
 	"github.com/volatiletech/null/v9"
 	"github.com/zerodha/logf"
 )
@@ -33,11 +40,6 @@ func (m *mockConversationStore) GetConversation(teamID int, uuid, refNum string)
 	return args.Get(0).(cmodels.Conversation), args.Error(1)
 }
 
-func (m *mockConversationStore) GetConversationsCreatedAfter(t time.Time) ([]cmodels.Conversation, error) {
-	args := m.Called(t)
-	return args.Get(0).([]cmodels.Conversation), args.Error(1)
-}
-
 // Test Helpers
 func createTestEngine(store *mockConversationStore) *Engine {
 	logger := logf.New(logf.Opts{Level: logf.DebugLevel})
@@ -61,11 +63,11 @@ func createTestConversation(opts ...func(*cmodels.Conversation)) cmodels.Convers
 			LastName:  "User",
 		},
 	}
-	
+
 	for _, opt := range opts {
 		opt(&conv)
 	}
-	
+
 	return conv
 }
 
@@ -107,8 +109,8 @@ func TestEvaluateGroup_AND_AllTrue(t *testing.T) {
 		),
 	}
 
-	engine.evalConversationRules(rules, conversation)
-	
+	engine.evalConversationRules(rules, conversation, false)
+
 	assert.Equal(t, 1, mockStore.callCount, "ApplyAction should be called once")
 	assert.Equal(t, models.ActionSetStatus, mockStore.appliedActions[0].Type)
 }
@@ -141,8 +143,8 @@ func TestEvaluateGroup_AND_ShortCircuit(t *testing.T) {
 		),
 	}
 
-	engine.evalConversationRules(rules, conversation)
-	
+	engine.evalConversationRules(rules, conversation, false)
+
 	assert.Equal(t, 0, mockStore.callCount, "ApplyAction should not be called when AND conditions fail")
 }
 
@@ -175,8 +177,8 @@ func TestEvaluateGroup_OR_OnlyOneTrue(t *testing.T) {
 		),
 	}
 
-	engine.evalConversationRules(rules, conversation)
-	
+	engine.evalConversationRules(rules, conversation, false)
+
 	assert.Equal(t, 1, mockStore.callCount, "ApplyAction should be called once for OR condition")
 }
 
@@ -216,8 +218,8 @@ func TestTwoGroups_AND_BothMustPass(t *testing.T) {
 		},
 	}
 
-	engine.evalConversationRules(rules, conversation)
-	
+	engine.evalConversationRules(rules, conversation, false)
+
 	assert.Equal(t, 1, mockStore.callCount, "ApplyAction should be called when both groups pass")
 }
 
@@ -253,8 +255,8 @@ func TestEmptyGroup_Skipped(t *testing.T) {
 		},
 	}
 
-	engine.evalConversationRules(rules, conversation)
-	
+	engine.evalConversationRules(rules, conversation, false)
+
 	assert.Equal(t, 1, mockStore.callCount, "ApplyAction should be called, empty group is skipped")
 }
 
@@ -301,8 +303,8 @@ func TestExecutionMode_FirstMatch(t *testing.T) {
 		},
 	}
 
-	engine.evalConversationRules(rules, conversation)
-	
+	engine.evalConversationRules(rules, conversation, false)
+
 	assert.Equal(t, 1, mockStore.callCount, "Only first matching rule should execute in first_match mode")
 	assert.Equal(t, models.ActionSetStatus, mockStore.appliedActions[0].Type)
 }
@@ -350,8 +352,8 @@ func TestExecutionMode_All(t *testing.T) {
 		},
 	}
 
-	engine.evalConversationRules(rules, conversation)
-	
+	engine.evalConversationRules(rules, conversation, false)
+
 	assert.Equal(t, 2, mockStore.callCount, "All matching rules should execute in 'all' mode")
 }
 
@@ -385,8 +387,8 @@ func TestNullFields_SetNotSet(t *testing.T) {
 		},
 	}
 
-	engine.evalConversationRules(rules, conversation)
-	
+	engine.evalConversationRules(rules, conversation, false)
+
 	assert.Equal(t, 1, mockStore.callCount, "Should handle null fields with set/not set operators")
 }
 
@@ -425,8 +427,8 @@ func TestCustomAttributes_StringComparison(t *testing.T) {
 		},
 	}
 
-	engine.evalConversationRules(rules, conversation)
-	
+	engine.evalConversationRules(rules, conversation, false)
+
 	assert.Equal(t, 1, mockStore.callCount, "Custom attributes should be compared correctly")
 	assert.Equal(t, models.ActionSendCSAT, mockStore.appliedActions[0].Type)
 }
@@ -463,8 +465,8 @@ func TestCustomAttributes_MissingField(t *testing.T) {
 		},
 	}
 
-	engine.evalConversationRules(rules, conversation)
-	
+	engine.evalConversationRules(rules, conversation, false)
+
 	assert.Equal(t, 0, mockStore.callCount, "Missing custom attribute should fail the rule")
 }
 
@@ -496,8 +498,8 @@ func TestContainsOperator_MultipleValues(t *testing.T) {
 		},
 	}
 
-	engine.evalConversationRules(rules, conversation)
-	
+	engine.evalConversationRules(rules, conversation, false)
+
 	assert.Equal(t, 1, mockStore.callCount, "Contains operator should match with comma-separated values")
 }
 
@@ -529,8 +531,8 @@ func TestNotContainsOperator(t *testing.T) {
 		},
 	}
 
-	engine.evalConversationRules(rules, conversation)
-	
+	engine.evalConversationRules(rules, conversation, false)
+
 	assert.Equal(t, 1, mockStore.callCount, "Not contains operator should pass when values are not present")
 }
 
@@ -562,8 +564,8 @@ func TestNumericComparisons(t *testing.T) {
 		},
 	}
 
-	engine.evalConversationRules(rules, conversation)
-	
+	engine.evalConversationRules(rules, conversation, false)
+
 	assert.Equal(t, 1, mockStore.callCount, "Greater than operator should work with numeric comparisons")
 }
 
@@ -610,8 +612,8 @@ func TestRealWorld_CSATAutomation(t *testing.T) {
 		},
 	}
 
-	engine.evalConversationRules(rules, conversation)
-	
+	engine.evalConversationRules(rules, conversation, false)
+
 	assert.Equal(t, 1, mockStore.callCount, "CSAT should be sent when status is resolved and client_id matches")
 	assert.Equal(t, models.ActionSendCSAT, mockStore.appliedActions[0].Type)
 }
@@ -646,8 +648,8 @@ func TestRealWorld_NewTicketAutomation(t *testing.T) {
 		},
 	}
 
-	engine.evalConversationRules(rules, conversation)
-	
+	engine.evalConversationRules(rules, conversation, false)
+
 	assert.Equal(t, 2, mockStore.callCount, "Both actions should be executed for new ticket")
 	assert.Equal(t, models.ActionSendPrivateNote, mockStore.appliedActions[0].Type)
 	assert.Equal(t, models.ActionSetSLA, mockStore.appliedActions[1].Type)
@@ -733,8 +735,8 @@ func TestCaseSensitivity_ContainsOperators(t *testing.T) {
 				},
 			}
 
-			engine.evalConversationRules(rules, conversation)
-			
+			engine.evalConversationRules(rules, conversation, false)
+
 			if tc.shouldMatch {
 				assert.Equal(t, 1, mockStore.callCount, "Expected action to be triggered")
 			} else {
@@ -778,8 +780,8 @@ func TestCaseSensitivity_Equals(t *testing.T) {
 		},
 	}
 
-	engine.evalConversationRules(rules, conversation)
-	
+	engine.evalConversationRules(rules, conversation, false)
+
 	assert.Equal(t, 1, mockStore.callCount, "Case insensitive comparison should match")
 }
 
@@ -808,8 +810,8 @@ func TestInvalidOperator(t *testing.T) {
 		},
 	}
 
-	engine.evalConversationRules(rules, conversation)
-	
+	engine.evalConversationRules(rules, conversation, false)
+
 	assert.Equal(t, 0, mockStore.callCount, "Invalid operator should not trigger action")
 }
 
@@ -841,8 +843,8 @@ func TestContradictoryConditions(t *testing.T) {
 		},
 	}
 
-	engine.evalConversationRules(rules, conversation)
-	
+	engine.evalConversationRules(rules, conversation, false)
+
 	assert.Equal(t, 0, mockStore.callCount, "Contradictory conditions should never match")
 }
 
@@ -875,8 +877,8 @@ func TestTautologyCondition(t *testing.T) {
 		},
 	}
 
-	engine.evalConversationRules(rules, conversation)
-	
+	engine.evalConversationRules(rules, conversation, false)
+
 	assert.Equal(t, 1, mockStore.callCount, "Tautology condition should always match")
 }
 
@@ -887,10 +889,10 @@ func TestCustomAttributeTypes(t *testing.T) {
 	engine := createTestEngine(mockStore)
 
 	customAttrs := map[string]interface{}{
-		"age":        25,          // int
-		"score":      98.5,        // float64
-		"is_premium": true,        // bool
-		"name":       "TestUser",  // string
+		"age":        25,         // int
+		"score":      98.5,       // float64
+		"is_premium": true,       // bool
+		"name":       "TestUser", // string
 	}
 	customJSON, _ := json.Marshal(customAttrs)
 
@@ -918,7 +920,7 @@ func TestCustomAttributeTypes(t *testing.T) {
 		}
 		mockStore.appliedActions = nil
 		mockStore.callCount = 0
-		engine.evalConversationRules(rules, conversation)
+		engine.evalConversationRules(rules, conversation, false)
 		assert.Equal(t, 1, mockStore.callCount, "Integer custom attribute should be compared correctly")
 	})
 
@@ -942,7 +944,7 @@ func TestCustomAttributeTypes(t *testing.T) {
 		}
 		mockStore.appliedActions = nil
 		mockStore.callCount = 0
-		engine.evalConversationRules(rules, conversation)
+		engine.evalConversationRules(rules, conversation, false)
 		assert.Equal(t, 1, mockStore.callCount, "Float custom attribute should be converted to int for comparison")
 	})
 
@@ -966,7 +968,7 @@ func TestCustomAttributeTypes(t *testing.T) {
 		}
 		mockStore.appliedActions = nil
 		mockStore.callCount = 0
-		engine.evalConversationRules(rules, conversation)
+		engine.evalConversationRules(rules, conversation, false)
 		assert.Equal(t, 1, mockStore.callCount, "Boolean custom attribute should be compared correctly")
 	})
 }
@@ -998,8 +1000,8 @@ func TestHoursSinceFields_NullHandling(t *testing.T) {
 		},
 	}
 
-	engine.evalConversationRules(rules, conversation)
-	
+	engine.evalConversationRules(rules, conversation, false)
+
 	assert.Equal(t, 0, mockStore.callCount, "Should not trigger action when time field is null")
 }
 
@@ -1034,8 +1036,8 @@ func TestMultipleActions(t *testing.T) {
 		},
 	}
 
-	engine.evalConversationRules(rules, conversation)
-	
+	engine.evalConversationRules(rules, conversation, false)
+
 	assert.Equal(t, 4, mockStore.callCount, "All actions should be executed")
 	assert.Equal(t, models.ActionSetStatus, mockStore.appliedActions[0].Type)
 	assert.Equal(t, models.ActionSetPriority, mockStore.appliedActions[1].Type)
@@ -1099,27 +1101,27 @@ func TestContainsOperator_TextNormalization(t *testing.T) {
 		},
 	}
 
-	engine.evalConversationRules(rules, conversation)
-	
+	engine.evalConversationRules(rules, conversation, false)
+
 	assert.Equal(t, 1, mockStore.callCount, "Contains should normalize whitespace and match")
 }
 
 // Test: Mock verification precision (The Mock Verifier's Gauntlet)
 func TestMockVerificationPrecision(t *testing.T) {
 	mockStore := new(mockConversationStore)
-	
+
 	expectedAction := models.RuleAction{
 		Type:  models.ActionReply,
 		Value: []string{"<p>Test reply automation!</p>"},
 	}
-	
+
 	conversation := createTestConversation(func(c *cmodels.Conversation) {
 		c.Contact.Email = null.StringFrom("libredesk.io@gmail.com")
 	})
-	
+
 	// Set up precise expectation
 	mockStore.On("ApplyAction", expectedAction, conversation, umodels.User{}).Return(nil).Once()
-	
+
 	engine := createTestEngine(mockStore)
 
 	rules := []models.Rule{
@@ -1132,14 +1134,14 @@ func TestMockVerificationPrecision(t *testing.T) {
 					},
 				},
 			},
-			Actions: []models.RuleAction{expectedAction},
+			Actions:       []models.RuleAction{expectedAction},
 			GroupOperator: models.OperatorOR,
 			ExecutionMode: models.ExecutionModeAll,
 		},
 	}
 
-	engine.evalConversationRules(rules, conversation)
-	
+	engine.evalConversationRules(rules, conversation, false)
+
 	// This will verify the exact parameters were passed
 	mockStore.AssertExpectations(t)
 	assert.Equal(t, 1, mockStore.callCount, "Action should be called exactly once")
@@ -1193,9 +1195,291 @@ func TestComplexRealWorldScenario(t *testing.T) {
 		},
 	}
 
-	engine.evalConversationRules(rules, conversation)
-	
+	engine.evalConversationRules(rules, conversation, false)
+
 	assert.Equal(t, 2, mockStore.callCount, "Complex conditions met, both actions should trigger")
 	assert.Equal(t, models.ActionSendCSAT, mockStore.appliedActions[0].Type)
 	assert.Equal(t, models.ActionSetTags, mockStore.appliedActions[1].Type)
-}
\ No newline at end of file
+}
+
+// Test: Dry run reports matches and actions without applying them
+func TestDryRun_DoesNotApplyActions(t *testing.T) {
+	mockStore := new(mockConversationStore)
+	engine := createTestEngine(mockStore)
+
+	conversation := createTestConversation(func(c *cmodels.Conversation) {
+		c.StatusID = null.IntFrom(1)
+	})
+
+	matching := createTestRule(
+		[]models.RuleGroup{
+			{
+				LogicalOp: models.OperatorAnd,
+				Rules: []models.RuleDetail{
+					{Field: models.ConversationStatus, Operator: models.RuleOperatorEquals, Value: "1", FieldType: models.FieldTypeConversationField},
+				},
+			},
+		},
+		[]models.RuleAction{
+			{Type: models.ActionSetPriority, Value: []string{"3"}},
+		},
+		models.OperatorAnd,
+	)
+	matching.ID = 7
+	notMatching := createTestRule(
+		[]models.RuleGroup{
+			{
+				LogicalOp: models.OperatorAnd,
+				Rules: []models.RuleDetail{
+					{Field: models.ConversationStatus, Operator: models.RuleOperatorEquals, Value: "2", FieldType: models.FieldTypeConversationField},
+				},
+			},
+		},
+		[]models.RuleAction{
+			{Type: models.ActionSetStatus, Value: []string{"3"}},
+		},
+		models.OperatorAnd,
+	)
+
+	results := engine.evalConversationRules([]models.Rule{matching, notMatching}, conversation, true)
+
+	assert.Equal(t, 0, mockStore.callCount, "ApplyAction should not be called in dry run")
+	assert.Len(t, results, 2)
+	assert.True(t, results[0].Matched)
+	assert.Equal(t, 7, results[0].RuleID)
+	assert.Equal(t, []bool{true}, []bool(results[0].GroupResults))
+	assert.JSONEq(t, `[{"type":"set_priority","value":["3"]}]`, string(results[0].Actions))
+	assert.False(t, results[1].Matched)
+	assert.Nil(t, results[1].Actions)
+}
+
+// Test: Conditions on conversation fields, custom attributes and the newer operators
+func TestEvaluateRule_FieldsAndOperators(t *testing.T) {
+	engine := createTestEngine(new(mockConversationStore))
+	engine.loadFacts = func(conversationID int) (models.ConversationFacts, error) {
+		return models.ConversationFacts{MessageCount: 4, SLAStatus: "breached", CSATScore: null.IntFrom(2)}, nil
+	}
+
+	conversation := createTestConversation(func(c *cmodels.Conversation) {
+		c.Subject = null.StringFrom("Refund for order #1234")
+		c.InboxChannel = "email"
+		c.Tags = null.JSONFrom([]byte(`["billing","vip-lost"]`))
+		c.CustomAttributes = json.RawMessage(`{"plan": "Enterprise", "seats": 25}`)
+	})
+
+	tests := []struct {
+		name     string
+		rule     models.RuleDetail
+		expected bool
+	}{
+		{"starts with", models.RuleDetail{Field: models.ConversationSubject, Operator: models.RuleOperatorStartsWith, Value: "refund"}, true},
+		{"starts with any of", models.RuleDetail{Field: models.ConversationSubject, Operator: models.RuleOperatorStartsWith, Value: "invoice, refund"}, true},
+		{"starts with case sensitive", models.RuleDetail{Field: models.ConversationSubject, Operator: models.RuleOperatorStartsWith, Value: "refund", CaseSensitiveMatch: true}, false},
+		{"ends with", models.RuleDetail{Field: models.ConversationSubject, Operator: models.RuleOperatorEndsWith, Value: "#1234"}, true},
+		{"matches regex", models.RuleDetail{Field: models.ConversationSubject, Operator: models.RuleOperatorRegex, Value: `ORDER #\d+$`}, true},
+		{"matches regex case sensitive", models.RuleDetail{Field: models.ConversationSubject, Operator: models.RuleOperatorRegex, Value: `ORDER #\d+$`, CaseSensitiveMatch: true}, false},
+		{"invalid regex", models.RuleDetail{Field: models.ConversationSubject, Operator: models.RuleOperatorRegex, Value: `order (`}, false},
+		{"channel is one of", models.RuleDetail{Field: models.ConversationChannel, Operator: models.RuleOperatorIsOneOf, Value: "livechat,email"}, true},
+		{"channel not in list", models.RuleDetail{Field: models.ConversationChannel, Operator: models.RuleOperatorInList, Value: "livechat, api"}, false},
+		{"contact name", models.RuleDetail{Field: models.ContactName, Operator: models.RuleOperatorEquals, Value: "test user"}, true},
+		{"contact email domain", models.RuleDetail{Field: models.ContactEmailDomain, Operator: models.RuleOperatorInList, Value: "example.com, example.org"}, true},
+		{"tags contain tag", models.RuleDetail{Field: models.ConversationTags, Operator: models.RuleOperatorContains, Value: "BILLING"}, true},
+		{"tags match whole tag", models.RuleDetail{Field: models.ConversationTags, Operator: models.RuleOperatorContains, Value: "vip"}, false},
+		{"tags do not contain", models.RuleDetail{Field: models.ConversationTags, Operator: models.RuleOperatorNotContains, Value: "vip, spam"}, true},
+		{"tags set", models.RuleDetail{Field: models.ConversationTags, Operator: models.RuleOperatorSet}, true},
+		{"conversation custom attribute", models.RuleDetail{Field: "plan", FieldType: models.FieldTypeConversationCustomAttribute, Operator: models.RuleOperatorEquals, Value: "enterprise"}, true},
+		{"conversation custom attribute number", models.RuleDetail{Field: "seats", FieldType: models.FieldTypeConversationCustomAttribute, Operator: models.RuleOperatorGreaterThan, Value: "10"}, true},
+		{"missing conversation custom attribute", models.RuleDetail{Field: "region", FieldType: models.FieldTypeConversationCustomAttribute, Operator: models.RuleOperatorNotSet}, false},
+		{"message count", models.RuleDetail{Field: models.ConversationMessageCount, Operator: models.RuleOperatorGreaterThan, Value: "3"}, true},
+		{"SLA status", models.RuleDetail{Field: models.ConversationSLAStatus, Operator: models.RuleOperatorEquals, Value: "breached"}, true},
+		{"CSAT score", models.RuleDetail{Field: models.ConversationCSATScore, Operator: models.RuleOperatorLessThan, Value: "3"}, true},
+		{"no business hours configured is open", models.RuleDetail{Field: models.ConversationWithinBusinessHours, Operator: models.RuleOperatorEquals, Value: "true"}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, engine.evaluateRule(tt.rule, conversation, &factsLoader{load: func() (models.ConversationFacts, error) {
+				return engine.loadFacts(conversation.ID)
+			}}))
+		})
+	}
+}
+
+// Test: Conversation facts are loaded once per conversation evaluation
+func TestEvalConversationRules_LoadsFactsOnce(t *testing.T) {
+	mockStore := new(mockConversationStore)
+	mockStore.On("ApplyAction", mock.Anything, mock.Anything, mock.Anything).Return(nil)
+	engine := createTestEngine(mockStore)
+
+	loads := 0
+	engine.loadFacts = func(conversationID int) (models.ConversationFacts, error) {
+		loads++
+		return models.ConversationFacts{MessageCount: 1, CSATScore: null.IntFrom(5)}, nil
+	}
+
+	rules := []models.Rule{
+		createTestRule(
+			[]models.RuleGroup{
+				{
+					LogicalOp: models.OperatorAnd,
+					Rules: []models.RuleDetail{
+						{Field: models.ConversationMessageCount, Operator: models.RuleOperatorEquals, Value: "1", FieldType: models.FieldTypeConversationField},
+						{Field: models.ConversationCSATScore, Operator: models.RuleOperatorEquals, Value: "5", FieldType: models.FieldTypeConversationField},
+					},
+				},
+			},
+			[]models.RuleAction{
+				{Type: models.ActionSetStatus, Value: []string{"2"}},
+			},
+			models.OperatorAnd,
+		),
+	}
+
+	engine.evalConversationRules(rules, createTestConversation(), false)
+
+	assert.Equal(t, 1, loads, "facts should be loaded once")
+	assert.Equal(t, 1, mockStore.callCount)
+}
+
+// Test: Delayed actions are cancelled when one of their cancel conditions is met
+func TestScheduledActionCancelReason(t *testing.T) {
+	engine := createTestEngine(new(mockConversationStore))
+	rule := createTestRule(
+		[]models.RuleGroup{
+			{
+				LogicalOp: models.OperatorAnd,
+				Rules: []models.RuleDetail{
+					{Field: models.ConversationStatus, Operator: models.RuleOperatorEquals, Value: "2", FieldType: models.FieldTypeConversationField},
+				},
+			},
+		},
+		nil,
+		models.OperatorAnd,
+	)
+	rule.ID = 7
+	engine.rules = []models.Rule{rule}
+
+	matching := createTestConversation(func(c *cmodels.Conversation) { c.StatusID = null.IntFrom(2) })
+	changed := createTestConversation(func(c *cmodels.Conversation) { c.StatusID = null.IntFrom(3) })
+
+	tests := []struct {
+		name         string
+		action       models.ScheduledAction
+		conversation cmodels.Conversation
+		expected     string
+	}{
+		{"nothing changed", models.ScheduledAction{RuleID: 7, CancelOn: []string{models.CancelOnContactReplied}}, matching, ""},
+		{"contact replied", models.ScheduledAction{RuleID: 7, ContactReplied: true, CancelOn: []string{models.CancelOnContactReplied}}, matching, models.CancelOnContactReplied},
+		{"contact replied without cancel condition", models.ScheduledAction{RuleID: 7, ContactReplied: true}, matching, ""},
+		{"status changed", models.ScheduledAction{RuleID: 7, StatusChanged: true, CancelOn: []string{models.CancelOnContactReplied, models.CancelOnStatusChanged}}, changed, models.CancelOnStatusChanged},
+		{"assignee changed", models.ScheduledAction{RuleID: 7, AssigneeChanged: true, CancelOn: []string{models.CancelOnAssigneeChanged}}, matching, models.CancelOnAssigneeChanged},
+		{"conditions still match", models.ScheduledAction{RuleID: 7, CancelOn: []string{models.CancelOnConditionsUnmet}}, matching, ""},
+		{"conditions no longer match", models.ScheduledAction{RuleID: 7, CancelOn: []string{models.CancelOnConditionsUnmet}}, changed, models.CancelOnConditionsUnmet},
+		{"rule disabled", models.ScheduledAction{RuleID: 8}, matching, cancelReasonRuleDisabled},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, engine.scheduledActionCancelReason(tt.action, tt.conversation))
+		})
+	}
+}
+
+// fakeBusinessHoursStore returns fixed business hours for every team and records the teams looked up.
+type fakeBusinessHoursStore struct {
+	bh       bmodels.BusinessHours
+	timezone string
+	err      error
+	teamIDs  []int
+}
+
+func (s *fakeBusinessHoursStore) GetBusinessHoursAndTimezone(assignedTeamID int) (bmodels.BusinessHours, string, error) {
+	s.teamIDs = append(s.teamIDs, assignedTeamID)
+	return s.bh, s.timezone, s.err
+}
+
+// Test: Business hours condition resolves the business hours of the conversation's team
+func TestEvaluateRule_WithinBusinessHours(t *testing.T) {
+	var (
+		rule         = models.RuleDetail{Field: models.ConversationWithinBusinessHours, Operator: models.RuleOperatorEquals, Value: "true"}
+		conversation = createTestConversation(func(c *cmodels.Conversation) { c.AssignedTeamID = null.IntFrom(4) })
+		today        = time.Now().UTC()
+		holiday      = types.JSONText(fmt.Sprintf(`[{"name": "Today", "date": %q}]`, today.Format(time.DateOnly)))
+	)
+
+	tests := []struct {
+		name     string
+		store    *fakeBusinessHoursStore
+		expected bool
+	}{
+		{"not configured is open", &fakeBusinessHoursStore{err: businesshours.ErrBusinessHoursNotConfigured}, true},
+		{"always open", &fakeBusinessHoursStore{bh: bmodels.BusinessHours{IsAlwaysOpen: true}, timezone: "UTC"}, true},
+		{"holiday is closed", &fakeBusinessHoursStore{bh: bmodels.BusinessHours{Hours: types.JSONText(`{}`), Holidays: holiday}, timezone: "UTC"}, false},
+		{"no working hours is closed", &fakeBusinessHoursStore{bh: bmodels.BusinessHours{Hours: types.JSONText(`{}`)}, timezone: "UTC"}, false},
+		{"lookup error", &fakeBusinessHoursStore{err: errors.New("db down")}, false},
+		{"invalid timezone", &fakeBusinessHoursStore{bh: bmodels.BusinessHours{Hours: types.JSONText(`{}`)}, timezone: "Mars/Olympus"}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			engine := createTestEngine(new(mockConversationStore))
+			engine.SetBusinessHoursStore(tt.store)
+			assert.Equal(t, tt.expected, engine.evaluateRule(rule, conversation, &factsLoader{}))
+			assert.Equal(t, []int{4}, tt.store.teamIDs)
+		})
+	}
+}
+
+// Test: A delayed action that is still pending is not scheduled again when the rule matches again
+func TestEvalConversationRules_DelayedActionPending(t *testing.T) {
+	mockStore := new(mockConversationStore)
+	mockStore.On("ApplyAction", mock.Anything, mock.Anything, mock.Anything).Return(nil)
+	engine := createTestEngine(mockStore)
+
+	// Mirrors the insert-scheduled-action query, which returns the pending run instead of inserting a duplicate.
+	pending := map[string]time.Time{}
+	inserts := 0
+	engine.insertScheduledAction = func(ruleID, conversationID int, action []byte, delayMinutes int, cancelOn pq.StringArray) (models.ScheduledRun, error) {
+		key := fmt.Sprintf("%d:%d:%s", ruleID, conversationID, action)
+		if runAt, ok := pending[key]; ok {
+			return models.ScheduledRun{RunAt: runAt}, nil
+		}
+		inserts++
+		runAt := time.Now().Add(time.Duration(delayMinutes) * time.Minute)
+		pending[key] = runAt
+		return models.ScheduledRun{RunAt: runAt, Scheduled: true}, nil
+	}
+
+	rule := createTestRule(
+		[]models.RuleGroup{
+			{
+				LogicalOp: models.OperatorAnd,
+				Rules: []models.RuleDetail{
+					{Field: models.ConversationStatus, Operator: models.RuleOperatorEquals, Value: "1", FieldType: models.FieldTypeConversationField},
+				},
+			},
+		},
+		[]models.RuleAction{{Type: models.ActionSetPriority, Value: []string{"3"}, DelayMinutes: 60}},
+		models.OperatorAnd,
+	)
+	rule.ID = 7
+	conversation := createTestConversation()
+
+	var runAts []time.Time
+	for range 2 {
+		results := engine.evalConversationRules([]models.Rule{rule}, conversation, false)
+		require.Len(t, results, 1)
+		assert.False(t, results[0].HasErrors)
+
+		var actions []models.ActionResult
+		require.NoError(t, json.Unmarshal(results[0].Actions, &actions))
+		require.Len(t, actions, 1)
+		require.NotNil(t, actions[0].RunAt)
+		runAts = append(runAts, *actions[0].RunAt)
+	}
+
+	assert.Equal(t, 1, inserts)
+	assert.Len(t, pending, 1)
+	assert.True(t, runAts[0].Equal(runAts[1]))
+	assert.Equal(t, 0, mockStore.callCount)
+}
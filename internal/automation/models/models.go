@@ -5,7 +5,9 @@ import (
 	"time"
 
 	authzModels "github.com/abhinavxd/libredesk/internal/authz/models"
+	"github.com/jmoiron/sqlx/types"
 	"github.com/lib/pq"
+	"github.com/volatiletech/null/v9"
 )
 
 const (
@@ -20,6 +22,9 @@ const (
 	ActionSetTags         = "set_tags"
 	ActionRemoveTags      = "remove_tags"
 	ActionSendCSAT        = "send_csat"
+	ActionAISummarize     = "ai_summarize"
+	ActionAIAutoTag       = "ai_auto_tag"
+	ActionHTTPRequest     = "http_request"
 
 	OperatorAnd = "AND"
 	OperatorOR  = "OR"
@@ -32,6 +37,13 @@ const (
 	RuleOperatorNotSet      = "not set"
 	RuleOperatorGreaterThan = "greater than"
 	RuleOperatorLessThan    = "less than"
+	RuleOperatorStartsWith  = "starts with"
+	RuleOperatorEndsWith    = "ends with"
+	RuleOperatorRegex       = "matches regex"
+	// RuleOperatorInList matches a comma separated list of free text values.
+	RuleOperatorInList = "in list"
+	// RuleOperatorIsOneOf matches a comma separated list of selected option values, eg: status IDs.
+	RuleOperatorIsOneOf = "is one of"
 
 	RuleTypeNewConversation    = "new_conversation"
 	RuleTypeConversationUpdate = "conversation_update"
@@ -49,6 +61,14 @@ const (
 	ConversationHoursSinceResolved   = "hours_since_resolved"
 	ConversationInbox                = "inbox"
 	ContactEmail                     = "contact_email"
+	ContactName                      = "contact_name"
+	ContactEmailDomain               = "contact_email_domain"
+	ConversationTags                 = "tags"
+	ConversationChannel              = "channel"
+	ConversationMessageCount         = "message_count"
+	ConversationWithinBusinessHours  = "within_business_hours"
+	ConversationSLAStatus            = "sla_status"
+	ConversationCSATScore            = "csat_score"
 
 	EventConversationUserAssigned    = "conversation.user.assigned"
 	EventConversationTeamAssigned    = "conversation.team.assigned"
@@ -61,7 +81,21 @@ const (
 	ExecutionModeFirstMatch = "first_match"
 
 	FieldTypeContactCustomAttribute      = "contact_custom_attribute"
+	FieldTypeConversationCustomAttribute = "conversation_custom_attribute"
 	FieldTypeConversationField           = "conversation"
+
+	// Conditions that cancel a delayed action before it runs.
+	CancelOnContactReplied  = "contact_replied"
+	CancelOnStatusChanged   = "status_changed"
+	CancelOnAssigneeChanged = "assignee_changed"
+	CancelOnConditionsUnmet = "conditions_unmet"
+
+	ScheduledActionStatusCompleted = "completed"
+	ScheduledActionStatusCancelled = "cancelled"
+	ScheduledActionStatusFailed    = "failed"
+
+	// Maximum delay of a delayed action, one year.
+	MaxActionDelayMinutes = 525600
 )
 
 // ActionPermissions maps actions to permissions
@@ -77,6 +111,11 @@ var ActionPermissions = map[string]string{
 	ActionRemoveTags:      authzModels.PermConversationsUpdateTags,
 }
 
+// ActionWithoutValue returns true if the action does not take a value.
+func ActionWithoutValue(action string) bool {
+	return action == ActionSendCSAT || action == ActionAISummarize || action == ActionAIAutoTag
+}
+
 // RuleRecord represents a rule record in the database
 type RuleRecord struct {
 	ID            int             `db:"id" json:"id"`
@@ -92,7 +131,57 @@ type RuleRecord struct {
 	Rules         json.RawMessage `db:"rules" json:"rules"`
 }
 
+// RuleVersion is a snapshot of a rule saved on every change.
+type RuleVersion struct {
+	ID          int64           `db:"id" json:"id"`
+	CreatedAt   time.Time       `db:"created_at" json:"created_at"`
+	RuleID      int             `db:"rule_id" json:"rule_id"`
+	Version     int             `db:"version" json:"version"`
+	UserID      null.Int        `db:"user_id" json:"user_id"`
+	UserName    string          `db:"user_name" json:"user_name"`
+	Name        string          `db:"name" json:"name"`
+	Description string          `db:"description" json:"description"`
+	Type        string          `db:"type" json:"type"`
+	Events      pq.StringArray  `db:"events" json:"events"`
+	Rules       json.RawMessage `db:"rules" json:"rules"`
+}
+
+// RuleChange is a value that differs between two versions of a rule, eg: `rules.0.groups.0.rules.1.value`.
+// Old is nil for added values and New is nil for removed values.
+type RuleChange struct {
+	Path string `json:"path"`
+	Old  any    `json:"old"`
+	New  any    `json:"new"`
+}
+
+// RuleExport is the file format rules are exported to and imported from.
+type RuleExport struct {
+	Version    int            `json:"version"`
+	ExportedAt time.Time      `json:"exported_at"`
+	Rules      []ExportedRule `json:"rules"`
+}
+
+// ExportedRule is a rule in an export file.
+type ExportedRule struct {
+	Name        string          `json:"name"`
+	Description string          `json:"description"`
+	Type        string          `json:"type"`
+	Events      []string        `json:"events"`
+	Enabled     bool            `json:"enabled"`
+	Weight      int             `json:"weight"`
+	Rules       json.RawMessage `json:"rules"`
+}
+
+// ImportResult is the number of rules created and updated by an import.
+type ImportResult struct {
+	Created int `json:"created"`
+	Updated int `json:"updated"`
+}
+
 type Rule struct {
+	// ID and Name are set from the rule record the rule belongs to.
+	ID            int          `json:"-"`
+	Name          string       `json:"-"`
 	Type          string       `json:"type"`
 	ExecutionMode string       `json:"execution_mode"`
 	Events        []string     `json:"event"`
@@ -118,4 +207,73 @@ type RuleAction struct {
 	Type         string   `json:"type" db:"type"`
 	Value        []string `json:"value" db:"value"`
 	DisplayValue []string `json:"display_value" db:"-"`
+
+	// DelayMinutes delays the action, which is then stored as a scheduled action and applied later.
+	DelayMinutes int `json:"delay_minutes,omitempty" db:"-"`
+	// CancelOn lists the conditions that cancel a delayed action before it runs.
+	CancelOn []string `json:"cancel_on,omitempty" db:"-"`
+}
+
+// ConversationFacts are values of a conversation used in rule conditions that are not part of the conversation record.
+type ConversationFacts struct {
+	MessageCount int      `db:"message_count"`
+	SLAStatus    string   `db:"sla_status"`
+	CSATScore    null.Int `db:"csat_score"`
+}
+
+// HTTPRequestAction is the config of an http_request action, stored as JSON in the first action value.
+type HTTPRequestAction struct {
+	Method  string            `json:"method"`
+	URL     string            `json:"url"`
+	Headers map[string]string `json:"headers"`
+	// Body is a Go template rendered with the conversation data.
+	Body string `json:"body"`
+	// ResponseMapping maps conversation custom attribute keys to dotted paths in the JSON response.
+	ResponseMapping map[string]string `json:"response_mapping"`
+}
+
+// RuleExecution is the result of evaluating a rule against a conversation.
+type RuleExecution struct {
+	Total            int             `db:"total" json:"-"`
+	ID               int64           `db:"id" json:"id"`
+	CreatedAt        time.Time       `db:"created_at" json:"created_at"`
+	RuleID           int             `db:"rule_id" json:"rule_id"`
+	RuleName         string          `db:"rule_name" json:"rule_name"`
+	ConversationID   int             `db:"conversation_id" json:"conversation_id"`
+	ConversationUUID string          `db:"conversation_uuid" json:"conversation_uuid"`
+	Trigger          string          `db:"trigger" json:"trigger"`
+	Matched          bool            `db:"-" json:"matched"`
+	GroupResults     pq.BoolArray    `db:"group_results" json:"group_results"`
+	Actions          json.RawMessage `db:"actions" json:"actions"`
+	HasErrors        bool            `db:"has_errors" json:"has_errors"`
+}
+
+// ActionResult is the outcome of a single action of a rule execution.
+type ActionResult struct {
+	Type         string     `json:"type"`
+	Value        []string   `json:"value"`
+	Error        string     `json:"error,omitempty"`
+	DelayMinutes int        `json:"delay_minutes,omitempty"`
+	RunAt        *time.Time `json:"run_at,omitempty"`
+}
+
+// ScheduledRun is the result of scheduling a delayed action, Scheduled is false if the same action was already pending.
+type ScheduledRun struct {
+	RunAt     time.Time `db:"run_at"`
+	Scheduled bool      `db:"scheduled"`
+}
+
+// ScheduledAction is a delayed rule action that is due to run.
+type ScheduledAction struct {
+	ID               int64          `db:"id"`
+	RuleID           int            `db:"rule_id"`
+	ConversationID   int            `db:"conversation_id"`
+	ConversationUUID string         `db:"conversation_uuid"`
+	Action           types.JSONText `db:"action"`
+	CancelOn         pq.StringArray `db:"cancel_on"`
+
+	// Changes to the conversation since the action was scheduled.
+	ContactReplied  bool `db:"contact_replied"`
+	StatusChanged   bool `db:"status_changed"`
+	AssigneeChanged bool `db:"assignee_changed"`
 }
@@ -1,5 +1,7 @@
 -- name: get-enabled-rules
 select
+    id,
+    name,
     type,
     events,
     rules,
@@ -49,4 +51,201 @@ WHERE id = $1;
 -- name: update-rule-execution-mode
 UPDATE automation_rules
 SET execution_mode = $2, updated_at = NOW()
-WHERE type = $1;
\ No newline at end of file
+WHERE type = $1;
+
+-- name: insert-rule-execution
+INSERT INTO automation_rule_executions (rule_id, conversation_id, "trigger", group_results, actions, has_errors)
+VALUES ($1, $2, $3, $4, $5, $6);
+
+-- name: get-rule-executions
+SELECT
+    COUNT(*) OVER() AS total,
+    e.id,
+    e.created_at,
+    e.rule_id,
+    r.name AS rule_name,
+    e.conversation_id,
+    c.uuid AS conversation_uuid,
+    e."trigger",
+    e.group_results,
+    e.actions,
+    e.has_errors
+FROM automation_rule_executions e
+JOIN automation_rules r ON r.id = e.rule_id
+JOIN conversations c ON c.id = e.conversation_id
+WHERE ($1 = 0 OR e.rule_id = $1)
+    AND ($2 = '' OR c.uuid::TEXT = $2)
+    AND ($3 = FALSE OR e.has_errors = TRUE)
+ORDER BY e.created_at DESC
+LIMIT $4 OFFSET $5;
+
+-- name: delete-old-rule-executions
+DELETE FROM automation_rule_executions WHERE created_at < NOW() - $1::INTERVAL;
+
+-- name: get-conversation-facts
+SELECT
+    (SELECT COUNT(*) FROM conversation_messages m
+     WHERE m.conversation_id = c.id AND m.type IN ('incoming', 'outgoing') AND m.private = false) AS message_count,
+    COALESCE((SELECT a.status::TEXT FROM applied_slas a
+     WHERE a.conversation_id = c.id ORDER BY a.created_at DESC LIMIT 1), '') AS sla_status,
+    (SELECT r.rating FROM csat_responses r
+     WHERE r.conversation_id = c.id AND r.response_timestamp IS NOT NULL ORDER BY r.created_at DESC LIMIT 1) AS csat_score
+FROM conversations c
+WHERE c.id = $1;
+
+-- name: get-time-trigger-conversations
+-- Returns a batch of conversation UUIDs matching the SQL side filters of a time trigger rule that the rule has not fired for yet.
+-- Conversations that are not resolved or closed are always included, others only if updated within the look back window.
+SELECT c.id, c.uuid
+FROM conversations c
+WHERE c.id > $1
+AND ((c.resolved_at IS NULL AND c.closed_at IS NULL) OR c.updated_at >= NOW() - $2::INTERVAL)
+AND (CARDINALITY($3::INT[]) = 0 OR c.status_id = ANY($3::INT[]))
+AND (CARDINALITY($4::INT[]) = 0 OR c.priority_id = ANY($4::INT[]))
+AND (CARDINALITY($5::INT[]) = 0 OR c.inbox_id = ANY($5::INT[]))
+AND (CARDINALITY($6::INT[]) = 0 OR c.assigned_team_id = ANY($6::INT[]))
+AND (CARDINALITY($7::INT[]) = 0 OR c.assigned_user_id = ANY($7::INT[]))
+AND ($8::INT = 0 OR c.created_at <= NOW() - make_interval(hours => $8::INT))
+AND ($9::INT = 0 OR c.first_reply_at <= NOW() - make_interval(hours => $9::INT))
+AND ($10::INT = 0 OR c.last_reply_at <= NOW() - make_interval(hours => $10::INT))
+AND ($11::INT = 0 OR c.resolved_at <= NOW() - make_interval(hours => $11::INT))
+AND NOT EXISTS (
+    SELECT 1 FROM automation_rule_firings f WHERE f.rule_id = $12 AND f.conversation_id = c.id
+)
+ORDER BY c.id
+LIMIT $13;
+
+-- name: insert-rule-firing
+INSERT INTO automation_rule_firings (rule_id, conversation_id)
+VALUES ($1, $2)
+ON CONFLICT DO NOTHING;
+
+-- name: get-rule-firings
+SELECT rule_id FROM automation_rule_firings WHERE conversation_id = $1;
+
+-- name: reset-rule-firings
+-- Deletes the firings of a time trigger rule if its type or rules are being changed so that it is evaluated again against all conversations.
+DELETE FROM automation_rule_firings
+WHERE rule_id = $1
+AND EXISTS (
+    SELECT 1 FROM automation_rules r WHERE r.id = $1 AND (r.type != $2 OR r.rules IS DISTINCT FROM $3::JSONB)
+);
+
+-- name: insert-scheduled-action
+-- Snapshots the conversation status and assignee so that the action can be cancelled if they change.
+-- An action of a rule that is already pending for the conversation is not scheduled again, the run time of the pending action is returned instead.
+WITH pending AS (
+    SELECT run_at FROM automation_scheduled_actions
+    WHERE rule_id = $1 AND conversation_id = $2 AND "action" = $3::JSONB AND status = 'pending'
+    LIMIT 1
+),
+inserted AS (
+    INSERT INTO automation_scheduled_actions (rule_id, conversation_id, "action", run_at, cancel_on, status_id, assigned_user_id)
+    SELECT $1, c.id, $3, NOW() + make_interval(mins => $4::INT), $5, c.status_id, c.assigned_user_id
+    FROM conversations c
+    WHERE c.id = $2 AND NOT EXISTS (SELECT 1 FROM pending)
+    RETURNING run_at
+)
+SELECT run_at, TRUE AS scheduled FROM inserted
+UNION ALL
+SELECT run_at, FALSE AS scheduled FROM pending;
+
+-- name: claim-due-scheduled-actions
+-- Picks due actions, and actions stuck in processing from a previous run, and marks them as processing.
+UPDATE automation_scheduled_actions sa
+SET status = 'processing', updated_at = NOW()
+FROM conversations c
+WHERE c.id = sa.conversation_id
+AND sa.id IN (
+    SELECT id FROM automation_scheduled_actions
+    WHERE (status = 'pending' AND run_at <= NOW())
+    OR (status = 'processing' AND updated_at < NOW() - INTERVAL '10 minutes')
+    ORDER BY run_at
+    LIMIT $1
+    FOR UPDATE SKIP LOCKED
+)
+RETURNING
+    sa.id,
+    sa.rule_id,
+    sa.conversation_id,
+    c.uuid AS conversation_uuid,
+    sa.action,
+    sa.cancel_on,
+    EXISTS (
+        SELECT 1 FROM conversation_messages m
+        WHERE m.conversation_id = sa.conversation_id AND m.type = 'incoming' AND m.created_at > sa.created_at
+    ) AS contact_replied,
+    c.status_id IS DISTINCT FROM sa.status_id AS status_changed,
+    c.assigned_user_id IS DISTINCT FROM sa.assigned_user_id AS assignee_changed;
+
+-- name: update-scheduled-action-status
+UPDATE automation_scheduled_actions
+SET status = $2, reason = NULLIF($3, ''), updated_at = NOW()
+WHERE id = $1;
+
+-- name: delete-old-scheduled-actions
+DELETE FROM automation_scheduled_actions
+WHERE status IN ('completed', 'cancelled', 'failed') AND updated_at < NOW() - $1::INTERVAL;
+
+-- name: insert-rule-version
+-- Snapshots the current state of a rule as its next version.
+INSERT INTO automation_rule_versions (rule_id, "version", user_id, "name", description, "type", rules, events)
+SELECT r.id,
+    COALESCE((SELECT MAX("version") FROM automation_rule_versions WHERE rule_id = r.id), 0) + 1,
+    NULLIF($2, 0),
+    r.name,
+    r.description,
+    r.type,
+    r.rules,
+    r.events
+FROM automation_rules r
+WHERE r.id = $1;
+
+-- name: get-rule-versions
+SELECT
+    v.id,
+    v.created_at,
+    v.rule_id,
+    v."version",
+    v.user_id,
+    COALESCE(TRIM(CONCAT(u.first_name, ' ', u.last_name)), '') AS user_name,
+    v."name",
+    COALESCE(v.description, '') AS description,
+    v."type",
+    v.events,
+    v.rules
+FROM automation_rule_versions v
+LEFT JOIN users u ON u.id = v.user_id
+WHERE v.rule_id = $1
+ORDER BY v."version" DESC;
+
+-- name: get-rule-version
+SELECT
+    v.id,
+    v.created_at,
+    v.rule_id,
+    v."version",
+    v.user_id,
+    COALESCE(TRIM(CONCAT(u.first_name, ' ', u.last_name)), '') AS user_name,
+    v."name",
+    COALESCE(v.description, '') AS description,
+    v."type",
+    v.events,
+    v.rules
+FROM automation_rule_versions v
+LEFT JOIN users u ON u.id = v.user_id
+WHERE v.rule_id = $1 AND v."version" = $2;
+
+-- name: get-rules-for-export
+SELECT id, created_at, updated_at, "name", COALESCE(description, '') AS description, "type", rules, events, enabled, weight, execution_mode
+FROM automation_rules
+WHERE ($1 = '' OR "type" = $1)
+ORDER BY "type", weight;
+
+-- name: get-rule-id-by-name
+SELECT id FROM automation_rules WHERE "name" = $1 AND "type" = $2 ORDER BY id LIMIT 1;
+
+-- name: import-rule
+INSERT INTO automation_rules ("name", description, "type", events, rules, enabled, weight)
+VALUES ($1, $2, $3, $4, $5, $6, $7)
+RETURNING id;
@@ -0,0 +1,185 @@
+package automation
+
+import (
+	"context"
+	"encoding/json"
+	"slices"
+	"time"
+
+	"github.com/abhinavxd/libredesk/internal/automation/models"
+	cmodels "github.com/abhinavxd/libredesk/internal/conversation/models"
+	umodels "github.com/abhinavxd/libredesk/internal/user/models"
+	"github.com/lib/pq"
+)
+
+const (
+	// Number of due scheduled actions picked at a time.
+	scheduledActionBatchSize = 100
+
+	// Trigger recorded in the execution log for delayed actions.
+	scheduledActionTrigger = "scheduled_action"
+
+	// Reason recorded for scheduled actions cancelled because their rule was disabled.
+	cancelReasonRuleDisabled = "rule_disabled"
+)
+
+// scheduleAction stores a delayed action of a matched rule to be applied once the delay has passed.
+// An action that is already pending for the conversation is not scheduled again and the pending run time is returned.
+func (e *Engine) scheduleAction(rule models.Rule, action models.RuleAction, conversation cmodels.Conversation) (time.Time, error) {
+	var runAt time.Time
+	b, err := json.Marshal(action)
+	if err != nil {
+		return runAt, err
+	}
+	cancelOn := pq.StringArray(action.CancelOn)
+	if cancelOn == nil {
+		cancelOn = pq.StringArray{}
+	}
+	run, err := e.insertScheduledAction(rule.ID, conversation.ID, b, action.DelayMinutes, cancelOn)
+	if err != nil {
+		return runAt, err
+	}
+	if !run.Scheduled {
+		e.lo.Debug("delayed action is already pending, not scheduling it again", "rule_id", rule.ID, "conversation_id", conversation.ID, "action", action.Type)
+	}
+	return run.RunAt, nil
+}
+
+// queryInsertScheduledAction stores a delayed action unless the same action of the rule is already pending for the conversation.
+func (e *Engine) queryInsertScheduledAction(ruleID, conversationID int, action []byte, delayMinutes int, cancelOn pq.StringArray) (models.ScheduledRun, error) {
+	var run models.ScheduledRun
+	err := e.q.InsertScheduledAction.Get(&run, ruleID, conversationID, action, delayMinutes, cancelOn)
+	return run, err
+}
+
+// RunScheduledActions periodically applies delayed actions that are due.
+func (e *Engine) RunScheduledActions(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			e.processScheduledActions(ctx)
+		}
+	}
+}
+
+// processScheduledActions applies due scheduled actions in batches.
+func (e *Engine) processScheduledActions(ctx context.Context) {
+	for {
+		var due []models.ScheduledAction
+		if err := e.q.ClaimDueScheduledActions.SelectContext(ctx, &due, scheduledActionBatchSize); err != nil {
+			e.lo.Error("error fetching due scheduled actions", "error", err)
+			return
+		}
+		for _, sa := range due {
+			e.runScheduledAction(sa)
+		}
+		if len(due) < scheduledActionBatchSize || ctx.Err() != nil {
+			return
+		}
+	}
+}
+
+// runScheduledAction applies a scheduled action unless one of its cancel conditions is met.
+func (e *Engine) runScheduledAction(sa models.ScheduledAction) {
+	var action models.RuleAction
+	if err := json.Unmarshal(sa.Action, &action); err != nil {
+		e.lo.Error("error unmarshalling scheduled action", "id", sa.ID, "error", err)
+		e.setScheduledActionStatus(sa.ID, models.ScheduledActionStatusFailed, err.Error())
+		return
+	}
+
+	conversation, err := e.conversationStore.GetConversation(0, sa.ConversationUUID, "")
+	if err != nil {
+		e.lo.Error("error fetching conversation for scheduled action", "id", sa.ID, "uuid", sa.ConversationUUID, "error", err)
+		e.setScheduledActionStatus(sa.ID, models.ScheduledActionStatusFailed, err.Error())
+		return
+	}
+
+	if reason := e.scheduledActionCancelReason(sa, conversation); reason != "" {
+		e.lo.Info("cancelling scheduled action", "id", sa.ID, "conversation_uuid", sa.ConversationUUID, "reason", reason)
+		e.setScheduledActionStatus(sa.ID, models.ScheduledActionStatusCancelled, reason)
+		return
+	}
+
+	var (
+		res  = models.ActionResult{Type: action.Type, Value: action.Value, DelayMinutes: action.DelayMinutes}
+		exec = models.RuleExecution{
+			RuleID:           sa.RuleID,
+			ConversationID:   conversation.ID,
+			ConversationUUID: conversation.UUID,
+			Matched:          true,
+		}
+		status = models.ScheduledActionStatusCompleted
+		reason = ""
+	)
+	action.DelayMinutes = 0
+	if err := e.conversationStore.ApplyAction(action, conversation, umodels.User{}); err != nil {
+		e.lo.Error("error applying scheduled action", "id", sa.ID, "action", action, "conversation_uuid", conversation.UUID, "error", err)
+		res.Error = err.Error()
+		exec.HasErrors = true
+		status, reason = models.ScheduledActionStatusFailed, err.Error()
+	}
+	exec.Actions, _ = json.Marshal([]models.ActionResult{res})
+	e.recordExecutions(scheduledActionTrigger, []models.RuleExecution{exec})
+	e.setScheduledActionStatus(sa.ID, status, reason)
+}
+
+// scheduledActionCancelReason returns the cancel condition of a scheduled action that is met, if any.
+func (e *Engine) scheduledActionCancelReason(sa models.ScheduledAction, conversation cmodels.Conversation) string {
+	var rules []models.Rule
+	e.rulesMu.RLock()
+	for _, rule := range e.rules {
+		if rule.ID == sa.RuleID {
+			rules = append(rules, rule)
+		}
+	}
+	e.rulesMu.RUnlock()
+
+	// Only enabled rules are loaded.
+	if len(rules) == 0 {
+		return cancelReasonRuleDisabled
+	}
+
+	switch {
+	case sa.ContactReplied && slices.Contains(sa.CancelOn, models.CancelOnContactReplied):
+		return models.CancelOnContactReplied
+	case sa.StatusChanged && slices.Contains(sa.CancelOn, models.CancelOnStatusChanged):
+		return models.CancelOnStatusChanged
+	case sa.AssigneeChanged && slices.Contains(sa.CancelOn, models.CancelOnAssigneeChanged):
+		return models.CancelOnAssigneeChanged
+	}
+
+	if slices.Contains(sa.CancelOn, models.CancelOnConditionsUnmet) {
+		for _, exec := range e.evalConversationRules(rules, conversation, true) {
+			if exec.Matched {
+				return ""
+			}
+		}
+		return models.CancelOnConditionsUnmet
+	}
+	return ""
+}
+
+// setScheduledActionStatus updates the status of a scheduled action.
+func (e *Engine) setScheduledActionStatus(id int64, status, reason string) {
+	if _, err := e.q.UpdateScheduledActionStatus.Exec(id, status, reason); err != nil {
+		e.lo.Error("error updating scheduled action status", "id", id, "status", status, "error", err)
+	}
+}
+
+// pruneScheduledActions deletes scheduled actions that are done and older than the retention period.
+func (e *Engine) pruneScheduledActions() {
+	res, err := e.q.DeleteOldScheduledActions.Exec(executionLogRetention)
+	if err != nil {
+		e.lo.Error("error deleting old scheduled actions", "error", err)
+		return
+	}
+	if n, _ := res.RowsAffected(); n > 0 {
+		e.lo.Info("deleted old scheduled actions", "count", n)
+	}
+}
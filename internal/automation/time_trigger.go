@@ -0,0 +1,132 @@
+package automation
+
+import (
+	"strconv"
+	"time"
+
+	"github.com/abhinavxd/libredesk/internal/automation/models"
+	"github.com/lib/pq"
+)
+
+const (
+	// Default look back window for resolved and closed conversations in time trigger rules.
+	defaultTimeTriggerLookback = 30 * 24 * time.Hour
+
+	// Default number of conversations fetched per batch in time trigger rules.
+	defaultTimeTriggerBatchSize = 500
+)
+
+// timeTriggerFilter holds the conditions of a time trigger rule that can be applied in SQL to narrow down
+// the conversations to evaluate. Conversations returned by the filter are still evaluated against the full rule.
+type timeTriggerFilter struct {
+	StatusIDs            pq.Int64Array
+	PriorityIDs          pq.Int64Array
+	InboxIDs             pq.Int64Array
+	TeamIDs              pq.Int64Array
+	UserIDs              pq.Int64Array
+	HoursSinceCreated    int
+	HoursSinceFirstReply int
+	HoursSinceLastReply  int
+	HoursSinceResolved   int
+}
+
+// timeTriggerConversation is a conversation returned by the time trigger SQL filter.
+type timeTriggerConversation struct {
+	ID   int    `db:"id"`
+	UUID string `db:"uuid"`
+}
+
+// compileTimeTriggerFilter compiles the conditions of a rule that must always hold for it to match into SQL side filters.
+// Only conditions of groups that are required to match, and that must all hold within the group, are compiled.
+func compileTimeTriggerFilter(rule models.Rule) timeTriggerFilter {
+	var (
+		filter timeTriggerFilter
+		groups = make([]models.RuleGroup, 0, len(rule.Groups))
+	)
+	for _, group := range rule.Groups {
+		if len(group.Rules) > 0 {
+			groups = append(groups, group)
+		}
+	}
+
+	// With OR between groups, no single group is required to match.
+	if len(groups) > 1 && rule.GroupOperator != models.OperatorAnd {
+		return filter
+	}
+
+	for _, group := range groups {
+		// With OR within a group, no single condition is required to hold.
+		if len(group.Rules) > 1 && group.LogicalOp != models.OperatorAnd {
+			continue
+		}
+		for _, cond := range group.Rules {
+			if cond.FieldType != "" && cond.FieldType != models.FieldTypeConversationField {
+				continue
+			}
+			switch cond.Field {
+			case models.ConversationStatus:
+				setIDFilter(&filter.StatusIDs, cond)
+			case models.ConversationPriority:
+				setIDFilter(&filter.PriorityIDs, cond)
+			case models.ConversationInbox:
+				setIDFilter(&filter.InboxIDs, cond)
+			case models.ConversationAssignedTeam:
+				setIDFilter(&filter.TeamIDs, cond)
+			case models.ConversationAssignedUser:
+				setIDFilter(&filter.UserIDs, cond)
+			case models.ConversationHoursSinceCreated:
+				setHoursFilter(&filter.HoursSinceCreated, cond)
+			case models.ConversationHoursSinceFirstReply:
+				setHoursFilter(&filter.HoursSinceFirstReply, cond)
+			case models.ConversationHoursSinceLastReply:
+				setHoursFilter(&filter.HoursSinceLastReply, cond)
+			case models.ConversationHoursSinceResolved:
+				setHoursFilter(&filter.HoursSinceResolved, cond)
+			}
+		}
+	}
+	return filter
+}
+
+// setIDFilter sets the IDs a field must be one of from an equals or is one of condition.
+// If the field is already filtered the first condition is kept, the rule evaluation checks the rest.
+func setIDFilter(ids *pq.Int64Array, cond models.RuleDetail) {
+	if len(*ids) > 0 {
+		return
+	}
+	var values []string
+	switch cond.Operator {
+	case models.RuleOperatorEquals:
+		values = []string{cond.Value}
+	case models.RuleOperatorIsOneOf, models.RuleOperatorInList:
+		values = splitRuleValues(cond.Value)
+	default:
+		return
+	}
+
+	out := make(pq.Int64Array, 0, len(values))
+	for _, v := range values {
+		id, err := strconv.ParseInt(v, 10, 64)
+		if err != nil || id <= 0 {
+			// A value that is not an ID can't be filtered in SQL.
+			return
+		}
+		out = append(out, id)
+	}
+	*ids = out
+}
+
+// setHoursFilter sets the minimum hours since a timestamp from a greater than condition.
+func setHoursFilter(hours *int, cond models.RuleDetail) {
+	if cond.Operator != models.RuleOperatorGreaterThan {
+		return
+	}
+	n, err := strconv.Atoi(cond.Value)
+	if err != nil || n <= 0 {
+		return
+	}
+	// Evaluated hours are rounded, a conversation only matches once more than n hours have passed.
+	if n > *hours {
+		*hours = n
+	}
+}
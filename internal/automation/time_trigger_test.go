@@ -0,0 +1,104 @@
+package automation
+
+import (
+	"testing"
+
+	"github.com/abhinavxd/libredesk/internal/automation/models"
+	"github.com/lib/pq"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCompileTimeTriggerFilter(t *testing.T) {
+	cond := func(field, operator, value string) models.RuleDetail {
+		return models.RuleDetail{Field: field, FieldType: models.FieldTypeConversationField, Operator: operator, Value: value}
+	}
+
+	testCases := []struct {
+		name     string
+		rule     models.Rule
+		expected timeTriggerFilter
+	}{
+		{
+			name: "AND conditions are compiled",
+			rule: models.Rule{
+				GroupOperator: models.OperatorOR,
+				Groups: []models.RuleGroup{
+					{LogicalOp: models.OperatorAnd, Rules: []models.RuleDetail{
+						cond(models.ConversationStatus, models.RuleOperatorEquals, "2"),
+						cond(models.ConversationInbox, models.RuleOperatorIsOneOf, "1, 3"),
+						cond(models.ConversationHoursSinceCreated, models.RuleOperatorGreaterThan, "48"),
+					}},
+					{LogicalOp: models.OperatorAnd},
+				},
+			},
+			expected: timeTriggerFilter{
+				StatusIDs:         pq.Int64Array{2},
+				InboxIDs:          pq.Int64Array{1, 3},
+				HoursSinceCreated: 48,
+			},
+		},
+		{
+			name: "Both groups compiled with AND between groups",
+			rule: models.Rule{
+				GroupOperator: models.OperatorAnd,
+				Groups: []models.RuleGroup{
+					{LogicalOp: models.OperatorOR, Rules: []models.RuleDetail{cond(models.ConversationPriority, models.RuleOperatorEquals, "1")}},
+					{LogicalOp: models.OperatorAnd, Rules: []models.RuleDetail{cond(models.ConversationHoursSinceLastReply, models.RuleOperatorGreaterThan, "4")}},
+				},
+			},
+			expected: timeTriggerFilter{PriorityIDs: pq.Int64Array{1}, HoursSinceLastReply: 4},
+		},
+		{
+			name: "OR between groups is not compiled",
+			rule: models.Rule{
+				GroupOperator: models.OperatorOR,
+				Groups: []models.RuleGroup{
+					{LogicalOp: models.OperatorAnd, Rules: []models.RuleDetail{cond(models.ConversationStatus, models.RuleOperatorEquals, "1")}},
+					{LogicalOp: models.OperatorAnd, Rules: []models.RuleDetail{cond(models.ConversationStatus, models.RuleOperatorEquals, "2")}},
+				},
+			},
+		},
+		{
+			name: "OR within group is not compiled",
+			rule: models.Rule{
+				Groups: []models.RuleGroup{
+					{LogicalOp: models.OperatorOR, Rules: []models.RuleDetail{
+						cond(models.ConversationStatus, models.RuleOperatorEquals, "1"),
+						cond(models.ConversationHoursSinceCreated, models.RuleOperatorGreaterThan, "2"),
+					}},
+				},
+			},
+		},
+		{
+			name: "Unsupported operators and values are not compiled",
+			rule: models.Rule{
+				Groups: []models.RuleGroup{
+					{LogicalOp: models.OperatorAnd, Rules: []models.RuleDetail{
+						cond(models.ConversationStatus, models.RuleOperatorNotEqual, "1"),
+						cond(models.ConversationAssignedUser, models.RuleOperatorEquals, "abc"),
+						cond(models.ConversationHoursSinceCreated, models.RuleOperatorLessThan, "2"),
+						{Field: models.ConversationStatus, FieldType: models.FieldTypeConversationCustomAttribute, Operator: models.RuleOperatorEquals, Value: "1"},
+					}},
+				},
+			},
+		},
+		{
+			name: "Largest hours threshold is kept",
+			rule: models.Rule{
+				Groups: []models.RuleGroup{
+					{LogicalOp: models.OperatorAnd, Rules: []models.RuleDetail{
+						cond(models.ConversationHoursSinceResolved, models.RuleOperatorGreaterThan, "24"),
+						cond(models.ConversationHoursSinceResolved, models.RuleOperatorGreaterThan, "72"),
+					}},
+				},
+			},
+			expected: timeTriggerFilter{HoursSinceResolved: 72},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.expected, compileTimeTriggerFilter(tc.rule))
+		})
+	}
+}
@@ -0,0 +1,265 @@
+package automation
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"time"
+
+	"github.com/abhinavxd/libredesk/internal/automation/models"
+	"github.com/abhinavxd/libredesk/internal/envelope"
+	"github.com/lib/pq"
+)
+
+// ExportFormatVersion is the version of the rule export file format.
+const ExportFormatVersion = 1
+
+// GetRuleVersions returns the versions of a rule, latest first.
+func (e *Engine) GetRuleVersions(ruleID int) ([]models.RuleVersion, error) {
+	var versions = make([]models.RuleVersion, 0)
+	if err := e.q.GetRuleVersions.Select(&versions, ruleID); err != nil {
+		e.lo.Error("error fetching rule versions", "rule_id", ruleID, "error", err)
+		return versions, envelope.NewError(envelope.GeneralError, e.i18n.T("globals.messages.somethingWentWrong"), nil)
+	}
+	return versions, nil
+}
+
+// GetRuleVersion returns a version of a rule.
+func (e *Engine) GetRuleVersion(ruleID, version int) (models.RuleVersion, error) {
+	var v models.RuleVersion
+	if err := e.q.GetRuleVersion.Get(&v, ruleID, version); err != nil {
+		if err == sql.ErrNoRows {
+			return v, envelope.NewError(envelope.NotFoundError, e.i18n.T("validation.notFoundRuleVersion"), nil)
+		}
+		e.lo.Error("error fetching rule version", "rule_id", ruleID, "version", version, "error", err)
+		return v, envelope.NewError(envelope.GeneralError, e.i18n.T("globals.messages.somethingWentWrong"), nil)
+	}
+	return v, nil
+}
+
+// DiffRuleVersions returns the changes from one version of a rule to another.
+// A zero `to` version compares against the current state of the rule.
+func (e *Engine) DiffRuleVersions(ruleID, from, to int) ([]models.RuleChange, error) {
+	fromVersion, err := e.GetRuleVersion(ruleID, from)
+	if err != nil {
+		return nil, err
+	}
+
+	var toVersion models.RuleVersion
+	if to > 0 {
+		if toVersion, err = e.GetRuleVersion(ruleID, to); err != nil {
+			return nil, err
+		}
+	} else {
+		rule, err := e.GetRule(ruleID)
+		if err != nil {
+			return nil, err
+		}
+		toVersion = models.RuleVersion{Name: rule.Name, Description: rule.Description, Type: rule.Type, Events: rule.Events, Rules: rule.Rules}
+	}
+
+	changes, err := diffRuleVersions(fromVersion, toVersion)
+	if err != nil {
+		e.lo.Error("error diffing rule versions", "rule_id", ruleID, "from", from, "to", to, "error", err)
+		return nil, envelope.NewError(envelope.GeneralError, e.i18n.T("globals.messages.somethingWentWrong"), nil)
+	}
+	return changes, nil
+}
+
+// RollbackRule restores a rule to a previous version, which is saved as a new version by the given user.
+// Whether the rule is enabled is not changed.
+func (e *Engine) RollbackRule(ruleID, version, userID int) (models.RuleRecord, error) {
+	v, err := e.GetRuleVersion(ruleID, version)
+	if err != nil {
+		return models.RuleRecord{}, err
+	}
+	current, err := e.GetRule(ruleID)
+	if err != nil {
+		return models.RuleRecord{}, err
+	}
+	return e.UpdateRule(ruleID, models.RuleRecord{
+		Name:        v.Name,
+		Description: v.Description,
+		Type:        v.Type,
+		Events:      v.Events,
+		Rules:       v.Rules,
+		Enabled:     current.Enabled,
+	}, userID)
+}
+
+// ExportRules returns all rules of a type, or all rules if the type is empty, in the export file format.
+func (e *Engine) ExportRules(ruleType string) (models.RuleExport, error) {
+	var (
+		records []models.RuleRecord
+		out     = models.RuleExport{Version: ExportFormatVersion, ExportedAt: time.Now(), Rules: []models.ExportedRule{}}
+	)
+	if err := e.q.GetRulesForExport.Select(&records, ruleType); err != nil {
+		e.lo.Error("error fetching rules for export", "error", err)
+		return out, envelope.NewError(envelope.GeneralError, e.i18n.T("globals.messages.somethingWentWrong"), nil)
+	}
+	for _, r := range records {
+		out.Rules = append(out.Rules, models.ExportedRule{
+			Name:        r.Name,
+			Description: r.Description,
+			Type:        r.Type,
+			Events:      r.Events,
+			Enabled:     r.Enabled,
+			Weight:      r.Weight,
+			Rules:       r.Rules,
+		})
+	}
+	return out, nil
+}
+
+// ImportRules creates the rules of an export file, rules with the same name and type as an existing rule update it.
+// All rules are imported in a single transaction and every created or updated rule gets a new version by the given user.
+func (e *Engine) ImportRules(rules []models.ExportedRule, userID int) (models.ImportResult, error) {
+	var result models.ImportResult
+
+	tx, err := e.db.Beginx()
+	if err != nil {
+		e.lo.Error("error starting transaction", "error", err)
+		return result, envelope.NewError(envelope.GeneralError, e.i18n.T("globals.messages.somethingWentWrong"), nil)
+	}
+	defer tx.Rollback()
+
+	for _, r := range rules {
+		events := pq.StringArray(r.Events)
+		if events == nil {
+			events = pq.StringArray{}
+		}
+
+		var id int
+		err := tx.Stmtx(e.q.GetRuleIDByName).Get(&id, r.Name, r.Type)
+		switch {
+		case err == sql.ErrNoRows:
+			if err := tx.Stmtx(e.q.ImportRule).Get(&id, r.Name, r.Description, r.Type, events, r.Rules, r.Enabled, r.Weight); err != nil {
+				e.lo.Error("error importing rule", "name", r.Name, "error", err)
+				return result, envelope.NewError(envelope.GeneralError, e.i18n.T("globals.messages.somethingWentWrong"), nil)
+			}
+			result.Created++
+		case err != nil:
+			e.lo.Error("error fetching rule by name", "name", r.Name, "error", err)
+			return result, envelope.NewError(envelope.GeneralError, e.i18n.T("globals.messages.somethingWentWrong"), nil)
+		default:
+			if _, err := tx.Stmtx(e.q.ResetRuleFirings).Exec(id, r.Type, r.Rules); err != nil {
+				e.lo.Error("error resetting imported rule firings", "name", r.Name, "error", err)
+				return result, envelope.NewError(envelope.GeneralError, e.i18n.T("globals.messages.somethingWentWrong"), nil)
+			}
+			var updated models.RuleRecord
+			if err := tx.Stmtx(e.q.UpdateRule).Get(&updated, id, r.Name, r.Description, r.Type, events, r.Rules, r.Enabled); err != nil {
+				e.lo.Error("error updating imported rule", "name", r.Name, "error", err)
+				return result, envelope.NewError(envelope.GeneralError, e.i18n.T("globals.messages.somethingWentWrong"), nil)
+			}
+			if _, err := tx.Stmtx(e.q.UpdateRuleWeight).Exec(id, r.Weight); err != nil {
+				e.lo.Error("error updating imported rule weight", "name", r.Name, "error", err)
+				return result, envelope.NewError(envelope.GeneralError, e.i18n.T("globals.messages.somethingWentWrong"), nil)
+			}
+			result.Updated++
+		}
+
+		if _, err := tx.Stmtx(e.q.InsertRuleVersion).Exec(id, userID); err != nil {
+			e.lo.Error("error inserting rule version", "rule_id", id, "error", err)
+			return result, envelope.NewError(envelope.GeneralError, e.i18n.T("globals.messages.somethingWentWrong"), nil)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		e.lo.Error("error committing rule import", "error", err)
+		return models.ImportResult{}, envelope.NewError(envelope.GeneralError, e.i18n.T("globals.messages.somethingWentWrong"), nil)
+	}
+	// Reload rules.
+	e.ReloadRules()
+	return result, nil
+}
+
+// diffRuleVersions returns the changes between the snapshots of two rule versions.
+func diffRuleVersions(from, to models.RuleVersion) ([]models.RuleChange, error) {
+	a, err := ruleVersionDoc(from)
+	if err != nil {
+		return nil, err
+	}
+	b, err := ruleVersionDoc(to)
+	if err != nil {
+		return nil, err
+	}
+	changes := make([]models.RuleChange, 0)
+	diffJSON("", a, b, &changes)
+	return changes, nil
+}
+
+// ruleVersionDoc returns the compared fields of a rule version as decoded JSON.
+func ruleVersionDoc(v models.RuleVersion) (map[string]any, error) {
+	var rules any
+	if len(v.Rules) > 0 {
+		if err := json.Unmarshal(v.Rules, &rules); err != nil {
+			return nil, fmt.Errorf("decoding rules of version %d: %w", v.Version, err)
+		}
+	}
+	events := make([]any, 0, len(v.Events))
+	for _, ev := range v.Events {
+		events = append(events, ev)
+	}
+	return map[string]any{
+		"name":        v.Name,
+		"description": v.Description,
+		"type":        v.Type,
+		"events":      events,
+		"rules":       rules,
+	}, nil
+}
+
+// diffJSON appends the differences between two decoded JSON values to changes.
+// Objects and arrays are compared by key and index, other values are compared as a whole.
+func diffJSON(path string, a, b any, changes *[]models.RuleChange) {
+	join := func(key string) string {
+		if path == "" {
+			return key
+		}
+		return path + "." + key
+	}
+
+	switch av := a.(type) {
+	case map[string]any:
+		bv, ok := b.(map[string]any)
+		if !ok {
+			break
+		}
+		keys := make([]string, 0, len(av)+len(bv))
+		for k := range av {
+			keys = append(keys, k)
+		}
+		for k := range bv {
+			if _, ok := av[k]; !ok {
+				keys = append(keys, k)
+			}
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			diffJSON(join(k), av[k], bv[k], changes)
+		}
+		return
+	case []any:
+		bv, ok := b.([]any)
+		if !ok {
+			break
+		}
+		for i := 0; i < max(len(av), len(bv)); i++ {
+			var x, y any
+			if i < len(av) {
+				x = av[i]
+			}
+			if i < len(bv) {
+				y = bv[i]
+			}
+			diffJSON(join(fmt.Sprint(i)), x, y, changes)
+		}
+		return
+	}
+
+	if !reflect.DeepEqual(a, b) {
+		*changes = append(*changes, models.RuleChange{Path: path, Old: a, New: b})
+	}
+}
@@ -0,0 +1,43 @@
+package automation
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/abhinavxd/libredesk/internal/automation/models"
+	"github.com/lib/pq"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDiffRuleVersions(t *testing.T) {
+	from := models.RuleVersion{
+		Version: 1,
+		Name:    "Escalate VIP",
+		Type:    models.RuleTypeConversationUpdate,
+		Events:  pq.StringArray{models.EventConversationStatusChange},
+		Rules:   json.RawMessage(`[{"group_operator": "OR", "groups": [{"logical_op": "AND", "rules": [{"field": "status", "value": "1"}]}], "actions": [{"type": "set_priority", "value": ["3"]}]}]`),
+	}
+	to := models.RuleVersion{
+		Version: 2,
+		Name:    "Escalate VIP customers",
+		Type:    models.RuleTypeConversationUpdate,
+		Events:  pq.StringArray{models.EventConversationStatusChange, models.EventConversationMessageIncoming},
+		Rules:   json.RawMessage(`[{"group_operator": "OR", "groups": [{"logical_op": "AND", "rules": [{"field": "status", "value": "2"}]}], "actions": []}]`),
+	}
+
+	changes, err := diffRuleVersions(from, to)
+	assert.NoError(t, err)
+	assert.Equal(t, []models.RuleChange{
+		{Path: "events.1", Old: nil, New: models.EventConversationMessageIncoming},
+		{Path: "name", Old: "Escalate VIP", New: "Escalate VIP customers"},
+		{Path: "rules.0.actions.0", Old: map[string]any{"type": "set_priority", "value": []any{"3"}}, New: nil},
+		{Path: "rules.0.groups.0.rules.0.value", Old: "1", New: "2"},
+	}, changes)
+
+	changes, err = diffRuleVersions(from, from)
+	assert.NoError(t, err)
+	assert.Empty(t, changes)
+
+	_, err = diffRuleVersions(from, models.RuleVersion{Rules: json.RawMessage(`not json`)})
+	assert.Error(t, err)
+}
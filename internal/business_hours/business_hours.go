@@ -4,7 +4,10 @@ package businesshours
 import (
 	"database/sql"
 	"embed"
+	"encoding/json"
 	"errors"
+	"fmt"
+	"time"
 
 	"github.com/abhinavxd/libredesk/internal/business_hours/models"
 	"github.com/abhinavxd/libredesk/internal/dbutil"
@@ -18,8 +21,9 @@ import (
 
 var (
 	//go:embed queries.sql
-	efs                      embed.FS
-	ErrBusinessHoursNotFound = errors.New("business hours not found")
+	efs                           embed.FS
+	ErrBusinessHoursNotFound      = errors.New("business hours not found")
+	ErrBusinessHoursNotConfigured = errors.New("business hours or timezone not configured")
 )
 
 type Manager struct {
@@ -110,3 +114,70 @@ func (m *Manager) Update(id int, name string, description null.String, isAlwaysO
 	}
 	return result, nil
 }
+
+// IsOpen reports whether t falls within the business hours in the given time zone. Holidays are closed all day.
+// Hours that close before they open run past midnight into the next day.
+func IsOpen(bh models.BusinessHours, timeZone string, t time.Time) (bool, error) {
+	if bh.IsAlwaysOpen {
+		return true, nil
+	}
+
+	loc, err := time.LoadLocation(timeZone)
+	if err != nil {
+		return false, fmt.Errorf("invalid time zone %s: %v", timeZone, err)
+	}
+	t = t.In(loc)
+
+	var holidays []models.Holiday
+	if len(bh.Holidays) > 0 {
+		// Holidays default to an empty object in the DB.
+		json.Unmarshal(bh.Holidays, &holidays)
+	}
+	date := t.Format(time.DateOnly)
+	for _, holiday := range holidays {
+		if holiday.Date == date {
+			return false, nil
+		}
+	}
+
+	var workingHours map[string]models.WorkingHours
+	if err := json.Unmarshal(bh.Hours, &workingHours); err != nil {
+		return false, fmt.Errorf("parsing working hours: %v", err)
+	}
+
+	now := t.Hour()*60 + t.Minute()
+	if hours, ok := workingHours[t.Weekday().String()]; ok {
+		openAt, closeAt, err := parseWorkingHours(hours)
+		if err != nil {
+			return false, err
+		}
+		if (openAt < closeAt && now >= openAt && now < closeAt) || (openAt > closeAt && now >= openAt) {
+			return true, nil
+		}
+	}
+
+	// Hours of the previous day that run past midnight.
+	if hours, ok := workingHours[t.AddDate(0, 0, -1).Weekday().String()]; ok {
+		openAt, closeAt, err := parseWorkingHours(hours)
+		if err != nil {
+			return false, err
+		}
+		if openAt > closeAt && now < closeAt {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// parseWorkingHours returns the open and close times of working hours in minutes since midnight.
+func parseWorkingHours(hours models.WorkingHours) (int, int, error) {
+	openAt, err := time.Parse("15:04", hours.Open)
+	if err != nil {
+		return 0, 0, fmt.Errorf("invalid open time %s: %v", hours.Open, err)
+	}
+	closeAt, err := time.Parse("15:04", hours.Close)
+	if err != nil {
+		return 0, 0, fmt.Errorf("invalid close time %s: %v", hours.Close, err)
+	}
+	return openAt.Hour()*60 + openAt.Minute(), closeAt.Hour()*60 + closeAt.Minute(), nil
+}
@@ -0,0 +1,83 @@
+package businesshours
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/abhinavxd/libredesk/internal/business_hours/models"
+	"github.com/jmoiron/sqlx/types"
+	"github.com/stretchr/testify/assert"
+)
+
+func mustMarshalJSON(v any) types.JSONText {
+	data, err := json.Marshal(v)
+	if err != nil {
+		panic(err)
+	}
+	return types.JSONText(data)
+}
+
+func TestIsOpen(t *testing.T) {
+	locIST, _ := time.LoadLocation("Asia/Kolkata")
+	bh := models.BusinessHours{
+		Hours: mustMarshalJSON(map[string]models.WorkingHours{
+			"Monday":  {Open: "09:00", Close: "18:00"},
+			"Tuesday": {Open: "09:00", Close: "18:00"},
+		}),
+		Holidays: mustMarshalJSON([]models.Holiday{{Name: "Holiday", Date: "2023-10-10"}}),
+	}
+
+	tests := []struct {
+		name     string
+		bh       models.BusinessHours
+		timeZone string
+		time     time.Time
+		expected bool
+	}{
+		{name: "Always open", bh: models.BusinessHours{IsAlwaysOpen: true}, timeZone: "UTC", time: time.Date(2023, 10, 8, 3, 0, 0, 0, time.UTC), expected: true},
+		{name: "Within working hours", bh: bh, timeZone: "UTC", time: time.Date(2023, 10, 9, 10, 0, 0, 0, time.UTC), expected: true},
+		{name: "At opening time", bh: bh, timeZone: "UTC", time: time.Date(2023, 10, 9, 9, 0, 0, 0, time.UTC), expected: true},
+		{name: "At closing time", bh: bh, timeZone: "UTC", time: time.Date(2023, 10, 9, 18, 0, 0, 0, time.UTC), expected: false},
+		{name: "Before working hours", bh: bh, timeZone: "UTC", time: time.Date(2023, 10, 9, 8, 59, 0, 0, time.UTC), expected: false},
+		{name: "Not a working day", bh: bh, timeZone: "UTC", time: time.Date(2023, 10, 11, 10, 0, 0, 0, time.UTC), expected: false},
+		{name: "Holiday", bh: bh, timeZone: "UTC", time: time.Date(2023, 10, 10, 10, 0, 0, 0, time.UTC), expected: false},
+		{name: "Converted to time zone", bh: bh, timeZone: "Asia/Kolkata", time: time.Date(2023, 10, 9, 4, 0, 0, 0, time.UTC), expected: true},
+		{name: "Time from another zone", bh: bh, timeZone: "UTC", time: time.Date(2023, 10, 9, 20, 0, 0, 0, locIST), expected: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			open, err := IsOpen(tt.bh, tt.timeZone, tt.time)
+			assert.NoError(t, err)
+			assert.Equal(t, tt.expected, open)
+		})
+	}
+
+	// 2023-10-09 is a Monday.
+	night := models.BusinessHours{
+		Hours: mustMarshalJSON(map[string]models.WorkingHours{
+			"Monday": {Open: "22:00", Close: "06:00"},
+		}),
+	}
+	for _, tt := range []struct {
+		name     string
+		time     time.Time
+		expected bool
+	}{
+		{name: "Overnight before opening", time: time.Date(2023, 10, 9, 21, 59, 0, 0, time.UTC), expected: false},
+		{name: "Overnight after opening", time: time.Date(2023, 10, 9, 23, 0, 0, 0, time.UTC), expected: true},
+		{name: "Overnight past midnight", time: time.Date(2023, 10, 10, 5, 59, 0, 0, time.UTC), expected: true},
+		{name: "Overnight at closing time", time: time.Date(2023, 10, 10, 6, 0, 0, 0, time.UTC), expected: false},
+		{name: "Overnight early on the opening day", time: time.Date(2023, 10, 9, 3, 0, 0, 0, time.UTC), expected: false},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			open, err := IsOpen(night, "UTC", tt.time)
+			assert.NoError(t, err)
+			assert.Equal(t, tt.expected, open)
+		})
+	}
+
+	_, err := IsOpen(bh, "Invalid/Zone", time.Now())
+	assert.Error(t, err)
+}
@@ -0,0 +1,129 @@
+package conversation
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"html"
+	"strings"
+
+	aimodels "github.com/abhinavxd/libredesk/internal/ai/models"
+	amodels "github.com/abhinavxd/libredesk/internal/automation/models"
+	"github.com/abhinavxd/libredesk/internal/conversation/models"
+	mmodels "github.com/abhinavxd/libredesk/internal/media/models"
+	tagModels "github.com/abhinavxd/libredesk/internal/tag/models"
+	umodels "github.com/abhinavxd/libredesk/internal/user/models"
+)
+
+// Maximum number of recent messages used to build the AI context of a conversation.
+const maxAIContextMessages = 100
+
+var errAINotConfigured = errors.New("AI store not set")
+
+type aiStore interface {
+	Summarize(cc aimodels.ConversationContext) (string, error)
+	ClassifyTag(cc aimodels.ConversationContext, tags []string) (string, error)
+}
+
+type tagStore interface {
+	GetAll() ([]tagModels.Tag, error)
+}
+
+// SetAIStore sets the AI and tag stores used by AI conversation actions.
+func (m *Manager) SetAIStore(ai aiStore, tags tagStore) {
+	m.aiStore = ai
+	m.tagStore = tags
+}
+
+// GetAIContext returns the context of a conversation sent to the AI provider, built from its
+// recent messages, contact details and custom attributes. Private notes and activity messages are excluded.
+func (m *Manager) GetAIContext(conversation models.Conversation) (aimodels.ConversationContext, error) {
+	private := false
+	messages, _, err := m.GetConversationMessages(conversation.UUID, 1, maxAIContextMessages, &private, []string{models.MessageIncoming, models.MessageOutgoing})
+	if err != nil {
+		return aimodels.ConversationContext{}, err
+	}
+
+	cc := aimodels.ConversationContext{
+		Subject:      conversation.Subject.String,
+		ContactName:  conversation.Contact.FullName(),
+		ContactEmail: conversation.Contact.Email.String,
+		Messages:     make([]aimodels.ContextMessage, 0, len(messages)),
+	}
+	if len(conversation.Contact.CustomAttributes) > 0 {
+		json.Unmarshal(conversation.Contact.CustomAttributes, &cc.ContactAttributes)
+	}
+	if len(conversation.CustomAttributes) > 0 {
+		json.Unmarshal(conversation.CustomAttributes, &cc.ConversationAttributes)
+	}
+
+	// Messages are returned newest first.
+	for i := len(messages) - 1; i >= 0; i-- {
+		msg := messages[i]
+		if msg.Private || (msg.Type != models.MessageIncoming && msg.Type != models.MessageOutgoing) {
+			continue
+		}
+		content := strings.TrimSpace(msg.TextContent)
+		if content == "" {
+			continue
+		}
+		cc.Messages = append(cc.Messages, aimodels.ContextMessage{
+			FromContact: msg.Type == models.MessageIncoming,
+			AuthorName:  strings.TrimSpace(msg.Author.FirstName + " " + msg.Author.LastName),
+			Content:     content,
+		})
+	}
+	return cc, nil
+}
+
+// SummarizeConversation summarizes a conversation with the AI provider and adds the summary as a private note.
+func (m *Manager) SummarizeConversation(conversation models.Conversation, actor umodels.User) (models.Message, error) {
+	if m.aiStore == nil {
+		return models.Message{}, errAINotConfigured
+	}
+	cc, err := m.GetAIContext(conversation)
+	if err != nil {
+		return models.Message{}, err
+	}
+	summary, err := m.aiStore.Summarize(cc)
+	if err != nil {
+		return models.Message{}, err
+	}
+
+	content := "<p><strong>" + html.EscapeString(m.i18n.T("ai.summary")) + "</strong></p><p>" +
+		strings.ReplaceAll(html.EscapeString(strings.TrimSpace(summary)), "\n", "<br>") + "</p>"
+	return m.SendPrivateNote([]mmodels.Media{}, actor.ID, conversation.UUID, content, nil)
+}
+
+// AutoTagConversation asks the AI provider to classify a conversation into one of the existing tags and adds the tag.
+// Nothing is changed if no tag fits the conversation.
+func (m *Manager) AutoTagConversation(conversation models.Conversation, actor umodels.User) error {
+	if m.aiStore == nil || m.tagStore == nil {
+		return errAINotConfigured
+	}
+	tags, err := m.tagStore.GetAll()
+	if err != nil {
+		return fmt.Errorf("fetching tags: %w", err)
+	}
+	if len(tags) == 0 {
+		return nil
+	}
+	names := make([]string, 0, len(tags))
+	for _, t := range tags {
+		names = append(names, t.Name)
+	}
+
+	cc, err := m.GetAIContext(conversation)
+	if err != nil {
+		return err
+	}
+	tag, err := m.aiStore.ClassifyTag(cc, names)
+	if err != nil {
+		return err
+	}
+	if tag == "" {
+		m.lo.Debug("no tag matched conversation", "conversation_uuid", conversation.UUID)
+		return nil
+	}
+	return m.SetConversationTags(conversation.UUID, amodels.ActionAddTags, []string{tag}, actor)
+}
@@ -9,6 +9,7 @@ import (
 	"errors"
 	"fmt"
 	"io"
+	"net/http"
 	"slices"
 	"strconv"
 	"strings"
@@ -70,7 +71,11 @@ type Manager struct {
 	settingsStore              settingsStore
 	csatStore                  csatStore
 	webhookStore               webhookStore
+	aiStore                    aiStore
+	tagStore                   tagStore
+	autoAssigner               autoAssigner
 	dispatcher                 *notifier.Dispatcher
+	httpClient                 *http.Client
 	lo                         *logf.Logger
 	db                         *sqlx.DB
 	i18n                       *i18n.I18n
@@ -129,6 +134,8 @@ type userStore interface {
 	GetSystemUser() (umodels.User, error)
 	CreateContact(user *umodels.User) error
 	UpgradeVisitorToContact(visitorID int) error
+	GetContactIDByIdentity(email string) (int, error)
+	SetEmailUndeliverable(email string) error
 }
 
 type mediaStore interface {
@@ -164,6 +171,11 @@ type webhookStore interface {
 	TriggerEvent(event wmodels.WebhookEvent, data any)
 }
 
+type autoAssigner interface {
+	ConversationCreated(uuid string)
+	ConversationTeamAssigned(uuid string)
+}
+
 // ContinuityConfig holds configuration for conversation continuity emails
 type ContinuityConfig struct {
 	BatchCheckInterval time.Duration
@@ -176,6 +188,8 @@ type Opts struct {
 	OutgoingMessageQueueSize int
 	IncomingMessageQueueSize int
 	ContinuityConfig         *ContinuityConfig
+	// HTTPClient is used by automation http_request actions.
+	HTTPClient *http.Client
 }
 
 // New initializes a new conversation Manager.
@@ -237,6 +251,7 @@ func New(
 		outgoingMessageQueue:       make(chan models.Message, opts.OutgoingMessageQueueSize),
 		outgoingProcessingMessages: sync.Map{},
 		continuityConfig:           continuityConfig,
+		httpClient:                 opts.HTTPClient,
 	}
 
 	return c, nil
@@ -246,14 +261,15 @@ type queries struct {
 	// Conversation queries.
 	GetConversationUUID                *sqlx.Stmt `query:"get-conversation-uuid"`
 	GetConversation                    *sqlx.Stmt `query:"get-conversation"`
-	GetConversationsCreatedAfter       *sqlx.Stmt `query:"get-conversations-created-after"`
 	GetUnassignedConversations         *sqlx.Stmt `query:"get-unassigned-conversations"`
+	GetContactLastAssignee             *sqlx.Stmt `query:"get-contact-last-assignee"`
 	GetConversations                   string     `query:"get-conversations"`
 	GetContactChatConversations        *sqlx.Stmt `query:"get-contact-chat-conversations"`
 	GetChatConversation                *sqlx.Stmt `query:"get-chat-conversation"`
 	GetContactPreviousConversations    *sqlx.Stmt `query:"get-contact-previous-conversations"`
 	GetConversationParticipants        *sqlx.Stmt `query:"get-conversation-participants"`
 	GetUserActiveConversationsCount    *sqlx.Stmt `query:"get-user-active-conversations-count"`
+	GetActiveConversationsByChannel    *sqlx.Stmt `query:"get-user-active-conversations-by-channel"`
 	UpdateConversationWaitingSince     *sqlx.Stmt `query:"update-conversation-waiting-since"`
 	UpdateConversationReplyTimestamps  *sqlx.Stmt `query:"update-conversation-reply-timestamps"`
 	UpdateConversationContactLastSeen  *sqlx.Stmt `query:"update-conversation-contact-last-seen"`
@@ -294,6 +310,8 @@ type queries struct {
 	GetConversationByMessageID         *sqlx.Stmt `query:"get-conversation-by-message-id"`
 	InsertMessage                      *sqlx.Stmt `query:"insert-message"`
 	UpdateMessageStatus                *sqlx.Stmt `query:"update-message-status"`
+	GetOutgoingMessageBySourceID       *sqlx.Stmt `query:"get-outgoing-message-by-source-id"`
+	SetMessageBounce                   *sqlx.Stmt `query:"set-message-bounce"`
 	UpdateMessageSourceID              *sqlx.Stmt `query:"update-message-source-id"`
 	DeleteMessage                      *sqlx.Stmt `query:"delete-message"`
 
@@ -437,16 +455,6 @@ func (c *Manager) SignAvatarURL(avatarURL *null.String) {
 	}
 }
 
-// GetConversationsCreatedAfter retrieves conversations created after the specified time.
-func (c *Manager) GetConversationsCreatedAfter(time time.Time) ([]models.Conversation, error) {
-	var conversations = make([]models.Conversation, 0)
-	if err := c.q.GetConversationsCreatedAfter.Select(&conversations, time); err != nil {
-		c.lo.Error("error fetching conversation", "error", err)
-		return conversations, err
-	}
-	return conversations, nil
-}
-
 // UpdateUserLastSeen updates the last seen timestamp for a specific user on a conversation.
 func (c *Manager) UpdateUserLastSeen(uuid string, userID int) error {
 	if _, err := c.q.UpsertUserLastSeen.Exec(userID, uuid); err != nil {
@@ -490,7 +498,7 @@ func (c *Manager) GetConversationParticipants(uuid string) ([]models.Conversatio
 // GetUnassignedConversations retrieves unassigned conversations.
 func (c *Manager) GetUnassignedConversations() ([]models.Conversation, error) {
 	var conv []models.Conversation
-	if err := c.q.GetUnassignedConversations.Select(&conv); err != nil {
+	if err := c.q.GetUnassignedConversations.Select(&conv, ""); err != nil {
 		if err != sql.ErrNoRows {
 			c.lo.Error("error fetching conversations", "error", err)
 			return conv, err
@@ -499,6 +507,39 @@ func (c *Manager) GetUnassignedConversations() ([]models.Conversation, error) {
 	return conv, nil
 }
 
+// SetAutoAssigner sets the auto assigner notified of new and team assigned conversations.
+func (c *Manager) SetAutoAssigner(a autoAssigner) {
+	c.autoAssigner = a
+}
+
+// GetUnassignedConversation retrieves a conversation that is assigned to a team but not to a user.
+// A zero conversation is returned if the conversation is not in that state.
+func (c *Manager) GetUnassignedConversation(uuid string) (models.Conversation, error) {
+	var conv []models.Conversation
+	if err := c.q.GetUnassignedConversations.Select(&conv, uuid); err != nil {
+		c.lo.Error("error fetching unassigned conversation", "uuid", uuid, "error", err)
+		return models.Conversation{}, err
+	}
+	if len(conv) == 0 {
+		return models.Conversation{}, nil
+	}
+	return conv[0], nil
+}
+
+// GetContactLastAssignee returns the agent assigned to the contact's most recent conversation other than the given one,
+// zero if there is none.
+func (c *Manager) GetContactLastAssignee(contactID, excludeConversationID int) (int, error) {
+	var userID int
+	if err := c.q.GetContactLastAssignee.Get(&userID, contactID, excludeConversationID); err != nil {
+		if err == sql.ErrNoRows {
+			return 0, nil
+		}
+		c.lo.Error("error fetching contact last assignee", "contact_id", contactID, "error", err)
+		return 0, envelope.NewError(envelope.GeneralError, c.i18n.T("globals.messages.somethingWentWrong"), nil)
+	}
+	return userID, nil
+}
+
 // GetConversationUUID retrieves the UUID of a conversation by its ID.
 func (c *Manager) GetConversationUUID(id int) (string, error) {
 	var uuid string
@@ -622,6 +663,23 @@ func (c *Manager) ActiveUserConversationsCount(userID int) (int, error) {
 	return count, nil
 }
 
+// ActiveUserConversationsByChannel returns the number of active conversations of a user by inbox channel.
+func (c *Manager) ActiveUserConversationsByChannel(userID int) (map[string]int, error) {
+	var rows []struct {
+		Channel string `db:"channel"`
+		Count   int    `db:"count"`
+	}
+	if err := c.q.GetActiveConversationsByChannel.Select(&rows, userID); err != nil {
+		c.lo.Error("error fetching active conversations by channel", "user_id", userID, "error", err)
+		return nil, envelope.NewError(envelope.GeneralError, c.i18n.T("globals.messages.somethingWentWrong"), nil)
+	}
+	counts := make(map[string]int, len(rows))
+	for _, r := range rows {
+		counts[r.Channel] = r.Count
+	}
+	return counts, nil
+}
+
 // UpdateConversationLastMessage updates the last message details for a conversation.
 // Also conditionally updates last_interaction fields if messageType != 'activity' and !private.
 func (c *Manager) UpdateConversationLastMessage(conversation int, conversationUUID, lastMessage, lastMessageSenderType, messageType string, private bool, lastMessageAt time.Time, senderID int) error {
@@ -747,6 +805,11 @@ func (c *Manager) UpdateConversationTeamAssignee(uuid string, teamID int, actor
 
 		// Evaluate automation rules for conversation team assignment.
 		c.automation.EvaluateConversationUpdateRules(conversation, amodels.EventConversationTeamAssigned)
+
+		// Auto assign the conversation to a member of the new team.
+		if c.autoAssigner != nil {
+			c.autoAssigner.ConversationTeamAssigned(uuid)
+		}
 	}
 
 	// Broadcast conversation update to widget clients.
@@ -1223,7 +1286,7 @@ func (m *Manager) ApplySLA(conversation models.Conversation, policyID int, actor
 // all actions are executed on behalf of the provided user if the user is not provided, system user is used.
 func (m *Manager) ApplyAction(action amodels.RuleAction, conv models.Conversation, user umodels.User) error {
 	// CSAT action does not require a value.
-	if len(action.Value) == 0 && action.Type != amodels.ActionSendCSAT {
+	if len(action.Value) == 0 && !amodels.ActionWithoutValue(action.Type) {
 		return fmt.Errorf("empty value for action %s", action.Type)
 	}
 
@@ -1303,6 +1366,16 @@ func (m *Manager) ApplyAction(action amodels.RuleAction, conv models.Conversatio
 		return m.SetConversationTags(conv.UUID, action.Type, action.Value, user)
 	case amodels.ActionSendCSAT:
 		return m.SendCSATReply(user.ID, conv)
+	case amodels.ActionAISummarize:
+		if _, err := m.SummarizeConversation(conv, user); err != nil {
+			return fmt.Errorf("summarizing conversation: %w", err)
+		}
+	case amodels.ActionAIAutoTag:
+		if err := m.AutoTagConversation(conv, user); err != nil {
+			return fmt.Errorf("auto-tagging conversation: %w", err)
+		}
+	case amodels.ActionHTTPRequest:
+		return m.SendHTTPRequestAction(conv, action.Value[0])
 	default:
 		return fmt.Errorf("unknown action: %s", action.Type)
 	}
@@ -0,0 +1,236 @@
+package conversation
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"text/template"
+
+	amodels "github.com/abhinavxd/libredesk/internal/automation/models"
+	"github.com/abhinavxd/libredesk/internal/conversation/models"
+	"github.com/abhinavxd/libredesk/internal/version"
+)
+
+const (
+	// Maximum number of response body bytes read from an http_request action.
+	maxHTTPActionResponseSize = 1 << 20
+
+	// Maximum number of response body bytes included in http_request action errors.
+	maxHTTPActionErrorSize = 256
+)
+
+// httpActionFuncs are the template functions available in http_request action bodies.
+var httpActionFuncs = template.FuncMap{
+	// json encodes a value as JSON so that template values are safely embedded in JSON bodies.
+	"json": func(v any) (string, error) {
+		b, err := json.Marshal(v)
+		return string(b), err
+	},
+}
+
+// ParseHTTPRequestAction parses and validates the config of an http_request action.
+func ParseHTTPRequestAction(value string) (amodels.HTTPRequestAction, error) {
+	var cfg amodels.HTTPRequestAction
+	if err := json.Unmarshal([]byte(value), &cfg); err != nil {
+		return cfg, fmt.Errorf("invalid http_request config: %w", err)
+	}
+
+	cfg.Method = strings.ToUpper(strings.TrimSpace(cfg.Method))
+	if cfg.Method == "" {
+		cfg.Method = http.MethodPost
+	}
+	switch cfg.Method {
+	case http.MethodGet, http.MethodPost, http.MethodPut, http.MethodPatch, http.MethodDelete:
+	default:
+		return cfg, fmt.Errorf("unsupported http_request method %q", cfg.Method)
+	}
+
+	u, err := url.Parse(strings.TrimSpace(cfg.URL))
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return cfg, fmt.Errorf("invalid http_request URL %q", cfg.URL)
+	}
+	cfg.URL = u.String()
+
+	if cfg.Body != "" {
+		if _, err := template.New("body").Funcs(httpActionFuncs).Parse(cfg.Body); err != nil {
+			return cfg, fmt.Errorf("invalid http_request body template: %w", err)
+		}
+	}
+	return cfg, nil
+}
+
+// SendHTTPRequestAction calls the URL configured in an http_request action with the templated body and
+// writes the mapped fields of the JSON response to the conversation custom attributes.
+func (m *Manager) SendHTTPRequestAction(conversation models.Conversation, value string) error {
+	if m.httpClient == nil {
+		return fmt.Errorf("http client not configured")
+	}
+
+	cfg, err := ParseHTTPRequestAction(value)
+	if err != nil {
+		return err
+	}
+
+	var body io.Reader
+	if cfg.Body != "" {
+		b, err := renderHTTPActionBody(cfg.Body, httpActionData(conversation))
+		if err != nil {
+			return err
+		}
+		body = bytes.NewReader(b)
+	}
+
+	req, err := http.NewRequest(cfg.Method, cfg.URL, body)
+	if err != nil {
+		return fmt.Errorf("creating http_request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "Libredesk-Automation/"+version.Version)
+	for k, v := range cfg.Headers {
+		req.Header.Set(k, v)
+	}
+
+	resp, err := m.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("sending http_request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	respBody, err := io.ReadAll(io.LimitReader(resp.Body, maxHTTPActionResponseSize))
+	if err != nil {
+		return fmt.Errorf("reading http_request response: %w", err)
+	}
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		if len(respBody) > maxHTTPActionErrorSize {
+			respBody = respBody[:maxHTTPActionErrorSize]
+		}
+		return fmt.Errorf("http_request returned status %d: %s", resp.StatusCode, string(respBody))
+	}
+
+	if len(cfg.ResponseMapping) == 0 {
+		return nil
+	}
+
+	var data any
+	if err := json.Unmarshal(respBody, &data); err != nil {
+		return fmt.Errorf("parsing http_request response: %w", err)
+	}
+
+	// Merge into the latest attributes so that changes made since the rule was evaluated are kept.
+	latest, err := m.GetConversation(conversation.ID, "", "")
+	if err != nil {
+		return fmt.Errorf("fetching conversation: %w", err)
+	}
+	attrs := map[string]any{}
+	if len(latest.CustomAttributes) > 0 {
+		if err := json.Unmarshal(latest.CustomAttributes, &attrs); err != nil || attrs == nil {
+			attrs = map[string]any{}
+		}
+	}
+
+	changed := false
+	for key, path := range cfg.ResponseMapping {
+		if v, ok := lookupJSONPath(data, path); ok {
+			attrs[key] = v
+			changed = true
+		}
+	}
+	if !changed {
+		return nil
+	}
+	return m.UpdateConversationCustomAttributes(latest.UUID, attrs)
+}
+
+// httpActionData returns the template data of a conversation available in http_request action bodies.
+func httpActionData(conversation models.Conversation) map[string]any {
+	var (
+		tags        []string
+		attrs       = map[string]any{}
+		contactAttr = map[string]any{}
+	)
+	if len(conversation.Tags.JSON) > 0 {
+		json.Unmarshal(conversation.Tags.JSON, &tags)
+	}
+	if len(conversation.CustomAttributes) > 0 {
+		json.Unmarshal(conversation.CustomAttributes, &attrs)
+	}
+	if len(conversation.Contact.CustomAttributes) > 0 {
+		json.Unmarshal(conversation.Contact.CustomAttributes, &contactAttr)
+	}
+
+	return map[string]any{
+		"Conversation": map[string]any{
+			"UUID":             conversation.UUID,
+			"ReferenceNumber":  conversation.ReferenceNumber,
+			"Subject":          conversation.Subject.String,
+			"Status":           conversation.Status.String,
+			"Priority":         conversation.Priority.String,
+			"InboxID":          conversation.InboxID,
+			"InboxName":        conversation.InboxName,
+			"AssignedUserID":   conversation.AssignedUserID.Int,
+			"AssignedTeamID":   conversation.AssignedTeamID.Int,
+			"Tags":             tags,
+			"CustomAttributes": attrs,
+			"CreatedAt":        conversation.CreatedAt,
+		},
+		"Contact": map[string]any{
+			"ID":               conversation.Contact.ID,
+			"FirstName":        conversation.Contact.FirstName,
+			"LastName":         conversation.Contact.LastName,
+			"FullName":         conversation.Contact.FullName(),
+			"Email":            conversation.Contact.Email.String,
+			"PhoneNumber":      conversation.Contact.PhoneNumber.String,
+			"ExternalUserID":   conversation.Contact.ExternalUserID.String,
+			"CustomAttributes": contactAttr,
+		},
+	}
+}
+
+// renderHTTPActionBody renders the body template of an http_request action and checks that the result is valid JSON.
+func renderHTTPActionBody(body string, data any) ([]byte, error) {
+	tpl, err := template.New("body").Funcs(httpActionFuncs).Option("missingkey=zero").Parse(body)
+	if err != nil {
+		return nil, fmt.Errorf("parsing http_request body template: %w", err)
+	}
+	var buf bytes.Buffer
+	if err := tpl.Execute(&buf, data); err != nil {
+		return nil, fmt.Errorf("rendering http_request body: %w", err)
+	}
+	if !json.Valid(buf.Bytes()) {
+		return nil, fmt.Errorf("http_request body is not valid JSON")
+	}
+	return buf.Bytes(), nil
+}
+
+// lookupJSONPath returns the value at a dotted path, eg: `data.customer.plan` or `items.0.id`, in decoded JSON.
+func lookupJSONPath(data any, path string) (any, bool) {
+	path = strings.TrimSpace(path)
+	if path == "" {
+		return nil, false
+	}
+	cur := data
+	for _, part := range strings.Split(path, ".") {
+		switch v := cur.(type) {
+		case map[string]any:
+			next, ok := v[part]
+			if !ok {
+				return nil, false
+			}
+			cur = next
+		case []any:
+			i, err := strconv.Atoi(part)
+			if err != nil || i < 0 || i >= len(v) {
+				return nil, false
+			}
+			cur = v[i]
+		default:
+			return nil, false
+		}
+	}
+	return cur, true
+}
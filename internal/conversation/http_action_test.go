@@ -0,0 +1,102 @@
+package conversation
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+func TestLookupJSONPath(t *testing.T) {
+	var data any
+	if err := json.Unmarshal([]byte(`{"customer": {"plan": "pro", "seats": 10}, "invoices": [{"id": "inv_1"}], "empty": null}`), &data); err != nil {
+		t.Fatal(err)
+	}
+
+	testCases := []struct {
+		name     string
+		path     string
+		expected any
+		found    bool
+	}{
+		{name: "Nested key", path: "customer.plan", expected: "pro", found: true},
+		{name: "Number value", path: "customer.seats", expected: float64(10), found: true},
+		{name: "Object value", path: "customer", expected: map[string]any{"plan": "pro", "seats": float64(10)}, found: true},
+		{name: "Array index", path: "invoices.0.id", expected: "inv_1", found: true},
+		{name: "Null value", path: "empty", expected: nil, found: true},
+		{name: "Missing key", path: "customer.name", found: false},
+		{name: "Index out of range", path: "invoices.1.id", found: false},
+		{name: "Non numeric index", path: "invoices.first", found: false},
+		{name: "Path through scalar", path: "customer.plan.name", found: false},
+		{name: "Empty path", path: "", found: false},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			got, found := lookupJSONPath(data, tc.path)
+			if found != tc.found {
+				t.Fatalf("lookupJSONPath(%q) found = %v, want %v", tc.path, found, tc.found)
+			}
+			if !reflect.DeepEqual(got, tc.expected) {
+				t.Errorf("lookupJSONPath(%q) = %v, want %v", tc.path, got, tc.expected)
+			}
+		})
+	}
+}
+
+func TestRenderHTTPActionBody(t *testing.T) {
+	data := map[string]any{
+		"Contact": map[string]any{"Email": "jane@example.com", "FullName": `Jane "JD" Doe`},
+	}
+
+	got, err := renderHTTPActionBody(`{"email": {{ json .Contact.Email }}, "name": {{ json .Contact.FullName }}}`, data)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	var out map[string]string
+	if err := json.Unmarshal(got, &out); err != nil {
+		t.Fatalf("rendered body is not valid JSON: %v", err)
+	}
+	if out["email"] != "jane@example.com" || out["name"] != `Jane "JD" Doe` {
+		t.Errorf("unexpected rendered body: %s", got)
+	}
+
+	// Values rendered without the json function can break the body.
+	if _, err := renderHTTPActionBody(`{"name": "{{ .Contact.FullName }}"}`, data); err == nil {
+		t.Error("expected error for invalid JSON body")
+	}
+}
+
+func TestParseHTTPRequestAction(t *testing.T) {
+	testCases := []struct {
+		name    string
+		value   string
+		method  string
+		wantErr bool
+	}{
+		{name: "Defaults to POST", value: `{"url": "https://crm.example.com/lookup"}`, method: "POST"},
+		{name: "Method is normalized", value: `{"url": "https://crm.example.com/lookup", "method": "get"}`, method: "GET"},
+		{name: "Unsupported method", value: `{"url": "https://crm.example.com/lookup", "method": "TRACE"}`, wantErr: true},
+		{name: "Missing URL", value: `{"method": "POST"}`, wantErr: true},
+		{name: "Unsupported scheme", value: `{"url": "ftp://crm.example.com"}`, wantErr: true},
+		{name: "Invalid body template", value: `{"url": "https://crm.example.com", "body": "{{ .Contact"}`, wantErr: true},
+		{name: "Invalid JSON", value: `not json`, wantErr: true},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			cfg, err := ParseHTTPRequestAction(tc.value)
+			if tc.wantErr {
+				if err == nil {
+					t.Fatal("expected error, got nil")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if cfg.Method != tc.method {
+				t.Errorf("method = %q, want %q", cfg.Method, tc.method)
+			}
+		})
+	}
+}
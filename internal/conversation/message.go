@@ -7,6 +7,8 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"maps"
+	"net/mail"
 	"path/filepath"
 	"slices"
 	"strings"
@@ -20,6 +22,8 @@ import (
 	"github.com/abhinavxd/libredesk/internal/inbox"
 	"github.com/abhinavxd/libredesk/internal/inbox/channel/livechat"
 	mmodels "github.com/abhinavxd/libredesk/internal/media/models"
+	notifier "github.com/abhinavxd/libredesk/internal/notification"
+	nmodels "github.com/abhinavxd/libredesk/internal/notification/models"
 	"github.com/abhinavxd/libredesk/internal/sla"
 	"github.com/abhinavxd/libredesk/internal/stringutil"
 	umodels "github.com/abhinavxd/libredesk/internal/user/models"
@@ -32,6 +36,8 @@ const (
 	maxMessagesPerPage = 500
 	// Only allow visitor-to-contact upgrade within this window after the last continuity email.
 	upgradeWindowTTL = 7 * 24 * time.Hour
+	// emailSignatureBlock prints the rendered signature below email replies.
+	emailSignatureBlock = `<br><div class="signature">{{ .Signature }}</div>`
 )
 
 // Run starts a pool of worker goroutines to handle message dispatching via inbox's channel and processes incoming messages. It scans for
@@ -221,16 +227,29 @@ func (m *Manager) sendOutgoingMessage(message models.Message) {
 
 // BuildTemplateData builds the common template data map for rendering message content variables.
 func (m *Manager) BuildTemplateData(conversationUUID string, senderID int) (map[string]any, error) {
+	conversation, sender, err := m.getTemplateSubjects(conversationUUID, senderID)
+	if err != nil {
+		return nil, err
+	}
+	return buildTemplateData(conversation, sender), nil
+}
+
+// getTemplateSubjects fetches the conversation and the sender used in template data.
+func (m *Manager) getTemplateSubjects(conversationUUID string, senderID int) (models.Conversation, umodels.User, error) {
 	conversation, err := m.GetConversation(0, conversationUUID, "")
 	if err != nil {
-		return nil, fmt.Errorf("fetching conversation: %w", err)
+		return conversation, umodels.User{}, fmt.Errorf("fetching conversation: %w", err)
 	}
 
 	sender, err := m.userStore.GetAgent(senderID, "")
 	if err != nil {
-		return nil, fmt.Errorf("fetching message sender user: %w", err)
+		return conversation, sender, fmt.Errorf("fetching message sender user: %w", err)
 	}
+	return conversation, sender, nil
+}
 
+// buildTemplateData builds the common template data map of a conversation and the message sender.
+func buildTemplateData(conversation models.Conversation, sender umodels.User) map[string]any {
 	data := map[string]any{
 		"Conversation": map[string]any{
 			"ReferenceNumber": conversation.ReferenceNumber,
@@ -267,30 +286,88 @@ func (m *Manager) BuildTemplateData(conversationUUID string, senderID int) (map[
 			"Email":     "",
 		}
 	}
+	return data
+}
 
-	return data, nil
+// renderEmailSignature renders the signature of the sender, falling back to the default signature of the inbox.
+// Automated replies only get the inbox signature. The inbox and team of the conversation are available to the signature.
+func (m *Manager) renderEmailSignature(conversation models.Conversation, sender umodels.User, data map[string]any) string {
+	signature := sender.EmailSignature.String
+	if signature == "" || sender.IsSystemUser() {
+		signature = ""
+		if inb, err := m.inboxStore.GetDBRecord(conversation.InboxID); err == nil && inb.Channel == inbox.ChannelEmail {
+			var cfg struct {
+				Signature string `json:"signature"`
+			}
+			if err := json.Unmarshal(inb.Config, &cfg); err == nil {
+				signature = cfg.Signature
+			}
+		}
+	}
+	if signature == "" {
+		return ""
+	}
+
+	var teamName string
+	if conversation.AssignedTeamID.Valid {
+		if team, err := m.teamStore.Get(conversation.AssignedTeamID.Int); err == nil {
+			teamName = team.Name
+		}
+	}
+	signatureData := maps.Clone(data)
+	signatureData["Inbox"] = map[string]any{
+		"Name":  conversation.InboxName,
+		"Email": conversation.InboxMail,
+	}
+	signatureData["Team"] = map[string]any{
+		"Name": teamName,
+	}
+	return m.template.RenderString(signatureData, signature)
+}
+
+// withEmailSignature appends the signature block to the content unless the outgoing email template places the signature.
+func withEmailSignature(content string, templatePlacesSignature bool) string {
+	if templatePlacesSignature {
+		return content
+	}
+	return content + emailSignatureBlock
 }
 
 // RenderMessageInTemplate renders message content in the email base template for sending.
 func (m *Manager) RenderMessageInTemplate(channel string, message *models.Message) error {
 	switch channel {
 	case inbox.ChannelEmail:
-		data, err := m.BuildTemplateData(message.ConversationUUID, message.SenderID)
+		conversation, sender, err := m.getTemplateSubjects(message.ConversationUUID, message.SenderID)
 		if err != nil {
 			return err
 		}
+		data := buildTemplateData(conversation, sender)
 
 		// Expose message meta flags to the template.
 		var (
 			isContinuity bool
+			isCSAT       bool
 			meta         map[string]any
 		)
 		if len(message.Meta) > 0 && json.Unmarshal(message.Meta, &meta) == nil {
 			isContinuity, _ = meta["continuity_email"].(bool)
+			isCSAT, _ = meta["is_csat"].(bool)
 		}
 		data["IsContinuityEmail"] = isContinuity
 
-		message.Content, err = m.template.RenderEmailWithTemplate(data, message.Content)
+		// Replies get the signature, which the outgoing email template can place with `{{ .Signature }}`.
+		// Otherwise it is appended to the content, printed by the template so that it is not parsed again.
+		var signature string
+		if !isContinuity && !isCSAT {
+			signature = m.renderEmailSignature(conversation, sender, data)
+		}
+		data["Signature"] = signature
+		content := message.Content
+		if signature != "" {
+			content = withEmailSignature(content, m.template.OutgoingEmailTemplateUsesSignature())
+		}
+
+		message.Content, err = m.template.RenderEmailWithTemplate(data, content)
 		if err != nil {
 			m.lo.Error("could not render email content using template", "id", message.ID, "error", err)
 			return fmt.Errorf("could not render email content using template: %w", err)
@@ -298,6 +375,9 @@ func (m *Manager) RenderMessageInTemplate(channel string, message *models.Messag
 	case inbox.ChannelLiveChat:
 		// Live chat doesn't use templates for rendering messages.
 		return nil
+	case inbox.ChannelAPI:
+		// API inbox delivers raw message content to the callback URL.
+		return nil
 	default:
 		m.lo.Warn("unknown message channel", "channel", channel)
 		return fmt.Errorf("unknown message channel: %s", channel)
@@ -379,6 +459,106 @@ func (m *Manager) UpdateMessageStatus(messageUUID string, status string) error {
 	return nil
 }
 
+// HandleEmailBounce marks the outgoing message a bounce refers to as failed with the bounce reason and notifies its author.
+// It returns false if there is no such outgoing message in the inbox.
+func (m *Manager) HandleEmailBounce(b models.EmailBounce) (bool, error) {
+	var bounced struct {
+		UUID string          `db:"uuid"`
+		Meta json.RawMessage `db:"meta"`
+	}
+	if err := m.q.GetOutgoingMessageBySourceID.Get(&bounced, b.SourceID, b.InboxID); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return false, nil
+		}
+		m.lo.Error("error fetching bounced message", "source_id", b.SourceID, "error", err)
+		return false, fmt.Errorf("fetching bounced message: %w", err)
+	}
+
+	bounce, err := json.Marshal(map[string]any{
+		"recipient": b.Recipient,
+		"status":    b.Status,
+		"reason":    b.Reason,
+		"permanent": b.Permanent,
+	})
+	if err != nil {
+		return false, fmt.Errorf("marshalling bounce: %w", err)
+	}
+	messageUUID := bounced.UUID
+	if _, err := m.q.SetMessageBounce.Exec(messageUUID, bounce); err != nil {
+		m.lo.Error("error saving message bounce", "message_uuid", messageUUID, "error", err)
+		return false, fmt.Errorf("saving message bounce: %w", err)
+	}
+	if err := m.UpdateMessageStatus(messageUUID, models.MessageStatusFailed); err != nil {
+		return false, err
+	}
+
+	// Only flag addresses the message was sent to, the report could name any address.
+	if b.Permanent && b.FlagContact && b.Recipient != "" {
+		if !isMessageRecipient(bounced.Meta, b.Recipient) {
+			m.lo.Warn("bounce recipient is not a recipient of the bounced message, not flagging contact", "email", b.Recipient, "message_uuid", messageUUID)
+		} else if err := m.userStore.SetEmailUndeliverable(b.Recipient); err != nil {
+			m.lo.Error("error flagging contact email as undeliverable", "email", b.Recipient, "error", err)
+		}
+	}
+
+	message, err := m.GetMessage(messageUUID)
+	if err != nil {
+		return true, nil
+	}
+	m.notifyBounce(message, b)
+	return true, nil
+}
+
+// isMessageRecipient reports whether the email is one of the `to` or `cc` recipients in the meta of an outgoing message.
+func isMessageRecipient(meta json.RawMessage, email string) bool {
+	var recipients struct {
+		To []string `json:"to"`
+		Cc []string `json:"cc"`
+	}
+	if len(meta) == 0 || json.Unmarshal(meta, &recipients) != nil {
+		return false
+	}
+	for _, recipient := range slices.Concat(recipients.To, recipients.Cc) {
+		if addr, err := mail.ParseAddress(recipient); err == nil {
+			recipient = addr.Address
+		}
+		if strings.EqualFold(strings.TrimSpace(recipient), email) {
+			return true
+		}
+	}
+	return false
+}
+
+// notifyBounce notifies the agent who sent a message that it bounced.
+func (m *Manager) notifyBounce(message models.Message, b models.EmailBounce) {
+	if message.SenderType != models.SenderTypeAgent {
+		return
+	}
+	systemUser, err := m.userStore.GetSystemUser()
+	if err != nil || message.SenderID == systemUser.ID {
+		return
+	}
+	conversation, err := m.GetConversation(message.ConversationID, "", "")
+	if err != nil {
+		m.lo.Error("error fetching conversation for bounce notification", "conversation_id", message.ConversationID, "error", err)
+		return
+	}
+
+	reason := b.Reason
+	if reason == "" {
+		reason = b.Status
+	}
+	m.dispatcher.Send(notifier.Notification{
+		Type:             nmodels.NotificationTypeEmailBounce,
+		RecipientIDs:     []int{message.SenderID},
+		Title:            m.i18n.Ts("notification.emailBounced", "recipient", b.Recipient, "referenceNumber", conversation.ReferenceNumber),
+		Body:             null.NewString(reason, reason != ""),
+		ConversationID:   null.IntFrom(conversation.ID),
+		MessageID:        null.IntFrom(message.ID),
+		ConversationUUID: conversation.UUID,
+	})
+}
+
 // MarkMessageAsPending updates message status to `Pending`, enqueuing it for sending.
 func (m *Manager) MarkMessageAsPending(uuid string) error {
 	if err := m.UpdateMessageStatus(uuid, models.MessageStatusPending); err != nil {
@@ -789,7 +969,8 @@ func (m *Manager) ProcessIncomingMessage(in models.IncomingMessage) (models.Mess
 	return msg, nil
 }
 
-// resolveSender resolves the sender for an incoming message via plus-addressing.
+// resolveSender resolves the sender for an incoming message via plus-addressing or
+// secondary email identities of merged contacts.
 // Returns senderID, and optionally conversationID/UUID if matched.
 // If sender is not resolved here, ProcessIncomingMessage handles it with conversation context.
 func (m *Manager) resolveSender(in *models.IncomingMessage) (senderID, conversationID int, conversationUUID string, err error) {
@@ -802,6 +983,18 @@ func (m *Manager) resolveSender(in *models.IncomingMessage) (senderID, conversat
 			in.Contact.ID = senderID
 		}
 	}
+
+	// Email may belong to a contact that was merged into another one.
+	if senderID == 0 && in.Contact.Email.String != "" {
+		senderID, err = m.userStore.GetContactIDByIdentity(in.Contact.Email.String)
+		if err != nil {
+			return 0, 0, "", err
+		}
+		if senderID > 0 {
+			m.lo.Debug("resolved sender by contact identity", "contact_id", senderID, "contact_email", in.Contact.Email.String)
+			in.Contact.ID = senderID
+		}
+	}
 	return senderID, conversationID, conversationUUID, nil
 }
 
@@ -1213,6 +1406,9 @@ func (m *Manager) ProcessIncomingMessageHooks(conversationUUID string, isNewConv
 		if err == nil {
 			m.webhookStore.TriggerEvent(wmodels.EventConversationCreated, conversation)
 			m.automation.EvaluateNewConversationRules(conversation)
+			if m.autoAssigner != nil {
+				m.autoAssigner.ConversationCreated(conversationUUID)
+			}
 		}
 		return nil
 	}
@@ -0,0 +1,180 @@
+package conversation
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/abhinavxd/libredesk/internal/conversation/models"
+	"github.com/abhinavxd/libredesk/internal/inbox"
+	imodels "github.com/abhinavxd/libredesk/internal/inbox/models"
+	tmodels "github.com/abhinavxd/libredesk/internal/team/models"
+	"github.com/abhinavxd/libredesk/internal/template"
+	umodels "github.com/abhinavxd/libredesk/internal/user/models"
+	"github.com/volatiletech/null/v9"
+	"github.com/zerodha/logf"
+)
+
+// fakeInboxStore returns inbox records by ID.
+type fakeInboxStore struct {
+	records map[int]imodels.Inbox
+}
+
+func (s fakeInboxStore) Get(int) (inbox.Inbox, error) { return nil, errors.New("not found") }
+func (s fakeInboxStore) GetDBRecord(id any) (imodels.Inbox, error) {
+	r, ok := s.records[id.(int)]
+	if !ok {
+		return r, errors.New("not found")
+	}
+	return r, nil
+}
+func (s fakeInboxStore) GetAll() ([]imodels.Inbox, error) { return nil, nil }
+
+// fakeTeamStore returns teams by ID.
+type fakeTeamStore struct {
+	teams map[int]tmodels.Team
+}
+
+func (s fakeTeamStore) Get(id int) (tmodels.Team, error) {
+	t, ok := s.teams[id]
+	if !ok {
+		return t, errors.New("not found")
+	}
+	return t, nil
+}
+func (s fakeTeamStore) UserBelongsToTeam(int, int) (bool, error)     { return false, nil }
+func (s fakeTeamStore) GetMembers(int) ([]tmodels.TeamMember, error) { return nil, nil }
+
+func TestIsMessageRecipient(t *testing.T) {
+	meta := json.RawMessage(`{"to": ["alice@example.com"], "cc": ["Bob <bob@example.com>"], "bcc": ["carol@example.com"]}`)
+
+	testCases := []struct {
+		name     string
+		meta     json.RawMessage
+		email    string
+		expected bool
+	}{
+		{name: "To recipient", meta: meta, email: "alice@example.com", expected: true},
+		{name: "To recipient different case", meta: meta, email: "Alice@Example.com", expected: true},
+		{name: "Cc recipient with name", meta: meta, email: "bob@example.com", expected: true},
+		{name: "Bcc recipient", meta: meta, email: "carol@example.com", expected: false},
+		{name: "Unrelated address", meta: meta, email: "mallory@example.com", expected: false},
+		{name: "No recipients", meta: json.RawMessage(`{}`), email: "alice@example.com", expected: false},
+		{name: "Empty meta", meta: nil, email: "alice@example.com", expected: false},
+		{name: "Invalid meta", meta: json.RawMessage(`{"to": "alice@example.com"}`), email: "alice@example.com", expected: false},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := isMessageRecipient(tc.meta, tc.email); got != tc.expected {
+				t.Errorf("isMessageRecipient(%s, %q) = %v, want %v", tc.meta, tc.email, got, tc.expected)
+			}
+		})
+	}
+}
+
+func TestRenderEmailSignature(t *testing.T) {
+	m := &Manager{
+		inboxStore: fakeInboxStore{records: map[int]imodels.Inbox{
+			1: {ID: 1, Channel: inbox.ChannelEmail, Config: json.RawMessage(`{"signature": "{{ .Inbox.Name }} support"}`)},
+			2: {ID: 2, Channel: inbox.ChannelEmail, Config: json.RawMessage(`{}`)},
+			3: {ID: 3, Channel: inbox.ChannelLiveChat, Config: json.RawMessage(`{"signature": "Live chat"}`)},
+		}},
+		teamStore: fakeTeamStore{teams: map[int]tmodels.Team{1: {Name: "Billing"}}},
+		template:  &template.Manager{},
+	}
+
+	var (
+		agent  = umodels.User{FirstName: "Jane", LastName: "Doe", Email: null.StringFrom("jane@example.com"), EmailSignature: null.StringFrom("{{ .Author.FullName }}, {{ .Team.Name }} team at {{ .Inbox.Email }}")}
+		plain  = umodels.User{FirstName: "John", Email: null.StringFrom("john@example.com")}
+		system = umodels.User{FirstName: "System", Email: null.StringFrom(umodels.SystemUserEmail), EmailSignature: null.StringFrom("Bot")}
+	)
+
+	testCases := []struct {
+		name     string
+		inboxID  int
+		sender   umodels.User
+		expected string
+	}{
+		{name: "Agent signature", inboxID: 1, sender: agent, expected: "Jane Doe, Billing team at support@example.com"},
+		{name: "Inbox signature without agent signature", inboxID: 1, sender: plain, expected: "Support support"},
+		{name: "Inbox signature for system user", inboxID: 1, sender: system, expected: "Support support"},
+		{name: "No signature", inboxID: 2, sender: plain, expected: ""},
+		{name: "Signature of a non email inbox", inboxID: 3, sender: plain, expected: ""},
+		{name: "Missing inbox", inboxID: 4, sender: plain, expected: ""},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			conversation := models.Conversation{
+				InboxID:        tc.inboxID,
+				InboxName:      "Support",
+				InboxMail:      "support@example.com",
+				AssignedTeamID: null.IntFrom(1),
+			}
+			got := m.renderEmailSignature(conversation, tc.sender, buildTemplateData(conversation, tc.sender))
+			if got != tc.expected {
+				t.Errorf("renderEmailSignature() = %q, want %q", got, tc.expected)
+			}
+		})
+	}
+}
+
+func TestWithEmailSignature(t *testing.T) {
+	if got := withEmailSignature("<p>Hi</p>", false); got != "<p>Hi</p>"+emailSignatureBlock {
+		t.Errorf("withEmailSignature() without the signature in the template = %q, want the signature block appended", got)
+	}
+	if got := withEmailSignature("<p>Hi</p>", true); got != "<p>Hi</p>" {
+		t.Errorf("withEmailSignature() with the signature in the template = %q, want the content unchanged", got)
+	}
+}
+
+// fakeIdentityStore resolves secondary email identities of merged contacts.
+type fakeIdentityStore struct {
+	userStore
+	identities map[string]int
+	err        error
+	lookups    []string
+}
+
+func (s *fakeIdentityStore) GetContactIDByIdentity(email string) (int, error) {
+	s.lookups = append(s.lookups, email)
+	return s.identities[email], s.err
+}
+
+func TestResolveSender_ContactIdentity(t *testing.T) {
+	lo := logf.New(logf.Opts{})
+
+	testCases := []struct {
+		name      string
+		email     string
+		err       error
+		wantID    int
+		wantErr   bool
+		wantCalls int
+	}{
+		{name: "Secondary email of merged contact", email: "old@example.com", wantID: 5, wantCalls: 1},
+		{name: "Unknown email", email: "new@example.com", wantID: 0, wantCalls: 1},
+		{name: "No email", email: "", wantID: 0, wantCalls: 0},
+		{name: "Lookup error", email: "old@example.com", err: errors.New("db down"), wantErr: true, wantCalls: 1},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			store := &fakeIdentityStore{identities: map[string]int{"old@example.com": 5}, err: tc.err}
+			m := &Manager{userStore: store, lo: &lo}
+			in := models.IncomingMessage{Contact: models.IncomingContact{Email: null.NewString(tc.email, tc.email != "")}}
+
+			senderID, _, _, err := m.resolveSender(&in)
+			if (err != nil) != tc.wantErr {
+				t.Fatalf("resolveSender() error = %v, wantErr %v", err, tc.wantErr)
+			}
+			if senderID != tc.wantID || in.Contact.ID != tc.wantID {
+				t.Errorf("resolveSender() sender = %d, contact ID = %d, want %d", senderID, in.Contact.ID, tc.wantID)
+			}
+			if len(store.lookups) != tc.wantCalls {
+				t.Errorf("resolveSender() looked up %d identities, want %d", len(store.lookups), tc.wantCalls)
+			}
+		})
+	}
+}
@@ -216,6 +216,7 @@ type ConversationContact struct {
 	LastActiveAt           null.Time       `db:"last_active_at" json:"last_active_at"`
 	LastLoginAt            null.Time       `db:"last_login_at" json:"last_login_at"`
 	ExternalUserID         null.String     `db:"external_user_id" json:"external_user_id"`
+	EmailUndeliverable     bool            `db:"email_undeliverable" json:"email_undeliverable"`
 }
 
 func (c *ConversationContact) FullName() string {
@@ -473,6 +474,20 @@ type IncomingMessage struct {
 	References                  []string
 }
 
+// EmailBounce is a failed delivery of an outgoing email reported back by a mail server.
+type EmailBounce struct {
+	InboxID int
+	// SourceID is the Message-ID of the outgoing message.
+	SourceID  string
+	Recipient string
+	// Status is the enhanced status code, e.g. 5.1.1.
+	Status string
+	Reason string
+	// Permanent is set for hard bounces, FlagContact marks the contact address as undeliverable on them.
+	Permanent   bool
+	FlagContact bool
+}
+
 // ToMessage converts IncomingMessage to a Message for DB insertion.
 func (in *IncomingMessage) ToMessage(senderID, conversationID int, conversationUUID string) Message {
 	return Message{
@@ -174,6 +174,7 @@ SELECT
    ct.last_active_at as "contact.last_active_at",
    ct.last_login_at as "contact.last_login_at",
    ct.external_user_id as "contact.external_user_id",
+   ct.email_undeliverable as "contact.email_undeliverable",
    as_latest.first_response_deadline_at,
    as_latest.resolution_deadline_at,
    as_latest.id as applied_sla_id,
@@ -209,13 +210,6 @@ WHERE
   ($3::TEXT != '' AND c.reference_number = $3::TEXT)
 
 
--- name: get-conversations-created-after
-SELECT
-    c.id,
-    c.uuid
-FROM conversations c
-WHERE c.created_at > $1;
-
 -- name: get-contact-previous-conversations
 SELECT
     c.id,
@@ -342,6 +336,13 @@ WHERE uuid = $1;
 -- name: get-user-active-conversations-count
 SELECT COUNT(*) FROM conversations WHERE status_id IN (SELECT id FROM conversation_statuses WHERE name NOT IN ('Resolved', 'Closed')) and assigned_user_id = $1;
 
+-- name: get-user-active-conversations-by-channel
+SELECT inb.channel, COUNT(*) AS count
+FROM conversations c
+JOIN inboxes inb ON inb.id = c.inbox_id
+WHERE c.status_id IN (SELECT id FROM conversation_statuses WHERE name NOT IN ('Resolved', 'Closed')) AND c.assigned_user_id = $1
+GROUP BY inb.channel;
+
 -- name: update-conversation-priority
 UPDATE conversations 
 SET priority_id = (SELECT id FROM conversation_priorities WHERE name = $2),
@@ -387,17 +388,35 @@ VALUES($1, (SELECT id FROM conversations WHERE uuid = $2));
 
 -- name: get-unassigned-conversations
 SELECT
+    c.id,
     c.created_at,
     c.updated_at,
     c.uuid,
+    c.contact_id,
     c.assigned_team_id,
     inb.channel as inbox_channel,
-    inb.name as inbox_name
+    inb.name as inbox_name,
+    COALESCE(
+        (SELECT json_agg(t.name)
+         FROM tags t
+         INNER JOIN conversation_tags ct ON ct.tag_id = t.id
+         WHERE ct.conversation_id = c.id),
+        '[]'::json
+    ) AS tags
 FROM conversations c
     JOIN inboxes inb ON c.inbox_id = inb.id 
 WHERE assigned_user_id IS NULL AND assigned_team_id IS NOT NULL
+    AND ($1 = '' OR c.uuid::text = $1)
 ORDER BY c.created_at ASC;
 
+-- name: get-contact-last-assignee
+-- Returns the agent assigned to the contact's most recent other conversation.
+SELECT c.assigned_user_id
+FROM conversations c
+WHERE c.contact_id = $1 AND c.id != $2 AND c.assigned_user_id IS NOT NULL
+ORDER BY c.created_at DESC
+LIMIT 1;
+
 -- name: add-conversation-tags
 -- Insert new tags
 INSERT INTO conversation_tags (conversation_id, tag_id)
@@ -696,6 +715,18 @@ WHERE source_id = ANY($1::text []);
 -- name: update-message-status
 update conversation_messages set status = $1, updated_at = NOW() where uuid = $2;
 
+-- name: get-outgoing-message-by-source-id
+SELECT m.uuid, m.meta
+FROM conversation_messages m
+JOIN conversations c ON c.id = m.conversation_id
+WHERE m.source_id = $1 AND m.type = 'outgoing' AND c.inbox_id = $2
+LIMIT 1;
+
+-- name: set-message-bounce
+UPDATE conversation_messages
+SET meta = COALESCE(meta, '{}'::jsonb) || jsonb_build_object('bounce', $2::jsonb), updated_at = NOW()
+WHERE uuid = $1;
+
 -- name: get-latest-message
 SELECT
     m.created_at,
@@ -0,0 +1,238 @@
+// Package gdpr handles exporting and erasing all data stored about a contact.
+package gdpr
+
+import (
+	"archive/zip"
+	"bytes"
+	"database/sql"
+	"embed"
+	"encoding/json"
+	"fmt"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/abhinavxd/libredesk/internal/dbutil"
+	"github.com/abhinavxd/libredesk/internal/envelope"
+	"github.com/abhinavxd/libredesk/internal/gdpr/models"
+	"github.com/abhinavxd/libredesk/internal/image"
+	mmodels "github.com/abhinavxd/libredesk/internal/media/models"
+	"github.com/jmoiron/sqlx"
+	"github.com/knadh/go-i18n"
+	"github.com/zerodha/logf"
+)
+
+var (
+	//go:embed queries.sql
+	efs embed.FS
+)
+
+const (
+	// RedactedContent replaces erased message content, subjects and previews.
+	RedactedContent = "[redacted]"
+
+	// Name given to anonymised contacts.
+	anonymisedName = "Deleted contact"
+
+	exportDataFile      = "contact.json"
+	exportAttachmentDir = "attachments"
+)
+
+type mediaStore interface {
+	GetBlob(name string) ([]byte, error)
+	Delete(name string) error
+}
+
+// contactStore runs the queries of an export or erasure.
+type contactStore interface {
+	GetContactExport(contactID int) (json.RawMessage, error)
+	GetContactMedia(contactID int, withPrivate bool) ([]mmodels.Media, error)
+	EraseContact(contactID int) (models.EraseResult, error)
+}
+
+// Manager handles data export and erasure requests.
+type Manager struct {
+	store contactStore
+	media mediaStore
+	lo    *logf.Logger
+	i18n  *i18n.I18n
+}
+
+// Opts contains options for initializing the Manager.
+type Opts struct {
+	DB    *sqlx.DB
+	Media mediaStore
+	Lo    *logf.Logger
+	I18n  *i18n.I18n
+}
+
+// queries contains prepared SQL queries.
+type queries struct {
+	GetContactExport *sqlx.Stmt `query:"get-contact-export"`
+	GetContactMedia  *sqlx.Stmt `query:"get-contact-media"`
+	EraseContact     *sqlx.Stmt `query:"erase-contact"`
+}
+
+// New creates and returns a new instance of the Manager.
+func New(opts Opts) (*Manager, error) {
+	var q queries
+	if err := dbutil.ScanSQLFile("queries.sql", &q, opts.DB, efs); err != nil {
+		return nil, err
+	}
+	return &Manager{
+		store: dbContactStore{q: q},
+		media: opts.Media,
+		lo:    opts.Lo,
+		i18n:  opts.I18n,
+	}, nil
+}
+
+// Export returns a zip archive with all data stored about the contact as JSON along with their attachments.
+func (m *Manager) Export(contactID int) ([]byte, error) {
+	data, err := m.store.GetContactExport(contactID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, envelope.NewError(envelope.NotFoundError, m.i18n.T("validation.notFoundUser"), nil)
+		}
+		m.lo.Error("error fetching contact export data", "contact_id", contactID, "error", err)
+		return nil, envelope.NewError(envelope.GeneralError, m.i18n.T("globals.messages.somethingWentWrong"), nil)
+	}
+
+	// Private notes are internal to agents and are not exported.
+	media, err := m.getContactMedia(contactID, false)
+	if err != nil {
+		return nil, err
+	}
+
+	var (
+		buf = bytes.Buffer{}
+		zw  = zip.NewWriter(&buf)
+	)
+	if err := addZipFile(zw, exportDataFile, indentJSON(data)); err != nil {
+		m.lo.Error("error writing contact export archive", "contact_id", contactID, "error", err)
+		return nil, envelope.NewError(envelope.GeneralError, m.i18n.T("globals.messages.somethingWentWrong"), nil)
+	}
+	for _, md := range media {
+		blob, err := m.media.GetBlob(md.UUID)
+		if err != nil {
+			// Skip files missing from the store, the JSON still lists them.
+			m.lo.Error("error fetching media blob for contact export", "contact_id", contactID, "uuid", md.UUID, "error", err)
+			continue
+		}
+		name := path.Join(exportAttachmentDir, md.UUID+"-"+sanitizeFilename(md.Filename))
+		if err := addZipFile(zw, name, blob); err != nil {
+			m.lo.Error("error writing contact export archive", "contact_id", contactID, "error", err)
+			return nil, envelope.NewError(envelope.GeneralError, m.i18n.T("globals.messages.somethingWentWrong"), nil)
+		}
+	}
+	if err := zw.Close(); err != nil {
+		m.lo.Error("error closing contact export archive", "contact_id", contactID, "error", err)
+		return nil, envelope.NewError(envelope.GeneralError, m.i18n.T("globals.messages.somethingWentWrong"), nil)
+	}
+	return buf.Bytes(), nil
+}
+
+// Erase anonymises the contact, redacts the content of their conversations and deletes their media.
+func (m *Manager) Erase(contactID int) (models.EraseResult, error) {
+	var result models.EraseResult
+
+	// Fetch media upfront so a failure here leaves the data untouched.
+	media, err := m.getContactMedia(contactID, true)
+	if err != nil {
+		return result, err
+	}
+
+	if result, err = m.store.EraseContact(contactID); err != nil {
+		m.lo.Error("error erasing contact data", "contact_id", contactID, "error", err)
+		return result, envelope.NewError(envelope.GeneralError, m.i18n.T("globals.messages.somethingWentWrong"), nil)
+	}
+	if result.ContactsAnonymised == 0 {
+		return result, envelope.NewError(envelope.NotFoundError, m.i18n.T("validation.notFoundUser"), nil)
+	}
+
+	for _, md := range media {
+		if err := m.media.Delete(md.UUID); err != nil {
+			m.lo.Error("error deleting contact media", "contact_id", contactID, "uuid", md.UUID, "error", err)
+			continue
+		}
+		if strings.HasPrefix(md.ContentType, "image/") {
+			if err := m.media.Delete(image.ThumbPrefix + md.UUID); err != nil {
+				m.lo.Error("error deleting contact media thumbnail", "contact_id", contactID, "uuid", md.UUID, "error", err)
+			}
+		}
+		result.MediaDeleted++
+	}
+	return result, nil
+}
+
+// getContactMedia returns all media owned by the contact, attachments of private notes are included only if withPrivate is set.
+func (m *Manager) getContactMedia(contactID int, withPrivate bool) ([]mmodels.Media, error) {
+	media, err := m.store.GetContactMedia(contactID, withPrivate)
+	if err != nil {
+		m.lo.Error("error fetching contact media", "contact_id", contactID, "error", err)
+		return nil, envelope.NewError(envelope.GeneralError, m.i18n.T("globals.messages.somethingWentWrong"), nil)
+	}
+	return media, nil
+}
+
+// dbContactStore runs the queries of an export or erasure on the database.
+type dbContactStore struct {
+	q queries
+}
+
+// GetContactExport returns everything stored about the contact as a single JSON document.
+func (s dbContactStore) GetContactExport(contactID int) (json.RawMessage, error) {
+	var data json.RawMessage
+	err := s.q.GetContactExport.Get(&data, contactID)
+	return data, err
+}
+
+// GetContactMedia returns all media owned by the contact.
+func (s dbContactStore) GetContactMedia(contactID int, withPrivate bool) ([]mmodels.Media, error) {
+	var media = make([]mmodels.Media, 0)
+	if err := s.q.GetContactMedia.Select(&media, contactID, withPrivate); err != nil {
+		return nil, err
+	}
+	return media, nil
+}
+
+// EraseContact anonymises the contact and redacts the content of their conversations.
+func (s dbContactStore) EraseContact(contactID int) (models.EraseResult, error) {
+	var result models.EraseResult
+	err := s.q.EraseContact.Get(&result, contactID, RedactedContent, anonymisedName)
+	return result, err
+}
+
+// addZipFile writes a single file to the zip archive.
+func addZipFile(zw *zip.Writer, name string, data []byte) error {
+	w, err := zw.CreateHeader(&zip.FileHeader{
+		Name:     name,
+		Method:   zip.Deflate,
+		Modified: time.Now(),
+	})
+	if err != nil {
+		return fmt.Errorf("creating %s: %w", name, err)
+	}
+	if _, err := w.Write(data); err != nil {
+		return fmt.Errorf("writing %s: %w", name, err)
+	}
+	return nil
+}
+
+// indentJSON pretty prints the JSON document, returning it as is if it can't be indented.
+func indentJSON(data []byte) []byte {
+	var out bytes.Buffer
+	if err := json.Indent(&out, data, "", "  "); err != nil {
+		return data
+	}
+	return out.Bytes()
+}
+
+// sanitizeFilename strips path separators from a filename so it can't escape the archive directory.
+func sanitizeFilename(name string) string {
+	name = strings.NewReplacer("/", "_", "\\", "_").Replace(name)
+	if name == "" || name == "." || name == ".." {
+		return "file"
+	}
+	return name
+}
@@ -0,0 +1,137 @@
+package gdpr
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io"
+	"os"
+	"testing"
+
+	"github.com/abhinavxd/libredesk/internal/envelope"
+	"github.com/abhinavxd/libredesk/internal/gdpr/models"
+	mmodels "github.com/abhinavxd/libredesk/internal/media/models"
+	"github.com/knadh/go-i18n"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/zerodha/logf"
+)
+
+// fakeContactStore holds the media of a contact, private holds the attachments of private notes.
+type fakeContactStore struct {
+	media   []mmodels.Media
+	private []mmodels.Media
+	result  models.EraseResult
+	erased  []int
+}
+
+func (s *fakeContactStore) GetContactExport(contactID int) (json.RawMessage, error) {
+	return json.RawMessage(`{"contact":{"id":1}}`), nil
+}
+
+func (s *fakeContactStore) GetContactMedia(contactID int, withPrivate bool) ([]mmodels.Media, error) {
+	if withPrivate {
+		return append(append([]mmodels.Media{}, s.media...), s.private...), nil
+	}
+	return s.media, nil
+}
+
+func (s *fakeContactStore) EraseContact(contactID int) (models.EraseResult, error) {
+	s.erased = append(s.erased, contactID)
+	return s.result, nil
+}
+
+// fakeMediaStore serves blobs held in memory and records deleted media.
+type fakeMediaStore struct {
+	blobs   map[string][]byte
+	deleted []string
+}
+
+func (s *fakeMediaStore) GetBlob(name string) ([]byte, error) {
+	b, ok := s.blobs[name]
+	if !ok {
+		return nil, errors.New("not found")
+	}
+	return b, nil
+}
+
+func (s *fakeMediaStore) Delete(name string) error {
+	s.deleted = append(s.deleted, name)
+	return nil
+}
+
+func createTestManager(t *testing.T, store *fakeContactStore, media *fakeMediaStore) *Manager {
+	t.Helper()
+	b, err := os.ReadFile("../../i18n/en.json")
+	require.NoError(t, err)
+	i, err := i18n.New(b)
+	require.NoError(t, err)
+	logger := logf.New(logf.Opts{Level: logf.DebugLevel})
+	return &Manager{store: store, media: media, lo: &logger, i18n: i}
+}
+
+func TestExport(t *testing.T) {
+	var (
+		store = &fakeContactStore{
+			media:   []mmodels.Media{{UUID: "doc", Filename: "../invoice.pdf"}},
+			private: []mmodels.Media{{UUID: "note", Filename: "internal.pdf"}},
+		}
+		media = &fakeMediaStore{blobs: map[string][]byte{"doc": []byte("invoice"), "note": []byte("internal")}}
+		m     = createTestManager(t, store, media)
+	)
+
+	archive, err := m.Export(1)
+	require.NoError(t, err)
+
+	zr, err := zip.NewReader(bytes.NewReader(archive), int64(len(archive)))
+	require.NoError(t, err)
+	files := map[string]string{}
+	for _, f := range zr.File {
+		rc, err := f.Open()
+		require.NoError(t, err)
+		b, err := io.ReadAll(rc)
+		rc.Close()
+		require.NoError(t, err)
+		files[f.Name] = string(b)
+	}
+
+	// Attachments of private notes are left out.
+	assert.Len(t, files, 2)
+	assert.JSONEq(t, `{"contact":{"id":1}}`, files[exportDataFile])
+	assert.Equal(t, "invoice", files["attachments/doc-.._invoice.pdf"])
+}
+
+func TestErase(t *testing.T) {
+	var (
+		store = &fakeContactStore{
+			media:   []mmodels.Media{{UUID: "doc", ContentType: "application/pdf"}},
+			private: []mmodels.Media{{UUID: "img", ContentType: "image/png"}},
+			result:  models.EraseResult{ContactsAnonymised: 1, MessagesRedacted: 3},
+		}
+		media = &fakeMediaStore{}
+		m     = createTestManager(t, store, media)
+	)
+
+	result, err := m.Erase(1)
+	require.NoError(t, err)
+	assert.Equal(t, []int{1}, store.erased)
+	assert.Equal(t, 3, result.MessagesRedacted)
+	// Attachments of private notes are erased too.
+	assert.Equal(t, 2, result.MediaDeleted)
+	assert.ElementsMatch(t, []string{"doc", "img", "thumb_img"}, media.deleted)
+}
+
+func TestErase_NotFound(t *testing.T) {
+	var (
+		store = &fakeContactStore{media: []mmodels.Media{{UUID: "doc"}}}
+		media = &fakeMediaStore{}
+		m     = createTestManager(t, store, media)
+	)
+
+	_, err := m.Erase(1)
+	var envErr envelope.Error
+	require.ErrorAs(t, err, &envErr)
+	assert.Equal(t, envelope.NotFoundError, envErr.ErrorType)
+	assert.Empty(t, media.deleted)
+}
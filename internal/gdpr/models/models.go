@@ -0,0 +1,12 @@
+package models
+
+// EraseResult holds the number of records affected by erasing a contact's data.
+type EraseResult struct {
+	ContactsAnonymised    int `db:"contacts_anonymised" json:"contacts_anonymised"`
+	ConversationsRedacted int `db:"conversations_redacted" json:"conversations_redacted"`
+	MessagesRedacted      int `db:"messages_redacted" json:"messages_redacted"`
+	CSATResponsesRedacted int `db:"csat_responses_redacted" json:"csat_responses_redacted"`
+	NotesDeleted          int `db:"notes_deleted" json:"notes_deleted"`
+	IdentitiesDeleted     int `db:"identities_deleted" json:"identities_deleted"`
+	MediaDeleted          int `db:"-" json:"media_deleted"`
+}
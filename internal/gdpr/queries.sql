@@ -0,0 +1,156 @@
+-- name: get-contact-export
+-- Returns everything stored about a contact or visitor as a single JSON document.
+SELECT json_build_object(
+    'contact', json_build_object(
+        'id', u.id,
+        'created_at', u.created_at,
+        'updated_at', u.updated_at,
+        'type', u.type,
+        'email', u.email,
+        'first_name', u.first_name,
+        'last_name', u.last_name,
+        'phone_number_country_code', u.phone_number_country_code,
+        'phone_number', u.phone_number,
+        'country', u.country,
+        'avatar_url', u.avatar_url,
+        'external_user_id', u.external_user_id,
+        'custom_attributes', u.custom_attributes,
+        'last_active_at', u.last_active_at
+    ),
+    'identities', COALESCE((
+        SELECT json_agg(json_build_object('email', ci.email, 'created_at', ci.created_at) ORDER BY ci.created_at)
+        FROM contact_identities ci
+        WHERE ci.contact_id = u.id
+    ), '[]'::json),
+    'notes', COALESCE((
+        SELECT json_agg(json_build_object('created_at', cn.created_at, 'note', cn.note) ORDER BY cn.created_at)
+        FROM contact_notes cn
+        WHERE cn.contact_id = u.id
+    ), '[]'::json),
+    'conversations', COALESCE((
+        SELECT json_agg(json_build_object(
+            'uuid', c.uuid,
+            'reference_number', c.reference_number,
+            'created_at', c.created_at,
+            'resolved_at', c.resolved_at,
+            'closed_at', c.closed_at,
+            'inbox', i.name,
+            'channel', i.channel,
+            'status', s.name,
+            'subject', c.subject,
+            'custom_attributes', c.custom_attributes,
+            'messages', COALESCE((
+                SELECT json_agg(json_build_object(
+                    'uuid', m.uuid,
+                    'created_at', m.created_at,
+                    'type', m.type,
+                    'sender_type', m.sender_type,
+                    'content_type', m.content_type,
+                    'content', m.content,
+                    'attachments', COALESCE((
+                        SELECT json_agg(json_build_object('uuid', md.uuid, 'filename', md.filename, 'content_type', md.content_type, 'size', md.size))
+                        FROM media md
+                        WHERE md.model_type = 'messages' AND md.model_id = m.id
+                    ), '[]'::json)
+                ) ORDER BY m.created_at)
+                FROM conversation_messages m
+                WHERE m.conversation_id = c.id AND m.type IN ('incoming', 'outgoing') AND m.private = false
+            ), '[]'::json),
+            'csat', (
+                SELECT json_build_object('rating', cr.rating, 'feedback', cr.feedback, 'response_timestamp', cr.response_timestamp)
+                FROM csat_responses cr
+                WHERE cr.conversation_id = c.id AND cr.response_timestamp IS NOT NULL
+                LIMIT 1
+            )
+        ) ORDER BY c.created_at)
+        FROM conversations c
+        JOIN inboxes i ON i.id = c.inbox_id
+        JOIN conversation_statuses s ON s.id = c.status_id
+        WHERE c.contact_id = u.id
+    ), '[]'::json)
+)
+FROM users u
+WHERE u.id = $1 AND u.type IN ('contact', 'visitor');
+
+-- name: get-contact-media
+-- Returns all media owned by a contact, message attachments of their conversations and their own uploads.
+-- Attachments of private notes are only returned if $2 is true.
+SELECT md.id, md.created_at, md.updated_at, md."uuid", md.store, md.filename, md.content_type, md.content_id, md.model_id, md.model_type, md.disposition, md."size", md.meta
+FROM media md
+WHERE (md.model_type = 'messages' AND md.model_id IN (
+        SELECT m.id FROM conversation_messages m
+        JOIN conversations c ON c.id = m.conversation_id
+        WHERE c.contact_id = $1 AND ($2 OR m.private = false)
+    ))
+    OR (md.model_type = 'users' AND md.model_id = $1)
+ORDER BY md.id;
+
+-- name: erase-contact
+-- Anonymises a contact and redacts the content of all their conversations.
+WITH contact AS (
+    SELECT id FROM users WHERE id = $1 AND type IN ('contact', 'visitor')
+),
+redact_messages AS (
+    UPDATE conversation_messages
+    SET content = $2,
+        text_content = $2,
+        content_type = 'text',
+        meta = '{}'::jsonb,
+        updated_at = NOW()
+    WHERE conversation_id IN (SELECT c.id FROM conversations c WHERE c.contact_id IN (SELECT id FROM contact))
+      AND type IN ('incoming', 'outgoing')
+    RETURNING id
+),
+redact_conversations AS (
+    UPDATE conversations
+    SET subject = CASE WHEN subject IS NULL THEN NULL ELSE $2 END,
+        last_message = CASE WHEN last_message IS NULL THEN NULL ELSE $2 END,
+        last_interaction = CASE WHEN last_interaction IS NULL THEN NULL ELSE $2 END,
+        custom_attributes = '{}'::jsonb,
+        meta = '{}'::jsonb,
+        anonymised_at = NOW(),
+        updated_at = NOW()
+    WHERE contact_id IN (SELECT id FROM contact)
+    RETURNING id
+),
+redact_csat AS (
+    UPDATE csat_responses
+    SET feedback = NULL,
+        meta = '{}'::jsonb,
+        updated_at = NOW()
+    WHERE conversation_id IN (SELECT id FROM redact_conversations)
+    RETURNING id
+),
+delete_notes AS (
+    DELETE FROM contact_notes
+    WHERE contact_id IN (SELECT id FROM contact)
+    RETURNING id
+),
+delete_identities AS (
+    DELETE FROM contact_identities
+    WHERE contact_id IN (SELECT id FROM contact)
+    RETURNING id
+),
+anonymise_contact AS (
+    UPDATE users
+    SET email = NULL,
+        first_name = $3,
+        last_name = NULL,
+        phone_number_country_code = NULL,
+        phone_number = NULL,
+        country = NULL,
+        avatar_url = NULL,
+        external_user_id = NULL,
+        custom_attributes = '{}'::jsonb,
+        enabled = FALSE,
+        updated_at = NOW()
+    WHERE id IN (SELECT id FROM contact)
+    RETURNING id
+)
+SELECT
+    (SELECT COUNT(*) FROM anonymise_contact) AS contacts_anonymised,
+    (SELECT COUNT(*) FROM redact_conversations) AS conversations_redacted,
+    (SELECT COUNT(*) FROM redact_messages) AS messages_redacted,
+    (SELECT COUNT(*) FROM redact_csat) AS csat_responses_redacted,
+    (SELECT COUNT(*) FROM delete_notes) AS notes_deleted,
+    (SELECT COUNT(*) FROM delete_identities) AS identities_deleted;
@@ -0,0 +1,221 @@
+// Package api implements a generic API inbox that receives messages over a signed HTTP endpoint
+// and delivers outgoing messages to a configured callback URL.
+package api
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/abhinavxd/libredesk/internal/conversation/models"
+	"github.com/abhinavxd/libredesk/internal/inbox"
+	"github.com/abhinavxd/libredesk/internal/version"
+	"github.com/abhinavxd/libredesk/internal/webhook"
+	"github.com/zerodha/logf"
+)
+
+const (
+	ChannelAPI = "api"
+
+	// Size of the buffered incoming message queue per inbox.
+	incomingQueueSize = 1000
+
+	// Maximum number of response body bytes logged on failed callback deliveries.
+	maxResponseLogSize = 1024
+)
+
+var (
+	// ErrQueueFull is returned when the incoming message queue of the inbox is full.
+	ErrQueueFull = errors.New("incoming message queue is full")
+
+	// ErrInboxClosed is returned when a message is ingested into a closed inbox.
+	ErrInboxClosed = errors.New("inbox is closed")
+)
+
+// Config holds the API inbox configuration.
+type Config struct {
+	CallbackURL string `json:"callback_url"`
+	Timeout     string `json:"timeout"`
+}
+
+// OutboundPayload is the JSON body POSTed to the callback URL for every outgoing message.
+type OutboundPayload struct {
+	Event     string                 `json:"event"`
+	Timestamp string                 `json:"timestamp"`
+	InboxID   int                    `json:"inbox_id"`
+	Message   models.OutboundMessage `json:"message"`
+}
+
+// API represents the generic API inbox.
+type API struct {
+	id           int
+	config       Config
+	from         string
+	secret       string
+	lo           *logf.Logger
+	httpClient   *http.Client
+	messageStore inbox.MessageStore
+	userStore    inbox.UserStore
+	incoming     chan models.IncomingMessage
+	done         chan struct{}
+}
+
+// Opts holds the options required for the API inbox.
+type Opts struct {
+	ID         int
+	Config     Config
+	From       string
+	Secret     string
+	Lo         *logf.Logger
+	HTTPClient *http.Client
+}
+
+// New returns a new instance of the API inbox.
+func New(store inbox.MessageStore, userStore inbox.UserStore, opts Opts) (*API, error) {
+	if opts.HTTPClient == nil {
+		return nil, fmt.Errorf("http client is required")
+	}
+	return &API{
+		id:           opts.ID,
+		config:       opts.Config,
+		from:         opts.From,
+		secret:       opts.Secret,
+		lo:           opts.Lo,
+		httpClient:   opts.HTTPClient,
+		messageStore: store,
+		userStore:    userStore,
+		incoming:     make(chan models.IncomingMessage, incomingQueueSize),
+		done:         make(chan struct{}),
+	}, nil
+}
+
+// Identifier returns the unique identifier of the inbox which is the database ID.
+func (a *API) Identifier() int {
+	return a.id
+}
+
+// Ingest queues an incoming message received over the HTTP endpoint for processing.
+func (a *API) Ingest(msg models.IncomingMessage) error {
+	select {
+	case <-a.done:
+		return ErrInboxClosed
+	default:
+	}
+
+	// Skip messages that were already received.
+	if msg.SourceID.String != "" {
+		exists, err := a.messageStore.MessageExists(msg.SourceID.String)
+		if err != nil {
+			return fmt.Errorf("checking if message exists: %w", err)
+		}
+		if exists {
+			a.lo.Debug("message already exists, skipping", "source_id", msg.SourceID.String)
+			return nil
+		}
+	}
+
+	msg.Channel = ChannelAPI
+	msg.InboxID = a.id
+
+	select {
+	case a.incoming <- msg:
+		return nil
+	default:
+		a.lo.Warn("incoming message queue is full, dropping message", "inbox_id", a.id, "queue_size", len(a.incoming))
+		return ErrQueueFull
+	}
+}
+
+// Receive hands over messages queued by Ingest to the message store until the context is cancelled.
+func (a *API) Receive(ctx context.Context) error {
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-a.done:
+			return nil
+		case msg := <-a.incoming:
+			if err := a.messageStore.EnqueueIncoming(msg); err != nil {
+				a.lo.Error("error enqueuing incoming message", "inbox_id", a.id, "source_id", msg.SourceID.String, "error", err)
+			}
+		}
+	}
+}
+
+// Send delivers the message to the configured callback URL as a signed JSON payload.
+func (a *API) Send(message models.OutboundMessage) error {
+	if a.config.CallbackURL == "" {
+		a.lo.Warn("callback URL not configured for API inbox, dropping outgoing message", "inbox_id", a.id, "message_id", message.UUID)
+		return nil
+	}
+
+	// Attachments are referenced by URL, don't ship their content.
+	for i := range message.Attachments {
+		message.Attachments[i].Content = nil
+	}
+
+	payload, err := json.Marshal(OutboundPayload{
+		Event:     "message.outgoing",
+		Timestamp: time.Now().UTC().Format(time.RFC3339),
+		InboxID:   a.id,
+		Message:   message,
+	})
+	if err != nil {
+		return fmt.Errorf("marshalling outgoing message: %w", err)
+	}
+
+	req, err := http.NewRequest(http.MethodPost, a.config.CallbackURL, bytes.NewReader(payload))
+	if err != nil {
+		return fmt.Errorf("creating callback request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "Libredesk-API-Inbox/"+version.Version)
+	if a.secret != "" {
+		req.Header.Set(webhook.SignatureHeader, webhook.GenerateSignature(payload, a.secret))
+	}
+
+	resp, err := a.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("delivering message to callback URL: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, maxResponseLogSize))
+		a.lo.Error("callback URL returned non-2xx status", "inbox_id", a.id, "message_id", message.UUID, "status_code", resp.StatusCode, "response", string(body))
+		return fmt.Errorf("callback URL returned status %d", resp.StatusCode)
+	}
+
+	a.lo.Debug("message delivered to callback URL", "inbox_id", a.id, "message_id", message.UUID, "status_code", resp.StatusCode)
+	return nil
+}
+
+// VerifySignature reports whether signature is a valid signature of payload for this inbox's secret.
+func (a *API) VerifySignature(payload []byte, signature string) bool {
+	return webhook.VerifySignature(payload, a.secret, signature)
+}
+
+// Close stops the inbox from accepting further messages.
+func (a *API) Close() error {
+	select {
+	case <-a.done:
+	default:
+		close(a.done)
+	}
+	return nil
+}
+
+// FromAddress returns the from address for this inbox.
+func (a *API) FromAddress() string {
+	return a.from
+}
+
+// Channel returns the channel name for this inbox.
+func (a *API) Channel() string {
+	return ChannelAPI
+}
@@ -0,0 +1,142 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/abhinavxd/libredesk/internal/attachment"
+	"github.com/abhinavxd/libredesk/internal/conversation/models"
+	"github.com/abhinavxd/libredesk/internal/webhook"
+	"github.com/volatiletech/null/v9"
+	"github.com/zerodha/logf"
+)
+
+// fakeMessageStore reports the source IDs in existing as already received and records enqueued messages.
+type fakeMessageStore struct {
+	existing map[string]bool
+	enqueued chan models.IncomingMessage
+}
+
+func (s *fakeMessageStore) MessageExists(sourceID string) (bool, error) {
+	return s.existing[sourceID], nil
+}
+
+func (s *fakeMessageStore) EnqueueIncoming(m models.IncomingMessage) error {
+	s.enqueued <- m
+	return nil
+}
+
+func (s *fakeMessageStore) HandleEmailBounce(models.EmailBounce) (bool, error) {
+	return false, nil
+}
+
+func newTestAPI(t *testing.T, store *fakeMessageStore, cfg Config) *API {
+	t.Helper()
+	lo := logf.New(logf.Opts{})
+	a, err := New(store, nil, Opts{ID: 7, Config: cfg, Secret: "secret", Lo: &lo, HTTPClient: &http.Client{Timeout: 5 * time.Second}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return a
+}
+
+func TestAPI_Ingest(t *testing.T) {
+	store := &fakeMessageStore{existing: map[string]bool{"seen": true}, enqueued: make(chan models.IncomingMessage, 1)}
+	a := newTestAPI(t, store, Config{})
+
+	if err := a.Ingest(models.IncomingMessage{SourceID: null.StringFrom("seen")}); err != nil {
+		t.Fatalf("Ingest() of a received message error = %v", err)
+	}
+	if len(a.incoming) != 0 {
+		t.Fatalf("Ingest() queued a message that was already received")
+	}
+
+	if err := a.Ingest(models.IncomingMessage{SourceID: null.StringFrom("new"), Content: "Hello"}); err != nil {
+		t.Fatalf("Ingest() error = %v", err)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go a.Receive(ctx)
+
+	select {
+	case m := <-store.enqueued:
+		if m.Channel != ChannelAPI || m.InboxID != 7 || m.Content != "Hello" {
+			t.Errorf("enqueued message = %+v, want channel %q and inbox 7", m, ChannelAPI)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("message was not enqueued")
+	}
+
+	a.Close()
+	if err := a.Ingest(models.IncomingMessage{}); !errors.Is(err, ErrInboxClosed) {
+		t.Errorf("Ingest() into a closed inbox error = %v, want %v", err, ErrInboxClosed)
+	}
+}
+
+func TestAPI_IngestQueueFull(t *testing.T) {
+	a := newTestAPI(t, &fakeMessageStore{}, Config{})
+	for range incomingQueueSize {
+		if err := a.Ingest(models.IncomingMessage{}); err != nil {
+			t.Fatalf("Ingest() error = %v", err)
+		}
+	}
+	if err := a.Ingest(models.IncomingMessage{}); !errors.Is(err, ErrQueueFull) {
+		t.Errorf("Ingest() into a full queue error = %v, want %v", err, ErrQueueFull)
+	}
+}
+
+func TestAPI_Send(t *testing.T) {
+	var (
+		body      []byte
+		signature string
+		status    = http.StatusOK
+	)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ = io.ReadAll(r.Body)
+		signature = r.Header.Get(webhook.SignatureHeader)
+		w.WriteHeader(status)
+	}))
+	defer srv.Close()
+
+	a := newTestAPI(t, &fakeMessageStore{}, Config{CallbackURL: srv.URL})
+	msg := models.OutboundMessage{
+		UUID:        "m1",
+		Content:     "Hi",
+		Attachments: attachment.Attachments{{Name: "a.txt", Content: []byte("data"), URL: "https://example.com/a.txt"}},
+	}
+	if err := a.Send(msg); err != nil {
+		t.Fatalf("Send() error = %v", err)
+	}
+
+	if !webhook.VerifySignature(body, "secret", signature) {
+		t.Errorf("Send() signature %q does not match the payload", signature)
+	}
+	var payload OutboundPayload
+	if err := json.Unmarshal(body, &payload); err != nil {
+		t.Fatal(err)
+	}
+	if payload.Event != "message.outgoing" || payload.InboxID != 7 || payload.Message.UUID != "m1" {
+		t.Errorf("Send() payload = %+v", payload)
+	}
+	if len(payload.Message.Attachments) != 1 || payload.Message.Attachments[0].Content != nil || payload.Message.Attachments[0].URL == "" {
+		t.Errorf("Send() attachments = %+v, want the URL without the content", payload.Message.Attachments)
+	}
+
+	status = http.StatusInternalServerError
+	if err := a.Send(msg); err == nil {
+		t.Error("Send() to a failing callback URL error = nil, want an error")
+	}
+}
+
+func TestAPI_SendWithoutCallbackURL(t *testing.T) {
+	a := newTestAPI(t, &fakeMessageStore{}, Config{})
+	if err := a.Send(models.OutboundMessage{UUID: "m1"}); err != nil {
+		t.Errorf("Send() without a callback URL error = %v, want nil", err)
+	}
+}
@@ -0,0 +1,250 @@
+package email
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/textproto"
+	"regexp"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/abhinavxd/libredesk/internal/conversation/models"
+	"github.com/emersion/go-imap/v2"
+	"github.com/emersion/go-imap/v2/imapclient"
+	"github.com/jhillyerd/enmime"
+)
+
+const (
+	headerContentType = "Content-Type"
+
+	dsnActionFailed = "failed"
+
+	// maxBounceReason is the length the bounce reason shown to agents is cut to.
+	maxBounceReason = 500
+)
+
+// reMessageIDLine matches a Message-ID header quoted in the text of non-standard bounces.
+var reMessageIDLine = regexp.MustCompile(`(?im)^\s*Message-ID:\s*<([^>\s]+)>`)
+
+// dsn is a delivery status notification (RFC 3464) or a bounce in a similar form.
+type dsn struct {
+	// OriginalMessageID is the Message-ID of the message the notification is about.
+	OriginalMessageID string
+	Recipient         string
+	Action            string
+	Status            string
+	Diagnostic        string
+}
+
+// failed reports whether the notification is about a failed delivery, delays and successful deliveries are not.
+func (d dsn) failed() bool {
+	if d.Action != "" {
+		return strings.EqualFold(d.Action, dsnActionFailed)
+	}
+	return !strings.HasPrefix(d.Status, "4")
+}
+
+// permanent reports whether the failure is a hard bounce.
+func (d dsn) permanent() bool {
+	return strings.HasPrefix(d.Status, "5")
+}
+
+// reason returns the diagnostic of the failure cut to a sane length.
+func (d dsn) reason() string {
+	reason := strings.Join(strings.Fields(d.Diagnostic), " ")
+	if len(reason) > maxBounceReason {
+		// Cut on a rune boundary so that the reason stays valid UTF-8.
+		n := maxBounceReason
+		for n > 0 && !utf8.RuneStart(reason[n]) {
+			n--
+		}
+		reason = reason[:n]
+	}
+	return reason
+}
+
+// looksLikeBounce reports whether a message with the given Content-Type and sender is a delivery status notification.
+func looksLikeBounce(contentType, from string) bool {
+	if isDeliveryReport(contentType) {
+		return true
+	}
+	local, _, _ := strings.Cut(strings.ToLower(from), "@")
+	return local == "mailer-daemon" || local == "postmaster"
+}
+
+// isDeliveryReport reports whether the Content-Type is that of a standard delivery status notification (RFC 3464).
+// Unlike bounces recognised by their sender, which can come from any domain, only these are trusted to flag contacts.
+func isDeliveryReport(contentType string) bool {
+	mediaType, params, err := mime.ParseMediaType(contentType)
+	return err == nil && strings.EqualFold(mediaType, "multipart/report") && strings.EqualFold(params["report-type"], "delivery-status")
+}
+
+// parseBounce parses a delivery status notification. It returns false if the message the notification is about can't be found.
+func parseBounce(envelope *enmime.Envelope) (dsn, bool) {
+	var d dsn
+	for _, part := range envelope.OtherParts {
+		ct := strings.ToLower(part.ContentType)
+		switch {
+		case ct == "message/delivery-status" || ct == "message/global-delivery-status":
+			parseDeliveryStatus(part.Content, &d)
+		case ct == "message/rfc822" || ct == "message/global" || ct == "message/global-headers" || strings.HasSuffix(ct, "rfc822-headers"):
+			if d.OriginalMessageID == "" {
+				d.OriginalMessageID = quotedMessageID(part.Content)
+			}
+		}
+	}
+	for _, att := range envelope.Attachments {
+		if d.OriginalMessageID == "" && strings.EqualFold(att.ContentType, "message/rfc822") {
+			d.OriginalMessageID = quotedMessageID(att.Content)
+		}
+	}
+
+	// Non-standard bounces quote the original headers in the text.
+	if d.OriginalMessageID == "" {
+		if m := reMessageIDLine.FindStringSubmatch(envelope.Text); m != nil {
+			d.OriginalMessageID = m[1]
+		}
+	}
+	if d.OriginalMessageID == "" {
+		return d, false
+	}
+	if d.Diagnostic == "" {
+		d.Diagnostic = firstParagraph(envelope.Text)
+	}
+	return d, true
+}
+
+// parseDeliveryStatus reads the per-recipient fields of a message/delivery-status part, preferring the first failed recipient.
+func parseDeliveryStatus(content []byte, d *dsn) {
+	r := bufio.NewReader(bytes.NewReader(content))
+
+	// The first block has the per-message fields.
+	if _, err := readHeaderBlock(r); err != nil {
+		return
+	}
+	for {
+		h, err := readHeaderBlock(r)
+		if len(h) > 0 {
+			rcpt := dsn{
+				Recipient:  addressType(h.Get("Final-Recipient")),
+				Action:     strings.ToLower(strings.TrimSpace(h.Get("Action"))),
+				Status:     strings.TrimSpace(h.Get("Status")),
+				Diagnostic: addressType(h.Get("Diagnostic-Code")),
+			}
+			if d.Recipient == "" || (!d.failed() && rcpt.failed()) {
+				rcpt.OriginalMessageID = d.OriginalMessageID
+				*d = rcpt
+			}
+		}
+		if err != nil {
+			return
+		}
+	}
+}
+
+// readHeaderBlock reads header fields up to the next blank line.
+func readHeaderBlock(r io.Reader) (textproto.MIMEHeader, error) {
+	br, ok := r.(*bufio.Reader)
+	if !ok {
+		br = bufio.NewReader(r)
+	}
+	// Skip blank lines between blocks.
+	for {
+		b, err := br.Peek(1)
+		if err != nil {
+			return nil, err
+		}
+		if b[0] != '\r' && b[0] != '\n' {
+			break
+		}
+		br.ReadByte()
+	}
+	h, err := textproto.NewReader(br).ReadMIMEHeader()
+	if errors.Is(err, io.EOF) && len(h) > 0 {
+		return h, nil
+	}
+	return h, err
+}
+
+// quotedMessageID returns the Message-ID of a quoted message or its headers.
+func quotedMessageID(content []byte) string {
+	h, err := readHeaderBlock(bytes.NewReader(content))
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(strings.Trim(h.Get(headerMessageID), " <>"))
+}
+
+// addressType strips the type prefix of DSN fields, e.g. "rfc822; jane@example.com".
+func addressType(v string) string {
+	if _, after, ok := strings.Cut(v, ";"); ok {
+		return strings.TrimSpace(after)
+	}
+	return strings.TrimSpace(v)
+}
+
+// firstParagraph returns the first non-empty paragraph of text.
+func firstParagraph(text string) string {
+	for _, p := range strings.Split(strings.ReplaceAll(text, "\r\n", "\n"), "\n\n") {
+		if p = strings.TrimSpace(p); p != "" {
+			return p
+		}
+	}
+	return ""
+}
+
+// processBounce fetches a suspected bounce and records it against the outgoing message. It returns false if the
+// message is not a bounce of a message sent from this inbox and should be processed as a regular message.
+func (e *Email) processBounce(ctx context.Context, client *imapclient.Client, uid imap.UID) (bool, error) {
+	section := &imap.FetchItemBodySection{Peek: true}
+	msgs, err := client.Fetch(imap.UIDSetNum(uid), &imap.FetchOptions{BodySection: []*imap.FetchItemBodySection{section}}).Collect()
+	if err != nil {
+		return false, fmt.Errorf("fetching bounce: %w", err)
+	}
+	if err := ctx.Err(); err != nil {
+		return false, err
+	}
+	if len(msgs) == 0 {
+		return true, nil
+	}
+	return e.handleBounce(msgs[0].BodySection[section])
+}
+
+// handleBounce parses a raw bounce and records it. It returns false if the message is not a bounce of a message sent from this inbox.
+func (e *Email) handleBounce(raw []byte) (bool, error) {
+	envelope, err := enmime.ReadEnvelope(bytes.NewReader(raw))
+	if err != nil {
+		return false, fmt.Errorf("parsing bounce: %w", err)
+	}
+	d, ok := parseBounce(envelope)
+	if !ok {
+		return false, nil
+	}
+	if !d.failed() {
+		// Delay and success notifications need no conversation.
+		e.lo.Info("ignoring delivery status notification", "action", d.Action, "status", d.Status, "message_id", d.OriginalMessageID, "inbox_id", e.Identifier())
+		return true, nil
+	}
+
+	found, err := e.messageStore.HandleEmailBounce(models.EmailBounce{
+		InboxID:     e.Identifier(),
+		SourceID:    d.OriginalMessageID,
+		Recipient:   strings.ToLower(d.Recipient),
+		Status:      d.Status,
+		Reason:      d.reason(),
+		Permanent:   d.permanent(),
+		FlagContact: e.flagBouncedContacts && isDeliveryReport(envelope.GetHeader(headerContentType)),
+	})
+	if err != nil {
+		return false, err
+	}
+	if found {
+		e.lo.Info("outgoing email bounced", "message_id", d.OriginalMessageID, "recipient", d.Recipient, "status", d.Status, "inbox_id", e.Identifier())
+	}
+	return found, nil
+}
@@ -10,6 +10,7 @@ import (
 	"github.com/abhinavxd/libredesk/internal/inbox"
 	"github.com/abhinavxd/libredesk/internal/inbox/channel/email/oauth"
 	"github.com/abhinavxd/libredesk/internal/inbox/models"
+	"github.com/emersion/go-imap/v2"
 	"github.com/knadh/smtppool"
 	"github.com/zerodha/logf"
 	xoauth2 "golang.org/x/oauth2"
@@ -38,12 +39,31 @@ type Email struct {
 	userStore            inbox.UserStore
 	wg                   sync.WaitGroup
 	tokenRefreshCallback TokenRefreshCallback
+	checkpointStore      CheckpointStore
+	inboundEnabled       bool
+	secret               string
+	flagBouncedContacts  bool
+	signature            string
+
+	// Messages waiting to be saved before they are moved or deleted, by mailbox.
+	pendingMu sync.Mutex
+	pending   map[string][]pendingMessage
+
+	// Failed attempts at processing messages, by mailbox and UID.
+	failuresMu sync.Mutex
+	failures   map[string]map[imap.UID]int
 }
 
 // TokenRefreshCallback is called when OAuth tokens are refreshed.
 // It receives the inbox ID and the updated config with new tokens.
 type TokenRefreshCallback func(inboxID int, updatedConfig models.Config) error
 
+// CheckpointStore persists the last processed UID of IMAP mailboxes.
+type CheckpointStore interface {
+	GetIMAPCheckpoint(inboxID int, host, username, mailbox string) (models.IMAPCheckpoint, error)
+	SetIMAPCheckpoint(inboxID int, host, username, mailbox string, c models.IMAPCheckpoint) error
+}
+
 // Opts holds the options required for the email inbox.
 type Opts struct {
 	ID                   int
@@ -51,6 +71,8 @@ type Opts struct {
 	Config               models.Config
 	Lo                   *logf.Logger
 	TokenRefreshCallback TokenRefreshCallback // Optional callback for token refresh
+	CheckpointStore      CheckpointStore      // Optional, without it every read scans the `ScanInboxSince` window
+	Secret               string               // Signs raw messages relayed to the inbound endpoint
 }
 
 // New returns a new instance of the email inbox.
@@ -80,6 +102,13 @@ func New(store inbox.MessageStore, userStore inbox.UserStore, opts Opts) (*Email
 		authType:             opts.Config.AuthType,
 		enablePlusAddressing: opts.Config.EnablePlusAddressing,
 		tokenRefreshCallback: opts.TokenRefreshCallback,
+		checkpointStore:      opts.CheckpointStore,
+		inboundEnabled:       opts.Config.InboundEnabled,
+		flagBouncedContacts:  opts.Config.FlagBouncedContacts,
+		signature:            opts.Config.Signature,
+		secret:               opts.Secret,
+		pending:              make(map[string][]pendingMessage),
+		failures:             make(map[string]map[imap.UID]int),
 	}
 	return e, nil
 }
@@ -89,16 +118,20 @@ func (e *Email) Identifier() int {
 	return e.id
 }
 
-// Receive starts reading incoming messages for each IMAP client.
+// Receive starts reading incoming messages from every mailbox of each IMAP client.
 func (e *Email) Receive(ctx context.Context) error {
 	for _, cfg := range e.imapCfg {
-		e.wg.Add(1)
-		go func(cfg models.IMAPConfig) {
-			defer e.wg.Done()
-			if err := e.ReadIncomingMessages(ctx, cfg); err != nil {
-				e.lo.Error("error reading incoming messages", "error", err)
-			}
-		}(cfg)
+		for _, mailbox := range cfg.AllMailboxes() {
+			cfg := cfg
+			cfg.Mailbox = mailbox
+			e.wg.Add(1)
+			go func(cfg models.IMAPConfig) {
+				defer e.wg.Done()
+				if err := e.ReadIncomingMessages(ctx, cfg); err != nil {
+					e.lo.Error("error reading incoming messages", "mailbox", cfg.Mailbox, "error", err)
+				}
+			}(cfg)
+		}
 	}
 	e.wg.Wait()
 	return nil
@@ -132,6 +165,9 @@ func (e *Email) getCurrentConfig() models.Config {
 		OAuth:                oauth,
 		AuthType:             e.authType,
 		EnablePlusAddressing: e.enablePlusAddressing,
+		InboundEnabled:       e.inboundEnabled,
+		FlagBouncedContacts:  e.flagBouncedContacts,
+		Signature:            e.signature,
 	}
 }
 
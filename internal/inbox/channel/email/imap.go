@@ -1,10 +1,14 @@
 package email
 
 import (
+	"cmp"
 	"context"
 	"crypto/tls"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
+	"slices"
 	"strings"
 	"time"
 
@@ -21,9 +25,20 @@ import (
 const (
 	defaultReadInterval   = time.Duration(5 * time.Minute)
 	defaultScanInboxSince = time.Duration(48 * time.Hour)
+
+	// maxIdleSession is how long an IDLE connection is kept before reconnecting, which also re-authenticates with a fresh OAuth token.
+	maxIdleSession = 50 * time.Minute
+
+	// maxPendingChecks is how many syncs an enqueued message is waited on to be saved before it is moved or deleted.
+	maxPendingChecks = 10
+
+	// maxMessageAttempts is how many syncs processing a message is attempted before it is skipped, so that a message
+	// that always fails doesn't hold back the checkpoint of the mailbox.
+	maxMessageAttempts = 5
 )
 
 // ReadIncomingMessages reads and processes incoming messages from an IMAP server based on the provided configuration.
+// New messages are pushed with IDLE on servers that support it, other servers are polled at the read interval.
 func (e *Email) ReadIncomingMessages(ctx context.Context, cfg imodels.IMAPConfig) error {
 	readInterval, err := time.ParseDuration(cfg.ReadInterval)
 	if err != nil {
@@ -37,33 +52,37 @@ func (e *Email) ReadIncomingMessages(ctx context.Context, cfg imodels.IMAPConfig
 		scanInboxSince = defaultScanInboxSince
 	}
 
-	readTicker := time.NewTicker(readInterval)
-	defer readTicker.Stop()
-
 	for {
+		idled, err := e.processMailbox(ctx, scanInboxSince, readInterval, cfg)
+		if err != nil && !errors.Is(err, context.Canceled) {
+			e.lo.Error("error searching emails", "error", err)
+		}
+		if ctx.Err() != nil {
+			return nil
+		}
+		e.lo.Info("email search complete", "mailbox", cfg.Mailbox, "inbox_id", e.Identifier())
+
+		// Reconnect right away when an IDLE session ends cleanly, otherwise wait for the next read.
+		if idled && err == nil {
+			continue
+		}
 		select {
 		case <-ctx.Done():
 			return nil
-		case <-readTicker.C:
-			// If the ticker interval is too short, it may trigger while the previous `processMailbox` call is still running,
-			// leading to overlapping executions or delays in handling context cancellation, check if the context is already done.
-			if ctx.Err() != nil {
-				return nil
-			}
-
-			if err := e.processMailbox(ctx, scanInboxSince, cfg); err != nil && err != context.Canceled {
-				e.lo.Error("error searching emails", "error", err)
-			}
-			e.lo.Info("email search complete", "mailbox", cfg.Mailbox, "inbox_id", e.Identifier())
+		case <-time.After(readInterval):
 		}
 	}
 }
 
-// processMailbox processes emails in the specified mailbox.
-func (e *Email) processMailbox(ctx context.Context, scanInboxSince time.Duration, cfg imodels.IMAPConfig) error {
+// processMailbox processes new emails in the specified mailbox and then waits for more with IDLE if the server supports it.
+// It reports whether the connection was used for IDLE.
+func (e *Email) processMailbox(ctx context.Context, scanInboxSince, readInterval time.Duration, cfg imodels.IMAPConfig) (bool, error) {
 	var (
 		client *imapclient.Client
 		err    error
+
+		// Signalled when the server announces new messages.
+		newMail = make(chan struct{}, 1)
 	)
 
 	address := fmt.Sprintf("%s:%d", cfg.Host, cfg.Port)
@@ -71,6 +90,17 @@ func (e *Email) processMailbox(ctx context.Context, scanInboxSince time.Duration
 		TLSConfig: &tls.Config{
 			InsecureSkipVerify: cfg.TLSSkipVerify,
 		},
+		UnilateralDataHandler: &imapclient.UnilateralDataHandler{
+			Mailbox: func(data *imapclient.UnilateralDataMailbox) {
+				if data.NumMessages == nil {
+					return
+				}
+				select {
+				case newMail <- struct{}{}:
+				default:
+				}
+			},
+		},
 	}
 	switch cfg.TLSType {
 	case "none":
@@ -80,10 +110,10 @@ func (e *Email) processMailbox(ctx context.Context, scanInboxSince time.Duration
 	case "tls":
 		client, err = imapclient.DialTLS(address, imapOptions)
 	default:
-		return fmt.Errorf("unknown IMAP TLS type: %q", cfg.TLSType)
+		return false, fmt.Errorf("unknown IMAP TLS type: %q", cfg.TLSType)
 	}
 	if err != nil {
-		return fmt.Errorf("failed to connect to IMAP server: %w", err)
+		return false, fmt.Errorf("failed to connect to IMAP server: %w", err)
 	}
 
 	defer client.Logout()
@@ -93,7 +123,7 @@ func (e *Email) processMailbox(ctx context.Context, scanInboxSince time.Duration
 		// Refresh OAuth token if needed
 		oauthConfig, _, err := e.refreshOAuthIfNeeded()
 		if err != nil {
-			return err
+			return false, err
 		}
 
 		// Use XOAUTH2 authentication
@@ -102,85 +132,374 @@ func (e *Email) processMailbox(ctx context.Context, scanInboxSince time.Duration
 			token:    oauthConfig.AccessToken,
 		}
 		if err := client.Authenticate(saslClient); err != nil {
-			return fmt.Errorf("error authenticating with OAuth to IMAP server: %w", err)
+			return false, fmt.Errorf("error authenticating with OAuth to IMAP server: %w", err)
 		}
 	} else {
 		if err := client.Login(cfg.Username, cfg.Password).Wait(); err != nil {
-			return fmt.Errorf("error logging in to the IMAP server: %w", err)
+			return false, fmt.Errorf("error logging in to the IMAP server: %w", err)
 		}
 	}
 
-	if _, err := client.Select(cfg.Mailbox, &imap.SelectOptions{ReadOnly: true}).Wait(); err != nil {
-		return fmt.Errorf("error selecting mailbox: %w", err)
+	// Post-processing changes the mailbox, otherwise it is only read.
+	selected, err := client.Select(cfg.Mailbox, &imap.SelectOptions{ReadOnly: cfg.PostProcessAction() == imodels.PostProcessNone}).Wait()
+	if err != nil {
+		return false, fmt.Errorf("error selecting mailbox: %w", err)
 	}
 
-	// Scan emails since the specified duration.
-	since := time.Now().Add(-scanInboxSince)
+	if err := e.syncMailbox(ctx, client, selected, scanInboxSince, cfg); err != nil {
+		return false, err
+	}
+
+	if !client.Caps().Has(imap.CapIdle) {
+		return false, nil
+	}
+	return true, e.idle(ctx, client, newMail, selected, scanInboxSince, readInterval, cfg)
+}
+
+// idle waits for new messages with IDLE and syncs the mailbox when the server announces them,
+// or at the read interval in case an announcement is missed. It returns when the session has to be reconnected.
+func (e *Email) idle(ctx context.Context, client *imapclient.Client, newMail <-chan struct{}, selected *imap.SelectData, scanInboxSince, readInterval time.Duration, cfg imodels.IMAPConfig) error {
+	e.lo.Info("waiting for new emails with IMAP IDLE", "mailbox", cfg.Mailbox, "inbox_id", e.Identifier())
+
+	sessionEnd := time.NewTimer(maxIdleSession)
+	defer sessionEnd.Stop()
 
-	e.lo.Info("searching emails", "since", since, "mailbox", cfg.Mailbox, "inbox_id", e.Identifier())
+	for {
+		idleCmd, err := client.Idle()
+		if err != nil {
+			return fmt.Errorf("error starting IDLE: %w", err)
+		}
+		idleDone := make(chan error, 1)
+		go func() {
+			idleDone <- idleCmd.Wait()
+		}()
+
+		var end bool
+		select {
+		case err := <-idleDone:
+			// The server ended IDLE, usually by closing the connection.
+			if err == nil {
+				err = errors.New("IDLE ended by server")
+			}
+			return err
+		case <-ctx.Done():
+			end = true
+		case <-sessionEnd.C:
+			end = true
+		case <-newMail:
+		case <-time.After(readInterval):
+		}
+
+		if err := idleCmd.Close(); err != nil {
+			return fmt.Errorf("error stopping IDLE: %w", err)
+		}
+		if err := <-idleDone; err != nil {
+			return fmt.Errorf("error stopping IDLE: %w", err)
+		}
+		if end {
+			return ctx.Err()
+		}
+
+		if err := e.syncMailbox(ctx, client, selected, scanInboxSince, cfg); err != nil {
+			return err
+		}
+	}
+}
+
+// syncMailbox processes messages that arrived after the last checkpoint of the mailbox.
+// Without a valid checkpoint, i.e. on the first read or after the mailbox UIDVALIDITY changed, messages since `scanInboxSince` are processed.
+func (e *Email) syncMailbox(ctx context.Context, client *imapclient.Client, selected *imap.SelectData, scanInboxSince time.Duration, cfg imodels.IMAPConfig) error {
+	stored := e.getCheckpoint(cfg)
+	checkpoint := stored
+	if checkpoint.UIDValidity != selected.UIDValidity {
+		if checkpoint.UIDValidity != 0 {
+			e.lo.Warn("IMAP mailbox UIDVALIDITY changed, rescanning mailbox", "mailbox", cfg.Mailbox, "inbox_id", e.Identifier(), "old_uid_validity", checkpoint.UIDValidity, "uid_validity", selected.UIDValidity)
+		}
+		checkpoint = imodels.IMAPCheckpoint{UIDValidity: selected.UIDValidity}
+	}
+
+	criteria := &imap.SearchCriteria{}
+	if checkpoint.LastUID > 0 {
+		e.lo.Debug("searching emails after checkpoint", "last_uid", checkpoint.LastUID, "mailbox", cfg.Mailbox, "inbox_id", e.Identifier())
+		var uids imap.UIDSet
+		uids.AddRange(imap.UID(checkpoint.LastUID+1), 0)
+		criteria.UID = []imap.UIDSet{uids}
+	} else {
+		criteria.Since = time.Now().Add(-scanInboxSince)
+		e.lo.Info("searching emails", "since", criteria.Since, "mailbox", cfg.Mailbox, "inbox_id", e.Identifier())
+	}
 
 	// Search for messages in the mailbox.
-	searchResults, err := e.searchMessages(client, since)
+	uids, err := e.searchMessages(client, criteria)
 	if err != nil {
 		return fmt.Errorf("error searching messages: %w", err)
 	}
+	uids = newUIDs(uids, checkpoint.LastUID)
 
-	return e.fetchAndProcessMessages(ctx, client, searchResults, e.Identifier())
+	last, enqueued, skipped, err := e.fetchAndProcessMessages(ctx, client, uids, cfg)
+
+	// Post-process the messages that were handled. Enqueued messages are only moved or deleted
+	// once they are saved, so that they are not lost if saving fails. Skipped messages are left as they are.
+	if action := cfg.PostProcessAction(); action != imodels.PostProcessNone {
+		destructive := action == imodels.PostProcessMove || action == imodels.PostProcessDelete
+		var handled []imap.UID
+		for _, uid := range uids {
+			if uid > last {
+				break
+			}
+			if slices.Contains(skipped, uid) {
+				continue
+			}
+			if sourceID, ok := enqueued[uid]; ok && destructive {
+				e.addPending(cfg, selected.UIDValidity, uid, sourceID)
+				continue
+			}
+			handled = append(handled, uid)
+		}
+		if destructive {
+			handled = append(handled, e.savedPending(cfg, selected.UIDValidity)...)
+		}
+		e.postProcess(client, handled, cfg)
+	}
+
+	// Move the checkpoint past the processed messages. If all messages were processed, older messages
+	// outside the scan window that are below UIDNEXT of the selected mailbox are skipped as well.
+	next := checkpoint
+	if uint32(last) > next.LastUID {
+		next.LastUID = uint32(last)
+	}
+	if err == nil && (len(uids) == 0 || last == uids[len(uids)-1]) && selected.UIDNext > 0 && uint32(selected.UIDNext)-1 > next.LastUID {
+		next.LastUID = uint32(selected.UIDNext) - 1
+	}
+	if next != stored {
+		e.setCheckpoint(cfg, next)
+	}
+	return err
 }
 
-// searchMessages searches for messages in the specified time range.
-// Uses ESEARCH if supported by the server, otherwise falls back to standard SEARCH.
-func (e *Email) searchMessages(client *imapclient.Client, since time.Time) (*imap.SearchData, error) {
-	criteria := &imap.SearchCriteria{
-		Since: since,
+// postProcess marks handled messages as seen, moves them to another mailbox or deletes them as configured for the mailbox.
+// Failures are logged and not retried as the checkpoint has already moved past the messages.
+func (e *Email) postProcess(client *imapclient.Client, uids []imap.UID, cfg imodels.IMAPConfig) {
+	if len(uids) == 0 {
+		return
 	}
 
-	// Attempt ESEARCH if server supports it
-	if client.Caps().Has(imap.CapESearch) {
-		opts := &imap.SearchOptions{
-			ReturnMin:   true,
-			ReturnMax:   true,
-			ReturnAll:   true,
-			ReturnCount: true,
+	var (
+		uidSet = imap.UIDSetNum(uids...)
+		err    error
+	)
+	switch cfg.PostProcessAction() {
+	case imodels.PostProcessSeen:
+		err = client.Store(uidSet, &imap.StoreFlags{Op: imap.StoreFlagsAdd, Silent: true, Flags: []imap.Flag{imap.FlagSeen}}, nil).Close()
+	case imodels.PostProcessMove:
+		// Without MOVE, the fallback of COPY and delete has to expunge only the handled messages.
+		if !client.Caps().Has(imap.CapMove) && !client.Caps().Has(imap.CapUIDPlus) {
+			e.lo.Error("not moving messages, IMAP server supports neither MOVE nor UIDPLUS", "mailbox", cfg.Mailbox, "inbox_id", e.Identifier())
+			return
+		}
+		_, err = client.Move(uidSet, cfg.MoveToMailbox).Wait()
+	case imodels.PostProcessDelete:
+		// A plain EXPUNGE would remove every message flagged as deleted in the mailbox, including those of other clients.
+		if !client.Caps().Has(imap.CapUIDPlus) {
+			e.lo.Error("not deleting messages, IMAP server does not support UIDPLUS", "mailbox", cfg.Mailbox, "inbox_id", e.Identifier())
+			return
+		}
+		err = client.Store(uidSet, &imap.StoreFlags{Op: imap.StoreFlagsAdd, Silent: true, Flags: []imap.Flag{imap.FlagDeleted}}, nil).Close()
+		if err == nil {
+			err = client.UIDExpunge(uidSet).Close()
+		}
+	default:
+		e.lo.Warn("unknown IMAP post-process action", "action", cfg.PostProcess, "mailbox", cfg.Mailbox, "inbox_id", e.Identifier())
+		return
+	}
+	if err != nil {
+		e.lo.Error("error post-processing messages", "action", cfg.PostProcess, "count", len(uids), "mailbox", cfg.Mailbox, "inbox_id", e.Identifier(), "error", err)
+		return
+	}
+	e.lo.Debug("post-processed messages", "action", cfg.PostProcess, "count", len(uids), "mailbox", cfg.Mailbox, "inbox_id", e.Identifier())
+}
+
+// pendingMessage is an enqueued message that is moved or deleted once it is saved.
+type pendingMessage struct {
+	uid         imap.UID
+	uidValidity uint32
+	sourceID    string
+	checks      int
+}
+
+// pendingKey returns the key of the mailbox in the pending messages and the failed attempts.
+func pendingKey(cfg imodels.IMAPConfig) string {
+	return cfg.Host + "|" + cfg.Username + "|" + cfg.Mailbox
+}
+
+// addPending holds back post-processing of an enqueued message until it is saved.
+func (e *Email) addPending(cfg imodels.IMAPConfig, uidValidity uint32, uid imap.UID, sourceID string) {
+	e.pendingMu.Lock()
+	defer e.pendingMu.Unlock()
+	key := pendingKey(cfg)
+	e.pending[key] = append(e.pending[key], pendingMessage{uid: uid, uidValidity: uidValidity, sourceID: sourceID})
+}
+
+// savedPending returns the UIDs of pending messages of the mailbox that have been saved and forgets them.
+// Messages that are not saved after `maxPendingChecks` are left in the mailbox.
+func (e *Email) savedPending(cfg imodels.IMAPConfig, uidValidity uint32) []imap.UID {
+	e.pendingMu.Lock()
+	defer e.pendingMu.Unlock()
+
+	var (
+		key   = pendingKey(cfg)
+		saved []imap.UID
+		keep  []pendingMessage
+	)
+	for _, p := range e.pending[key] {
+		// UIDs of an older UIDVALIDITY no longer refer to the same messages.
+		if p.uidValidity != uidValidity {
+			continue
+		}
+		exists, err := e.messageStore.MessageExists(p.sourceID)
+		if err != nil {
+			e.lo.Error("error checking if message exists", "message_id", p.sourceID, "error", err)
+		}
+		switch {
+		case exists:
+			saved = append(saved, p.uid)
+		case p.checks+1 >= maxPendingChecks:
+			e.lo.Warn("message was not saved, leaving it in the mailbox", "uid", p.uid, "message_id", p.sourceID, "mailbox", cfg.Mailbox, "inbox_id", e.Identifier())
+		default:
+			p.checks++
+			keep = append(keep, p)
+		}
+	}
+	if len(keep) == 0 {
+		delete(e.pending, key)
+	} else {
+		e.pending[key] = keep
+	}
+	return saved
+}
+
+// recordFailure counts a failed attempt at processing a message of the mailbox and reports whether the message
+// has failed `maxMessageAttempts` times and should be skipped.
+func (e *Email) recordFailure(cfg imodels.IMAPConfig, uid imap.UID) bool {
+	e.failuresMu.Lock()
+	defer e.failuresMu.Unlock()
+
+	key := pendingKey(cfg)
+	if e.failures[key] == nil {
+		e.failures[key] = make(map[imap.UID]int)
+	}
+	e.failures[key][uid]++
+	if e.failures[key][uid] < maxMessageAttempts {
+		return false
+	}
+	delete(e.failures[key], uid)
+	if len(e.failures[key]) == 0 {
+		delete(e.failures, key)
+	}
+	return true
+}
+
+// clearFailures forgets the failed attempts at processing a message of the mailbox.
+func (e *Email) clearFailures(cfg imodels.IMAPConfig, uid imap.UID) {
+	e.failuresMu.Lock()
+	defer e.failuresMu.Unlock()
+
+	key := pendingKey(cfg)
+	if _, ok := e.failures[key]; !ok {
+		return
+	}
+	delete(e.failures[key], uid)
+	if len(e.failures[key]) == 0 {
+		delete(e.failures, key)
+	}
+}
+
+// getCheckpoint returns the checkpoint of the mailbox, a zero checkpoint if there is none or checkpoints are not stored.
+func (e *Email) getCheckpoint(cfg imodels.IMAPConfig) imodels.IMAPCheckpoint {
+	if e.checkpointStore == nil {
+		return imodels.IMAPCheckpoint{}
+	}
+	c, err := e.checkpointStore.GetIMAPCheckpoint(e.Identifier(), cfg.Host, cfg.Username, cfg.Mailbox)
+	if err != nil {
+		e.lo.Error("error fetching IMAP checkpoint", "mailbox", cfg.Mailbox, "inbox_id", e.Identifier(), "error", err)
+	}
+	return c
+}
+
+// setCheckpoint saves the checkpoint of the mailbox.
+func (e *Email) setCheckpoint(cfg imodels.IMAPConfig, c imodels.IMAPCheckpoint) {
+	if e.checkpointStore == nil {
+		return
+	}
+	if err := e.checkpointStore.SetIMAPCheckpoint(e.Identifier(), cfg.Host, cfg.Username, cfg.Mailbox, c); err != nil {
+		e.lo.Error("error saving IMAP checkpoint", "mailbox", cfg.Mailbox, "inbox_id", e.Identifier(), "error", err)
+	}
+}
+
+// newUIDs returns the UIDs after lastUID in ascending order.
+// A search for `lastUID+1:*` matches the last message even if its UID is lower, so results are filtered.
+func newUIDs(uids []imap.UID, lastUID uint32) []imap.UID {
+	out := make([]imap.UID, 0, len(uids))
+	for _, uid := range uids {
+		if uint32(uid) > lastUID {
+			out = append(out, uid)
 		}
+	}
+	slices.Sort(out)
+	return out
+}
 
-		result, err := client.Search(criteria, opts).Wait()
+// searchMessages searches for the UIDs of messages matching the criteria.
+// Uses ESEARCH if supported by the server, otherwise falls back to standard SEARCH.
+func (e *Email) searchMessages(client *imapclient.Client, criteria *imap.SearchCriteria) ([]imap.UID, error) {
+	// Attempt ESEARCH if server supports it
+	if client.Caps().Has(imap.CapESearch) {
+		result, err := client.UIDSearch(criteria, &imap.SearchOptions{ReturnAll: true}).Wait()
 		if err == nil {
-			return result, nil
+			return result.AllUIDs(), nil
 		}
 
 		e.lo.Warn("ESEARCH failed, falling back to standard SEARCH", "error", err, "inbox_id", e.Identifier())
 	}
 
-	return client.Search(criteria, nil).Wait()
+	result, err := client.UIDSearch(criteria, nil).Wait()
+	if err != nil {
+		return nil, err
+	}
+	return result.AllUIDs(), nil
 }
 
-// fetchAndProcessMessages fetches and processes messages based on the search results.
-func (e *Email) fetchAndProcessMessages(ctx context.Context, client *imapclient.Client, searchResults *imap.SearchData, inboxID int) error {
-	seqSet := imap.SeqSet{}
-	if searchResults.Min > 0 && searchResults.Max > 0 {
-		e.lo.Debug("using ESEARCH range", "min", searchResults.Min, "max", searchResults.Max, "inbox_id", inboxID)
-		seqSet.AddRange(searchResults.Min, searchResults.Max)
-	} else if seqNums := searchResults.AllSeqNums(); len(seqNums) > 0 {
-		e.lo.Debug("using SEARCH fallback (no ESEARCH support)", "count", len(seqNums), "inbox_id", inboxID)
-		seqSet.AddNum(seqNums...)
-	} else {
-		// No results found
-		e.lo.Debug("no messages found in search results", "inbox_id", inboxID)
-		return nil
+// fetchAndProcessMessages fetches and processes the messages with the given UIDs in ascending order.
+// It returns the UID up to which all messages were processed, a message that fails to process holds it back so that it is retried.
+// A message that fails `maxMessageAttempts` times is skipped and returned with the skipped UIDs.
+func (e *Email) fetchAndProcessMessages(ctx context.Context, client *imapclient.Client, uids []imap.UID, cfg imodels.IMAPConfig) (imap.UID, map[imap.UID]string, []imap.UID, error) {
+	var (
+		last     imap.UID
+		enqueued = make(map[imap.UID]string)
+		skipped  []imap.UID
+		inboxID  = e.Identifier()
+	)
+	if len(uids) == 0 {
+		e.lo.Debug("no new messages found", "inbox_id", inboxID)
+		return last, enqueued, skipped, nil
 	}
+	e.lo.Debug("fetching new messages", "count", len(uids), "inbox_id", inboxID)
+	uidSet := imap.UIDSetNum(uids...)
 
 	// Fetch envelope and headers needed for auto-reply detection.
 	fetchOptions := &imap.FetchOptions{
+		UID:      true,
 		Envelope: true,
 		BodySection: []*imap.FetchItemBodySection{
 			{
 				Specifier: imap.PartSpecifierHeader,
+				Peek:      true,
 				HeaderFields: []string{
 					headerAutoSubmitted,
 					headerAutoreply,
 					headerLibredeskLoopPrevention,
 					headerMessageID,
+					headerContentType,
 				},
 			},
 		},
@@ -189,30 +508,31 @@ func (e *Email) fetchAndProcessMessages(ctx context.Context, client *imapclient.
 	// Collect messages to process later.
 	type msgData struct {
 		env                *imap.Envelope
-		seqNum             uint32
+		uid                imap.UID
 		autoReply          bool
 		isLoop             bool
+		bounce             bool
 		extractedMessageID string
 	}
 	var messages []msgData
 
-	fetchCmd := client.Fetch(seqSet, fetchOptions)
+	fetchCmd := client.Fetch(uidSet, fetchOptions)
 
 	// Extract the inbox email address.
 	inboxEmail, err := stringutil.ExtractEmail(e.FromAddress())
 	if err != nil {
 		e.lo.Error("failed to extract email address from the 'From' header", "error", err)
-		return fmt.Errorf("failed to extract email address from 'From' header: %w", err)
+		return last, enqueued, skipped, fmt.Errorf("failed to extract email address from 'From' header: %w", err)
 	}
 	if inboxEmail == "" {
 		e.lo.Error("inbox email address is empty, cannot process messages", "inbox_id", e.Identifier())
-		return fmt.Errorf("inbox (%d) email address is empty, cannot process messages", e.Identifier())
+		return last, enqueued, skipped, fmt.Errorf("inbox (%d) email address is empty, cannot process messages", e.Identifier())
 	}
 	for {
 		// Check for context cancellation before fetching the next message.
 		select {
 		case <-ctx.Done():
-			return ctx.Err()
+			return last, enqueued, skipped, ctx.Err()
 		default:
 		}
 
@@ -225,8 +545,10 @@ func (e *Email) fetchAndProcessMessages(ctx context.Context, client *imapclient.
 
 		var (
 			env                *imap.Envelope
+			uid                imap.UID
 			autoReply          bool
 			isLoop             bool
+			contentType        string
 			extractedMessageID string
 		)
 		// Process all fetch items for the current message.
@@ -234,7 +556,7 @@ func (e *Email) fetchAndProcessMessages(ctx context.Context, client *imapclient.
 			// Check for context cancellation before processing the next item.
 			select {
 			case <-ctx.Done():
-				return ctx.Err()
+				return last, enqueued, skipped, ctx.Err()
 			default:
 			}
 
@@ -261,58 +583,146 @@ func (e *Email) fetchAndProcessMessages(ctx context.Context, client *imapclient.
 
 				// Extract Message-Id from raw headers as fallback for problematic Message IDs
 				extractedMessageID = extractMessageIDFromHeaders(envelope)
+				contentType = envelope.GetHeader(headerContentType)
 			}
 
 			// Envelope.
 			if ed, ok := item.(imapclient.FetchItemDataEnvelope); ok {
 				env = ed.Envelope
 			}
+
+			if u, ok := item.(imapclient.FetchItemDataUID); ok {
+				uid = u.UID
+			}
 		}
 
 		// Skip if we couldn't get the envelope.
 		if env == nil {
-			e.lo.Warn("skipping message without envelope", "seq_num", msg.SeqNum, "inbox_id", e.Identifier())
+			e.lo.Warn("skipping message without envelope", "uid", uid, "inbox_id", e.Identifier())
 			continue
 		}
 
-		messages = append(messages, msgData{env: env, seqNum: msg.SeqNum, autoReply: autoReply, isLoop: isLoop, extractedMessageID: extractedMessageID})
+		var from string
+		if len(env.From) > 0 {
+			from = env.From[0].Addr()
+		}
+		bounce := looksLikeBounce(contentType, from)
+
+		messages = append(messages, msgData{env: env, uid: uid, autoReply: autoReply, isLoop: isLoop, bounce: bounce, extractedMessageID: extractedMessageID})
 	}
 
 	// Now process each collected message.
+	slices.SortFunc(messages, func(a, b msgData) int { return cmp.Compare(a.uid, b.uid) })
+	var failed bool
 	for _, msgData := range messages {
 		// Check for context cancellation before processing each message.
 		select {
 		case <-ctx.Done():
-			return ctx.Err()
+			return last, enqueued, skipped, ctx.Err()
 		default:
 		}
 
-		// Skip if this is an auto-reply message.
-		if msgData.autoReply {
-			e.lo.Info("skipping auto-reply message", "subject", msgData.env.Subject, "message_id", msgData.env.MessageID)
-			continue
+		// Bounces of messages sent from this inbox fail the message instead of making a conversation.
+		var bounced bool
+		if msgData.bounce {
+			var err error
+			if bounced, err = e.processBounce(ctx, client, msgData.uid); err != nil {
+				if errors.Is(err, context.Canceled) {
+					return last, enqueued, skipped, err
+				}
+				e.lo.Error("error processing bounce", "uid", msgData.uid, "error", err)
+			}
 		}
 
-		// Skip if this message is a loop prevention message.
-		if msgData.isLoop {
+		switch {
+		case bounced:
+		case msgData.autoReply:
+			// Skip if this is an auto-reply message.
+			e.lo.Info("skipping auto-reply message", "subject", msgData.env.Subject, "message_id", msgData.env.MessageID)
+		case msgData.isLoop:
+			// Skip if this message is a loop prevention message.
 			e.lo.Info("skipping message with loop prevention header", "subject", msgData.env.Subject, "message_id", msgData.env.MessageID)
-			continue
+		default:
+			// Process the envelope.
+			sourceID, err := e.processEnvelope(ctx, client, msgData.env, msgData.uid, inboxID, msgData.extractedMessageID)
+			if err != nil {
+				if errors.Is(err, context.Canceled) {
+					return last, enqueued, skipped, err
+				}
+				if e.recordFailure(cfg, msgData.uid) {
+					e.lo.Error("skipping message that failed to process", "uid", msgData.uid, "attempts", maxMessageAttempts, "mailbox", cfg.Mailbox, "inbox_id", inboxID, "error", err)
+					skipped = append(skipped, msgData.uid)
+				} else {
+					e.lo.Error("error processing envelope", "uid", msgData.uid, "error", err)
+					failed = true
+				}
+				break
+			}
+			e.clearFailures(cfg, msgData.uid)
+			if sourceID != "" {
+				enqueued[msgData.uid] = sourceID
+			}
 		}
-
-		// Process the envelope.
-		if err := e.processEnvelope(ctx, client, msgData.env, msgData.seqNum, inboxID, msgData.extractedMessageID); err != nil && err != context.Canceled {
-			e.lo.Error("error processing envelope", "error", err)
+		if !failed {
+			last = msgData.uid
 		}
 	}
 
-	return nil
+	// Messages that could not be fetched, e.g. deleted in the meantime, don't hold back the checkpoint.
+	if !failed {
+		last = uids[len(uids)-1]
+	}
+	return last, enqueued, skipped, nil
+}
+
+// processEnvelope processes a single email envelope. It returns the Message-ID of the message if it was enqueued.
+func (e *Email) processEnvelope(ctx context.Context, client *imapclient.Client, env *imap.Envelope, uid imap.UID, inboxID int, extractedMessageID string) (string, error) {
+	incomingMsg, ok, err := e.newIncomingMessage(env, inboxID, extractedMessageID)
+	if err != nil || !ok {
+		return "", err
+	}
+
+	// Fetch full message body.
+	fetchOptions := &imap.FetchOptions{
+		// Peek to not mark the message as seen, post-processing takes care of it.
+		BodySection: []*imap.FetchItemBodySection{{Peek: true}},
+	}
+	fullFetchCmd := client.Fetch(imap.UIDSetNum(uid), fetchOptions)
+	fullMsg := fullFetchCmd.Next()
+	if fullMsg == nil {
+		return "", nil
+	}
+
+	// Fetch full message.
+	for {
+		// Check for context cancellation before processing the next item.
+		select {
+		case <-ctx.Done():
+			return "", ctx.Err()
+		default:
+		}
+
+		fullFetchItem := fullMsg.Next()
+		if fullFetchItem == nil {
+			return "", nil
+		}
+
+		if fullItem, ok := fullFetchItem.(imapclient.FetchItemDataBodySection); ok {
+			e.lo.Debug("fetching full message body", "message_id", incomingMsg.SourceID.String)
+			if err := e.processFullMessage(fullItem.Literal, incomingMsg); err != nil {
+				return "", err
+			}
+			return incomingMsg.SourceID.String, nil
+		}
+	}
 }
 
-// processEnvelope processes a single email envelope.
-func (e *Email) processEnvelope(ctx context.Context, client *imapclient.Client, env *imap.Envelope, seqNum uint32, inboxID int, extractedMessageID string) error {
+// newIncomingMessage makes an incoming message with the contact and meta from the envelope.
+// It returns false if the message is to be dropped, i.e. it has no sender or Message-ID, already exists or the sender is blocked.
+func (e *Email) newIncomingMessage(env *imap.Envelope, inboxID int, extractedMessageID string) (models.IncomingMessage, bool, error) {
 	if len(env.From) == 0 {
 		e.lo.Warn("no sender received for email", "message_id", env.MessageID)
-		return nil
+		return models.IncomingMessage{}, false, nil
 	}
 	var fromAddress = strings.ToLower(env.From[0].Addr())
 
@@ -328,26 +738,26 @@ func (e *Email) processEnvelope(ctx context.Context, client *imapclient.Client,
 	// Drop message if we still don't have a valid Message ID
 	if messageID == "" {
 		e.lo.Error("dropping message: no valid Message-ID found in IMAP parsing or raw headers", "subject", env.Subject, "from", fromAddress)
-		return nil
+		return models.IncomingMessage{}, false, nil
 	}
 
 	// Check if the message already exists in the database; if it does, ignore it.
 	exists, err := e.messageStore.MessageExists(messageID)
 	if err != nil {
 		e.lo.Error("error checking if message exists", "message_id", messageID)
-		return fmt.Errorf("checking if message exists in DB: %w", err)
+		return models.IncomingMessage{}, false, fmt.Errorf("checking if message exists in DB: %w", err)
 	}
 	if exists {
-		return nil
+		return models.IncomingMessage{}, false, nil
 	}
 
 	// Check if any contact with this email is blocked, if so, ignore the message.
 	if blocked, err := e.userStore.IsEmailBlocked(fromAddress); err != nil {
 		e.lo.Error("error checking if email is blocked", "email", fromAddress, "error", err)
-		return fmt.Errorf("checking if email is blocked: %w", err)
+		return models.IncomingMessage{}, false, fmt.Errorf("checking if email is blocked: %w", err)
 	} else if blocked {
 		e.lo.Info("contact email is blocked dropping incoming email", "email", fromAddress)
-		return nil
+		return models.IncomingMessage{}, false, nil
 	}
 
 	e.lo.Debug("processing new incoming message", "message_id", messageID, "subject", env.Subject, "from", fromAddress, "inbox_id", inboxID)
@@ -395,7 +805,7 @@ func (e *Email) processEnvelope(ctx context.Context, client *imapclient.Client,
 	})
 	if err != nil {
 		e.lo.Error("error marshalling meta", "error", err)
-		return fmt.Errorf("marshalling meta: %w", err)
+		return models.IncomingMessage{}, false, fmt.Errorf("marshalling meta: %w", err)
 	}
 	incomingMsg := models.IncomingMessage{
 		Channel:  ChannelEmail,
@@ -405,44 +815,12 @@ func (e *Email) processEnvelope(ctx context.Context, client *imapclient.Client,
 		SourceID: null.StringFrom(messageID),
 		Meta:     meta,
 	}
-
-	// Fetch full message body.
-	fetchOptions := &imap.FetchOptions{
-		BodySection: []*imap.FetchItemBodySection{{}},
-	}
-	seqSet := imap.SeqSet{}
-	seqSet.AddNum(seqNum)
-
-	fullFetchCmd := client.Fetch(seqSet, fetchOptions)
-	fullMsg := fullFetchCmd.Next()
-	if fullMsg == nil {
-		return nil
-	}
-
-	// Fetch full message.
-	for {
-		// Check for context cancellation before processing the next item.
-		select {
-		case <-ctx.Done():
-			return ctx.Err()
-		default:
-		}
-
-		fullFetchItem := fullMsg.Next()
-		if fullFetchItem == nil {
-			return nil
-		}
-
-		if fullItem, ok := fullFetchItem.(imapclient.FetchItemDataBodySection); ok {
-			e.lo.Debug("fetching full message body", "message_id", messageID)
-			return e.processFullMessage(fullItem, incomingMsg)
-		}
-	}
+	return incomingMsg, true, nil
 }
 
 // processFullMessage processes the full message and enqueues it for inserting into the database.
-func (e *Email) processFullMessage(item imapclient.FetchItemDataBodySection, incomingMsg models.IncomingMessage) error {
-	envelope, err := enmime.ReadEnvelope(item.Literal)
+func (e *Email) processFullMessage(r io.Reader, incomingMsg models.IncomingMessage) error {
+	envelope, err := enmime.ReadEnvelope(r)
 	if err != nil {
 		e.lo.Error("error parsing email envelope", "error", err, "message_id", incomingMsg.SourceID.String)
 		for _, err := range envelope.Errors {
@@ -1,9 +1,13 @@
 package email
 
 import (
+	"slices"
 	"strings"
 	"testing"
+	"unicode/utf8"
 
+	imodels "github.com/abhinavxd/libredesk/internal/inbox/models"
+	"github.com/emersion/go-imap/v2"
 	"github.com/emersion/go-message/mail"
 	"github.com/jhillyerd/enmime"
 )
@@ -184,3 +188,149 @@ Body`,
 		})
 	}
 }
+
+func TestNewUIDs(t *testing.T) {
+	tests := []struct {
+		name    string
+		uids    []imap.UID
+		lastUID uint32
+		want    []imap.UID
+	}{
+		{name: "no checkpoint", uids: []imap.UID{7, 3, 5}, lastUID: 0, want: []imap.UID{3, 5, 7}},
+		{name: "after checkpoint", uids: []imap.UID{10, 11, 12}, lastUID: 10, want: []imap.UID{11, 12}},
+		// A search for `11:*` returns the last message when there is nothing newer.
+		{name: "nothing new", uids: []imap.UID{10}, lastUID: 10, want: []imap.UID{}},
+		{name: "empty", uids: nil, lastUID: 5, want: []imap.UID{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := newUIDs(tt.uids, tt.lastUID); !slices.Equal(got, tt.want) {
+				t.Errorf("newUIDs() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRecordFailure(t *testing.T) {
+	var (
+		e   = &Email{failures: make(map[string]map[imap.UID]int)}
+		cfg = imodels.IMAPConfig{Host: "imap.example.com", Username: "support", Mailbox: "INBOX"}
+	)
+	for i := 1; i < maxMessageAttempts; i++ {
+		if e.recordFailure(cfg, 7) {
+			t.Fatalf("recordFailure() skipped the message after %d attempts, want %d", i, maxMessageAttempts)
+		}
+	}
+	if !e.recordFailure(cfg, 7) {
+		t.Fatalf("recordFailure() did not skip the message after %d attempts", maxMessageAttempts)
+	}
+	if len(e.failures) != 0 {
+		t.Errorf("failures of a skipped message are kept: %v", e.failures)
+	}
+
+	// A successful attempt resets the count.
+	e.recordFailure(cfg, 8)
+	e.clearFailures(cfg, 8)
+	if len(e.failures) != 0 {
+		t.Errorf("failures of a processed message are kept: %v", e.failures)
+	}
+}
+
+func TestHeaderAddresses(t *testing.T) {
+	rawEmail := "From: \"Jane Doe\" <Jane@Example.com>\nTo: support@example.com, undisclosed-recipients:;\n\nBody"
+	envelope, err := enmime.ReadEnvelope(strings.NewReader(rawEmail))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	from := headerAddresses(envelope, "From")
+	if len(from) != 1 || from[0].Name != "Jane Doe" || from[0].Addr() != "Jane@Example.com" {
+		t.Errorf("headerAddresses(From) = %v", from)
+	}
+	if to := headerAddresses(envelope, "To"); len(to) != 1 || to[0].Addr() != "support@example.com" {
+		t.Errorf("headerAddresses(To) = %v", to)
+	}
+	if cc := headerAddresses(envelope, "Cc"); len(cc) != 0 {
+		t.Errorf("headerAddresses(Cc) = %v, want none", cc)
+	}
+}
+
+func TestParseBounce(t *testing.T) {
+	rawEmail := strings.Join([]string{
+		"From: Mail Delivery System <MAILER-DAEMON@mx.example.org>",
+		"To: support@example.com",
+		"Subject: Undelivered Mail Returned to Sender",
+		"MIME-Version: 1.0",
+		`Content-Type: multipart/report; report-type=delivery-status; boundary="b1"`,
+		"",
+		"--b1",
+		"Content-Type: text/plain",
+		"",
+		"Your message could not be delivered.",
+		"",
+		"--b1",
+		"Content-Type: message/delivery-status",
+		"",
+		"Reporting-MTA: dns; mx.example.org",
+		"",
+		"Final-Recipient: rfc822; jane@example.org",
+		"Action: failed",
+		"Status: 5.1.1",
+		"Diagnostic-Code: smtp; 550 5.1.1 User unknown",
+		"",
+		"--b1",
+		"Content-Type: text/rfc822-headers",
+		"",
+		"From: support@example.com",
+		"Message-ID: <abc.123@example.com>",
+		"",
+		"--b1--",
+		"",
+	}, "\r\n")
+	envelope, err := enmime.ReadEnvelope(strings.NewReader(rawEmail))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !looksLikeBounce(envelope.GetHeader(headerContentType), "MAILER-DAEMON@mx.example.org") {
+		t.Error("looksLikeBounce() = false, want true")
+	}
+	if looksLikeBounce("text/plain", "jane@example.org") {
+		t.Error("looksLikeBounce() = true for a regular message")
+	}
+	if !isDeliveryReport(envelope.GetHeader(headerContentType)) {
+		t.Error("isDeliveryReport() = false, want true")
+	}
+	// A sender that looks like a mailer daemon can be on any domain, it is not trusted to flag contacts.
+	if isDeliveryReport("text/plain") {
+		t.Error("isDeliveryReport() = true for a plain text message")
+	}
+
+	d, ok := parseBounce(envelope)
+	if !ok {
+		t.Fatal("parseBounce() found no original message")
+	}
+	if d.OriginalMessageID != "abc.123@example.com" {
+		t.Errorf("OriginalMessageID = %q", d.OriginalMessageID)
+	}
+	if d.Recipient != "jane@example.org" || d.Status != "5.1.1" {
+		t.Errorf("Recipient = %q, Status = %q", d.Recipient, d.Status)
+	}
+	if !d.failed() || !d.permanent() {
+		t.Errorf("failed() = %v, permanent() = %v, want both true", d.failed(), d.permanent())
+	}
+	if d.reason() != "550 5.1.1 User unknown" {
+		t.Errorf("reason() = %q", d.reason())
+	}
+}
+
+func TestBounceReasonTruncation(t *testing.T) {
+	// A multibyte rune straddles the cut.
+	d := dsn{Diagnostic: strings.Repeat("a", maxBounceReason-1) + "é" + "tail"}
+	reason := d.reason()
+	if !utf8.ValidString(reason) {
+		t.Fatalf("reason() is not valid UTF-8: %q", reason[len(reason)-4:])
+	}
+	if len(reason) != maxBounceReason-1 {
+		t.Errorf("len(reason()) = %d, want %d", len(reason), maxBounceReason-1)
+	}
+}
@@ -0,0 +1,115 @@
+package email
+
+import (
+	"bytes"
+	"fmt"
+	"net/mail"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/abhinavxd/libredesk/internal/stringutil"
+	"github.com/abhinavxd/libredesk/internal/webhook"
+	"github.com/emersion/go-imap/v2"
+	"github.com/jhillyerd/enmime"
+)
+
+const (
+	// InboundTimestampHeader carries the Unix time at which a relayed message was signed.
+	InboundTimestampHeader = "X-Libredesk-Timestamp"
+
+	// Relayed messages signed further than this from now are rejected so that captured requests cannot be replayed.
+	inboundMaxClockSkew = 5 * time.Minute
+)
+
+// InboundEnabled reports whether the inbox accepts raw messages relayed by an MTA.
+func (e *Email) InboundEnabled() bool {
+	return e.inboundEnabled && e.secret != ""
+}
+
+// VerifySignature reports whether signature is a valid signature of the timestamp and payload for this inbox's secret
+// and the timestamp is recent. The signed content is the timestamp, a dot and the payload.
+func (e *Email) VerifySignature(payload []byte, timestamp, signature string) bool {
+	ts, err := strconv.ParseInt(timestamp, 10, 64)
+	if err != nil {
+		return false
+	}
+	if age := time.Since(time.Unix(ts, 0)); age > inboundMaxClockSkew || age < -inboundMaxClockSkew {
+		return false
+	}
+	return webhook.VerifySignature(InboundSignedPayload(timestamp, payload), e.secret, signature)
+}
+
+// InboundSignedPayload returns the content signed for a message relayed at the given Unix timestamp.
+func InboundSignedPayload(timestamp string, payload []byte) []byte {
+	return slices.Concat([]byte(timestamp+"."), payload)
+}
+
+// ProcessRawMessage parses a raw RFC 822 message, e.g. piped by an MTA, and enqueues it
+// the same way as messages read over IMAP.
+func (e *Email) ProcessRawMessage(raw []byte) error {
+	envelope, err := enmime.ReadEnvelope(bytes.NewReader(raw))
+	if err != nil {
+		return fmt.Errorf("parsing email envelope: %w", err)
+	}
+
+	inboxEmail, err := stringutil.ExtractEmail(e.FromAddress())
+	if err != nil || inboxEmail == "" {
+		return fmt.Errorf("inbox (%d) email address is empty, cannot process messages", e.Identifier())
+	}
+
+	var from string
+	if list, _ := envelope.AddressList("From"); len(list) > 0 {
+		from = list[0].Address
+	}
+	if looksLikeBounce(envelope.GetHeader(headerContentType), from) {
+		if bounced, err := e.handleBounce(raw); err != nil {
+			e.lo.Error("error processing bounce", "inbox_id", e.Identifier(), "error", err)
+		} else if bounced {
+			return nil
+		}
+	}
+
+	if isAutoReply(envelope) {
+		e.lo.Info("skipping auto-reply message", "subject", envelope.GetHeader("Subject"), "inbox_id", e.Identifier())
+		return nil
+	}
+	if isLoopMessage(envelope, inboxEmail) {
+		e.lo.Info("skipping message with loop prevention header", "subject", envelope.GetHeader("Subject"), "inbox_id", e.Identifier())
+		return nil
+	}
+
+	env := &imap.Envelope{
+		Subject:   envelope.GetHeader("Subject"),
+		From:      headerAddresses(envelope, "From"),
+		To:        headerAddresses(envelope, "To"),
+		Cc:        headerAddresses(envelope, "Cc"),
+		Bcc:       headerAddresses(envelope, "Bcc"),
+		MessageID: extractMessageIDFromHeaders(envelope),
+	}
+	incomingMsg, ok, err := e.newIncomingMessage(env, e.Identifier(), "")
+	if err != nil || !ok {
+		return err
+	}
+	return e.processFullMessage(bytes.NewReader(raw), incomingMsg)
+}
+
+// headerAddresses returns the addresses in the header as IMAP addresses, skipping ones that can't be parsed.
+func headerAddresses(envelope *enmime.Envelope, header string) []imap.Address {
+	list, _ := envelope.AddressList(header)
+	return toIMAPAddresses(list)
+}
+
+// toIMAPAddresses converts mail addresses to IMAP addresses.
+func toIMAPAddresses(list []*mail.Address) []imap.Address {
+	var out []imap.Address
+	for _, a := range list {
+		mailbox, host, ok := strings.Cut(a.Address, "@")
+		if !ok {
+			continue
+		}
+		out = append(out, imap.Address{Name: a.Name, Mailbox: mailbox, Host: host})
+	}
+	return out
+}
@@ -25,6 +25,7 @@ const (
 
 	HomeAppAnnouncement  = "announcement"
 	HomeAppExternalLink  = "external_link"
+	HomeAppKBSearch      = "kb_search"
 )
 
 type PreChatFormField struct {
@@ -105,6 +106,7 @@ type Config struct {
 		ImageURL    string `json:"image_url,omitempty"`
 		URL         string `json:"url"`
 		Text        string `json:"text,omitempty"`
+		Locale      string `json:"locale,omitempty"`
 	} `json:"home_apps"`
 	TrustedDomains                 []string         `json:"trusted_domains"`
 	BlockedIPs                     []string         `json:"blocked_ips"`
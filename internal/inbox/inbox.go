@@ -28,6 +28,7 @@ import (
 const (
 	ChannelEmail    = "email"
 	ChannelLiveChat = "livechat"
+	ChannelAPI      = "api"
 )
 
 var (
@@ -70,6 +71,7 @@ type Inbox interface {
 type MessageStore interface {
 	MessageExists(string) (bool, error)
 	EnqueueIncoming(models.IncomingMessage) error
+	HandleEmailBounce(models.EmailBounce) (bool, error)
 }
 
 // UserStore defines methods for fetching user information.
@@ -114,6 +116,9 @@ type queries struct {
 	SoftDelete     *sqlx.Stmt `query:"soft-delete"`
 	InsertInbox    *sqlx.Stmt `query:"insert-inbox"`
 	UpdateConfig   *sqlx.Stmt `query:"update-config"`
+
+	GetIMAPCheckpoint    *sqlx.Stmt `query:"get-imap-checkpoint"`
+	UpsertIMAPCheckpoint *sqlx.Stmt `query:"upsert-imap-checkpoint"`
 }
 
 // New returns a new inbox manager.
@@ -229,8 +234,8 @@ func (m *Manager) GetAll() ([]imodels.Inbox, error) {
 
 // Create creates an inbox in the DB.
 func (m *Manager) Create(inbox imodels.Inbox) (imodels.Inbox, error) {
-	// Generate and encrypt secret for livechat inboxes if not provided
-	if inbox.Channel == ChannelLiveChat && !inbox.Secret.Valid {
+	// Generate and encrypt secret for livechat and API inboxes if not provided
+	if (inbox.Channel == ChannelLiveChat || inbox.Channel == ChannelAPI) && !inbox.Secret.Valid {
 		secret, err := stringutil.RandomAlphanumeric(32)
 		if err != nil {
 			return imodels.Inbox{}, fmt.Errorf("generating inbox secret: %w", err)
@@ -240,6 +245,11 @@ func (m *Manager) Create(inbox imodels.Inbox) (imodels.Inbox, error) {
 			return imodels.Inbox{}, fmt.Errorf("encrypting inbox secret: %w", err)
 		}
 		inbox.Secret = null.StringFrom(encryptedSecret)
+	} else if inbox.Channel == ChannelEmail {
+		// Email inboxes only have a secret if one is set for relayed messages.
+		if err := m.encryptSecret(&inbox, imodels.Inbox{}); err != nil {
+			return imodels.Inbox{}, err
+		}
 	}
 
 	// Encrypt sensitive fields before saving
@@ -340,6 +350,9 @@ func (m *Manager) Update(id int, inbox imodels.Inbox) (imodels.Inbox, error) {
 			IMAP                 []map[string]any  `json:"imap"`
 			SMTP                 []map[string]any  `json:"smtp"`
 			EnablePlusAddressing bool              `json:"enable_plus_addressing"`
+			InboundEnabled       bool              `json:"inbound_enabled"`
+			FlagBouncedContacts  bool              `json:"flag_bounced_contacts"`
+			Signature            string            `json:"signature"`
 		}
 		var updateCfg struct {
 			AuthType             string            `json:"auth_type"`
@@ -347,6 +360,9 @@ func (m *Manager) Update(id int, inbox imodels.Inbox) (imodels.Inbox, error) {
 			IMAP                 []map[string]any  `json:"imap"`
 			SMTP                 []map[string]any  `json:"smtp"`
 			EnablePlusAddressing bool              `json:"enable_plus_addressing"`
+			InboundEnabled       bool              `json:"inbound_enabled"`
+			FlagBouncedContacts  bool              `json:"flag_bounced_contacts"`
+			Signature            string            `json:"signature"`
 		}
 
 		if err := json.Unmarshal(current.Config, &currentCfg); err != nil {
@@ -361,7 +377,8 @@ func (m *Manager) Update(id int, inbox imodels.Inbox) (imodels.Inbox, error) {
 			return imodels.Inbox{}, envelope.NewError(envelope.GeneralError, m.i18n.T("globals.messages.somethingWentWrong"), nil)
 		}
 
-		if len(updateCfg.IMAP) == 0 {
+		// Relayed inboxes can go without IMAP.
+		if len(updateCfg.IMAP) == 0 && !updateCfg.InboundEnabled {
 			return imodels.Inbox{}, envelope.NewError(envelope.InputError, m.i18n.T("inbox.emptyIMAP"), nil)
 		}
 
@@ -401,17 +418,13 @@ func (m *Manager) Update(id int, inbox imodels.Inbox) (imodels.Inbox, error) {
 			return imodels.Inbox{}, err
 		}
 		inbox.Config = updatedConfig
-	case "livechat":
-		// Preserve existing secret if update contains password dummy
-		if inbox.Secret.Valid && strings.Contains(inbox.Secret.String, stringutil.PasswordDummy) {
-			inbox.Secret = current.Secret
-		} else if inbox.Secret.Valid && inbox.Secret.String != "" {
-			// Encrypt new secret
-			encryptedSecret, err := crypto.Encrypt(inbox.Secret.String, m.encryptionKey)
-			if err != nil {
-				return imodels.Inbox{}, fmt.Errorf("encrypting inbox secret: %w", err)
-			}
-			inbox.Secret = null.StringFrom(encryptedSecret)
+
+		if err := m.encryptSecret(&inbox, current); err != nil {
+			return imodels.Inbox{}, err
+		}
+	case "livechat", "api":
+		if err := m.encryptSecret(&inbox, current); err != nil {
+			return imodels.Inbox{}, err
 		}
 	}
 
@@ -462,6 +475,25 @@ func (m *Manager) SoftDelete(id int) error {
 	return nil
 }
 
+// GetIMAPCheckpoint returns the last processed UID of an IMAP mailbox, a zero checkpoint if there is none.
+func (m *Manager) GetIMAPCheckpoint(inboxID int, host, username, mailbox string) (imodels.IMAPCheckpoint, error) {
+	var c imodels.IMAPCheckpoint
+	if err := m.queries.GetIMAPCheckpoint.Get(&c, inboxID, host, username, mailbox); err != nil && !errors.Is(err, sql.ErrNoRows) {
+		m.lo.Error("error fetching IMAP checkpoint", "inbox_id", inboxID, "mailbox", mailbox, "error", err)
+		return c, fmt.Errorf("fetching IMAP checkpoint: %w", err)
+	}
+	return c, nil
+}
+
+// SetIMAPCheckpoint saves the last processed UID of an IMAP mailbox.
+func (m *Manager) SetIMAPCheckpoint(inboxID int, host, username, mailbox string, c imodels.IMAPCheckpoint) error {
+	if _, err := m.queries.UpsertIMAPCheckpoint.Exec(inboxID, host, username, mailbox, c.UIDValidity, c.LastUID); err != nil {
+		m.lo.Error("error saving IMAP checkpoint", "inbox_id", inboxID, "mailbox", mailbox, "error", err)
+		return fmt.Errorf("saving IMAP checkpoint: %w", err)
+	}
+	return nil
+}
+
 // UpdateConfig updates only the config field of an inbox in the DB.
 func (m *Manager) UpdateConfig(id int, config json.RawMessage) error {
 	// Encrypt fields before updating
@@ -698,6 +730,23 @@ func (m *Manager) decryptInboxConfig(config json.RawMessage) (json.RawMessage, e
 	return decrypted, nil
 }
 
+// encryptSecret encrypts the inbox secret, keeping the current one if the update contains the password dummy.
+func (m *Manager) encryptSecret(inbox *imodels.Inbox, current imodels.Inbox) error {
+	// Preserve existing secret if update contains password dummy
+	if inbox.Secret.Valid && strings.Contains(inbox.Secret.String, stringutil.PasswordDummy) {
+		inbox.Secret = current.Secret
+	}
+	if inbox.Secret.Valid && inbox.Secret.String != "" {
+		// Encrypt the secret, the current one is decrypted when read and already encrypted values are left as is.
+		encryptedSecret, err := crypto.Encrypt(inbox.Secret.String, m.encryptionKey)
+		if err != nil {
+			return fmt.Errorf("encrypting inbox secret: %w", err)
+		}
+		inbox.Secret = null.StringFrom(encryptedSecret)
+	}
+	return nil
+}
+
 // decryptInboxSecret decrypts the inbox secret field if present.
 func (m *Manager) decryptInboxSecret(inbox *imodels.Inbox) {
 	if inbox.Secret.Valid && inbox.Secret.String != "" {
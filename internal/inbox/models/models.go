@@ -4,6 +4,7 @@ import (
 	"crypto/tls"
 	"encoding/json"
 	"net/smtp"
+	"slices"
 	"strings"
 	"time"
 
@@ -17,6 +18,14 @@ const (
 	AuthTypeOAuth2   = "oauth2"
 )
 
+// IMAP post-processing actions applied to messages once they are ingested.
+const (
+	PostProcessNone   = "none"
+	PostProcessSeen   = "seen"
+	PostProcessMove   = "move"
+	PostProcessDelete = "delete"
+)
+
 // Inbox represents a inbox record in DB.
 type Inbox struct {
 	ID                 int             `db:"id" json:"id"`
@@ -41,6 +50,9 @@ type Config struct {
 	IMAP                 []IMAPConfig `json:"imap"`
 	From                 string       `json:"from"`
 	EnablePlusAddressing bool         `json:"enable_plus_addressing"` // Enable plus-addressing in Reply-To header for conversation matching
+	InboundEnabled       bool         `json:"inbound_enabled"`        // Accept raw messages relayed by an MTA at the inbound email endpoint
+	FlagBouncedContacts  bool         `json:"flag_bounced_contacts"`  // Flag contact addresses that hard bounce as undeliverable
+	Signature            string       `json:"signature"`              // Default signature of replies, agent signatures take precedence
 }
 
 // OAuthConfig holds OAuth 2.0 authentication details.
@@ -79,15 +91,45 @@ type SMTPConfig struct {
 
 // IMAPConfig holds IMAP client credentials and configuration.
 type IMAPConfig struct {
-	Host           string `json:"host"`
-	Port           int    `json:"port"`
-	Username       string `json:"username"`
-	Password       string `json:"password"`
-	Mailbox        string `json:"mailbox"`
-	ReadInterval   string `json:"read_interval"`
-	ScanInboxSince string `json:"scan_inbox_since"`
-	TLSType        string `json:"tls_type"`
-	TLSSkipVerify  bool   `json:"tls_skip_verify"`
+	Host     string `json:"host"`
+	Port     int    `json:"port"`
+	Username string `json:"username"`
+	Password string `json:"password"`
+	Mailbox  string `json:"mailbox"`
+	// Mailboxes are read in addition to Mailbox.
+	Mailboxes      []string `json:"mailboxes"`
+	ReadInterval   string   `json:"read_interval"`
+	ScanInboxSince string   `json:"scan_inbox_since"`
+	TLSType        string   `json:"tls_type"`
+	TLSSkipVerify  bool     `json:"tls_skip_verify"`
+	// PostProcess is one of the PostProcess* actions, MoveToMailbox is the destination of PostProcessMove.
+	PostProcess   string `json:"post_process"`
+	MoveToMailbox string `json:"move_to_mailbox"`
+}
+
+// AllMailboxes returns Mailbox followed by the additional mailboxes, without blanks and duplicates.
+func (c IMAPConfig) AllMailboxes() []string {
+	var out []string
+	for _, m := range append([]string{c.Mailbox}, c.Mailboxes...) {
+		if m != "" && !slices.Contains(out, m) {
+			out = append(out, m)
+		}
+	}
+	return out
+}
+
+// PostProcessAction returns the post-processing action, PostProcessNone when unset.
+func (c IMAPConfig) PostProcessAction() string {
+	if c.PostProcess == "" {
+		return PostProcessNone
+	}
+	return c.PostProcess
+}
+
+// IMAPCheckpoint is the last processed UID of an IMAP mailbox, valid while the mailbox UIDVALIDITY is unchanged.
+type IMAPCheckpoint struct {
+	UIDValidity uint32 `db:"uid_validity"`
+	LastUID     uint32 `db:"last_uid"`
 }
 
 // ClearPasswords masks all config passwords
@@ -132,8 +174,13 @@ func (m *Inbox) ClearPasswords() error {
 		}
 
 		m.Config = clearedConfig
-	case "livechat":
-		// Mask the secret field for livechat
+
+		// Mask the secret used to sign relayed messages.
+		if m.Secret.Valid && m.Secret.String != "" {
+			m.Secret = null.StringFrom(dummyPassword)
+		}
+	case "livechat", "api":
+		// Mask the secret field for livechat and API inboxes
 		if m.Secret.Valid && m.Secret.String != "" {
 			m.Secret = null.StringFrom(strings.Repeat(stringutil.PasswordDummy, 10))
 		}
@@ -34,4 +34,13 @@ RETURNING *;
 -- name: update-config
 UPDATE inboxes
 SET config = $2, updated_at = NOW()
-WHERE id = $1 AND deleted_at IS NULL;
\ No newline at end of file
+WHERE id = $1 AND deleted_at IS NULL;
+
+-- name: get-imap-checkpoint
+SELECT uid_validity, last_uid FROM imap_checkpoints WHERE inbox_id = $1 AND host = $2 AND username = $3 AND mailbox = $4;
+
+-- name: upsert-imap-checkpoint
+INSERT INTO imap_checkpoints (inbox_id, host, username, mailbox, uid_validity, last_uid)
+VALUES ($1, $2, $3, $4, $5, $6)
+ON CONFLICT (inbox_id, host, username, mailbox)
+DO UPDATE SET uid_validity = EXCLUDED.uid_validity, last_uid = EXCLUDED.last_uid, updated_at = NOW();
@@ -0,0 +1,334 @@
+// Package knowledgebase manages help center articles organised in categories.
+package knowledgebase
+
+import (
+	"database/sql"
+	"embed"
+	"regexp"
+	"strings"
+
+	"github.com/abhinavxd/libredesk/internal/dbutil"
+	"github.com/abhinavxd/libredesk/internal/envelope"
+	"github.com/abhinavxd/libredesk/internal/knowledgebase/models"
+	"github.com/abhinavxd/libredesk/internal/stringutil"
+	"github.com/jmoiron/sqlx"
+	"github.com/knadh/go-i18n"
+	"github.com/volatiletech/null/v9"
+	"github.com/zerodha/logf"
+)
+
+var (
+	//go:embed queries.sql
+	efs embed.FS
+
+	slugRegexp   = regexp.MustCompile(`[^\p{L}\p{N}]+`)
+	localeRegexp = regexp.MustCompile(`^[a-z]{2,3}(-[A-Za-z0-9]{2,4})?$`)
+)
+
+const (
+	// Maximum length of a search query.
+	maxQueryLength = 500
+
+	// Maximum number of search results.
+	maxSearchResults = 50
+)
+
+// Manager manages knowledge base categories and articles.
+type Manager struct {
+	q    queries
+	lo   *logf.Logger
+	i18n *i18n.I18n
+}
+
+// Opts contains options for initializing the Manager.
+type Opts struct {
+	DB   *sqlx.DB
+	Lo   *logf.Logger
+	I18n *i18n.I18n
+}
+
+// queries contains prepared SQL queries.
+type queries struct {
+	GetCategories          *sqlx.Stmt `query:"get-categories"`
+	GetCategory            *sqlx.Stmt `query:"get-category"`
+	InsertCategory         *sqlx.Stmt `query:"insert-category"`
+	UpdateCategory         *sqlx.Stmt `query:"update-category"`
+	DeleteCategory         *sqlx.Stmt `query:"delete-category"`
+	GetArticles            *sqlx.Stmt `query:"get-articles"`
+	GetArticle             *sqlx.Stmt `query:"get-article"`
+	GetArticleTranslations *sqlx.Stmt `query:"get-article-translations"`
+	InsertArticle          *sqlx.Stmt `query:"insert-article"`
+	UpdateArticle          *sqlx.Stmt `query:"update-article"`
+	DeleteArticle          *sqlx.Stmt `query:"delete-article"`
+	SearchArticles         *sqlx.Stmt `query:"search-articles"`
+}
+
+// New creates and returns a new instance of the Manager.
+func New(opts Opts) (*Manager, error) {
+	var q queries
+	if err := dbutil.ScanSQLFile("queries.sql", &q, opts.DB, efs); err != nil {
+		return nil, err
+	}
+	return &Manager{
+		q:    q,
+		lo:   opts.Lo,
+		i18n: opts.I18n,
+	}, nil
+}
+
+// GetCategories returns all categories.
+func (m *Manager) GetCategories() ([]models.Category, error) {
+	var categories = make([]models.Category, 0)
+	if err := m.q.GetCategories.Select(&categories); err != nil {
+		m.lo.Error("error fetching knowledge base categories", "error", err)
+		return nil, envelope.NewError(envelope.GeneralError, m.i18n.T("globals.messages.somethingWentWrong"), nil)
+	}
+	return categories, nil
+}
+
+// GetCategory returns a category by ID.
+func (m *Manager) GetCategory(id int) (models.Category, error) {
+	var category models.Category
+	if err := m.q.GetCategory.Get(&category, id); err != nil {
+		if err == sql.ErrNoRows {
+			return category, envelope.NewError(envelope.NotFoundError, m.i18n.T("globals.messages.notFound"), nil)
+		}
+		m.lo.Error("error fetching knowledge base category", "id", id, "error", err)
+		return category, envelope.NewError(envelope.GeneralError, m.i18n.T("globals.messages.somethingWentWrong"), nil)
+	}
+	return category, nil
+}
+
+// CreateCategory creates a new category.
+func (m *Manager) CreateCategory(c models.Category) (models.Category, error) {
+	var out models.Category
+	if err := m.validateCategory(&c); err != nil {
+		return out, err
+	}
+	if err := m.q.InsertCategory.Get(&out, c.Name, c.Description, c.Position); err != nil {
+		if dbutil.IsUniqueViolationError(err) {
+			return out, envelope.NewError(envelope.ConflictError, m.i18n.T("globals.messages.errorAlreadyExists"), nil)
+		}
+		m.lo.Error("error inserting knowledge base category", "error", err)
+		return out, envelope.NewError(envelope.GeneralError, m.i18n.T("globals.messages.somethingWentWrong"), nil)
+	}
+	return out, nil
+}
+
+// UpdateCategory updates a category.
+func (m *Manager) UpdateCategory(id int, c models.Category) (models.Category, error) {
+	var out models.Category
+	if err := m.validateCategory(&c); err != nil {
+		return out, err
+	}
+	if err := m.q.UpdateCategory.Get(&out, id, c.Name, c.Description, c.Position); err != nil {
+		if err == sql.ErrNoRows {
+			return out, envelope.NewError(envelope.NotFoundError, m.i18n.T("globals.messages.notFound"), nil)
+		}
+		if dbutil.IsUniqueViolationError(err) {
+			return out, envelope.NewError(envelope.ConflictError, m.i18n.T("globals.messages.errorAlreadyExists"), nil)
+		}
+		m.lo.Error("error updating knowledge base category", "id", id, "error", err)
+		return out, envelope.NewError(envelope.GeneralError, m.i18n.T("globals.messages.somethingWentWrong"), nil)
+	}
+	return out, nil
+}
+
+// DeleteCategory deletes a category, its articles are kept without a category.
+func (m *Manager) DeleteCategory(id int) error {
+	if _, err := m.q.DeleteCategory.Exec(id); err != nil {
+		m.lo.Error("error deleting knowledge base category", "id", id, "error", err)
+		return envelope.NewError(envelope.GeneralError, m.i18n.T("globals.messages.somethingWentWrong"), nil)
+	}
+	return nil
+}
+
+// GetArticles returns a page of articles without their content, optionally filtered by category, status and locale.
+func (m *Manager) GetArticles(categoryID int, status, locale string, page, pageSize int) ([]models.Article, error) {
+	var articles = make([]models.Article, 0)
+	if page < 1 {
+		page = 1
+	}
+	if err := m.q.GetArticles.Select(&articles, categoryID, status, locale, pageSize, (page-1)*pageSize); err != nil {
+		m.lo.Error("error fetching knowledge base articles", "error", err)
+		return nil, envelope.NewError(envelope.GeneralError, m.i18n.T("globals.messages.somethingWentWrong"), nil)
+	}
+	return articles, nil
+}
+
+// GetArticle returns an article by ID.
+func (m *Manager) GetArticle(id int) (models.Article, error) {
+	return m.getArticle(id, "")
+}
+
+// GetPublishedArticle returns a published article by UUID.
+func (m *Manager) GetPublishedArticle(uuid string) (models.Article, error) {
+	article, err := m.getArticle(0, uuid)
+	if err != nil {
+		return article, err
+	}
+	if article.Status != models.StatusPublished {
+		return models.Article{}, envelope.NewError(envelope.NotFoundError, m.i18n.T("globals.messages.notFound"), nil)
+	}
+	return article, nil
+}
+
+// GetTranslations returns the published translations of an article.
+func (m *Manager) GetTranslations(slug string) ([]models.Translation, error) {
+	var out = make([]models.Translation, 0)
+	if err := m.q.GetArticleTranslations.Select(&out, slug); err != nil {
+		m.lo.Error("error fetching knowledge base article translations", "slug", slug, "error", err)
+		return nil, envelope.NewError(envelope.GeneralError, m.i18n.T("globals.messages.somethingWentWrong"), nil)
+	}
+	return out, nil
+}
+
+// CreateArticle creates a new article.
+func (m *Manager) CreateArticle(a models.Article) (models.Article, error) {
+	if err := m.validateArticle(&a); err != nil {
+		return models.Article{}, err
+	}
+	var id int
+	if err := m.q.InsertArticle.Get(&id, a.CategoryID, a.AuthorID, a.Slug, a.Locale, a.Title, a.Content, stringutil.HTML2Text(a.Content), a.Status); err != nil {
+		return models.Article{}, m.articleWriteError(err)
+	}
+	return m.GetArticle(id)
+}
+
+// UpdateArticle updates an article.
+func (m *Manager) UpdateArticle(id int, a models.Article) (models.Article, error) {
+	if err := m.validateArticle(&a); err != nil {
+		return models.Article{}, err
+	}
+	res, err := m.q.UpdateArticle.Exec(id, a.CategoryID, a.Slug, a.Locale, a.Title, a.Content, stringutil.HTML2Text(a.Content), a.Status)
+	if err != nil {
+		return models.Article{}, m.articleWriteError(err)
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return models.Article{}, envelope.NewError(envelope.NotFoundError, m.i18n.T("globals.messages.notFound"), nil)
+	}
+	return m.GetArticle(id)
+}
+
+// DeleteArticle deletes an article.
+func (m *Manager) DeleteArticle(id int) error {
+	if _, err := m.q.DeleteArticle.Exec(id); err != nil {
+		m.lo.Error("error deleting knowledge base article", "id", id, "error", err)
+		return envelope.NewError(envelope.GeneralError, m.i18n.T("globals.messages.somethingWentWrong"), nil)
+	}
+	return nil
+}
+
+// Search runs a full-text search over articles ranked by relevance. If matchAny is set, articles matching
+// any of the words in the query are returned instead of articles matching all of them.
+func (m *Manager) Search(query, locale string, publishedOnly, matchAny bool, limit int) ([]models.SearchResult, error) {
+	var results = make([]models.SearchResult, 0)
+	query = strings.TrimSpace(query)
+	if query == "" {
+		return results, nil
+	}
+	if r := []rune(query); len(r) > maxQueryLength {
+		query = string(r[:maxQueryLength])
+	}
+	if limit <= 0 || limit > maxSearchResults {
+		limit = maxSearchResults
+	}
+	if err := m.q.SearchArticles.Select(&results, query, locale, matchAny, publishedOnly, limit); err != nil {
+		m.lo.Error("error searching knowledge base articles", "error", err)
+		return nil, envelope.NewError(envelope.GeneralError, m.i18n.T("globals.messages.somethingWentWrong"), nil)
+	}
+	return results, nil
+}
+
+// ArticleURL returns the public URL of an article.
+func ArticleURL(rootURL, uuid string) string {
+	return strings.TrimRight(rootURL, "/") + "/kb/" + uuid
+}
+
+// getArticle returns an article by ID or UUID.
+func (m *Manager) getArticle(id int, uuid string) (models.Article, error) {
+	var article models.Article
+	if err := m.q.GetArticle.Get(&article, id, uuid); err != nil {
+		if err == sql.ErrNoRows {
+			return article, envelope.NewError(envelope.NotFoundError, m.i18n.T("globals.messages.notFound"), nil)
+		}
+		m.lo.Error("error fetching knowledge base article", "id", id, "uuid", uuid, "error", err)
+		return article, envelope.NewError(envelope.GeneralError, m.i18n.T("globals.messages.somethingWentWrong"), nil)
+	}
+	return article, nil
+}
+
+// validateCategory validates and normalises a category.
+func (m *Manager) validateCategory(c *models.Category) error {
+	c.Name = strings.TrimSpace(c.Name)
+	c.Description = strings.TrimSpace(c.Description)
+	if c.Name == "" {
+		return envelope.NewError(envelope.InputError, m.i18n.Ts("globals.messages.empty", "name", "`name`"), nil)
+	}
+	if len(c.Name) > 140 || len(c.Description) > 1000 {
+		return envelope.NewError(envelope.InputError, m.i18n.T("validation.invalidValue"), nil)
+	}
+	return nil
+}
+
+// validateArticle validates and normalises an article.
+func (m *Manager) validateArticle(a *models.Article) error {
+	a.Title = strings.TrimSpace(a.Title)
+	if a.Title == "" {
+		return envelope.NewError(envelope.InputError, m.i18n.Ts("globals.messages.empty", "name", "`title`"), nil)
+	}
+	if len(a.Title) > 300 {
+		return envelope.NewError(envelope.InputError, m.i18n.T("validation.invalidValue"), nil)
+	}
+
+	// Articles are rendered on public pages.
+	a.Content = stringutil.SanitizeHTML(a.Content)
+
+	a.Slug = makeSlug(a.Slug)
+	if a.Slug == "" {
+		a.Slug = makeSlug(a.Title)
+	}
+	if a.Slug == "" || len(a.Slug) > 200 {
+		return envelope.NewError(envelope.InputError, m.i18n.Ts("globals.messages.empty", "name", "`slug`"), nil)
+	}
+
+	a.Locale = strings.TrimSpace(a.Locale)
+	if a.Locale == "" {
+		a.Locale = models.DefaultLocale
+	}
+	if !localeRegexp.MatchString(a.Locale) {
+		return envelope.NewError(envelope.InputError, m.i18n.T("validation.invalidValue"), nil)
+	}
+
+	switch a.Status {
+	case "":
+		a.Status = models.StatusDraft
+	case models.StatusDraft, models.StatusPublished:
+	default:
+		return envelope.NewError(envelope.InputError, m.i18n.T("validation.invalidValue"), nil)
+	}
+
+	if a.CategoryID.Valid && a.CategoryID.Int <= 0 {
+		a.CategoryID = null.Int{}
+	}
+	if a.CategoryID.Valid {
+		if _, err := m.GetCategory(a.CategoryID.Int); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// articleWriteError converts an error from inserting or updating an article to an envelope error.
+func (m *Manager) articleWriteError(err error) error {
+	if dbutil.IsUniqueViolationError(err) {
+		return envelope.NewError(envelope.ConflictError, m.i18n.T("globals.messages.errorAlreadyExists"), nil)
+	}
+	m.lo.Error("error saving knowledge base article", "error", err)
+	return envelope.NewError(envelope.GeneralError, m.i18n.T("globals.messages.somethingWentWrong"), nil)
+}
+
+// makeSlug returns a URL friendly slug for s.
+func makeSlug(s string) string {
+	return strings.Trim(slugRegexp.ReplaceAllString(strings.ToLower(strings.TrimSpace(s)), "-"), "-")
+}
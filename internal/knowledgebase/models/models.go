@@ -0,0 +1,65 @@
+package models
+
+import (
+	"time"
+
+	"github.com/volatiletech/null/v9"
+)
+
+const (
+	StatusDraft     = "draft"
+	StatusPublished = "published"
+
+	DefaultLocale = "en"
+)
+
+// Category groups knowledge base articles.
+type Category struct {
+	ID           int       `db:"id" json:"id"`
+	CreatedAt    time.Time `db:"created_at" json:"created_at"`
+	UpdatedAt    time.Time `db:"updated_at" json:"updated_at"`
+	Name         string    `db:"name" json:"name"`
+	Description  string    `db:"description" json:"description"`
+	Position     int       `db:"position" json:"position"`
+	ArticleCount int       `db:"article_count" json:"article_count"`
+}
+
+// Article is a knowledge base article in a single locale.
+type Article struct {
+	Total        int         `db:"total" json:"-"`
+	ID           int         `db:"id" json:"id"`
+	CreatedAt    time.Time   `db:"created_at" json:"created_at"`
+	UpdatedAt    time.Time   `db:"updated_at" json:"updated_at"`
+	UUID         string      `db:"uuid" json:"uuid"`
+	CategoryID   null.Int    `db:"category_id" json:"category_id"`
+	CategoryName null.String `db:"category_name" json:"category_name"`
+	AuthorID     null.Int    `db:"author_id" json:"author_id"`
+	Slug         string      `db:"slug" json:"slug"`
+	Locale       string      `db:"locale" json:"locale"`
+	Title        string      `db:"title" json:"title"`
+	Content      string      `db:"content" json:"content,omitempty"`
+	Status       string      `db:"status" json:"status"`
+	PublishedAt  null.Time   `db:"published_at" json:"published_at"`
+}
+
+// Translation is a published translation of an article.
+type Translation struct {
+	UUID   string `db:"uuid" json:"uuid"`
+	Locale string `db:"locale" json:"locale"`
+	Title  string `db:"title" json:"title"`
+}
+
+// SearchResult is an article matching a search query.
+type SearchResult struct {
+	ID           int         `db:"id" json:"id"`
+	UUID         string      `db:"uuid" json:"uuid"`
+	Slug         string      `db:"slug" json:"slug"`
+	Locale       string      `db:"locale" json:"locale"`
+	Title        string      `db:"title" json:"title"`
+	Status       string      `db:"status" json:"status"`
+	CategoryName null.String `db:"category_name" json:"category_name"`
+	Rank         float64     `db:"rank" json:"rank"`
+	Snippet      string      `db:"snippet" json:"snippet"`
+	TextContent  string      `db:"text_content" json:"-"`
+	URL          string      `db:"-" json:"url"`
+}
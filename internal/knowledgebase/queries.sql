@@ -0,0 +1,104 @@
+-- name: get-categories
+SELECT c.id, c.created_at, c.updated_at, c.name, c.description, c.position,
+    (SELECT COUNT(*) FROM kb_articles a WHERE a.category_id = c.id) AS article_count
+FROM kb_categories c
+ORDER BY c.position, c.name;
+
+-- name: get-category
+SELECT c.id, c.created_at, c.updated_at, c.name, c.description, c.position,
+    (SELECT COUNT(*) FROM kb_articles a WHERE a.category_id = c.id) AS article_count
+FROM kb_categories c
+WHERE c.id = $1;
+
+-- name: insert-category
+INSERT INTO kb_categories (name, description, position)
+VALUES ($1, $2, $3)
+RETURNING id, created_at, updated_at, name, description, position, 0 AS article_count;
+
+-- name: update-category
+UPDATE kb_categories
+SET name = $2,
+    description = $3,
+    position = $4,
+    updated_at = NOW()
+WHERE id = $1
+RETURNING id, created_at, updated_at, name, description, position,
+    (SELECT COUNT(*) FROM kb_articles a WHERE a.category_id = kb_categories.id) AS article_count;
+
+-- name: delete-category
+DELETE FROM kb_categories WHERE id = $1;
+
+-- name: get-articles
+SELECT COUNT(*) OVER() AS total,
+    a.id, a.created_at, a.updated_at, a.uuid, a.category_id, c.name AS category_name, a.author_id,
+    a.slug, a.locale, a.title, a.status, a.published_at
+FROM kb_articles a
+LEFT JOIN kb_categories c ON c.id = a.category_id
+WHERE ($1 = 0 OR a.category_id = $1)
+AND ($2 = '' OR a.status::TEXT = $2)
+AND ($3 = '' OR a.locale = $3)
+ORDER BY a.updated_at DESC
+LIMIT $4 OFFSET $5;
+
+-- name: get-article
+SELECT 0 AS total,
+    a.id, a.created_at, a.updated_at, a.uuid, a.category_id, c.name AS category_name, a.author_id,
+    a.slug, a.locale, a.title, a.content, a.status, a.published_at
+FROM kb_articles a
+LEFT JOIN kb_categories c ON c.id = a.category_id
+WHERE ($1 > 0 AND a.id = $1) OR ($2 != '' AND a.uuid::TEXT = $2);
+
+-- name: get-article-translations
+SELECT a.uuid, a.locale, a.title
+FROM kb_articles a
+WHERE a.slug = $1 AND a.status = 'published'
+ORDER BY a.locale;
+
+-- name: insert-article
+INSERT INTO kb_articles (category_id, author_id, slug, locale, title, content, text_content, status, published_at)
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8, CASE WHEN $8 = 'published' THEN NOW() END)
+RETURNING id;
+
+-- name: update-article
+UPDATE kb_articles
+SET category_id = $2,
+    slug = $3,
+    locale = $4,
+    title = $5,
+    content = $6,
+    text_content = $7,
+    status = $8,
+    -- Keep the first publish time, clear it when the article goes back to draft.
+    published_at = CASE WHEN $8 = 'published' THEN COALESCE(published_at, NOW()) END,
+    updated_at = NOW()
+WHERE id = $1;
+
+-- name: delete-article
+DELETE FROM kb_articles WHERE id = $1;
+
+-- name: search-articles
+-- $2 is the locale, $3 matches articles with any of the query words instead of all of them,
+-- $4 restricts the search to published articles.
+WITH q AS (
+    SELECT CASE WHEN $3::BOOLEAN
+        THEN to_tsquery('simple', COALESCE(NULLIF(array_to_string(tsvector_to_array(to_tsvector('simple', $1)), ' | '), ''), ''))
+        ELSE websearch_to_tsquery('simple', $1)
+    END AS query
+),
+matches AS (
+    SELECT a.id, a.uuid, a.slug, a.locale, a.title, a.text_content, a.status, c.name AS category_name,
+        ts_rank_cd(to_tsvector('simple', a.title || ' ' || a.text_content), q.query) AS rank
+    FROM kb_articles a
+    CROSS JOIN q
+    LEFT JOIN kb_categories c ON c.id = a.category_id
+    WHERE to_tsvector('simple', a.title || ' ' || a.text_content) @@ q.query
+    AND ($2 = '' OR a.locale = $2)
+    AND (NOT $4::BOOLEAN OR a.status = 'published')
+    ORDER BY rank DESC, a.updated_at DESC
+    LIMIT $5
+)
+SELECT m.id, m.uuid, m.slug, m.locale, m.title, m.status, m.category_name, m.rank, m.text_content,
+    ts_headline('simple', m.text_content, q.query, 'StartSel=<mark>, StopSel=</mark>, MaxWords=30, MinWords=10, MaxFragments=1') AS snippet
+FROM matches m
+CROSS JOIN q
+ORDER BY m.rank DESC;
@@ -0,0 +1,398 @@
+package migrations
+
+import (
+	"github.com/jmoiron/sqlx"
+	"github.com/knadh/koanf/v2"
+	"github.com/knadh/stuffbin"
+)
+
+// V2_1_0 updates the database schema to v2.1.0.
+func V2_1_0(db *sqlx.DB, fs stuffbin.FileSystem, ko *koanf.Koanf) error {
+	// Add API inbox channel.
+	_, err := db.Exec(`ALTER TYPE channels ADD VALUE IF NOT EXISTS 'api'`)
+	if err != nil {
+		return err
+	}
+
+	// Add secondary email identities for merged contacts.
+	_, err = db.Exec(`
+		CREATE TABLE IF NOT EXISTS contact_identities (
+			id SERIAL PRIMARY KEY,
+			created_at TIMESTAMPTZ DEFAULT NOW(),
+			updated_at TIMESTAMPTZ DEFAULT NOW(),
+			contact_id BIGINT NOT NULL REFERENCES users(id) ON DELETE CASCADE ON UPDATE CASCADE,
+			email TEXT NOT NULL,
+			CONSTRAINT constraint_contact_identities_on_email_length CHECK (LENGTH(email) <= 320)
+		);
+		CREATE UNIQUE INDEX IF NOT EXISTS index_unique_contact_identities_on_email ON contact_identities (email);
+		CREATE INDEX IF NOT EXISTS index_contact_identities_on_contact_id ON contact_identities (contact_id);
+	`)
+	if err != nil {
+		return err
+	}
+
+	_, err = db.Exec(`ALTER TYPE activity_log_type ADD VALUE IF NOT EXISTS 'contact_merged'`)
+	if err != nil {
+		return err
+	}
+
+	// Add `contacts:merge` permission to Admin role.
+	_, err = db.Exec(`
+		UPDATE roles
+		SET permissions = array_append(permissions, 'contacts:merge')
+		WHERE name = 'Admin' AND NOT ('contacts:merge' = ANY(permissions));
+	`)
+	if err != nil {
+		return err
+	}
+
+	// GDPR data export and erasure.
+	_, err = db.Exec(`ALTER TYPE activity_log_type ADD VALUE IF NOT EXISTS 'contact_data_exported'`)
+	if err != nil {
+		return err
+	}
+
+	_, err = db.Exec(`ALTER TYPE activity_log_type ADD VALUE IF NOT EXISTS 'contact_data_erased'`)
+	if err != nil {
+		return err
+	}
+
+	// Add `contacts:export` and `contacts:erase` permissions to Admin role.
+	for _, perm := range []string{"contacts:export", "contacts:erase"} {
+		_, err = db.Exec(`
+			UPDATE roles
+			SET permissions = array_append(permissions, $1)
+			WHERE name = 'Admin' AND NOT ($1 = ANY(permissions));
+		`, perm)
+		if err != nil {
+			return err
+		}
+	}
+
+	// Per-inbox data retention policies.
+	_, err = db.Exec(`
+		DO $$
+		BEGIN
+			IF NOT EXISTS (SELECT 1 FROM pg_type WHERE typname = 'retention_action') THEN
+				CREATE TYPE retention_action AS ENUM ('delete', 'anonymise');
+			END IF;
+		END$$;
+	`)
+	if err != nil {
+		return err
+	}
+
+	_, err = db.Exec(`
+		CREATE TABLE IF NOT EXISTS inbox_retention_policies (
+			id SERIAL PRIMARY KEY,
+			created_at TIMESTAMPTZ DEFAULT NOW(),
+			updated_at TIMESTAMPTZ DEFAULT NOW(),
+			inbox_id INT NOT NULL UNIQUE REFERENCES inboxes(id) ON DELETE CASCADE ON UPDATE CASCADE,
+			enabled BOOL DEFAULT FALSE NOT NULL,
+			retention_days INT NOT NULL,
+			statuses TEXT[] DEFAULT '{Resolved,Closed}'::TEXT[] NOT NULL,
+			"action" retention_action DEFAULT 'delete' NOT NULL,
+			last_run_at TIMESTAMPTZ NULL,
+			CONSTRAINT constraint_inbox_retention_policies_on_retention_days CHECK (retention_days > 0)
+		);
+		ALTER TABLE conversations ADD COLUMN IF NOT EXISTS anonymised_at TIMESTAMPTZ NULL;
+	`)
+	if err != nil {
+		return err
+	}
+
+	_, err = db.Exec(`ALTER TYPE activity_log_type ADD VALUE IF NOT EXISTS 'retention_sweep'`)
+	if err != nil {
+		return err
+	}
+
+	// Full-text search indexes for messages and conversation subjects.
+	_, err = db.Exec(`
+		CREATE INDEX IF NOT EXISTS index_fts_conversation_messages_on_text_content ON conversation_messages USING GIN (to_tsvector('simple', COALESCE(text_content, '')));
+		CREATE INDEX IF NOT EXISTS index_fts_conversations_on_subject ON conversations USING GIN (to_tsvector('simple', COALESCE(subject, '')));
+	`)
+	if err != nil {
+		return err
+	}
+
+	// Anthropic and OpenAI-compatible AI providers.
+	_, err = db.Exec(`ALTER TYPE ai_provider ADD VALUE IF NOT EXISTS 'anthropic'`)
+	if err != nil {
+		return err
+	}
+	_, err = db.Exec(`ALTER TYPE ai_provider ADD VALUE IF NOT EXISTS 'openai_compatible'`)
+	if err != nil {
+		return err
+	}
+	_, err = db.Exec(`
+		CREATE UNIQUE INDEX IF NOT EXISTS index_unique_ai_providers_on_provider ON ai_providers USING btree (provider);
+		INSERT INTO ai_providers ("name", provider, config, is_default)
+		VALUES
+			('anthropic', 'anthropic', '{"api_key": ""}'::jsonb, false),
+			('openai_compatible', 'openai_compatible', '{"api_key": ""}'::jsonb, false)
+		ON CONFLICT (provider) DO NOTHING;
+	`)
+	if err != nil {
+		return err
+	}
+
+	// Knowledge base categories and articles.
+	_, err = db.Exec(`
+		DO $$
+		BEGIN
+			IF NOT EXISTS (SELECT 1 FROM pg_type WHERE typname = 'kb_article_status') THEN
+				CREATE TYPE kb_article_status AS ENUM ('draft', 'published');
+			END IF;
+		END$$;
+	`)
+	if err != nil {
+		return err
+	}
+
+	_, err = db.Exec(`
+		CREATE TABLE IF NOT EXISTS kb_categories (
+			id SERIAL PRIMARY KEY,
+			created_at TIMESTAMPTZ DEFAULT NOW(),
+			updated_at TIMESTAMPTZ DEFAULT NOW(),
+			"name" TEXT NOT NULL,
+			description TEXT DEFAULT '' NOT NULL,
+			position INT DEFAULT 0 NOT NULL,
+			CONSTRAINT constraint_kb_categories_on_name CHECK (length("name") <= 140),
+			CONSTRAINT constraint_kb_categories_on_description CHECK (length(description) <= 1000)
+		);
+		CREATE UNIQUE INDEX IF NOT EXISTS index_unique_kb_categories_on_name ON kb_categories (LOWER("name"));
+
+		CREATE TABLE IF NOT EXISTS kb_articles (
+			id SERIAL PRIMARY KEY,
+			created_at TIMESTAMPTZ DEFAULT NOW(),
+			updated_at TIMESTAMPTZ DEFAULT NOW(),
+			"uuid" UUID DEFAULT gen_random_uuid() NOT NULL UNIQUE,
+			category_id INT REFERENCES kb_categories(id) ON DELETE SET NULL ON UPDATE CASCADE NULL,
+			author_id INT REFERENCES users(id) ON DELETE SET NULL ON UPDATE CASCADE NULL,
+			slug TEXT NOT NULL,
+			locale TEXT DEFAULT 'en' NOT NULL,
+			title TEXT NOT NULL,
+			"content" TEXT DEFAULT '' NOT NULL,
+			text_content TEXT DEFAULT '' NOT NULL,
+			status kb_article_status DEFAULT 'draft' NOT NULL,
+			published_at TIMESTAMPTZ NULL,
+			CONSTRAINT constraint_kb_articles_on_slug CHECK (length(slug) <= 200),
+			CONSTRAINT constraint_kb_articles_on_locale CHECK (length(locale) <= 10),
+			CONSTRAINT constraint_kb_articles_on_title CHECK (length(title) <= 300)
+		);
+		CREATE UNIQUE INDEX IF NOT EXISTS index_unique_kb_articles_on_slug_locale ON kb_articles (slug, locale);
+		CREATE INDEX IF NOT EXISTS index_kb_articles_on_category_id ON kb_articles (category_id);
+		CREATE INDEX IF NOT EXISTS index_fts_kb_articles ON kb_articles USING GIN (to_tsvector('simple', title || ' ' || text_content));
+	`)
+	if err != nil {
+		return err
+	}
+
+	// Add `kb:manage` permission to Admin role.
+	_, err = db.Exec(`
+		UPDATE roles
+		SET permissions = array_append(permissions, 'kb:manage')
+		WHERE name = 'Admin' AND NOT ('kb:manage' = ANY(permissions));
+	`)
+	if err != nil {
+		return err
+	}
+
+	_, err = db.Exec(`
+		CREATE TABLE IF NOT EXISTS automation_rule_executions (
+			id BIGSERIAL PRIMARY KEY,
+			created_at TIMESTAMPTZ DEFAULT NOW(),
+			rule_id INT REFERENCES automation_rules(id) ON DELETE CASCADE ON UPDATE CASCADE NOT NULL,
+			conversation_id BIGINT REFERENCES conversations(id) ON DELETE CASCADE ON UPDATE CASCADE NOT NULL,
+			"trigger" TEXT NOT NULL,
+			group_results BOOLEAN[] DEFAULT '{}'::BOOLEAN[] NOT NULL,
+			actions JSONB DEFAULT '[]'::jsonb NOT NULL,
+			has_errors BOOLEAN DEFAULT FALSE NOT NULL
+		);
+		CREATE INDEX IF NOT EXISTS index_automation_rule_executions_on_rule_id_and_created_at ON automation_rule_executions (rule_id, created_at DESC);
+		CREATE INDEX IF NOT EXISTS index_automation_rule_executions_on_conversation_id ON automation_rule_executions (conversation_id);
+		CREATE INDEX IF NOT EXISTS index_automation_rule_executions_on_created_at ON automation_rule_executions (created_at);
+	`)
+	if err != nil {
+		return err
+	}
+
+	_, err = db.Exec(`
+		CREATE TABLE IF NOT EXISTS automation_rule_firings (
+			created_at TIMESTAMPTZ DEFAULT NOW(),
+			rule_id INT REFERENCES automation_rules(id) ON DELETE CASCADE ON UPDATE CASCADE NOT NULL,
+			conversation_id BIGINT REFERENCES conversations(id) ON DELETE CASCADE ON UPDATE CASCADE NOT NULL,
+			PRIMARY KEY (rule_id, conversation_id)
+		);
+	`)
+	if err != nil {
+		return err
+	}
+
+	// Delayed automation actions.
+	_, err = db.Exec(`
+		DO $$
+		BEGIN
+			IF NOT EXISTS (SELECT 1 FROM pg_type WHERE typname = 'automation_scheduled_action_status') THEN
+				CREATE TYPE automation_scheduled_action_status AS ENUM ('pending', 'processing', 'completed', 'cancelled', 'failed');
+			END IF;
+		END$$;
+	`)
+	if err != nil {
+		return err
+	}
+
+	_, err = db.Exec(`
+		CREATE TABLE IF NOT EXISTS automation_scheduled_actions (
+			id BIGSERIAL PRIMARY KEY,
+			created_at TIMESTAMPTZ DEFAULT NOW(),
+			updated_at TIMESTAMPTZ DEFAULT NOW(),
+			rule_id INT REFERENCES automation_rules(id) ON DELETE CASCADE ON UPDATE CASCADE NOT NULL,
+			conversation_id BIGINT REFERENCES conversations(id) ON DELETE CASCADE ON UPDATE CASCADE NOT NULL,
+			"action" JSONB NOT NULL,
+			run_at TIMESTAMPTZ NOT NULL,
+			status automation_scheduled_action_status DEFAULT 'pending' NOT NULL,
+			cancel_on TEXT[] DEFAULT '{}'::TEXT[] NOT NULL,
+			status_id INT NULL,
+			assigned_user_id INT NULL,
+			reason TEXT NULL
+		);
+		CREATE INDEX IF NOT EXISTS index_automation_scheduled_actions_on_status_and_run_at ON automation_scheduled_actions (status, run_at);
+		CREATE INDEX IF NOT EXISTS index_automation_scheduled_actions_on_conversation_id ON automation_scheduled_actions (conversation_id);
+		CREATE INDEX IF NOT EXISTS index_automation_scheduled_actions_on_pending ON automation_scheduled_actions (rule_id, conversation_id) WHERE status = 'pending';
+	`)
+	if err != nil {
+		return err
+	}
+
+	// Automation rule versions, existing rules get their current state as the first version.
+	_, err = db.Exec(`
+		CREATE TABLE IF NOT EXISTS automation_rule_versions (
+			id BIGSERIAL PRIMARY KEY,
+			created_at TIMESTAMPTZ DEFAULT NOW(),
+			rule_id INT REFERENCES automation_rules(id) ON DELETE CASCADE ON UPDATE CASCADE NOT NULL,
+			"version" INT NOT NULL,
+			user_id INT REFERENCES users(id) ON DELETE SET NULL ON UPDATE CASCADE NULL,
+			"name" TEXT NOT NULL,
+			description TEXT NULL,
+			"type" VARCHAR NOT NULL,
+			rules JSONB NULL,
+			events TEXT[] DEFAULT '{}'::TEXT[] NOT NULL,
+			CONSTRAINT constraint_automation_rule_versions_unique UNIQUE (rule_id, "version")
+		);
+		INSERT INTO automation_rule_versions (rule_id, "version", "name", description, "type", rules, events)
+		SELECT r.id, 1, r.name, r.description, r.type, r.rules, r.events
+		FROM automation_rules r
+		WHERE NOT EXISTS (SELECT 1 FROM automation_rule_versions v WHERE v.rule_id = r.id);
+	`)
+	if err != nil {
+		return err
+	}
+
+	// Least busy, skill based and sticky team assignment strategies.
+	_, err = db.Exec(`ALTER TYPE conversation_assignment_type ADD VALUE IF NOT EXISTS 'Least busy';`)
+	if err != nil {
+		return err
+	}
+	_, err = db.Exec(`ALTER TYPE conversation_assignment_type ADD VALUE IF NOT EXISTS 'Skill based';`)
+	if err != nil {
+		return err
+	}
+	_, err = db.Exec(`ALTER TYPE conversation_assignment_type ADD VALUE IF NOT EXISTS 'Sticky';`)
+	if err != nil {
+		return err
+	}
+
+	// Agent skills, matched against conversation tags and inbox for skill based assignment.
+	_, err = db.Exec(`
+		ALTER TABLE users ADD COLUMN IF NOT EXISTS skills TEXT[] DEFAULT '{}'::TEXT[] NOT NULL;
+	`)
+	if err != nil {
+		return err
+	}
+
+	// Auto assignment decision log.
+	_, err = db.Exec(`
+		CREATE TABLE IF NOT EXISTS auto_assignment_decisions (
+			id BIGSERIAL PRIMARY KEY,
+			created_at TIMESTAMPTZ DEFAULT NOW(),
+			conversation_id BIGINT REFERENCES conversations(id) ON DELETE CASCADE ON UPDATE CASCADE NOT NULL,
+			team_id INT REFERENCES teams(id) ON DELETE SET NULL ON UPDATE CASCADE NULL,
+			strategy TEXT NOT NULL,
+			"trigger" TEXT NOT NULL,
+			outcome TEXT NOT NULL,
+			fallback TEXT NULL,
+			assigned_user_id INT REFERENCES users(id) ON DELETE SET NULL ON UPDATE CASCADE NULL,
+			candidates JSONB DEFAULT '[]'::jsonb NOT NULL
+		);
+		CREATE INDEX IF NOT EXISTS index_auto_assignment_decisions_on_conversation_id ON auto_assignment_decisions (conversation_id);
+		CREATE INDEX IF NOT EXISTS index_auto_assignment_decisions_on_created_at ON auto_assignment_decisions (created_at);
+	`)
+	if err != nil {
+		return err
+	}
+
+	// Agent capacity override and channel weights used by auto assignment.
+	_, err = db.Exec(`
+		ALTER TABLE users ADD COLUMN IF NOT EXISTS capacity INT NULL;
+		ALTER TABLE users ADD COLUMN IF NOT EXISTS channel_weights JSONB DEFAULT '{}'::jsonb NOT NULL;
+		DO $$
+		BEGIN
+			IF NOT EXISTS (SELECT 1 FROM pg_constraint WHERE conname = 'constraint_users_on_capacity') THEN
+				ALTER TABLE users ADD CONSTRAINT constraint_users_on_capacity CHECK (capacity IS NULL OR capacity > 0);
+			END IF;
+		END
+		$$;
+	`)
+	if err != nil {
+		return err
+	}
+
+	// Agent shift schedules.
+	_, err = db.Exec(`
+		ALTER TABLE users ADD COLUMN IF NOT EXISTS shift_hours JSONB NULL;
+		ALTER TABLE users ADD COLUMN IF NOT EXISTS shift_timezone TEXT NULL;
+		ALTER TABLE users ADD COLUMN IF NOT EXISTS shift_reassign BOOLEAN DEFAULT false NOT NULL;
+		ALTER TABLE users ADD COLUMN IF NOT EXISTS shift_on_duty BOOLEAN NULL;
+	`)
+	if err != nil {
+		return err
+	}
+
+	// IMAP UID checkpoints.
+	_, err = db.Exec(`
+		CREATE TABLE IF NOT EXISTS imap_checkpoints (
+			id SERIAL PRIMARY KEY,
+			created_at TIMESTAMPTZ DEFAULT NOW(),
+			updated_at TIMESTAMPTZ DEFAULT NOW(),
+			inbox_id INT REFERENCES inboxes(id) ON DELETE CASCADE ON UPDATE CASCADE NOT NULL,
+			host TEXT NOT NULL,
+			username TEXT NOT NULL,
+			mailbox TEXT NOT NULL,
+			uid_validity BIGINT NOT NULL,
+			last_uid BIGINT NOT NULL,
+			CONSTRAINT constraint_imap_checkpoints_unique UNIQUE (inbox_id, host, username, mailbox)
+		);
+	`)
+	if err != nil {
+		return err
+	}
+
+	// Email bounces.
+	_, err = db.Exec(`ALTER TYPE user_notification_type ADD VALUE IF NOT EXISTS 'email_bounce'`)
+	if err != nil {
+		return err
+	}
+	_, err = db.Exec(`ALTER TABLE users ADD COLUMN IF NOT EXISTS email_undeliverable BOOLEAN DEFAULT false NOT NULL;`)
+	if err != nil {
+		return err
+	}
+
+	// Email signatures.
+	_, err = db.Exec(`ALTER TABLE users ADD COLUMN IF NOT EXISTS email_signature TEXT NULL;`)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
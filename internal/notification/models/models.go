@@ -15,6 +15,8 @@ const (
 	NotificationTypeAssignment NotificationType = "assignment"
 	NotificationTypeSLAWarning NotificationType = "sla_warning"
 	NotificationTypeSLABreach  NotificationType = "sla_breach"
+	// NotificationTypeEmailBounce is sent to the author of an outgoing email that bounced.
+	NotificationTypeEmailBounce NotificationType = "email_bounce"
 )
 
 // UserNotification represents an in-app notification for a user.
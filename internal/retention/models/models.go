@@ -0,0 +1,34 @@
+package models
+
+import (
+	"time"
+
+	"github.com/lib/pq"
+	"github.com/volatiletech/null/v9"
+)
+
+const (
+	ActionDelete    = "delete"
+	ActionAnonymise = "anonymise"
+)
+
+// Policy is the data retention policy of an inbox.
+type Policy struct {
+	ID            int            `db:"id" json:"id"`
+	CreatedAt     time.Time      `db:"created_at" json:"created_at"`
+	UpdatedAt     time.Time      `db:"updated_at" json:"updated_at"`
+	InboxID       int            `db:"inbox_id" json:"inbox_id"`
+	InboxName     string         `db:"inbox_name" json:"-"`
+	Enabled       bool           `db:"enabled" json:"enabled"`
+	RetentionDays int            `db:"retention_days" json:"retention_days"`
+	Statuses      pq.StringArray `db:"statuses" json:"statuses"`
+	Action        string         `db:"action" json:"action"`
+	LastRunAt     null.Time      `db:"last_run_at" json:"last_run_at"`
+}
+
+// Result holds the number of records a policy affects.
+type Result struct {
+	Conversations int `db:"conversations" json:"conversations"`
+	Messages      int `db:"messages" json:"messages"`
+	Attachments   int `db:"attachments" json:"attachments"`
+}
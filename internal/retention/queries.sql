@@ -0,0 +1,114 @@
+-- name: get-policy
+SELECT p.id, p.created_at, p.updated_at, p.inbox_id, i.name AS inbox_name, p.enabled, p.retention_days, p.statuses, p."action", p.last_run_at
+FROM inbox_retention_policies p
+JOIN inboxes i ON i.id = p.inbox_id
+WHERE p.inbox_id = $1;
+
+-- name: get-enabled-policies
+SELECT p.id, p.created_at, p.updated_at, p.inbox_id, i.name AS inbox_name, p.enabled, p.retention_days, p.statuses, p."action", p.last_run_at
+FROM inbox_retention_policies p
+JOIN inboxes i ON i.id = p.inbox_id
+WHERE p.enabled = TRUE AND i.deleted_at IS NULL
+ORDER BY p.inbox_id;
+
+-- name: upsert-policy
+INSERT INTO inbox_retention_policies (inbox_id, enabled, retention_days, statuses, "action")
+VALUES ($1, $2, $3, $4, $5)
+ON CONFLICT (inbox_id) DO UPDATE
+SET enabled = EXCLUDED.enabled,
+    retention_days = EXCLUDED.retention_days,
+    statuses = EXCLUDED.statuses,
+    "action" = EXCLUDED."action",
+    updated_at = NOW();
+
+-- name: update-last-run
+UPDATE inbox_retention_policies SET last_run_at = NOW() WHERE id = $1;
+
+-- name: count-expired
+-- $1 = inbox_id, $2 = statuses, $3 = expiry cutoff, $4 = action
+WITH expired AS (
+    SELECT c.id
+    FROM conversations c
+    JOIN conversation_statuses s ON s.id = c.status_id
+    WHERE c.inbox_id = $1
+      AND s.name = ANY($2::TEXT[])
+      AND COALESCE(GREATEST(c.resolved_at, c.closed_at, c.last_message_at), c.created_at) < $3::TIMESTAMPTZ
+      AND ($4::retention_action = 'delete' OR c.anonymised_at IS NULL)
+),
+expired_messages AS (
+    SELECT m.id
+    FROM conversation_messages m
+    WHERE m.conversation_id IN (SELECT id FROM expired)
+)
+SELECT
+    (SELECT COUNT(*) FROM expired) AS conversations,
+    (SELECT COUNT(*) FROM expired_messages) AS messages,
+    (SELECT COUNT(*) FROM media md WHERE md.model_type = 'messages' AND md.model_id IN (SELECT id FROM expired_messages)) AS attachments;
+
+-- name: get-expired-conversations
+-- $1 = inbox_id, $2 = statuses, $3 = expiry cutoff, $4 = action, $5 = batch size
+SELECT c.id
+FROM conversations c
+JOIN conversation_statuses s ON s.id = c.status_id
+WHERE c.inbox_id = $1
+  AND s.name = ANY($2::TEXT[])
+  AND COALESCE(GREATEST(c.resolved_at, c.closed_at, c.last_message_at), c.created_at) < $3::TIMESTAMPTZ
+  AND ($4::retention_action = 'delete' OR c.anonymised_at IS NULL)
+ORDER BY c.id
+LIMIT $5;
+
+-- name: get-conversations-media
+SELECT md.id, md.created_at, md.updated_at, md."uuid", md.store, md.filename, md.content_type, md.content_id, md.model_id, md.model_type, md.disposition, md."size", md.meta
+FROM media md
+WHERE md.model_type = 'messages' AND md.model_id IN (
+    SELECT m.id FROM conversation_messages m WHERE m.conversation_id = ANY($1::BIGINT[])
+);
+
+-- name: delete-conversations
+WITH messages AS (
+    SELECT COUNT(*) AS total FROM conversation_messages WHERE conversation_id = ANY($1::BIGINT[])
+),
+deleted AS (
+    DELETE FROM conversations WHERE id = ANY($1::BIGINT[])
+    RETURNING id
+)
+SELECT
+    (SELECT COUNT(*) FROM deleted) AS conversations,
+    (SELECT total FROM messages) AS messages;
+
+-- name: anonymise-conversations
+-- $1 = conversation IDs, $2 = redacted content
+WITH redact_messages AS (
+    UPDATE conversation_messages
+    SET content = $2,
+        text_content = $2,
+        content_type = 'text',
+        meta = '{}'::jsonb,
+        updated_at = NOW()
+    WHERE conversation_id = ANY($1::BIGINT[])
+      AND type IN ('incoming', 'outgoing')
+    RETURNING id
+),
+redact_csat AS (
+    UPDATE csat_responses
+    SET feedback = NULL,
+        meta = '{}'::jsonb,
+        updated_at = NOW()
+    WHERE conversation_id = ANY($1::BIGINT[])
+    RETURNING id
+),
+redact_conversations AS (
+    UPDATE conversations
+    SET subject = CASE WHEN subject IS NULL THEN NULL ELSE $2 END,
+        last_message = CASE WHEN last_message IS NULL THEN NULL ELSE $2 END,
+        last_interaction = CASE WHEN last_interaction IS NULL THEN NULL ELSE $2 END,
+        custom_attributes = '{}'::jsonb,
+        meta = '{}'::jsonb,
+        anonymised_at = NOW(),
+        updated_at = NOW()
+    WHERE id = ANY($1::BIGINT[])
+    RETURNING id
+)
+SELECT
+    (SELECT COUNT(*) FROM redact_conversations) AS conversations,
+    (SELECT COUNT(*) FROM redact_messages) AS messages;
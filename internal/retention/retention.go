@@ -0,0 +1,332 @@
+// Package retention purges or anonymises old conversations according to per-inbox retention policies.
+package retention
+
+import (
+	"context"
+	"database/sql"
+	"embed"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/abhinavxd/libredesk/internal/conversation/models"
+	"github.com/abhinavxd/libredesk/internal/dbutil"
+	"github.com/abhinavxd/libredesk/internal/envelope"
+	"github.com/abhinavxd/libredesk/internal/gdpr"
+	"github.com/abhinavxd/libredesk/internal/image"
+	mmodels "github.com/abhinavxd/libredesk/internal/media/models"
+	rmodels "github.com/abhinavxd/libredesk/internal/retention/models"
+	umodels "github.com/abhinavxd/libredesk/internal/user/models"
+	"github.com/jmoiron/sqlx"
+	"github.com/knadh/go-i18n"
+	"github.com/lib/pq"
+	"github.com/zerodha/logf"
+)
+
+var (
+	//go:embed queries.sql
+	efs embed.FS
+
+	// Conversation statuses a retention policy can apply to.
+	allowedStatuses = []string{models.StatusResolved, models.StatusClosed}
+)
+
+const (
+	// Number of conversations purged per query.
+	batchSize = 100
+)
+
+type mediaStore interface {
+	Delete(name string) error
+}
+
+type userStore interface {
+	GetSystemUser() (umodels.User, error)
+}
+
+type activityLogStore interface {
+	RetentionSweep(actorID int, actorEmail string, inboxID int, inboxName, action string, conversations, messages, attachments int) error
+}
+
+// sweepStore runs the queries of a policy sweep.
+type sweepStore interface {
+	GetExpiredConversations(ctx context.Context, policy rmodels.Policy, cutoff time.Time, limit int) ([]int64, error)
+	GetConversationsMedia(ctx context.Context, ids []int64) ([]mmodels.Media, error)
+	DeleteConversations(ctx context.Context, ids []int64) (rmodels.Result, error)
+	AnonymiseConversations(ctx context.Context, ids []int64) (rmodels.Result, error)
+}
+
+// Manager manages retention policies and runs the retention sweeper.
+type Manager struct {
+	q           queries
+	store       sweepStore
+	media       mediaStore
+	user        userStore
+	activityLog activityLogStore
+	lo          *logf.Logger
+	i18n        *i18n.I18n
+}
+
+// Opts contains options for initializing the Manager.
+type Opts struct {
+	DB          *sqlx.DB
+	Media       mediaStore
+	User        userStore
+	ActivityLog activityLogStore
+	Lo          *logf.Logger
+	I18n        *i18n.I18n
+}
+
+// queries contains prepared SQL queries.
+type queries struct {
+	GetPolicy               *sqlx.Stmt `query:"get-policy"`
+	GetEnabledPolicies      *sqlx.Stmt `query:"get-enabled-policies"`
+	UpsertPolicy            *sqlx.Stmt `query:"upsert-policy"`
+	UpdateLastRun           *sqlx.Stmt `query:"update-last-run"`
+	CountExpired            *sqlx.Stmt `query:"count-expired"`
+	GetExpiredConversations *sqlx.Stmt `query:"get-expired-conversations"`
+	GetConversationsMedia   *sqlx.Stmt `query:"get-conversations-media"`
+	DeleteConversations     *sqlx.Stmt `query:"delete-conversations"`
+	AnonymiseConversations  *sqlx.Stmt `query:"anonymise-conversations"`
+}
+
+// New creates and returns a new instance of the Manager.
+func New(opts Opts) (*Manager, error) {
+	var q queries
+	if err := dbutil.ScanSQLFile("queries.sql", &q, opts.DB, efs); err != nil {
+		return nil, err
+	}
+	return &Manager{
+		q:           q,
+		store:       dbSweepStore{q: q},
+		media:       opts.Media,
+		user:        opts.User,
+		activityLog: opts.ActivityLog,
+		lo:          opts.Lo,
+		i18n:        opts.I18n,
+	}, nil
+}
+
+// GetPolicy returns the retention policy of an inbox, or a disabled default policy if none is set.
+func (m *Manager) GetPolicy(inboxID int) (rmodels.Policy, error) {
+	var policy rmodels.Policy
+	if err := m.q.GetPolicy.Get(&policy, inboxID); err != nil {
+		if err == sql.ErrNoRows {
+			return rmodels.Policy{
+				InboxID:  inboxID,
+				Statuses: allowedStatuses,
+				Action:   rmodels.ActionDelete,
+			}, nil
+		}
+		m.lo.Error("error fetching retention policy", "inbox_id", inboxID, "error", err)
+		return policy, envelope.NewError(envelope.GeneralError, m.i18n.T("globals.messages.somethingWentWrong"), nil)
+	}
+	return policy, nil
+}
+
+// UpsertPolicy creates or updates the retention policy of an inbox.
+func (m *Manager) UpsertPolicy(inboxID int, policy rmodels.Policy) (rmodels.Policy, error) {
+	if err := m.validatePolicy(&policy); err != nil {
+		return rmodels.Policy{}, err
+	}
+	if _, err := m.q.UpsertPolicy.Exec(inboxID, policy.Enabled, policy.RetentionDays, policy.Statuses, policy.Action); err != nil {
+		m.lo.Error("error upserting retention policy", "inbox_id", inboxID, "error", err)
+		return rmodels.Policy{}, envelope.NewError(envelope.GeneralError, m.i18n.T("globals.messages.somethingWentWrong"), nil)
+	}
+	return m.GetPolicy(inboxID)
+}
+
+// DryRun returns the number of records the policy would delete or anonymise if it ran now.
+func (m *Manager) DryRun(inboxID int, policy rmodels.Policy) (rmodels.Result, error) {
+	var result rmodels.Result
+	if err := m.validatePolicy(&policy); err != nil {
+		return result, err
+	}
+	if err := m.q.CountExpired.Get(&result, inboxID, policy.Statuses, expiryCutoff(time.Now(), policy.RetentionDays), policy.Action); err != nil {
+		m.lo.Error("error counting expired conversations", "inbox_id", inboxID, "error", err)
+		return result, envelope.NewError(envelope.GeneralError, m.i18n.T("globals.messages.somethingWentWrong"), nil)
+	}
+	return result, nil
+}
+
+// Run runs the retention sweeper at the given interval until the context is cancelled.
+func (m *Manager) Run(ctx context.Context, interval time.Duration) {
+	if interval <= 0 {
+		m.lo.Info("retention sweep interval is non-positive, skipping retention sweeper", "interval", interval)
+		return
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := m.Sweep(ctx); err != nil {
+				m.lo.Error("error running retention sweep", "error", err)
+			}
+		}
+	}
+}
+
+// Sweep applies all enabled retention policies.
+func (m *Manager) Sweep(ctx context.Context) error {
+	var policies []rmodels.Policy
+	if err := m.q.GetEnabledPolicies.SelectContext(ctx, &policies); err != nil {
+		return err
+	}
+	if len(policies) == 0 {
+		return nil
+	}
+
+	systemUser, err := m.user.GetSystemUser()
+	if err != nil {
+		return err
+	}
+
+	for _, policy := range policies {
+		if ctx.Err() != nil {
+			return nil
+		}
+
+		m.lo.Info("running retention sweep", "inbox_id", policy.InboxID, "action", policy.Action, "retention_days", policy.RetentionDays)
+		result, err := m.sweepPolicy(ctx, policy)
+		if err != nil {
+			// The policy is not marked as run so that the failed sweep is not mistaken for a completed one.
+			m.lo.Error("error running retention sweep", "inbox_id", policy.InboxID, "conversations", result.Conversations,
+				"messages", result.Messages, "attachments", result.Attachments, "error", err)
+			continue
+		}
+		m.lo.Info("retention sweep done", "inbox_id", policy.InboxID, "conversations", result.Conversations, "messages", result.Messages, "attachments", result.Attachments)
+
+		if _, err := m.q.UpdateLastRun.Exec(policy.ID); err != nil {
+			m.lo.Error("error updating retention policy last run", "inbox_id", policy.InboxID, "error", err)
+		}
+		if err := m.activityLog.RetentionSweep(systemUser.ID, systemUser.Email.String, policy.InboxID, policy.InboxName, policy.Action,
+			result.Conversations, result.Messages, result.Attachments); err != nil {
+			m.lo.Error("error creating activity log", "error", err)
+		}
+	}
+	return nil
+}
+
+// sweepPolicy deletes or anonymises the expired conversations of a policy in batches.
+func (m *Manager) sweepPolicy(ctx context.Context, policy rmodels.Policy) (rmodels.Result, error) {
+	var (
+		total  rmodels.Result
+		cutoff = expiryCutoff(time.Now(), policy.RetentionDays)
+	)
+	for ctx.Err() == nil {
+		ids, err := m.store.GetExpiredConversations(ctx, policy, cutoff, batchSize)
+		if err != nil {
+			return total, err
+		}
+		if len(ids) == 0 {
+			break
+		}
+
+		// Fetch media before the messages they are linked to are gone.
+		media, err := m.store.GetConversationsMedia(ctx, ids)
+		if err != nil {
+			return total, err
+		}
+
+		var result rmodels.Result
+		switch policy.Action {
+		case rmodels.ActionAnonymise:
+			if result, err = m.store.AnonymiseConversations(ctx, ids); err != nil {
+				return total, err
+			}
+		default:
+			if result, err = m.store.DeleteConversations(ctx, ids); err != nil {
+				return total, err
+			}
+		}
+
+		for _, md := range media {
+			if err := m.media.Delete(md.UUID); err != nil {
+				m.lo.Error("error deleting media", "uuid", md.UUID, "error", err)
+				continue
+			}
+			if strings.HasPrefix(md.ContentType, "image/") {
+				if err := m.media.Delete(image.ThumbPrefix + md.UUID); err != nil {
+					m.lo.Error("error deleting media thumbnail", "uuid", md.UUID, "error", err)
+				}
+			}
+			result.Attachments++
+		}
+
+		total.Conversations += result.Conversations
+		total.Messages += result.Messages
+		total.Attachments += result.Attachments
+
+		if len(ids) < batchSize {
+			break
+		}
+	}
+	return total, nil
+}
+
+// expiryCutoff returns the time before which the conversations of a policy with the given retention period have expired.
+func expiryCutoff(now time.Time, retentionDays int) time.Time {
+	return now.AddDate(0, 0, -retentionDays)
+}
+
+// dbSweepStore runs the queries of a policy sweep on the database.
+type dbSweepStore struct {
+	q queries
+}
+
+// GetExpiredConversations returns the IDs of up to limit conversations of the policy that expired before the cutoff.
+func (s dbSweepStore) GetExpiredConversations(ctx context.Context, policy rmodels.Policy, cutoff time.Time, limit int) ([]int64, error) {
+	var ids pq.Int64Array
+	if err := s.q.GetExpiredConversations.SelectContext(ctx, &ids, policy.InboxID, policy.Statuses, cutoff, policy.Action, limit); err != nil {
+		return nil, err
+	}
+	return ids, nil
+}
+
+// GetConversationsMedia returns the message attachments of the conversations.
+func (s dbSweepStore) GetConversationsMedia(ctx context.Context, ids []int64) ([]mmodels.Media, error) {
+	var media []mmodels.Media
+	if err := s.q.GetConversationsMedia.SelectContext(ctx, &media, pq.Int64Array(ids)); err != nil {
+		return nil, err
+	}
+	return media, nil
+}
+
+// DeleteConversations deletes the conversations along with their messages.
+func (s dbSweepStore) DeleteConversations(ctx context.Context, ids []int64) (rmodels.Result, error) {
+	var result rmodels.Result
+	err := s.q.DeleteConversations.GetContext(ctx, &result, pq.Int64Array(ids))
+	return result, err
+}
+
+// AnonymiseConversations redacts the content of the conversations and their messages.
+func (s dbSweepStore) AnonymiseConversations(ctx context.Context, ids []int64) (rmodels.Result, error) {
+	var result rmodels.Result
+	err := s.q.AnonymiseConversations.GetContext(ctx, &result, pq.Int64Array(ids), gdpr.RedactedContent)
+	return result, err
+}
+
+// validatePolicy validates the policy and sets defaults.
+func (m *Manager) validatePolicy(policy *rmodels.Policy) error {
+	if policy.RetentionDays <= 0 {
+		return envelope.NewError(envelope.InputError, m.i18n.Ts("globals.messages.required", "name", "`retention_days`"), nil)
+	}
+	if policy.Action == "" {
+		policy.Action = rmodels.ActionDelete
+	}
+	if policy.Action != rmodels.ActionDelete && policy.Action != rmodels.ActionAnonymise {
+		return envelope.NewError(envelope.InputError, m.i18n.T("validation.invalidValue"), nil)
+	}
+	if len(policy.Statuses) == 0 {
+		policy.Statuses = allowedStatuses
+	}
+	for _, status := range policy.Statuses {
+		if !slices.Contains(allowedStatuses, status) {
+			return envelope.NewError(envelope.InputError, m.i18n.T("validation.invalidValue"), nil)
+		}
+	}
+	return nil
+}
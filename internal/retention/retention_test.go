@@ -0,0 +1,164 @@
+package retention
+
+import (
+	"context"
+	"slices"
+	"testing"
+	"time"
+
+	mmodels "github.com/abhinavxd/libredesk/internal/media/models"
+	rmodels "github.com/abhinavxd/libredesk/internal/retention/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/zerodha/logf"
+)
+
+// fakeConversation is a conversation held by the fake sweep store.
+type fakeConversation struct {
+	id           int64
+	lastActivity time.Time
+	anonymised   bool
+	media        []mmodels.Media
+}
+
+// fakeSweepStore applies the expiry filters of the sweep queries to conversations held in memory.
+type fakeSweepStore struct {
+	conversations []*fakeConversation
+	deleted       []int64
+	anonymised    []int64
+	cutoffs       []time.Time
+}
+
+func (s *fakeSweepStore) GetExpiredConversations(_ context.Context, policy rmodels.Policy, cutoff time.Time, limit int) ([]int64, error) {
+	s.cutoffs = append(s.cutoffs, cutoff)
+	var ids []int64
+	for _, c := range s.conversations {
+		if !c.lastActivity.Before(cutoff) {
+			continue
+		}
+		if policy.Action == rmodels.ActionAnonymise && c.anonymised {
+			continue
+		}
+		ids = append(ids, c.id)
+		if len(ids) == limit {
+			break
+		}
+	}
+	return ids, nil
+}
+
+func (s *fakeSweepStore) GetConversationsMedia(_ context.Context, ids []int64) ([]mmodels.Media, error) {
+	var media []mmodels.Media
+	for _, c := range s.conversations {
+		if slices.Contains(ids, c.id) {
+			media = append(media, c.media...)
+		}
+	}
+	return media, nil
+}
+
+func (s *fakeSweepStore) DeleteConversations(_ context.Context, ids []int64) (rmodels.Result, error) {
+	s.deleted = append(s.deleted, ids...)
+	s.conversations = slices.DeleteFunc(s.conversations, func(c *fakeConversation) bool { return slices.Contains(ids, c.id) })
+	return rmodels.Result{Conversations: len(ids), Messages: 2 * len(ids)}, nil
+}
+
+func (s *fakeSweepStore) AnonymiseConversations(_ context.Context, ids []int64) (rmodels.Result, error) {
+	s.anonymised = append(s.anonymised, ids...)
+	for _, c := range s.conversations {
+		if slices.Contains(ids, c.id) {
+			c.anonymised = true
+		}
+	}
+	return rmodels.Result{Conversations: len(ids), Messages: 2 * len(ids)}, nil
+}
+
+// fakeMediaStore records deleted media.
+type fakeMediaStore struct {
+	deleted []string
+}
+
+func (s *fakeMediaStore) Delete(name string) error {
+	s.deleted = append(s.deleted, name)
+	return nil
+}
+
+func createTestManager(store *fakeSweepStore, media *fakeMediaStore) *Manager {
+	logger := logf.New(logf.Opts{Level: logf.DebugLevel})
+	return &Manager{store: store, media: media, lo: &logger}
+}
+
+func TestExpiryCutoff(t *testing.T) {
+	now := time.Date(2026, 3, 31, 10, 0, 0, 0, time.UTC)
+	assert.Equal(t, time.Date(2026, 3, 1, 10, 0, 0, 0, time.UTC), expiryCutoff(now, 30))
+	assert.Equal(t, time.Date(2026, 3, 30, 10, 0, 0, 0, time.UTC), expiryCutoff(now, 1))
+}
+
+func TestSweepPolicy_OnlyExpiredConversations(t *testing.T) {
+	var (
+		now   = time.Now()
+		store = &fakeSweepStore{conversations: []*fakeConversation{
+			{id: 1, lastActivity: now.AddDate(0, 0, -31)},
+			{id: 2, lastActivity: now.AddDate(0, 0, -30).Add(-time.Minute)},
+			// Within the retention period.
+			{id: 3, lastActivity: now.AddDate(0, 0, -30).Add(time.Minute)},
+			{id: 4, lastActivity: now.AddDate(0, 0, -1)},
+		}}
+		m = createTestManager(store, &fakeMediaStore{})
+	)
+
+	result, err := m.sweepPolicy(context.Background(), rmodels.Policy{InboxID: 1, RetentionDays: 30, Action: rmodels.ActionDelete})
+	require.NoError(t, err)
+	assert.Equal(t, []int64{1, 2}, store.deleted)
+	assert.Equal(t, 2, result.Conversations)
+	require.NotEmpty(t, store.cutoffs)
+	assert.WithinDuration(t, now.AddDate(0, 0, -30), store.cutoffs[0], time.Minute)
+}
+
+func TestSweepPolicy_Action(t *testing.T) {
+	tests := []struct {
+		name           string
+		action         string
+		wantDeleted    []int64
+		wantAnonymised []int64
+	}{
+		{name: "delete", action: rmodels.ActionDelete, wantDeleted: []int64{1}},
+		{name: "anonymise", action: rmodels.ActionAnonymise, wantAnonymised: []int64{1}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var (
+				store = &fakeSweepStore{conversations: []*fakeConversation{
+					{id: 1, lastActivity: time.Now().AddDate(0, 0, -60), media: []mmodels.Media{
+						{UUID: "doc", ContentType: "application/pdf"},
+						{UUID: "img", ContentType: "image/png"},
+					}},
+				}}
+				media = &fakeMediaStore{}
+				m     = createTestManager(store, media)
+			)
+
+			result, err := m.sweepPolicy(context.Background(), rmodels.Policy{InboxID: 1, RetentionDays: 30, Action: tt.action})
+			require.NoError(t, err)
+			assert.Equal(t, tt.wantDeleted, store.deleted)
+			assert.Equal(t, tt.wantAnonymised, store.anonymised)
+			assert.Equal(t, 1, result.Conversations)
+			assert.Equal(t, 2, result.Attachments)
+			assert.ElementsMatch(t, []string{"doc", "img", "thumb_img"}, media.deleted)
+		})
+	}
+}
+
+func TestSweepPolicy_Batches(t *testing.T) {
+	store := &fakeSweepStore{}
+	for i := 1; i <= batchSize+5; i++ {
+		store.conversations = append(store.conversations, &fakeConversation{id: int64(i), lastActivity: time.Now().AddDate(0, 0, -60)})
+	}
+	m := createTestManager(store, &fakeMediaStore{})
+
+	// Anonymised conversations are not returned again, so the sweep ends once all are anonymised.
+	result, err := m.sweepPolicy(context.Background(), rmodels.Policy{InboxID: 1, RetentionDays: 30, Action: rmodels.ActionAnonymise})
+	require.NoError(t, err)
+	assert.Equal(t, batchSize+5, result.Conversations)
+	assert.Len(t, store.anonymised, batchSize+5)
+}
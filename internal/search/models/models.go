@@ -6,21 +6,49 @@ import (
 	"github.com/volatiletech/null/v9"
 )
 
+// Filters narrows down conversation and message search results, zero values are ignored.
+type Filters struct {
+	InboxID        int
+	StatusID       int
+	AssignedUserID int
+	AssignedTeamID int
+	TagIDs         []int
+	From           null.Time
+	To             null.Time
+}
+
+// Access holds the conversation read permissions of the user searching.
+type Access struct {
+	UserID         int
+	TeamIDs        []int
+	ReadAll        bool
+	ReadAssigned   bool
+	ReadTeamAll    bool
+	ReadTeamInbox  bool
+	ReadUnassigned bool
+}
+
 type ConversationResult struct {
+	Total           int       `db:"total" json:"-"`
 	CreatedAt       time.Time `db:"created_at" json:"created_at"`
 	UUID            string    `db:"uuid" json:"uuid"`
 	ReferenceNumber string    `db:"reference_number" json:"reference_number"`
 	Subject         string    `db:"subject" json:"subject"`
 	Status          string    `db:"status" json:"status"`
+	Rank            float64   `db:"rank" json:"rank"`
+	Snippet         string    `db:"snippet" json:"snippet"`
 }
 
 type MessageResult struct {
+	Total                       int       `db:"total" json:"-"`
 	CreatedAt                   time.Time `db:"created_at" json:"created_at"`
 	TextContent                 string    `db:"text_content" json:"text_content"`
 	ConversationCreatedAt       time.Time `db:"conversation_created_at" json:"conversation_created_at"`
 	ConversationUUID            string    `db:"conversation_uuid" json:"conversation_uuid"`
 	ConversationReferenceNumber string    `db:"conversation_reference_number" json:"conversation_reference_number"`
 	ConversationStatus          string    `db:"conversation_status" json:"conversation_status"`
+	Rank                        float64   `db:"rank" json:"rank"`
+	Snippet                     string    `db:"snippet" json:"snippet"`
 }
 
 type ContactResult struct {
@@ -1,40 +1,113 @@
--- name: search-conversations-by-reference-number
+-- name: search-conversations
+-- Matches the reference number, the contact email or the subject.
+-- $1 = query, $2-$8 = filters, $9-$15 = access, $16 = limit, $17 = offset
+WITH q AS (
+    SELECT websearch_to_tsquery('simple', $1) AS query
+),
+matches AS (
+    SELECT
+        COUNT(*) OVER() AS total,
+        c.id,
+        c.created_at,
+        c.uuid,
+        c.reference_number,
+        COALESCE(c.subject, '') AS subject,
+        cs.name AS status,
+        (CASE WHEN c.reference_number = $1 THEN 2 ELSE 0 END)
+        + (CASE WHEN u.email = lower($1) THEN 1 ELSE 0 END)
+        + ts_rank_cd(to_tsvector('simple', COALESCE(c.subject, '')), q.query) AS rank
+    FROM conversations c
+    CROSS JOIN q
+    JOIN users u ON u.id = c.contact_id
+    LEFT JOIN conversation_statuses cs ON cs.id = c.status_id
+    WHERE (
+        c.reference_number = $1
+        OR u.email = lower($1)
+        OR EXISTS (SELECT 1 FROM contact_identities ci WHERE ci.contact_id = c.contact_id AND ci.email = lower($1))
+        OR to_tsvector('simple', COALESCE(c.subject, '')) @@ q.query
+    )
+      -- Filters.
+      AND ($2::INT = 0 OR c.inbox_id = $2)
+      AND ($3::INT = 0 OR c.status_id = $3)
+      AND ($4::INT = 0 OR c.assigned_user_id = $4)
+      AND ($5::INT = 0 OR c.assigned_team_id = $5)
+      AND (cardinality($6::INT[]) = 0 OR EXISTS (
+          SELECT 1 FROM conversation_tags ct WHERE ct.conversation_id = c.id AND ct.tag_id = ANY($6::INT[])
+      ))
+      AND ($7::TIMESTAMPTZ IS NULL OR c.created_at >= $7)
+      AND ($8::TIMESTAMPTZ IS NULL OR c.created_at < $8)
+      -- Conversation access of the user.
+      AND (
+          $9::BOOLEAN
+          OR ($10::BOOLEAN AND c.assigned_user_id = $14)
+          OR ($11::BOOLEAN AND c.assigned_team_id = ANY($15::INT[]))
+          OR ($12::BOOLEAN AND c.assigned_user_id IS NULL AND c.assigned_team_id = ANY($15::INT[]))
+          OR ($13::BOOLEAN AND c.assigned_user_id IS NULL AND c.assigned_team_id IS NULL)
+      )
+    ORDER BY rank DESC, c.created_at DESC
+    LIMIT $16 OFFSET $17
+)
 SELECT
-    conversations.created_at,
-    conversations.uuid,
-    conversations.reference_number,
-    conversations.subject,
-    cs.name AS status
-FROM conversations
-LEFT JOIN conversation_statuses cs ON conversations.status_id = cs.id
-WHERE reference_number::text = $1;
-
--- name: search-conversations-by-contact-email
-SELECT
-    conversations.created_at,
-    conversations.uuid,
-    conversations.reference_number,
-    conversations.subject,
-    cs.name AS status
-FROM conversations
-JOIN users ON conversations.contact_id = users.id
-LEFT JOIN conversation_statuses cs ON conversations.status_id = cs.id
-WHERE users.email = $1
-ORDER BY conversations.created_at DESC
-LIMIT 1000;
+    matches.total,
+    matches.created_at,
+    matches.uuid,
+    matches.reference_number,
+    matches.subject,
+    matches.status,
+    matches.rank,
+    ts_headline('simple', matches.subject, q.query, 'StartSel=<mark>, StopSel=</mark>, HighlightAll=true') AS snippet
+FROM matches
+CROSS JOIN q
+ORDER BY matches.rank DESC, matches.created_at DESC;
 
 -- name: search-messages
+-- $1 = query, $2-$8 = filters, $9-$15 = access, $16 = limit, $17 = offset
+WITH q AS (
+    SELECT websearch_to_tsquery('simple', $1) AS query
+),
+matches AS (
+    SELECT
+        COUNT(*) OVER() AS total,
+        m.created_at,
+        COALESCE(m.text_content, '') AS text_content,
+        c.created_at AS conversation_created_at,
+        c.reference_number AS conversation_reference_number,
+        c.uuid AS conversation_uuid,
+        cs.name AS conversation_status,
+        ts_rank_cd(to_tsvector('simple', COALESCE(m.text_content, '')), q.query) AS rank
+    FROM conversation_messages m
+    CROSS JOIN q
+    JOIN conversations c ON c.id = m.conversation_id
+    LEFT JOIN conversation_statuses cs ON cs.id = c.status_id
+    WHERE m.type != 'activity'
+      AND to_tsvector('simple', COALESCE(m.text_content, '')) @@ q.query
+      -- Filters.
+      AND ($2::INT = 0 OR c.inbox_id = $2)
+      AND ($3::INT = 0 OR c.status_id = $3)
+      AND ($4::INT = 0 OR c.assigned_user_id = $4)
+      AND ($5::INT = 0 OR c.assigned_team_id = $5)
+      AND (cardinality($6::INT[]) = 0 OR EXISTS (
+          SELECT 1 FROM conversation_tags ct WHERE ct.conversation_id = c.id AND ct.tag_id = ANY($6::INT[])
+      ))
+      AND ($7::TIMESTAMPTZ IS NULL OR m.created_at >= $7)
+      AND ($8::TIMESTAMPTZ IS NULL OR m.created_at < $8)
+      -- Conversation access of the user.
+      AND (
+          $9::BOOLEAN
+          OR ($10::BOOLEAN AND c.assigned_user_id = $14)
+          OR ($11::BOOLEAN AND c.assigned_team_id = ANY($15::INT[]))
+          OR ($12::BOOLEAN AND c.assigned_user_id IS NULL AND c.assigned_team_id = ANY($15::INT[]))
+          OR ($13::BOOLEAN AND c.assigned_user_id IS NULL AND c.assigned_team_id IS NULL)
+      )
+    ORDER BY rank DESC, m.created_at DESC
+    LIMIT $16 OFFSET $17
+)
 SELECT
-    c.created_at as "conversation_created_at",
-    c.reference_number as "conversation_reference_number",
-    c.uuid as "conversation_uuid",
-    m.text_content,
-    cs.name as "conversation_status"
-FROM conversation_messages m
-    JOIN conversations c ON m.conversation_id = c.id
-    LEFT JOIN conversation_statuses cs ON c.status_id = cs.id
-WHERE m.type != 'activity' and m.text_content ILIKE '%' || $1 || '%'
-LIMIT 30;
+    matches.*,
+    ts_headline('simple', matches.text_content, q.query, 'StartSel=<mark>, StopSel=</mark>, MaxWords=35, MinWords=15, MaxFragments=2') AS snippet
+FROM matches
+CROSS JOIN q
+ORDER BY matches.rank DESC, matches.created_at DESC;
 
 -- name: search-contacts
 SELECT
@@ -9,6 +9,7 @@ import (
 	models "github.com/abhinavxd/libredesk/internal/search/models"
 	"github.com/jmoiron/sqlx"
 	"github.com/knadh/go-i18n"
+	"github.com/lib/pq"
 	"github.com/zerodha/logf"
 )
 
@@ -33,10 +34,9 @@ type Opts struct {
 
 // queries contains all the prepared queries
 type queries struct {
-	SearchConversationsByRefNum       *sqlx.Stmt `query:"search-conversations-by-reference-number"`
-	SearchConversationsByContactEmail *sqlx.Stmt `query:"search-conversations-by-contact-email"`
-	SearchMessages                    *sqlx.Stmt `query:"search-messages"`
-	SearchContacts                    *sqlx.Stmt `query:"search-contacts"`
+	SearchConversations *sqlx.Stmt `query:"search-conversations"`
+	SearchMessages      *sqlx.Stmt `query:"search-messages"`
+	SearchContacts      *sqlx.Stmt `query:"search-contacts"`
 }
 
 // New creates a new search manager
@@ -48,26 +48,20 @@ func New(opts Opts) (*Manager, error) {
 	return &Manager{q: q, lo: opts.Lo, i18n: opts.I18n}, nil
 }
 
-// Conversations searches conversations based on the query
-func (s *Manager) Conversations(query string) ([]models.ConversationResult, error) {
-	var refNumResults = make([]models.ConversationResult, 0)
-	if err := s.q.SearchConversationsByRefNum.Select(&refNumResults, query); err != nil {
+// Conversations searches conversations by reference number, contact email and subject.
+func (s *Manager) Conversations(query string, filters models.Filters, access models.Access, page, pageSize int) ([]models.ConversationResult, error) {
+	var results = make([]models.ConversationResult, 0)
+	if err := s.q.SearchConversations.Select(&results, makeSearchArgs(query, filters, access, page, pageSize)...); err != nil {
 		s.lo.Error("error searching conversations", "error", err)
 		return nil, envelope.NewError(envelope.GeneralError, s.i18n.T("globals.messages.somethingWentWrong"), nil)
 	}
-
-	var emailResults = make([]models.ConversationResult, 0)
-	if err := s.q.SearchConversationsByContactEmail.Select(&emailResults, query); err != nil {
-		s.lo.Error("error searching conversations", "error", err)
-		return nil, envelope.NewError(envelope.GeneralError, s.i18n.T("globals.messages.somethingWentWrong"), nil)
-	}
-	return append(refNumResults, emailResults...), nil
+	return results, nil
 }
 
-// Messages searches messages based on the query
-func (s *Manager) Messages(query string) ([]models.MessageResult, error) {
+// Messages runs a full-text search on message content.
+func (s *Manager) Messages(query string, filters models.Filters, access models.Access, page, pageSize int) ([]models.MessageResult, error) {
 	var results = make([]models.MessageResult, 0)
-	if err := s.q.SearchMessages.Select(&results, query); err != nil {
+	if err := s.q.SearchMessages.Select(&results, makeSearchArgs(query, filters, access, page, pageSize)...); err != nil {
 		s.lo.Error("error searching messages", "error", err)
 		return nil, envelope.NewError(envelope.GeneralError, s.i18n.T("globals.messages.somethingWentWrong"), nil)
 	}
@@ -83,3 +77,36 @@ func (s *Manager) Contacts(query string) ([]models.ContactResult, error) {
 	}
 	return results, nil
 }
+
+// makeSearchArgs returns the positional arguments shared by the conversation and message search queries.
+func makeSearchArgs(query string, filters models.Filters, access models.Access, page, pageSize int) []any {
+	if page < 1 {
+		page = 1
+	}
+	// nil slices are sent as NULL which never matches.
+	if filters.TagIDs == nil {
+		filters.TagIDs = []int{}
+	}
+	if access.TeamIDs == nil {
+		access.TeamIDs = []int{}
+	}
+	return []any{
+		query,
+		filters.InboxID,
+		filters.StatusID,
+		filters.AssignedUserID,
+		filters.AssignedTeamID,
+		pq.Array(filters.TagIDs),
+		filters.From,
+		filters.To,
+		access.ReadAll,
+		access.ReadAssigned,
+		access.ReadTeamAll,
+		access.ReadTeamInbox,
+		access.ReadUnassigned,
+		access.UserID,
+		pq.Array(access.TeamIDs),
+		pageSize,
+		(page - 1) * pageSize,
+	}
+}
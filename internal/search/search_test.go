@@ -0,0 +1,77 @@
+package search
+
+import (
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	models "github.com/abhinavxd/libredesk/internal/search/models"
+	"github.com/lib/pq"
+	"github.com/volatiletech/null/v9"
+)
+
+func TestMakeSearchArgs(t *testing.T) {
+	var (
+		from    = null.TimeFrom(time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC))
+		filters = models.Filters{InboxID: 1, StatusID: 2, AssignedUserID: 3, AssignedTeamID: 4, TagIDs: []int{5}, From: from}
+		access  = models.Access{UserID: 9, TeamIDs: []int{7, 8}, ReadAssigned: true, ReadTeamInbox: true}
+	)
+
+	got := makeSearchArgs("refund", filters, access, 3, 20)
+	want := []any{
+		"refund",
+		1, 2, 3, 4, pq.Array([]int{5}), from, null.Time{},
+		false, true, false, true, false, 9, pq.Array([]int{7, 8}),
+		20, 40,
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("makeSearchArgs() = %v, want %v", got, want)
+	}
+}
+
+func TestMakeSearchArgs_Defaults(t *testing.T) {
+	args := makeSearchArgs("refund", models.Filters{}, models.Access{}, 0, 10)
+
+	// Empty tag and team filters are sent as empty arrays as NULL never matches.
+	if got := args[5]; !reflect.DeepEqual(got, pq.Array([]int{})) {
+		t.Errorf("tag IDs = %#v, want an empty array", got)
+	}
+	if got := args[14]; !reflect.DeepEqual(got, pq.Array([]int{})) {
+		t.Errorf("team IDs = %#v, want an empty array", got)
+	}
+	// Pages start at 1.
+	if got := args[16]; got != 0 {
+		t.Errorf("offset = %v, want 0", got)
+	}
+}
+
+// TestMakeSearchArgs_Placeholders checks that the paginated search queries take exactly the arguments built by makeSearchArgs.
+func TestMakeSearchArgs_Placeholders(t *testing.T) {
+	b, err := efs.ReadFile("queries.sql")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var (
+		nArgs       = len(makeSearchArgs("", models.Filters{}, models.Access{}, 1, 10))
+		placeholder = regexp.MustCompile(`\$(\d+)`)
+	)
+	for _, name := range []string{"search-conversations", "search-messages"} {
+		_, query, ok := strings.Cut(string(b), "-- name: "+name+"\n")
+		if !ok {
+			t.Fatalf("query %s not found", name)
+		}
+		query, _, _ = strings.Cut(query, "-- name: ")
+
+		highest := 0
+		for _, m := range placeholder.FindAllStringSubmatch(query, -1) {
+			n, _ := strconv.Atoi(m[1])
+			highest = max(highest, n)
+		}
+		if highest != nArgs {
+			t.Errorf("%s uses %d arguments, makeSearchArgs returns %d", name, highest, nArgs)
+		}
+	}
+}
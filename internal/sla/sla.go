@@ -219,7 +219,7 @@ func (m *Manager) Delete(id int) error {
 func (m *Manager) GetDeadlines(startTime time.Time, slaPolicyID, assignedTeamID int) (Deadlines, error) {
 	var deadlines Deadlines
 
-	businessHrs, timezone, err := m.getBusinessHoursAndTimezone(assignedTeamID)
+	businessHrs, timezone, err := m.GetBusinessHoursAndTimezone(assignedTeamID)
 	if err != nil {
 		return deadlines, err
 	}
@@ -745,8 +745,9 @@ func (m *Manager) Close() error {
 	return nil
 }
 
-// getBusinessHoursAndTimezone returns the business hours ID and timezone for a team, falling back to app settings i.e. default helpdesk settings.
-func (m *Manager) getBusinessHoursAndTimezone(assignedTeamID int) (bmodels.BusinessHours, string, error) {
+// GetBusinessHoursAndTimezone returns the business hours and timezone for a team, falling back to app settings i.e. default helpdesk settings.
+// It returns businesshours.ErrBusinessHoursNotConfigured if neither the team nor the app settings set them.
+func (m *Manager) GetBusinessHoursAndTimezone(assignedTeamID int) (bmodels.BusinessHours, string, error) {
 	var (
 		businessHrsID int
 		timezone      string
@@ -781,7 +782,7 @@ func (m *Manager) getBusinessHoursAndTimezone(assignedTeamID int) (bmodels.Busin
 
 	// If still not found, return error.
 	if businessHrsID == 0 || timezone == "" {
-		return bh, "", fmt.Errorf("business hours or timezone not configured")
+		return bh, "", businesshours.ErrBusinessHoursNotConfigured
 	}
 
 	bh, err := m.businessHrsStore.Get(businessHrsID)
@@ -0,0 +1,120 @@
+package stringutil
+
+import (
+	"html"
+	"io"
+	"net/url"
+	"strings"
+
+	xhtml "golang.org/x/net/html"
+)
+
+var (
+	// sanitizeAllowedTags are the elements kept by SanitizeHTML, other elements are dropped but their text is kept.
+	sanitizeAllowedTags = map[string]bool{
+		"a": true, "b": true, "blockquote": true, "br": true, "code": true, "del": true, "div": true, "em": true,
+		"h1": true, "h2": true, "h3": true, "h4": true, "h5": true, "h6": true, "hr": true, "i": true, "img": true,
+		"li": true, "mark": true, "ol": true, "p": true, "pre": true, "s": true, "span": true, "strike": true,
+		"strong": true, "sub": true, "sup": true, "table": true, "tbody": true, "td": true, "tfoot": true, "th": true,
+		"thead": true, "tr": true, "u": true, "ul": true,
+	}
+
+	// sanitizeDroppedTags are the elements dropped by SanitizeHTML along with their content.
+	sanitizeDroppedTags = map[string]bool{
+		"script": true, "style": true, "iframe": true, "object": true, "embed": true, "noscript": true,
+		"template": true, "textarea": true, "select": true, "svg": true, "math": true, "title": true,
+	}
+
+	// sanitizeAllowedAttrs are the attributes kept by SanitizeHTML per element, "*" applies to all elements.
+	sanitizeAllowedAttrs = map[string]map[string]bool{
+		"*":   {"class": true, "title": true},
+		"a":   {"href": true, "target": true},
+		"img": {"src": true, "alt": true, "width": true, "height": true},
+		"td":  {"colspan": true, "rowspan": true},
+		"th":  {"colspan": true, "rowspan": true},
+		"ol":  {"start": true},
+	}
+
+	// sanitizeURLSchemes are the URL schemes allowed in href and src attributes, relative URLs are always allowed.
+	sanitizeURLSchemes = map[string]bool{"http": true, "https": true, "mailto": true, "tel": true}
+)
+
+// SanitizeHTML returns the HTML with only an allowlist of elements, attributes and URL schemes so that it is safe to
+// render on a page, e.g. scripts, event handlers and javascript: URLs are removed.
+func SanitizeHTML(s string) string {
+	var (
+		b        strings.Builder
+		z        = xhtml.NewTokenizer(strings.NewReader(s))
+		dropping string
+		depth    int
+	)
+	for {
+		tt := z.Next()
+		if tt == xhtml.ErrorToken {
+			if z.Err() != io.EOF {
+				return ""
+			}
+			return b.String()
+		}
+		tok := z.Token()
+
+		// Skip everything inside a dropped element, tracking nested elements of the same name.
+		if dropping != "" {
+			switch {
+			case tt == xhtml.StartTagToken && tok.Data == dropping:
+				depth++
+			case tt == xhtml.EndTagToken && tok.Data == dropping:
+				if depth--; depth == 0 {
+					dropping = ""
+				}
+			}
+			continue
+		}
+
+		switch tt {
+		case xhtml.TextToken:
+			b.WriteString(html.EscapeString(tok.Data))
+		case xhtml.StartTagToken, xhtml.SelfClosingTagToken:
+			if sanitizeDroppedTags[tok.Data] {
+				if tt == xhtml.StartTagToken {
+					dropping, depth = tok.Data, 1
+				}
+				continue
+			}
+			if !sanitizeAllowedTags[tok.Data] {
+				continue
+			}
+			b.WriteString("<" + tok.Data)
+			for _, a := range tok.Attr {
+				key := strings.ToLower(a.Key)
+				if a.Namespace != "" || !(sanitizeAllowedAttrs["*"][key] || sanitizeAllowedAttrs[tok.Data][key]) {
+					continue
+				}
+				if (key == "href" || key == "src") && !safeURL(a.Val) {
+					continue
+				}
+				b.WriteString(" " + key + `="` + html.EscapeString(a.Val) + `"`)
+			}
+			if tok.Data == "a" {
+				b.WriteString(` rel="noopener noreferrer nofollow"`)
+			}
+			if tt == xhtml.SelfClosingTagToken {
+				b.WriteString(" /")
+			}
+			b.WriteString(">")
+		case xhtml.EndTagToken:
+			if sanitizeAllowedTags[tok.Data] {
+				b.WriteString("</" + tok.Data + ">")
+			}
+		}
+	}
+}
+
+// safeURL reports whether the URL is relative or has an allowed scheme.
+func safeURL(v string) bool {
+	u, err := url.Parse(strings.TrimSpace(v))
+	if err != nil {
+		return false
+	}
+	return u.Scheme == "" || sanitizeURLSchemes[strings.ToLower(u.Scheme)]
+}
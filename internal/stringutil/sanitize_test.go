@@ -0,0 +1,49 @@
+package stringutil
+
+import "testing"
+
+func TestSanitizeHTML(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		want  string
+	}{
+		{
+			name:  "formatting is kept",
+			input: `<p>Hello <strong>world</strong><br><a href="https://example.com/a?b=1&amp;c=2">link</a></p>`,
+			want:  `<p>Hello <strong>world</strong><br><a href="https://example.com/a?b=1&amp;c=2" rel="noopener noreferrer nofollow">link</a></p>`,
+		},
+		{
+			name:  "script is dropped with its content",
+			input: `<p>a</p><script>alert(1)</script><p>b</p>`,
+			want:  `<p>a</p><p>b</p>`,
+		},
+		{
+			name:  "event handlers are dropped",
+			input: `<img src="x.png" onerror="alert(1)"><p onclick="alert(1)">c</p>`,
+			want:  `<img src="x.png"><p>c</p>`,
+		},
+		{
+			name:  "javascript urls are dropped",
+			input: `<a href="javascript:alert(1)">x</a><a href=" JaVaScRiPt:alert(1)">y</a>`,
+			want:  `<a rel="noopener noreferrer nofollow">x</a><a rel="noopener noreferrer nofollow">y</a>`,
+		},
+		{
+			name:  "unknown elements keep their text",
+			input: `<form><button>press</button></form><svg><script>alert(1)</script></svg>`,
+			want:  `press`,
+		},
+		{
+			name:  "text is escaped",
+			input: `1 &lt; 2 &amp;&amp; <b>"quoted"</b>`,
+			want:  `1 &lt; 2 &amp;&amp; <b>&#34;quoted&#34;</b>`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := SanitizeHTML(tt.input); got != tt.want {
+				t.Errorf("SanitizeHTML() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
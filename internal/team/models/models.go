@@ -6,6 +6,7 @@ import (
 	"fmt"
 	"time"
 
+	"github.com/lib/pq"
 	"github.com/volatiletech/null/v9"
 )
 
@@ -29,9 +30,13 @@ type TeamCompact struct {
 }
 
 type TeamMember struct {
-	ID                 int    `db:"id" json:"id"`
-	AvailabilityStatus string `db:"availability_status" json:"availability_status"`
-	TeamID             int    `db:"team_id" json:"team_id"`
+	ID                 int             `db:"id" json:"id"`
+	AvailabilityStatus string          `db:"availability_status" json:"availability_status"`
+	TeamID             int             `db:"team_id" json:"team_id"`
+	Skills             pq.StringArray  `db:"skills" json:"skills"`
+	Capacity           null.Int        `db:"capacity" json:"capacity"`
+	ChannelWeights     json.RawMessage `db:"channel_weights" json:"channel_weights"`
+	OffShift           bool            `db:"off_shift" json:"off_shift"`
 }
 
 type TeamsCompact []TeamCompact
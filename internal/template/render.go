@@ -3,12 +3,16 @@ package template
 import (
 	"bytes"
 	"fmt"
+	"regexp"
 	"strings"
 	"text/template"
 
 	"github.com/valyala/fasthttp"
 )
 
+// regexpSignatureField matches references to the signature field of the template data, e.g. `{{ .Signature }}`.
+var regexpSignatureField = regexp.MustCompile(`\.Signature\b`)
+
 const (
 	// Built-in templates names stored in the database.
 	TmplConversationAssigned = "Conversation assigned"
@@ -50,6 +54,23 @@ func (m *Manager) RenderStoredTemplate(name string, data any) (string, error) {
 	return m.RenderString(data, tmpl.Body), nil
 }
 
+// OutgoingEmailTemplateUsesSignature reports whether the default outgoing email template places the signature with `{{ .Signature }}`.
+func (m *Manager) OutgoingEmailTemplateUsesSignature() bool {
+	m.mutex.RLock()
+	defer m.mutex.RUnlock()
+
+	tmpl, err := m.getDefaultOutgoingEmailTemplate()
+	if err != nil {
+		return false
+	}
+	return usesSignature(tmpl.Body)
+}
+
+// usesSignature reports whether the template body references the `.Signature` field.
+func usesSignature(body string) bool {
+	return regexpSignatureField.MatchString(body)
+}
+
 // RenderEmailWithTemplate renders content inside the default outgoing email template.
 func (m *Manager) RenderEmailWithTemplate(data any, content string) (string, error) {
 	m.mutex.RLock()
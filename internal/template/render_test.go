@@ -0,0 +1,25 @@
+package template
+
+import "testing"
+
+func TestUsesSignature(t *testing.T) {
+	testCases := []struct {
+		name     string
+		body     string
+		expected bool
+	}{
+		{name: "Signature field", body: `{{ template "content" . }}<div>{{ .Signature }}</div>`, expected: true},
+		{name: "Signature in condition", body: `{{ if .Signature }}<hr>{{ .Signature }}{{ end }}`, expected: true},
+		{name: "No signature", body: `{{ template "content" . }}`, expected: false},
+		{name: "Other field with the same prefix", body: `{{ .SignatureImage }}`, expected: false},
+		{name: "Signature text", body: `<p>Signature</p>`, expected: false},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := usesSignature(tc.body); got != tc.expected {
+				t.Errorf("usesSignature(%q) = %v, want %v", tc.body, got, tc.expected)
+			}
+		})
+	}
+}
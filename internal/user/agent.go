@@ -2,12 +2,16 @@ package user
 
 import (
 	"context"
+	"encoding/json"
 	"strings"
 	"time"
 
+	businesshours "github.com/abhinavxd/libredesk/internal/business_hours"
+	bhmodels "github.com/abhinavxd/libredesk/internal/business_hours/models"
 	"github.com/abhinavxd/libredesk/internal/dbutil"
 	"github.com/abhinavxd/libredesk/internal/envelope"
 	"github.com/abhinavxd/libredesk/internal/user/models"
+	"github.com/jmoiron/sqlx/types"
 	"github.com/lib/pq"
 	"github.com/volatiletech/null/v9"
 	"golang.org/x/crypto/bcrypt"
@@ -29,6 +33,24 @@ func (u *Manager) MonitorUserAvailability(ctx context.Context, onUsersOffline fu
 	}
 }
 
+// MonitorAgentShifts sets agents with a shift schedule online when their shift starts and offline when it ends.
+func (u *Manager) MonitorAgentShifts(ctx context.Context, onShiftChange func(models.ShiftChange)) {
+	ticker := time.NewTicker(time.Minute)
+	defer ticker.Stop()
+	for {
+		for _, change := range u.UpdateAgentShifts(time.Now()) {
+			if onShiftChange != nil {
+				onShiftChange(change)
+			}
+		}
+		select {
+		case <-ticker.C:
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
 // GetAgent retrieves an agent by ID and also caches it for future requests.
 func (u *Manager) GetAgent(id int, email string) (models.User, error) {
 	agent, err := u.Get(id, email, []string{models.UserTypeAgent})
@@ -139,6 +161,133 @@ func (u *Manager) UpdateAgent(id int, firstName, lastName, email string, roles [
 	return nil
 }
 
+// UpdateAgentSkills sets the skills of an agent, skills are trimmed, lowercased and deduplicated.
+func (u *Manager) UpdateAgentSkills(id int, skills []string) error {
+	var (
+		seen    = make(map[string]struct{}, len(skills))
+		cleaned = make([]string, 0, len(skills))
+	)
+	for _, s := range skills {
+		s = strings.ToLower(strings.TrimSpace(s))
+		if _, ok := seen[s]; ok || s == "" {
+			continue
+		}
+		seen[s] = struct{}{}
+		cleaned = append(cleaned, s)
+	}
+	if _, err := u.q.UpdateAgentSkills.Exec(id, pq.Array(cleaned)); err != nil {
+		u.lo.Error("error updating agent skills", "user_id", id, "error", err)
+		return envelope.NewError(envelope.GeneralError, u.i18n.T("globals.messages.somethingWentWrong"), nil)
+	}
+	u.InvalidateAgentCache(id)
+	return nil
+}
+
+// UpdateAgentCapacity sets the capacity override and channel weights of an agent, a null capacity uses the team limits.
+func (u *Manager) UpdateAgentCapacity(id int, capacity null.Int, weights models.ChannelWeights) error {
+	if _, err := u.q.UpdateAgentCapacity.Exec(id, capacity, weights); err != nil {
+		u.lo.Error("error updating agent capacity", "user_id", id, "error", err)
+		return envelope.NewError(envelope.GeneralError, u.i18n.T("globals.messages.somethingWentWrong"), nil)
+	}
+	u.InvalidateAgentCache(id)
+	return nil
+}
+
+// UpdateAgentEmailSignature sets the signature appended to the email replies of an agent, an empty signature removes it.
+func (u *Manager) UpdateAgentEmailSignature(id int, signature string) error {
+	signature = strings.TrimSpace(signature)
+	if _, err := u.q.UpdateAgentEmailSignature.Exec(id, null.NewString(signature, signature != "")); err != nil {
+		u.lo.Error("error updating agent email signature", "user_id", id, "error", err)
+		return envelope.NewError(envelope.GeneralError, u.i18n.T("globals.messages.somethingWentWrong"), nil)
+	}
+	u.InvalidateAgentCache(id)
+	return nil
+}
+
+// GetAgentsLoad returns the active conversations and capacity of enabled agents.
+func (u *Manager) GetAgentsLoad() ([]models.AgentLoad, error) {
+	var loads = make([]models.AgentLoad, 0)
+	if err := u.q.GetAgentsLoad.Select(&loads); err != nil {
+		u.lo.Error("error fetching agents load", "error", err)
+		return loads, envelope.NewError(envelope.GeneralError, u.i18n.T("globals.messages.somethingWentWrong"), nil)
+	}
+	for i := range loads {
+		loads[i].Load = loads[i].ChannelWeights.Load(loads[i].Conversations)
+	}
+	return loads, nil
+}
+
+// UpdateAgentShift sets the weekly shift of an agent, nil hours removes the shift.
+func (u *Manager) UpdateAgentShift(id int, hours json.RawMessage, timezone null.String, reassign bool) error {
+	// Insert NULL and not an empty JSON value.
+	var shiftHours any
+	if len(hours) > 0 && string(hours) != "null" {
+		shiftHours = hours
+	}
+	if _, err := u.q.UpdateAgentShift.Exec(id, shiftHours, timezone, reassign); err != nil {
+		u.lo.Error("error updating agent shift", "user_id", id, "error", err)
+		return envelope.NewError(envelope.GeneralError, u.i18n.T("globals.messages.somethingWentWrong"), nil)
+	}
+	u.InvalidateAgentCache(id)
+	return nil
+}
+
+// UpdateAgentShifts sets agents online or offline whose shift started or ended as of t and returns them.
+func (u *Manager) UpdateAgentShifts(t time.Time) []models.ShiftChange {
+	var agents []models.AgentShift
+	if err := u.q.GetAgentShifts.Select(&agents); err != nil {
+		u.lo.Error("error fetching agent shifts", "error", err)
+		return nil
+	}
+
+	var changes []models.ShiftChange
+	for _, a := range agents {
+		onDuty, err := businesshours.IsOpen(bhmodels.BusinessHours{Hours: types.JSONText(a.ShiftHours)}, a.ShiftTimezone, t)
+		if err != nil {
+			u.lo.Error("error checking agent shift", "user_id", a.ID, "error", err)
+			continue
+		}
+		if a.ShiftOnDuty.Valid && a.ShiftOnDuty.Bool == onDuty {
+			continue
+		}
+
+		status := shiftStatus(a, onDuty)
+		if _, err := u.q.UpdateAgentShiftState.Exec(a.ID, onDuty, status); err != nil {
+			u.lo.Error("error updating agent shift state", "user_id", a.ID, "error", err)
+			continue
+		}
+		u.InvalidateAgentCache(a.ID)
+		if !onDuty && !a.ShiftOnDuty.Valid {
+			continue
+		}
+		u.lo.Info("agent shift changed", "user_id", a.ID, "on_duty", onDuty, "availability_status", status)
+
+		changes = append(changes, models.ShiftChange{
+			UserID:   a.ID,
+			OnDuty:   onDuty,
+			Status:   status,
+			Reassign: !onDuty && a.ShiftReassign,
+		})
+	}
+	return changes
+}
+
+// shiftStatus returns the availability status of the agent after their shift starts or ends.
+func shiftStatus(a models.AgentShift, onDuty bool) string {
+	switch {
+	case onDuty && a.AvailabilityStatus == models.Offline:
+		return models.Online
+	case onDuty:
+		// A status the agent set, e.g. away, is kept when the shift starts.
+	case !a.ShiftOnDuty.Valid:
+		// The first check after a schedule change outside the shift is not the end of a shift, keep the status.
+	case a.AvailabilityStatus == models.Online:
+		// Only undo the online status set at the start of the shift, a status the agent set, e.g. away, is kept.
+		return models.Offline
+	}
+	return a.AvailabilityStatus
+}
+
 // SoftDeleteAgent soft deletes an agent by ID.
 func (u *Manager) SoftDeleteAgent(id int) error {
 	// Disallow if user is system user.
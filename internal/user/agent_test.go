@@ -0,0 +1,35 @@
+package user
+
+import (
+	"testing"
+
+	"github.com/abhinavxd/libredesk/internal/user/models"
+	"github.com/volatiletech/null/v9"
+)
+
+func TestShiftStatus(t *testing.T) {
+	tests := []struct {
+		name   string
+		status string
+		// wasOnDuty is the shift state stored at the previous check, null right after a schedule change.
+		wasOnDuty null.Bool
+		onDuty    bool
+		want      string
+	}{
+		{name: "shift starts", status: models.Offline, wasOnDuty: null.BoolFrom(false), onDuty: true, want: models.Online},
+		{name: "shift starts while away", status: models.AwayManual, wasOnDuty: null.BoolFrom(false), onDuty: true, want: models.AwayManual},
+		{name: "shift starts while away and reassigning", status: models.AwayAndReassigning, wasOnDuty: null.BoolFrom(false), onDuty: true, want: models.AwayAndReassigning},
+		{name: "schedule set during the shift", status: models.Offline, onDuty: true, want: models.Online},
+		{name: "shift ends", status: models.Online, wasOnDuty: null.BoolFrom(true), onDuty: false, want: models.Offline},
+		{name: "shift ends while away", status: models.AwayManual, wasOnDuty: null.BoolFrom(true), onDuty: false, want: models.AwayManual},
+		{name: "schedule set outside the shift", status: models.Online, onDuty: false, want: models.Online},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			a := models.AgentShift{AvailabilityStatus: tt.status, ShiftOnDuty: tt.wasOnDuty}
+			if got := shiftStatus(a, tt.onDuty); got != tt.want {
+				t.Errorf("shiftStatus() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -1,6 +1,8 @@
 package user
 
 import (
+	"database/sql"
+	"errors"
 	"fmt"
 	"strings"
 
@@ -105,3 +107,50 @@ func (u *Manager) GetContacts(page, pageSize int, order, orderBy string, filters
 	}
 	return u.GetAllUsers(page, pageSize, []string{models.UserTypeContact, models.UserTypeVisitor}, order, orderBy, filtersJSON)
 }
+
+// MergeContacts moves conversations, notes, custom attributes and email identities of the source contact
+// into the target contact and deletes the source. The source email is kept as a secondary identity of the target.
+func (u *Manager) MergeContacts(sourceID, targetID int) (models.ContactMergeResult, error) {
+	var result models.ContactMergeResult
+
+	if sourceID == targetID {
+		return result, envelope.NewError(envelope.InputError, u.i18n.T("contact.cannotMergeSameContact"), nil)
+	}
+
+	// Source can be a contact or a visitor, target must be a contact.
+	if _, err := u.GetContactOrVisitor(sourceID, ""); err != nil {
+		return result, err
+	}
+	if _, err := u.Get(targetID, "", []string{models.UserTypeContact}); err != nil {
+		return result, err
+	}
+
+	if err := u.q.MergeContacts.Get(&result, sourceID, targetID); err != nil {
+		u.lo.Error("error merging contacts", "source_id", sourceID, "target_id", targetID, "error", err)
+		return result, envelope.NewError(envelope.GeneralError, u.i18n.T("globals.messages.somethingWentWrong"), nil)
+	}
+	return result, nil
+}
+
+// GetContactIdentities returns the secondary email identities of a contact.
+func (u *Manager) GetContactIdentities(contactID int) ([]models.ContactIdentity, error) {
+	var identities = make([]models.ContactIdentity, 0)
+	if err := u.q.GetContactIdentities.Select(&identities, contactID); err != nil {
+		u.lo.Error("error fetching contact identities", "contact_id", contactID, "error", err)
+		return identities, envelope.NewError(envelope.GeneralError, u.i18n.T("globals.messages.somethingWentWrong"), nil)
+	}
+	return identities, nil
+}
+
+// GetContactIDByIdentity returns the ID of the contact owning the given secondary email, 0 if none.
+func (u *Manager) GetContactIDByIdentity(email string) (int, error) {
+	var id int
+	if err := u.q.GetContactIDByIdentity.Get(&id, strings.TrimSpace(email)); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return 0, nil
+		}
+		u.lo.Error("error fetching contact by identity", "email", email, "error", err)
+		return 0, fmt.Errorf("fetching contact by identity: %w", err)
+	}
+	return id, nil
+}
@@ -0,0 +1,39 @@
+package user
+
+import (
+	"errors"
+	"os"
+	"testing"
+
+	"github.com/abhinavxd/libredesk/internal/envelope"
+	"github.com/knadh/go-i18n"
+	"github.com/zerodha/logf"
+)
+
+func newTestManager(t *testing.T) *Manager {
+	t.Helper()
+	b, err := os.ReadFile("../../i18n/en.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	i, err := i18n.New(b)
+	if err != nil {
+		t.Fatal(err)
+	}
+	lo := logf.New(logf.Opts{})
+	return &Manager{i18n: i, lo: &lo}
+}
+
+func TestMergeContacts_SameContact(t *testing.T) {
+	m := newTestManager(t)
+
+	// Rejected before any contact is looked up.
+	_, err := m.MergeContacts(3, 3)
+	var envErr envelope.Error
+	if !errors.As(err, &envErr) || envErr.ErrorType != envelope.InputError {
+		t.Fatalf("MergeContacts() error = %v, want an input error", err)
+	}
+	if envErr.Message != m.i18n.T("contact.cannotMergeSameContact") {
+		t.Errorf("MergeContacts() error message = %q", envErr.Message)
+	}
+}
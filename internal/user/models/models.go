@@ -1,7 +1,9 @@
 package models
 
 import (
+	"database/sql/driver"
 	"encoding/json"
+	"fmt"
 	"slices"
 	"time"
 
@@ -69,6 +71,15 @@ type User struct {
 	CustomAttributes       json.RawMessage      `db:"custom_attributes" json:"custom_attributes"`
 	ExternalUserID         null.String          `db:"external_user_id" json:"external_user_id"`
 	Teams                  tmodels.TeamsCompact `db:"teams" json:"teams"`
+	Skills                 pq.StringArray       `db:"skills" json:"skills"`
+	Capacity               null.Int             `db:"capacity" json:"capacity"`
+	ChannelWeights         ChannelWeights       `db:"channel_weights" json:"channel_weights"`
+	ShiftHours             json.RawMessage      `db:"shift_hours" json:"shift_hours"`
+	ShiftTimezone          null.String          `db:"shift_timezone" json:"shift_timezone"`
+	ShiftReassign          bool                 `db:"shift_reassign" json:"shift_reassign"`
+	ShiftOnDuty            null.Bool            `db:"shift_on_duty" json:"shift_on_duty"`
+	EmailUndeliverable     bool                 `db:"email_undeliverable" json:"email_undeliverable"`
+	EmailSignature         null.String          `db:"email_signature" json:"email_signature"`
 	ContactChannelID       int                  `db:"contact_channel_id" json:"contact_channel_id,omitempty"`
 	NewPassword            string               `db:"-" json:"new_password,omitempty"`
 	SendWelcomeEmail       bool                 `db:"-" json:"send_welcome_email,omitempty"`
@@ -105,6 +116,115 @@ type Note struct {
 	AvatarURL null.String `db:"avatar_url" json:"avatar_url"`
 }
 
+// ContactIdentity is a secondary email address of a contact.
+type ContactIdentity struct {
+	ID        int       `db:"id" json:"id"`
+	CreatedAt time.Time `db:"created_at" json:"created_at"`
+	UpdatedAt time.Time `db:"updated_at" json:"updated_at"`
+	ContactID int       `db:"contact_id" json:"contact_id"`
+	Email     string    `db:"email" json:"email"`
+}
+
+// ContactMergeResult holds the number of records moved by a contact merge.
+type ContactMergeResult struct {
+	ConversationsTransferred int  `db:"conversations_transferred" json:"conversations_transferred"`
+	MessagesTransferred      int  `db:"messages_transferred" json:"messages_transferred"`
+	NotesTransferred         int  `db:"notes_transferred" json:"notes_transferred"`
+	IdentitiesTransferred    int  `db:"identities_transferred" json:"identities_transferred"`
+	SourceDeleted            bool `db:"source_deleted" json:"source_deleted"`
+}
+
+// AgentLoad is the current load of an agent against their capacity.
+type AgentLoad struct {
+	ID                 int            `db:"id" json:"id"`
+	FirstName          string         `db:"first_name" json:"first_name"`
+	LastName           string         `db:"last_name" json:"last_name"`
+	AvatarURL          null.String    `db:"avatar_url" json:"avatar_url"`
+	AvailabilityStatus string         `db:"availability_status" json:"availability_status"`
+	CapacityOverride   null.Int       `db:"capacity_override" json:"capacity_override"`
+	Capacity           null.Int       `db:"capacity" json:"capacity"`
+	ChannelWeights     ChannelWeights `db:"channel_weights" json:"channel_weights"`
+	Conversations      ChannelCounts  `db:"conversations" json:"conversations"`
+	Load               float64        `db:"-" json:"load"`
+}
+
+// ChannelWeights is the load a conversation of each channel adds to an agent, unlisted channels weigh 1.
+type ChannelWeights map[string]float64
+
+// Weight returns the weight of a channel.
+func (w ChannelWeights) Weight(channel string) float64 {
+	if v, ok := w[channel]; ok && v > 0 {
+		return v
+	}
+	return 1
+}
+
+// Load returns the load of active conversations counted by channel.
+func (w ChannelWeights) Load(conversations map[string]int) float64 {
+	var load float64
+	for channel, n := range conversations {
+		load += float64(n) * w.Weight(channel)
+	}
+	return load
+}
+
+// Scan implements the sql.Scanner interface for ChannelWeights.
+func (w *ChannelWeights) Scan(src any) error {
+	switch v := src.(type) {
+	case nil:
+		*w = nil
+		return nil
+	case []byte:
+		return json.Unmarshal(v, w)
+	default:
+		return fmt.Errorf("unsupported type for ChannelWeights: %T", src)
+	}
+}
+
+// Value implements the driver.Valuer interface for ChannelWeights.
+func (w ChannelWeights) Value() (driver.Value, error) {
+	if w == nil {
+		return []byte("{}"), nil
+	}
+	return json.Marshal(w)
+}
+
+// ChannelCounts is a number of conversations by channel.
+type ChannelCounts map[string]int
+
+// Scan implements the sql.Scanner interface for ChannelCounts.
+func (c *ChannelCounts) Scan(src any) error {
+	switch v := src.(type) {
+	case nil:
+		*c = nil
+		return nil
+	case []byte:
+		return json.Unmarshal(v, c)
+	default:
+		return fmt.Errorf("unsupported type for ChannelCounts: %T", src)
+	}
+}
+
+// AgentShift is the weekly shift of an agent, the hours are in the business hours format.
+type AgentShift struct {
+	ID                 int             `db:"id"`
+	AvailabilityStatus string          `db:"availability_status"`
+	ShiftHours         json.RawMessage `db:"shift_hours"`
+	ShiftTimezone      string          `db:"shift_timezone"`
+	ShiftReassign      bool            `db:"shift_reassign"`
+	ShiftOnDuty        null.Bool       `db:"shift_on_duty"`
+}
+
+// ShiftChange is an agent whose shift started or ended.
+type ShiftChange struct {
+	UserID int
+	OnDuty bool
+	// Status is the availability status the agent was set to.
+	Status string
+	// Reassign is set when the shift ended and the open conversations of the agent are to be unassigned.
+	Reassign bool
+}
+
 type OfflineUser struct {
 	ID   int    `db:"id"`
 	Type string `db:"type"`
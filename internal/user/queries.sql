@@ -45,6 +45,15 @@ SELECT
     u.api_key_last_used_at,
     u.external_user_id,
     u.api_secret,
+    u.skills,
+    u.capacity,
+    u.channel_weights,
+    u.shift_hours,
+    u.shift_timezone,
+    u.shift_reassign,
+    u.shift_on_duty,
+    u.email_undeliverable,
+    u.email_signature,
     array_agg(DISTINCT r.name) FILTER (WHERE r.name IS NOT NULL) AS roles,
     COALESCE(
         (SELECT json_agg(json_build_object('id', t.id, 'name', t.name, 'emoji', t.emoji))
@@ -114,6 +123,72 @@ UPDATE users
 SET avatar_url = $2, updated_at = now()
 WHERE id = $1;
 
+-- name: update-agent-skills
+UPDATE users SET skills = $2, updated_at = now() WHERE id = $1 AND type = 'agent';
+
+-- name: update-agent-capacity
+UPDATE users SET capacity = $2, channel_weights = $3, updated_at = now() WHERE id = $1 AND type = 'agent';
+
+-- name: update-agent-email-signature
+UPDATE users SET email_signature = $2, updated_at = now() WHERE id = $1 AND type = 'agent';
+
+-- name: update-agent-shift
+-- The shift state is reset when the schedule changes so that it is applied on the next check.
+UPDATE users
+SET shift_hours = $2,
+    shift_timezone = $3,
+    shift_reassign = $4,
+    shift_on_duty = CASE
+        WHEN shift_hours IS NOT DISTINCT FROM $2::jsonb AND shift_timezone IS NOT DISTINCT FROM $3 THEN shift_on_duty
+        ELSE NULL
+    END,
+    updated_at = now()
+WHERE id = $1 AND type = 'agent';
+
+-- name: get-agent-shifts
+SELECT id, availability_status, shift_hours, COALESCE(shift_timezone, '') AS shift_timezone, shift_reassign, shift_on_duty
+FROM users
+WHERE type = 'agent' AND deleted_at IS NULL AND enabled = true AND shift_hours IS NOT NULL;
+
+-- name: update-agent-shift-state
+UPDATE users
+SET shift_on_duty = $2,
+    availability_status = $3
+WHERE id = $1;
+
+-- name: get-agents-load
+-- Capacity is the agent override or else the lowest limit among their teams, null is unlimited.
+SELECT
+    u.id,
+    u.first_name,
+    u.last_name,
+    u.avatar_url,
+    u.availability_status,
+    u.capacity AS capacity_override,
+    COALESCE(
+        u.capacity,
+        (SELECT MIN(t.max_auto_assigned_conversations)
+         FROM team_members tm
+         JOIN teams t ON t.id = tm.team_id
+         WHERE tm.user_id = u.id AND t.max_auto_assigned_conversations > 0)
+    ) AS capacity,
+    u.channel_weights,
+    COALESCE(
+        (SELECT json_object_agg(x.channel, x.count)
+         FROM (
+            SELECT inb.channel, COUNT(*) AS count
+            FROM conversations c
+            JOIN inboxes inb ON inb.id = c.inbox_id
+            WHERE c.assigned_user_id = u.id
+                AND c.status_id IN (SELECT id FROM conversation_statuses WHERE name NOT IN ('Resolved', 'Closed'))
+            GROUP BY inb.channel
+         ) x),
+        '{}'
+    ) AS conversations
+FROM users u
+WHERE u.type = 'agent' AND u.deleted_at IS NULL AND u.enabled = true
+ORDER BY u.first_name, u.last_name;
+
 -- name: update-availability
 UPDATE users
 SET availability_status = $2
@@ -132,6 +207,8 @@ WHERE
   type IN ('agent', 'contact', 'visitor')
   AND (last_active_at IS NULL OR last_active_at < NOW() - INTERVAL '5 minutes')
   AND availability_status NOT IN ('offline', 'away_and_reassigning', 'away_manual')
+  -- Agents on shift stay online until their shift ends.
+  AND shift_on_duty IS NOT TRUE
 RETURNING id, type;
 
 -- name: get-availability-status
@@ -189,6 +266,10 @@ SELECT EXISTS(
     WHERE email = $1 AND type IN ('contact', 'visitor') AND deleted_at IS NULL AND enabled = false
 ) AS is_blocked;
 
+-- name: set-email-undeliverable
+UPDATE users SET email_undeliverable = true, updated_at = now()
+WHERE email = $1 AND type = 'contact' AND deleted_at IS NULL;
+
 -- name: set-external-user-id
 UPDATE users SET external_user_id = $2, updated_at = now()
 WHERE id = $1 AND type = 'contact' AND deleted_at IS NULL;
@@ -214,6 +295,7 @@ UPDATE users
 SET first_name = COALESCE($2, first_name),
     last_name = COALESCE($3, last_name),
     email = COALESCE($4, email),
+    email_undeliverable = email_undeliverable AND email IS NOT DISTINCT FROM COALESCE($4, email),
     avatar_url = $5,
     phone_number = $6,
     phone_number_country_code = $7,
@@ -226,6 +308,7 @@ UPDATE users
 SET first_name = COALESCE(NULLIF($2, ''), first_name),
     last_name = COALESCE(NULLIF($3, ''), last_name),
     email = COALESCE(NULLIF($4, ''), email),
+    email_undeliverable = email_undeliverable AND email IS NOT DISTINCT FROM COALESCE(NULLIF($4, ''), email),
     updated_at = now()
 WHERE id = $1 AND type IN ('contact', 'visitor');
 
@@ -411,3 +494,94 @@ SELECT
     (SELECT COUNT(*) FROM transfer_conversations) as conversations_transferred,
     (SELECT COUNT(*) FROM transfer_messages) as messages_transferred,
     (SELECT COUNT(*) FROM delete_visitor) as visitor_deleted;
+
+-- name: merge-contacts
+-- Moves everything owned by the source contact ($1) to the target contact ($2), keeps the
+-- source email addresses as secondary identities of the target and soft deletes the source.
+WITH source AS (
+    SELECT id, email, custom_attributes FROM users
+    WHERE id = $1 AND type IN ('contact', 'visitor') AND deleted_at IS NULL
+),
+target AS (
+    SELECT id, email FROM users
+    WHERE id = $2 AND type = 'contact' AND deleted_at IS NULL
+),
+transfer_conversations AS (
+    UPDATE conversations
+    SET contact_id = (SELECT id FROM target), updated_at = now()
+    WHERE contact_id = (SELECT id FROM source)
+    RETURNING id
+),
+transfer_messages AS (
+    UPDATE conversation_messages
+    SET sender_id = (SELECT id FROM target)
+    WHERE sender_id = (SELECT id FROM source) AND sender_type = 'contact'
+    RETURNING id
+),
+transfer_participants AS (
+    UPDATE conversation_participants
+    SET user_id = (SELECT id FROM target)
+    WHERE user_id = (SELECT id FROM source) AND NOT EXISTS (
+        SELECT 1 FROM conversation_participants cp WHERE cp.user_id = (SELECT id FROM target) AND cp.conversation_id = conversation_participants.conversation_id
+    )
+    RETURNING id
+),
+delete_duplicate_participants AS (
+    DELETE FROM conversation_participants
+    WHERE user_id = (SELECT id FROM source) AND EXISTS (
+        SELECT 1 FROM conversation_participants cp WHERE cp.user_id = (SELECT id FROM target) AND cp.conversation_id = conversation_participants.conversation_id
+    )
+    RETURNING id
+),
+transfer_notes AS (
+    UPDATE contact_notes
+    SET contact_id = (SELECT id FROM target)
+    WHERE contact_id = (SELECT id FROM source)
+    RETURNING id
+),
+merge_custom_attributes AS (
+    -- Target attributes win on conflicting keys.
+    UPDATE users
+    SET custom_attributes = (SELECT custom_attributes FROM source) || users.custom_attributes, updated_at = now()
+    WHERE id = (SELECT id FROM target)
+    RETURNING id
+),
+transfer_identities AS (
+    UPDATE contact_identities
+    SET contact_id = (SELECT id FROM target), updated_at = now()
+    WHERE contact_id = (SELECT id FROM source)
+    RETURNING id
+),
+insert_identity AS (
+    INSERT INTO contact_identities (contact_id, email)
+    SELECT (SELECT id FROM target), LOWER(s.email) FROM source s
+    WHERE s.email IS NOT NULL AND s.email <> ''
+        AND LOWER(s.email) IS DISTINCT FROM (SELECT LOWER(email) FROM target)
+    ON CONFLICT DO NOTHING
+    RETURNING id
+),
+delete_source AS (
+    UPDATE users
+    SET deleted_at = now(), updated_at = now()
+    WHERE id = (SELECT id FROM source) AND EXISTS (SELECT 1 FROM target)
+    RETURNING id
+)
+SELECT
+    (SELECT COUNT(*) FROM transfer_conversations) as conversations_transferred,
+    (SELECT COUNT(*) FROM transfer_messages) as messages_transferred,
+    (SELECT COUNT(*) FROM transfer_notes) as notes_transferred,
+    (SELECT COUNT(*) FROM transfer_identities) + (SELECT COUNT(*) FROM insert_identity) as identities_transferred,
+    (SELECT COUNT(*) FROM delete_source) as source_deleted;
+
+-- name: get-contact-identities
+SELECT id, created_at, updated_at, contact_id, email
+FROM contact_identities
+WHERE contact_id = $1
+ORDER BY created_at;
+
+-- name: get-contact-id-by-identity
+SELECT ci.contact_id
+FROM contact_identities ci
+JOIN users u ON u.id = ci.contact_id
+WHERE ci.email = LOWER($1) AND u.deleted_at IS NULL
+LIMIT 1;
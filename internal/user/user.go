@@ -69,6 +69,13 @@ type queries struct {
 	UpdateContact                 *sqlx.Stmt `query:"update-contact"`
 	UpdateContactBasicInfo        *sqlx.Stmt `query:"update-contact-basic-info"`
 	UpdateAgent                   *sqlx.Stmt `query:"update-agent"`
+	UpdateAgentSkills             *sqlx.Stmt `query:"update-agent-skills"`
+	UpdateAgentCapacity           *sqlx.Stmt `query:"update-agent-capacity"`
+	UpdateAgentEmailSignature     *sqlx.Stmt `query:"update-agent-email-signature"`
+	GetAgentsLoad                 *sqlx.Stmt `query:"get-agents-load"`
+	UpdateAgentShift              *sqlx.Stmt `query:"update-agent-shift"`
+	GetAgentShifts                *sqlx.Stmt `query:"get-agent-shifts"`
+	UpdateAgentShiftState         *sqlx.Stmt `query:"update-agent-shift-state"`
 	UpdateCustomAttributes        *sqlx.Stmt `query:"update-custom-attributes"`
 	UpsertCustomAttributes        *sqlx.Stmt `query:"upsert-custom-attributes"`
 	UpdateAvatar                  *sqlx.Stmt `query:"update-avatar"`
@@ -88,6 +95,7 @@ type queries struct {
 	GetContactByEmail             *sqlx.Stmt `query:"get-contact-by-email"`
 	GetContactByEmailWithoutExtID *sqlx.Stmt `query:"get-contact-by-email-without-ext-id"`
 	IsEmailBlocked                *sqlx.Stmt `query:"is-email-blocked"`
+	SetEmailUndeliverable         *sqlx.Stmt `query:"set-email-undeliverable"`
 	SetExternalUserID             *sqlx.Stmt `query:"set-external-user-id"`
 	InsertNote                    *sqlx.Stmt `query:"insert-note"`
 	InsertVisitor                 *sqlx.Stmt `query:"insert-visitor"`
@@ -102,6 +110,11 @@ type queries struct {
 	UpdateAPIKeyLastUsed *sqlx.Stmt `query:"update-api-key-last-used"`
 
 	MergeVisitorToContact *sqlx.Stmt `query:"merge-visitor-to-contact"`
+
+	// Contact merge queries
+	MergeContacts          *sqlx.Stmt `query:"merge-contacts"`
+	GetContactIdentities   *sqlx.Stmt `query:"get-contact-identities"`
+	GetContactIDByIdentity *sqlx.Stmt `query:"get-contact-id-by-identity"`
 }
 
 // New creates and returns a new instance of the Manager.
@@ -239,6 +252,15 @@ func (u *Manager) IsEmailBlocked(email string) (bool, error) {
 	return blocked, nil
 }
 
+// SetEmailUndeliverable flags the contact with the given email as undeliverable.
+func (u *Manager) SetEmailUndeliverable(email string) error {
+	if _, err := u.q.SetEmailUndeliverable.Exec(strings.ToLower(email)); err != nil {
+		u.lo.Error("error setting email undeliverable", "email", email, "error", err)
+		return fmt.Errorf("setting email undeliverable: %w", err)
+	}
+	return nil
+}
+
 // GetVisitorByEmail retrieves a visitor by email address.
 func (u *Manager) GetVisitorByEmail(email string) (models.User, error) {
 	var user models.User
@@ -37,6 +37,11 @@ var (
 	efs embed.FS
 )
 
+const (
+	// SignatureHeader is the HTTP header carrying the HMAC-SHA256 signature of the request body.
+	SignatureHeader = "X-Libredesk-Signature"
+)
+
 // Manager handles webhook-related operations.
 type Manager struct {
 	q             queries
@@ -91,33 +96,36 @@ func New(opts Opts) (*Manager, error) {
 		return nil, err
 	}
 
-	// Parse allowed host CIDRs for SSRF exceptions.
-	allowed := parseAllowedHosts(opts.AllowedHosts, opts.Lo)
-	guard := ssrfguard.New(allowed...)
-
 	return &Manager{
 		q:             q,
 		lo:            opts.Lo,
 		i18n:          opts.I18n,
 		db:            opts.DB,
 		deliveryQueue: make(chan DeliveryTask, opts.QueueSize),
-		httpClient: &http.Client{
-			Timeout: opts.Timeout,
-			Transport: &http.Transport{
-				DialContext: (&net.Dialer{
-					Timeout:   3 * time.Second,
-					KeepAlive: 30 * time.Second,
-					Control:   guard.Control,
-				}).DialContext,
-				TLSHandshakeTimeout:   3 * time.Second,
-				ResponseHeaderTimeout: 3 * time.Second,
-			},
-		},
+		httpClient:    NewHTTPClient(opts.Timeout, opts.AllowedHosts, opts.Lo),
 		workers:       opts.Workers,
 		encryptionKey: opts.EncryptionKey,
 	}, nil
 }
 
+// NewHTTPClient returns an HTTP client with SSRF protection for outbound requests to user configured URLs.
+// allowedHosts is a list of CIDR prefixes allowed to bypass the SSRF protection.
+func NewHTTPClient(timeout time.Duration, allowedHosts []string, lo *logf.Logger) *http.Client {
+	guard := ssrfguard.New(parseAllowedHosts(allowedHosts, lo)...)
+	return &http.Client{
+		Timeout: timeout,
+		Transport: &http.Transport{
+			DialContext: (&net.Dialer{
+				Timeout:   3 * time.Second,
+				KeepAlive: 30 * time.Second,
+				Control:   guard.Control,
+			}).DialContext,
+			TLSHandshakeTimeout:   3 * time.Second,
+			ResponseHeaderTimeout: 3 * time.Second,
+		},
+	}
+}
+
 // GetAll retrieves all webhooks.
 func (m *Manager) GetAll() ([]models.Webhook, error) {
 	var webhooks = make([]models.Webhook, 0)
@@ -345,8 +353,7 @@ func (m *Manager) deliverSingleWebhook(webhook models.Webhook, task DeliveryTask
 
 	// Add signature if secret is provided
 	if webhook.Secret != "" {
-		signature := m.generateSignature(payloadBytes, webhook.Secret)
-		req.Header.Set("X-Libredesk-Signature", signature)
+		req.Header.Set(SignatureHeader, GenerateSignature(payloadBytes, webhook.Secret))
 	}
 
 	m.lo.Debug("delivering webhook",
@@ -395,13 +402,21 @@ func (m *Manager) deliverSingleWebhook(webhook models.Webhook, task DeliveryTask
 	}
 }
 
-// generateSignature generates HMAC-SHA256 signature for webhook payload.
-func (m *Manager) generateSignature(payload []byte, secret string) string {
+// GenerateSignature generates HMAC-SHA256 signature for webhook payload.
+func GenerateSignature(payload []byte, secret string) string {
 	h := hmac.New(sha256.New, []byte(secret))
 	h.Write(payload)
 	return "sha256=" + hex.EncodeToString(h.Sum(nil))
 }
 
+// VerifySignature reports whether signature is a valid HMAC-SHA256 signature of payload for the given secret.
+func VerifySignature(payload []byte, secret, signature string) bool {
+	if secret == "" || signature == "" {
+		return false
+	}
+	return hmac.Equal([]byte(GenerateSignature(payload, secret)), []byte(signature))
+}
+
 // getWebhooksByEvent retrieves active webhooks that are subscribed to a specific event.
 func (m *Manager) getWebhooksByEvent(event string) ([]models.Webhook, error) {
 	var webhooks = make([]models.Webhook, 0)
@@ -1,15 +1,15 @@
 CREATE EXTENSION IF NOT EXISTS pg_trgm;
 
-DROP TYPE IF EXISTS "channels" CASCADE; CREATE TYPE "channels" AS ENUM ('email', 'livechat');
+DROP TYPE IF EXISTS "channels" CASCADE; CREATE TYPE "channels" AS ENUM ('email', 'livechat', 'api');
 DROP TYPE IF EXISTS "message_type" CASCADE; CREATE TYPE "message_type" AS ENUM ('incoming','outgoing','activity');
 DROP TYPE IF EXISTS "message_sender_type" CASCADE; CREATE TYPE "message_sender_type" AS ENUM ('agent','contact');
 DROP TYPE IF EXISTS "message_status" CASCADE; CREATE TYPE "message_status" AS ENUM ('received','sent','failed','pending');
 DROP TYPE IF EXISTS "content_type" CASCADE; CREATE TYPE "content_type" AS ENUM ('text','html');
-DROP TYPE IF EXISTS "conversation_assignment_type" CASCADE; CREATE TYPE "conversation_assignment_type" AS ENUM ('Round robin','Manual');
+DROP TYPE IF EXISTS "conversation_assignment_type" CASCADE; CREATE TYPE "conversation_assignment_type" AS ENUM ('Round robin','Manual','Least busy','Skill based','Sticky');
 DROP TYPE IF EXISTS "template_type" CASCADE; CREATE TYPE "template_type" AS ENUM ('email_outgoing', 'email_notification');
 -- Visitors are unauthenticated contacts.
 DROP TYPE IF EXISTS "user_type" CASCADE; CREATE TYPE "user_type" AS ENUM ('agent', 'contact', 'visitor');
-DROP TYPE IF EXISTS "ai_provider" CASCADE; CREATE TYPE "ai_provider" AS ENUM ('openai');
+DROP TYPE IF EXISTS "ai_provider" CASCADE; CREATE TYPE "ai_provider" AS ENUM ('openai', 'anthropic', 'openai_compatible');
 DROP TYPE IF EXISTS "automation_execution_mode" CASCADE; CREATE TYPE "automation_execution_mode" AS ENUM ('all', 'first_match');
 DROP TYPE IF EXISTS "macro_visibility" CASCADE; CREATE TYPE "macro_visibility" AS ENUM ('all', 'team', 'user');
 DROP TYPE IF EXISTS "view_visibility" CASCADE; CREATE TYPE "view_visibility" AS ENUM ('all', 'team', 'user');
@@ -20,9 +20,9 @@ DROP TYPE IF EXISTS "applied_sla_status" CASCADE; CREATE TYPE "applied_sla_statu
 DROP TYPE IF EXISTS "sla_event_status" CASCADE; CREATE TYPE "sla_event_status" AS ENUM ('pending', 'breached', 'met');
 DROP TYPE IF EXISTS "sla_metric" CASCADE; CREATE TYPE "sla_metric" AS ENUM ('first_response', 'resolution', 'next_response');
 DROP TYPE IF EXISTS "sla_notification_type" CASCADE; CREATE TYPE "sla_notification_type" AS ENUM ('warning', 'breach');
-DROP TYPE IF EXISTS "activity_log_type" CASCADE; CREATE TYPE "activity_log_type" AS ENUM ('agent_login', 'agent_logout', 'agent_away', 'agent_away_reassigned', 'agent_online', 'agent_password_set', 'agent_role_permissions_changed');
+DROP TYPE IF EXISTS "activity_log_type" CASCADE; CREATE TYPE "activity_log_type" AS ENUM ('agent_login', 'agent_logout', 'agent_away', 'agent_away_reassigned', 'agent_online', 'agent_password_set', 'agent_role_permissions_changed', 'contact_merged', 'contact_data_exported', 'contact_data_erased', 'retention_sweep');
 DROP TYPE IF EXISTS "macro_visible_when" CASCADE; CREATE TYPE "macro_visible_when" AS ENUM ('replying', 'starting_conversation', 'adding_private_note');
-DROP TYPE IF EXISTS "user_notification_type" CASCADE; CREATE TYPE "user_notification_type" AS ENUM ('mention', 'assignment', 'sla_warning', 'sla_breach');
+DROP TYPE IF EXISTS "user_notification_type" CASCADE; CREATE TYPE "user_notification_type" AS ENUM ('mention', 'assignment', 'sla_warning', 'sla_breach', 'email_bounce');
 DROP TYPE IF EXISTS "webhook_event" CASCADE; CREATE TYPE webhook_event AS ENUM (
 	'conversation.created',
 	'conversation.status_changed',
@@ -32,6 +32,9 @@ DROP TYPE IF EXISTS "webhook_event" CASCADE; CREATE TYPE webhook_event AS ENUM (
 	'message.created',
 	'message.updated'
 );
+DROP TYPE IF EXISTS "retention_action" CASCADE; CREATE TYPE "retention_action" AS ENUM ('delete', 'anonymise');
+DROP TYPE IF EXISTS "kb_article_status" CASCADE; CREATE TYPE "kb_article_status" AS ENUM ('draft', 'published');
+DROP TYPE IF EXISTS "automation_scheduled_action_status" CASCADE; CREATE TYPE "automation_scheduled_action_status" AS ENUM ('pending', 'processing', 'completed', 'cancelled', 'failed');
 
 -- Sequence to generate reference number for conversations.
 DROP SEQUENCE IF EXISTS conversation_reference_number_sequence; CREATE SEQUENCE conversation_reference_number_sequence START 100;
@@ -91,6 +94,21 @@ CREATE TABLE inboxes (
 	CONSTRAINT constraint_inboxes_on_name CHECK (length("name") <= 140)
 );
 
+-- Last processed UID of IMAP mailboxes, reset when the mailbox UIDVALIDITY changes.
+DROP TABLE IF EXISTS imap_checkpoints CASCADE;
+CREATE TABLE imap_checkpoints (
+	id SERIAL PRIMARY KEY,
+	created_at TIMESTAMPTZ DEFAULT NOW(),
+	updated_at TIMESTAMPTZ DEFAULT NOW(),
+	inbox_id INT REFERENCES inboxes(id) ON DELETE CASCADE ON UPDATE CASCADE NOT NULL,
+	host TEXT NOT NULL,
+	username TEXT NOT NULL,
+	mailbox TEXT NOT NULL,
+	uid_validity BIGINT NOT NULL,
+	last_uid BIGINT NOT NULL,
+	CONSTRAINT constraint_imap_checkpoints_unique UNIQUE (inbox_id, host, username, mailbox)
+);
+
 DROP TABLE IF EXISTS teams CASCADE;
 CREATE TABLE teams (
 	id SERIAL PRIMARY KEY,
@@ -151,6 +169,24 @@ CREATE TABLE users (
 	api_key TEXT NULL,
 	api_secret TEXT NULL,
 	api_key_last_used_at TIMESTAMPTZ NULL,
+	-- Skills are matched against conversation tags and inbox names for skill based assignment.
+	skills TEXT[] DEFAULT '{}'::TEXT[] NOT NULL,
+	-- Overrides the team max auto assigned conversations, measured in load units.
+	capacity INT NULL,
+	-- Load a conversation of each channel adds to the agent, unlisted channels add 1.
+	channel_weights JSONB DEFAULT '{}'::jsonb NOT NULL,
+	-- Weekly shift in the business hours format, agents are set online and offline as their shift starts and ends.
+	shift_hours JSONB NULL,
+	shift_timezone TEXT NULL,
+	-- Unassign open conversations when the shift ends.
+	shift_reassign BOOLEAN DEFAULT false NOT NULL,
+	-- Whether the agent was on shift at the last check, null until the shift is first checked.
+	shift_on_duty BOOLEAN NULL,
+	-- Set when email to the contact hard bounces.
+	email_undeliverable BOOLEAN DEFAULT false NOT NULL,
+	-- Appended to the agent's email replies in place of the inbox signature.
+	email_signature TEXT NULL,
+    CONSTRAINT constraint_users_on_capacity CHECK (capacity IS NULL OR capacity > 0),
     CONSTRAINT constraint_users_on_country CHECK (LENGTH(country) <= 140),
     CONSTRAINT constraint_users_on_phone_number CHECK (LENGTH(phone_number) <= 20),
 	CONSTRAINT constraint_users_on_phone_number_country_code CHECK (LENGTH(phone_number_country_code) <= 10),
@@ -245,7 +281,8 @@ CREATE TABLE conversations (
 	last_interaction_at TIMESTAMPTZ NULL,
 	next_sla_deadline_at TIMESTAMPTZ NULL,
 	snoozed_until TIMESTAMPTZ NULL,
-	last_continuity_email_sent_at TIMESTAMPTZ NULL
+	last_continuity_email_sent_at TIMESTAMPTZ NULL,
+	anonymised_at TIMESTAMPTZ NULL
 );
 CREATE INDEX index_conversations_on_assigned_user_id ON conversations (assigned_user_id);
 CREATE INDEX index_conversations_on_assigned_team_id ON conversations (assigned_team_id);
@@ -260,6 +297,7 @@ CREATE INDEX index_conversations_on_last_interaction_at ON conversations (last_i
 CREATE INDEX index_conversations_on_next_sla_deadline_at ON conversations (next_sla_deadline_at);
 CREATE INDEX index_conversations_on_waiting_since ON conversations (waiting_since);
 CREATE INDEX index_conversations_on_last_continuity_email_sent_at ON conversations (last_continuity_email_sent_at);
+CREATE INDEX index_fts_conversations_on_subject ON conversations USING GIN (to_tsvector('simple', COALESCE(subject, '')));
 
 DROP TABLE IF EXISTS conversation_messages CASCADE;
 CREATE TABLE conversation_messages (
@@ -280,6 +318,7 @@ CREATE TABLE conversation_messages (
     meta JSONB DEFAULT '{}'::JSONB NULL
 );
 CREATE INDEX index_trgm_conversation_messages_on_text_content ON conversation_messages USING GIN (text_content gin_trgm_ops);
+CREATE INDEX index_fts_conversation_messages_on_text_content ON conversation_messages USING GIN (to_tsvector('simple', COALESCE(text_content, '')));
 CREATE INDEX index_conversation_messages_on_conversation_id ON conversation_messages (conversation_id);
 CREATE INDEX index_conversation_messages_on_created_at ON conversation_messages (created_at);
 CREATE INDEX index_conversation_messages_on_source_id ON conversation_messages (source_id);
@@ -305,6 +344,93 @@ CREATE TABLE automation_rules (
 CREATE INDEX index_automation_rules_on_enabled_and_weight ON automation_rules(enabled, weight);
 CREATE INDEX index_automation_rules_on_type_and_weight ON automation_rules(type, weight);
 
+DROP TABLE IF EXISTS automation_rule_versions CASCADE;
+CREATE TABLE automation_rule_versions (
+	id BIGSERIAL PRIMARY KEY,
+	created_at TIMESTAMPTZ DEFAULT NOW(),
+	rule_id INT REFERENCES automation_rules(id) ON DELETE CASCADE ON UPDATE CASCADE NOT NULL,
+	"version" INT NOT NULL,
+
+	-- User who made the change, null for changes made by the system.
+	user_id INT REFERENCES users(id) ON DELETE SET NULL ON UPDATE CASCADE NULL,
+
+	-- Snapshot of the rule.
+	"name" TEXT NOT NULL,
+	description TEXT NULL,
+	"type" VARCHAR NOT NULL,
+	rules JSONB NULL,
+	events TEXT[] DEFAULT '{}'::TEXT[] NOT NULL,
+	CONSTRAINT constraint_automation_rule_versions_unique UNIQUE (rule_id, "version")
+);
+
+DROP TABLE IF EXISTS automation_rule_executions CASCADE;
+CREATE TABLE automation_rule_executions (
+	id BIGSERIAL PRIMARY KEY,
+	created_at TIMESTAMPTZ DEFAULT NOW(),
+	rule_id INT REFERENCES automation_rules(id) ON DELETE CASCADE ON UPDATE CASCADE NOT NULL,
+	conversation_id BIGINT REFERENCES conversations(id) ON DELETE CASCADE ON UPDATE CASCADE NOT NULL,
+
+	-- Rule type or conversation event that triggered the evaluation.
+	"trigger" TEXT NOT NULL,
+	group_results BOOLEAN[] DEFAULT '{}'::BOOLEAN[] NOT NULL,
+	actions JSONB DEFAULT '[]'::jsonb NOT NULL,
+	has_errors BOOLEAN DEFAULT FALSE NOT NULL
+);
+CREATE INDEX index_automation_rule_executions_on_rule_id_and_created_at ON automation_rule_executions (rule_id, created_at DESC);
+CREATE INDEX index_automation_rule_executions_on_conversation_id ON automation_rule_executions (conversation_id);
+CREATE INDEX index_automation_rule_executions_on_created_at ON automation_rule_executions (created_at);
+
+DROP TABLE IF EXISTS automation_rule_firings CASCADE;
+CREATE TABLE automation_rule_firings (
+	created_at TIMESTAMPTZ DEFAULT NOW(),
+	rule_id INT REFERENCES automation_rules(id) ON DELETE CASCADE ON UPDATE CASCADE NOT NULL,
+	conversation_id BIGINT REFERENCES conversations(id) ON DELETE CASCADE ON UPDATE CASCADE NOT NULL,
+
+	-- Time trigger rules fire once per conversation.
+	PRIMARY KEY (rule_id, conversation_id)
+);
+
+DROP TABLE IF EXISTS automation_scheduled_actions CASCADE;
+CREATE TABLE automation_scheduled_actions (
+	id BIGSERIAL PRIMARY KEY,
+	created_at TIMESTAMPTZ DEFAULT NOW(),
+	updated_at TIMESTAMPTZ DEFAULT NOW(),
+	rule_id INT REFERENCES automation_rules(id) ON DELETE CASCADE ON UPDATE CASCADE NOT NULL,
+	conversation_id BIGINT REFERENCES conversations(id) ON DELETE CASCADE ON UPDATE CASCADE NOT NULL,
+	"action" JSONB NOT NULL,
+	run_at TIMESTAMPTZ NOT NULL,
+	status automation_scheduled_action_status DEFAULT 'pending' NOT NULL,
+	cancel_on TEXT[] DEFAULT '{}'::TEXT[] NOT NULL,
+
+	-- Conversation status and assignee when the action was scheduled, used to cancel the action if they change.
+	status_id INT NULL,
+	assigned_user_id INT NULL,
+
+	-- Why the action was cancelled or failed.
+	reason TEXT NULL
+);
+CREATE INDEX index_automation_scheduled_actions_on_status_and_run_at ON automation_scheduled_actions (status, run_at);
+CREATE INDEX index_automation_scheduled_actions_on_conversation_id ON automation_scheduled_actions (conversation_id);
+CREATE INDEX index_automation_scheduled_actions_on_pending ON automation_scheduled_actions (rule_id, conversation_id) WHERE status = 'pending';
+
+DROP TABLE IF EXISTS auto_assignment_decisions CASCADE;
+CREATE TABLE auto_assignment_decisions (
+	id BIGSERIAL PRIMARY KEY,
+	created_at TIMESTAMPTZ DEFAULT NOW(),
+	conversation_id BIGINT REFERENCES conversations(id) ON DELETE CASCADE ON UPDATE CASCADE NOT NULL,
+	team_id INT REFERENCES teams(id) ON DELETE SET NULL ON UPDATE CASCADE NULL,
+	strategy TEXT NOT NULL,
+	"trigger" TEXT NOT NULL,
+	outcome TEXT NOT NULL,
+	fallback TEXT NULL,
+	assigned_user_id INT REFERENCES users(id) ON DELETE SET NULL ON UPDATE CASCADE NULL,
+
+	-- Team members considered and why each was or wasn't picked.
+	candidates JSONB DEFAULT '[]'::jsonb NOT NULL
+);
+CREATE INDEX index_auto_assignment_decisions_on_conversation_id ON auto_assignment_decisions (conversation_id);
+CREATE INDEX index_auto_assignment_decisions_on_created_at ON auto_assignment_decisions (created_at);
+
 DROP TABLE IF EXISTS conversation_drafts CASCADE;
 CREATE TABLE conversation_drafts (
     id BIGSERIAL PRIMARY KEY,
@@ -577,6 +703,7 @@ CREATE TABLE ai_providers (
 );
 CREATE UNIQUE INDEX index_unique_ai_providers_on_is_default_when_is_default_is_true ON ai_providers USING btree (is_default)
 WHERE (is_default = true);
+CREATE UNIQUE INDEX index_unique_ai_providers_on_provider ON ai_providers USING btree (provider);
 
 DROP TABLE IF EXISTS ai_prompts CASCADE;
 CREATE TABLE ai_prompts (
@@ -625,6 +752,19 @@ CREATE TABLE contact_notes (
 );
 CREATE INDEX index_contact_notes_on_contact_id_created_at ON contact_notes (contact_id, created_at);
 
+DROP TABLE IF EXISTS contact_identities CASCADE;
+CREATE TABLE contact_identities (
+	id SERIAL PRIMARY KEY,
+	created_at TIMESTAMPTZ DEFAULT NOW(),
+	updated_at TIMESTAMPTZ DEFAULT NOW(),
+	-- Secondary email addresses of a contact, kept when contacts are merged.
+	contact_id BIGINT NOT NULL REFERENCES users(id) ON DELETE CASCADE ON UPDATE CASCADE,
+	email TEXT NOT NULL,
+	CONSTRAINT constraint_contact_identities_on_email_length CHECK (LENGTH(email) <= 320)
+);
+CREATE UNIQUE INDEX index_unique_contact_identities_on_email ON contact_identities (email);
+CREATE INDEX index_contact_identities_on_contact_id ON contact_identities (contact_id);
+
 DROP TABLE IF EXISTS activity_logs CASCADE;
 CREATE TABLE activity_logs (
 	id BIGSERIAL PRIMARY KEY,
@@ -641,6 +781,63 @@ CREATE INDEX IF NOT EXISTS index_activity_logs_on_actor_id ON activity_logs (act
 CREATE INDEX IF NOT EXISTS index_activity_logs_on_activity_type ON activity_logs (activity_type);
 CREATE INDEX IF NOT EXISTS index_activity_logs_on_created_at ON activity_logs (created_at);
 
+DROP TABLE IF EXISTS inbox_retention_policies CASCADE;
+CREATE TABLE inbox_retention_policies (
+	id SERIAL PRIMARY KEY,
+	created_at TIMESTAMPTZ DEFAULT NOW(),
+	updated_at TIMESTAMPTZ DEFAULT NOW(),
+
+	-- Cascade deletes when inbox is deleted.
+	inbox_id INT NOT NULL UNIQUE REFERENCES inboxes(id) ON DELETE CASCADE ON UPDATE CASCADE,
+
+	enabled BOOL DEFAULT FALSE NOT NULL,
+	retention_days INT NOT NULL,
+	statuses TEXT[] DEFAULT '{Resolved,Closed}'::TEXT[] NOT NULL,
+	"action" retention_action DEFAULT 'delete' NOT NULL,
+	last_run_at TIMESTAMPTZ NULL,
+	CONSTRAINT constraint_inbox_retention_policies_on_retention_days CHECK (retention_days > 0)
+);
+
+DROP TABLE IF EXISTS kb_categories CASCADE;
+CREATE TABLE kb_categories (
+	id SERIAL PRIMARY KEY,
+	created_at TIMESTAMPTZ DEFAULT NOW(),
+	updated_at TIMESTAMPTZ DEFAULT NOW(),
+	"name" TEXT NOT NULL,
+	description TEXT DEFAULT '' NOT NULL,
+	position INT DEFAULT 0 NOT NULL,
+	CONSTRAINT constraint_kb_categories_on_name CHECK (length("name") <= 140),
+	CONSTRAINT constraint_kb_categories_on_description CHECK (length(description) <= 1000)
+);
+CREATE UNIQUE INDEX index_unique_kb_categories_on_name ON kb_categories (LOWER("name"));
+
+DROP TABLE IF EXISTS kb_articles CASCADE;
+CREATE TABLE kb_articles (
+	id SERIAL PRIMARY KEY,
+	created_at TIMESTAMPTZ DEFAULT NOW(),
+	updated_at TIMESTAMPTZ DEFAULT NOW(),
+	"uuid" UUID DEFAULT gen_random_uuid() NOT NULL UNIQUE,
+
+	-- Articles are kept when their category or author is deleted.
+	category_id INT REFERENCES kb_categories(id) ON DELETE SET NULL ON UPDATE CASCADE NULL,
+	author_id INT REFERENCES users(id) ON DELETE SET NULL ON UPDATE CASCADE NULL,
+
+	-- Translations of an article share the slug and differ in locale.
+	slug TEXT NOT NULL,
+	locale TEXT DEFAULT 'en' NOT NULL,
+	title TEXT NOT NULL,
+	"content" TEXT DEFAULT '' NOT NULL,
+	text_content TEXT DEFAULT '' NOT NULL,
+	status kb_article_status DEFAULT 'draft' NOT NULL,
+	published_at TIMESTAMPTZ NULL,
+	CONSTRAINT constraint_kb_articles_on_slug CHECK (length(slug) <= 200),
+	CONSTRAINT constraint_kb_articles_on_locale CHECK (length(locale) <= 10),
+	CONSTRAINT constraint_kb_articles_on_title CHECK (length(title) <= 300)
+);
+CREATE UNIQUE INDEX index_unique_kb_articles_on_slug_locale ON kb_articles (slug, locale);
+CREATE INDEX index_kb_articles_on_category_id ON kb_articles (category_id);
+CREATE INDEX index_fts_kb_articles ON kb_articles USING GIN (to_tsvector('simple', title || ' ' || text_content));
+
 DROP TABLE IF EXISTS webhooks CASCADE;
 CREATE TABLE webhooks (
 	id SERIAL PRIMARY KEY,
@@ -696,7 +893,10 @@ CREATE INDEX index_user_notifications_on_conversation_id ON user_notifications(c
 
 INSERT INTO ai_providers
 ("name", provider, config, is_default)
-VALUES('openai', 'openai', '{"api_key": ""}'::jsonb, true);
+VALUES
+	('openai', 'openai', '{"api_key": ""}'::jsonb, true),
+	('anthropic', 'anthropic', '{"api_key": ""}'::jsonb, false),
+	('openai_compatible', 'openai_compatible', '{"api_key": ""}'::jsonb, false);
 
 -- Default AI prompts
 INSERT INTO ai_prompts ("key", "content", title)
@@ -763,7 +963,7 @@ VALUES
 	(
 		'Admin',
 		'Role for users who have complete access to everything.',
-		'{webhooks:manage,context_links:manage,activity_logs:manage,custom_attributes:manage,contacts:read_all,contacts:read,contacts:write,contacts:block,contact_notes:read,contact_notes:write,contact_notes:delete,conversations:write,ai:manage,general_settings:manage,notification_settings:manage,oidc:manage,conversations:read_all,conversations:read_unassigned,conversations:read_assigned,conversations:read_team_inbox,conversations:read_team_all,conversations:read,conversations:update_user_assignee,conversations:update_team_assignee,conversations:update_priority,conversations:update_status,conversations:update_tags,messages:read,messages:write,view:manage,shared_views:manage,status:manage,tags:manage,macros:manage,users:manage,teams:manage,automations:manage,inboxes:manage,roles:manage,reports:manage,templates:manage,business_hours:manage,sla:manage}'
+		'{webhooks:manage,context_links:manage,activity_logs:manage,custom_attributes:manage,contacts:read_all,contacts:read,contacts:write,contacts:block,contacts:merge,contacts:export,contacts:erase,contact_notes:read,contact_notes:write,contact_notes:delete,conversations:write,ai:manage,general_settings:manage,notification_settings:manage,oidc:manage,conversations:read_all,conversations:read_unassigned,conversations:read_assigned,conversations:read_team_inbox,conversations:read_team_all,conversations:read,conversations:update_user_assignee,conversations:update_team_assignee,conversations:update_priority,conversations:update_status,conversations:update_tags,messages:read,messages:write,view:manage,shared_views:manage,status:manage,tags:manage,macros:manage,users:manage,teams:manage,automations:manage,inboxes:manage,roles:manage,reports:manage,templates:manage,business_hours:manage,sla:manage,kb:manage}'
 	);
 
 